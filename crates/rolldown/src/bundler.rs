@@ -1,14 +1,14 @@
 use std::path::PathBuf;
 
-use rolldown_core::{Asset, BuildResult, BundlerCore};
+use rolldown_core::{Asset, BuildResult, BundlerCore, WarningHandler};
 use rolldown_plugin::BuildPlugin;
-use sugar_path::AsPath;
 
-use crate::InputOptions;
+use crate::{output_options::target_of_browserslist, InputOptions};
 
 pub struct Bundler {
   core: BundlerCore,
   cwd: PathBuf,
+  on_warn: WarningHandler,
 }
 
 impl Bundler {
@@ -19,6 +19,7 @@ impl Bundler {
   pub fn with_plugins(input_opts: InputOptions, plugins: Vec<Box<dyn BuildPlugin>>) -> Self {
     rolldown_tracing::enable_tracing_on_demand();
     let cwd = input_opts.cwd.clone();
+    let on_warn = input_opts.on_warn.clone();
 
     let bundler = BundlerCore::with_plugins(
       rolldown_core::BuildInputOptions {
@@ -31,32 +32,33 @@ impl Bundler {
         preserve_symlinks: input_opts.preserve_symlinks,
         builtins: rolldown_core::BuiltinsOptions {
           tsconfig: input_opts.builtins.tsconfig.unwrap_or_default(),
+          jsx_side_effects: input_opts.builtins.jsx_side_effects,
+          jsx: input_opts.builtins.jsx,
           ..Default::default()
         },
+        concurrency: input_opts
+          .concurrency
+          .unwrap_or_else(rolldown_core::default_concurrency),
+        platform: input_opts.platform,
+        prefix_node_builtins: input_opts.prefix_node_builtins,
+        import_map: input_opts.import_map,
+        resolve_overrides: input_opts.resolve_overrides,
+        tsconfig_paths: input_opts.tsconfig_paths,
+        resolver: input_opts.resolver,
       },
       plugins,
     );
-    Self { cwd, core: bundler }
+    Self {
+      cwd,
+      core: bundler,
+      on_warn,
+    }
   }
 
   pub async fn write(&mut self, output_options: crate::OutputOptions) -> BuildResult<Vec<Asset>> {
-    let dir = output_options.dir.clone().unwrap_or_else(|| {
-      self
-        .cwd
-        .as_path()
-        .join("dist")
-        .to_string_lossy()
-        .to_string()
-    });
-    let output = self
-      .core
-      .build(rolldown_core::BuildOutputOptions {
-        entry_file_names: output_options.entry_file_names,
-        chunk_file_names: output_options.chunk_file_names,
-        format: output_options.format,
-        export_mode: output_options.export_mode,
-      })
-      .await?;
+    let output_options = self.normalize_output_options(output_options)?;
+    let dir = output_options.dir.clone();
+    let output = self.core.build(output_options).await?;
 
     std::fs::create_dir_all(&dir).unwrap_or_else(|_| {
       panic!(
@@ -72,12 +74,19 @@ impl Bundler {
           std::fs::create_dir_all(p)?;
         }
       };
-      std::fs::write(dest, &chunk.content).unwrap_or_else(|_| {
+      let content = chunk.binary.as_deref().unwrap_or(chunk.content.as_bytes());
+      std::fs::write(&dest, content).unwrap_or_else(|_| {
         panic!(
           "Failed to write file in {:?}",
           dir.as_path().join(&chunk.filename)
         )
       });
+      if let Some(map) = &chunk.map {
+        let mut map_dest = dest.into_os_string();
+        map_dest.push(".map");
+        std::fs::write(&map_dest, map)
+          .unwrap_or_else(|_| panic!("Failed to write file in {map_dest:?}"));
+      }
     }
     Ok(output)
   }
@@ -86,16 +95,58 @@ impl Bundler {
     &mut self,
     output_options: crate::OutputOptions,
   ) -> BuildResult<Vec<Asset>> {
-    let output = self
-      .core
-      .build(rolldown_core::BuildOutputOptions {
-        entry_file_names: output_options.entry_file_names,
-        chunk_file_names: output_options.chunk_file_names,
-        format: output_options.format,
-        export_mode: output_options.export_mode,
-      })
-      .await?;
+    let output_options = self.normalize_output_options(output_options)?;
+    let output = self.core.build(output_options).await?;
 
     Ok(output)
   }
+
+  fn normalize_output_options(
+    &self,
+    output_options: crate::OutputOptions,
+  ) -> BuildResult<rolldown_core::BuildOutputOptions> {
+    let target = match output_options.target {
+      Some(target) => target,
+      // Queries like `defaults` or `> 0.5%` depend on usage data and can't be mapped to a target,
+      // which shouldn't fail the build.
+      None => match target_of_browserslist(&self.cwd, output_options.mode) {
+        Ok(target) => target.unwrap_or_default(),
+        Err(err) => {
+          (self.on_warn)(err);
+          Default::default()
+        }
+      },
+    };
+    Ok(rolldown_core::BuildOutputOptions {
+      entry_file_names: output_options.entry_file_names,
+      chunk_file_names: output_options.chunk_file_names,
+      format: output_options.format,
+      export_mode: output_options.export_mode,
+      minify: output_options.minify,
+      preserve_modules: output_options.preserve_modules,
+      manual_chunks: output_options.manual_chunks,
+      target,
+      dir: output_options
+        .dir
+        .map(|dir| self.cwd.join(dir))
+        .unwrap_or_else(|| self.cwd.join("dist")),
+      sourcemap: output_options.sourcemap,
+      source_root: output_options.source_root,
+      graph_file: output_options
+        .graph_file
+        .map(|graph_file| self.cwd.join(graph_file)),
+      manifest_file: output_options
+        .manifest_file
+        .map(|manifest_file| self.cwd.join(manifest_file)),
+      asset_file_names: output_options.asset_file_names,
+      public_path: output_options.public_path,
+      module_preload: output_options.module_preload,
+      chunk_load_error_handler: output_options.chunk_load_error_handler,
+      name: output_options.name,
+      globals: output_options.globals,
+      polyfills: output_options.polyfills,
+      banner: output_options.banner,
+      hash_ignores_comments: output_options.hash_ignores_comments,
+    })
+  }
 }
@@ -1,5 +1,5 @@
 use derivative::Derivative;
-pub use rolldown_core::TsConfig;
+pub use rolldown_core::{JsxMode, TsConfig};
 
 #[derive(Derivative)]
 #[derivative(Debug)]
@@ -7,12 +7,16 @@ pub struct BuiltinsOptions {
   /// None means disable the builtin
   /// None means default
   pub tsconfig: Option<TsConfig>,
+  pub jsx_side_effects: bool,
+  pub jsx: JsxMode,
 }
 
 impl Default for BuiltinsOptions {
   fn default() -> Self {
     Self {
       tsconfig: Some(Default::default()),
+      jsx_side_effects: true,
+      jsx: Default::default(),
     }
   }
 }
@@ -2,7 +2,10 @@ use std::{path::PathBuf, sync::Arc};
 
 use derivative::Derivative;
 use futures::{future, FutureExt};
-pub use rolldown_core::{InputItem, IsExternal, WarningHandler};
+pub use rolldown_core::{
+  CustomResolution, CustomResolver, ImportMap, InputItem, IsExternal, Platform, ResolveOverrides,
+  TsConfigPaths, WarningHandler,
+};
 mod builtins;
 pub use builtins::*;
 
@@ -10,6 +13,9 @@ pub use builtins::*;
 #[derivative(Debug)]
 pub struct InputOptions {
   pub input: Vec<InputItem>,
+  /// Identify modules by the paths they are imported through instead of their real paths. It
+  /// should be off for pnpm, whose packages only find their dependencies and peers next to their
+  /// real paths in `node_modules/.pnpm`, and which are bundled once by those paths then.
   pub preserve_symlinks: bool,
   pub treeshake: bool,
   pub cwd: PathBuf,
@@ -19,6 +25,17 @@ pub struct InputOptions {
   pub on_warn: WarningHandler,
   pub shim_missing_exports: bool,
   pub builtins: BuiltinsOptions,
+  /// None means one module per available core
+  pub concurrency: Option<usize>,
+  pub platform: Platform,
+  pub prefix_node_builtins: bool,
+  pub import_map: Option<ImportMap>,
+  pub resolve_overrides: ResolveOverrides,
+  pub tsconfig_paths: TsConfigPaths,
+  /// Resolves specifiers by itself, for setups such as content-addressed modules. Returning `None`
+  /// falls back to the built-in resolution and the plugins.
+  #[derivative(Debug = "ignore")]
+  pub resolver: Option<CustomResolver>,
 }
 
 pub fn default_warning_handler() -> WarningHandler {
@@ -38,6 +55,13 @@ impl Default for InputOptions {
       on_warn: default_warning_handler(),
       shim_missing_exports: false,
       builtins: Default::default(),
+      concurrency: None,
+      platform: Default::default(),
+      prefix_node_builtins: false,
+      import_map: None,
+      resolve_overrides: Default::default(),
+      tsconfig_paths: Default::default(),
+      resolver: None,
     }
   }
 }
@@ -4,8 +4,13 @@ mod output_options;
 pub use {
   bundler::Bundler,
   input_options::{
-    default_warning_handler, BuiltinsOptions, InputItem, InputOptions, IsExternal, TsConfig,
+    default_warning_handler, BuiltinsOptions, CustomResolution, CustomResolver, ImportMap,
+    InputItem, InputOptions, IsExternal, JsxMode, Platform, ResolveOverrides, TsConfig,
+    TsConfigPaths,
   },
-  output_options::{ExportMode, FileNameTemplate, ModuleFormat, OutputOptions},
-  rolldown_core::{Asset, BuildResult},
+  output_options::{
+    BuildMode, ExportMode, FileNameTemplate, ManualChunk, ManualChunkTest, MinifyOptions,
+    ModuleFormat, OutputOptions, Target,
+  },
+  rolldown_core::{Asset, BuildResult, RenderedModule, RUNTIME_MODULE_ID},
 };
@@ -0,0 +1,123 @@
+use std::path::{Path, PathBuf};
+
+use rolldown_core::{BuildError, Target};
+use rolldown_error::Result;
+use serde_json::Value;
+
+use super::BuildMode;
+
+/// A browserslist config found in a directory, either `.browserslistrc`, `browserslist` or the
+/// `browserslist` key of `package.json`.
+enum BrowserslistConfig {
+  Rc(String),
+  PackageJson(Value),
+}
+
+fn find_config(cwd: &Path) -> Result<Option<(PathBuf, BrowserslistConfig)>> {
+  for dir in cwd.ancestors() {
+    for name in [".browserslistrc", "browserslist"] {
+      let path = dir.join(name);
+      if path.is_file() {
+        let content = std::fs::read_to_string(&path).map_err(BuildError::io_error)?;
+        return Ok(Some((path, BrowserslistConfig::Rc(content))));
+      }
+    }
+    let path = dir.join("package.json");
+    if path.is_file() {
+      let content = std::fs::read_to_string(&path).map_err(BuildError::io_error)?;
+      let package_json = serde_json::from_str::<Value>(&content).map_err(|e| {
+        BuildError::invalid_browserslist_config(path.clone(), format!("Invalid package.json: {e}"))
+      })?;
+      if let Some(browserslist) = package_json.get("browserslist") {
+        return Ok(Some((
+          path,
+          BrowserslistConfig::PackageJson(browserslist.clone()),
+        )));
+      }
+    }
+  }
+  Ok(None)
+}
+
+/// `chrome 60, firefox 60 or safari 12` => `["chrome 60", "firefox 60", "safari 12"]`
+fn split_queries(queries: &str) -> impl Iterator<Item = &str> {
+  queries
+    .split(',')
+    .flat_map(|query| query.split(" or "))
+    .map(str::trim)
+    .filter(|query| !query.is_empty())
+}
+
+/// Queries outside of sections apply to every env. A section like `[production staging]` only
+/// applies to the listed envs.
+fn queries_of_rc(content: &str, env: &str) -> Vec<String> {
+  let mut default_queries = vec![];
+  let mut env_queries = None::<Vec<String>>;
+  let mut in_section = None::<bool>;
+  content
+    .lines()
+    .map(|line| line.split('#').next().unwrap().trim())
+    .filter(|line| !line.is_empty())
+    .for_each(|line| {
+      if let Some(envs) = line.strip_prefix('[').and_then(|l| l.strip_suffix(']')) {
+        let is_env = envs.split_whitespace().any(|name| name == env);
+        if is_env {
+          env_queries.get_or_insert_with(Vec::new);
+        }
+        in_section = Some(is_env);
+        return;
+      }
+      let queries = split_queries(line).map(|query| query.to_string());
+      match in_section {
+        None => default_queries.extend(queries),
+        Some(true) => env_queries.as_mut().unwrap().extend(queries),
+        Some(false) => {}
+      }
+    });
+  env_queries.unwrap_or(default_queries)
+}
+
+fn queries_of_value(value: &Value) -> Option<Vec<String>> {
+  match value {
+    Value::String(queries) => Some(split_queries(queries).map(|q| q.to_string()).collect()),
+    Value::Array(queries) => queries
+      .iter()
+      .map(|query| query.as_str().map(|q| q.to_string()))
+      .collect(),
+    _ => None,
+  }
+}
+
+/// The value is either the queries, or an object of queries keyed by env. The `defaults` key is
+/// used when the env isn't listed.
+fn queries_of_package_json(value: &Value, env: &str) -> Option<Vec<String>> {
+  match value {
+    Value::Object(envs) => envs
+      .get(env)
+      .or_else(|| envs.get("defaults"))
+      .map_or(Some(vec![]), queries_of_value),
+    value => queries_of_value(value),
+  }
+}
+
+/// The target inferred from the nearest browserslist config of `cwd`, if there's any. The sections
+/// of the config are picked by the build mode.
+pub(crate) fn target_of_browserslist(cwd: &Path, mode: BuildMode) -> Result<Option<Target>> {
+  let Some((path, config)) = find_config(cwd)? else {
+    return Ok(None);
+  };
+  let env = mode.as_str();
+  let queries = match config {
+    BrowserslistConfig::Rc(content) => queries_of_rc(&content, env),
+    BrowserslistConfig::PackageJson(value) => {
+      queries_of_package_json(&value, env).ok_or_else(|| {
+        BuildError::invalid_browserslist_config(
+          path.clone(),
+          r#""browserslist" must be a string, an array of strings or an object of them"#,
+        )
+      })?
+    }
+  };
+  Target::from_browserslist(&queries)
+    .map_err(|reason| BuildError::invalid_browserslist_config(path, reason))
+}
@@ -0,0 +1,31 @@
+use std::str::FromStr;
+
+/// Selects the env sections of the browserslist config, like `[development]` of a
+/// `.browserslistrc`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum BuildMode {
+  #[default]
+  Production,
+  Development,
+}
+
+impl BuildMode {
+  pub fn as_str(&self) -> &'static str {
+    match self {
+      BuildMode::Production => "production",
+      BuildMode::Development => "development",
+    }
+  }
+}
+
+impl FromStr for BuildMode {
+  type Err = String;
+
+  fn from_str(value: &str) -> Result<Self, Self::Err> {
+    match value {
+      "production" => Ok(BuildMode::Production),
+      "development" => Ok(BuildMode::Development),
+      _ => Err(format!("Invalid build mode: {value}")),
+    }
+  }
+}
@@ -1,5 +1,15 @@
+use std::collections::HashMap;
+
 use derivative::Derivative;
-pub use rolldown_core::{file_name::FileNameTemplate, ExportMode, ModuleFormat};
+
+mod browserslist;
+pub(crate) use browserslist::target_of_browserslist;
+mod build_mode;
+pub use build_mode::*;
+pub use rolldown_core::{
+  file_name::FileNameTemplate, ExportMode, ManualChunk, ManualChunkTest, MinifyOptions,
+  ModuleFormat, Target,
+};
 
 #[derive(Derivative)]
 #[derivative(Debug)]
@@ -9,6 +19,39 @@ pub struct OutputOptions {
   pub chunk_file_names: FileNameTemplate,
   pub format: ModuleFormat,
   pub export_mode: ExportMode,
+  pub minify: MinifyOptions,
+  pub preserve_modules: bool,
+  /// Modules matching a manual chunk are put into it. The first matching one wins.
+  pub manual_chunks: Vec<ManualChunk>,
+  /// Inferred from the browserslist config of `cwd` when unspecified, like `.browserslistrc`.
+  /// Defaults to `esnext` without a config, or with queries that can't be mapped to a target.
+  pub target: Option<Target>,
+  /// Picks the env sections of the browserslist config.
+  pub mode: BuildMode,
+  pub sourcemap: bool,
+  pub source_root: Option<String>,
+  /// Relative to `cwd`
+  pub graph_file: Option<String>,
+  /// Relative to `cwd`
+  pub manifest_file: Option<String>,
+  /// Names of files copied by the file loader, like `.wasm`. The extension is appended.
+  pub asset_file_names: FileNameTemplate,
+  pub public_path: Option<String>,
+  /// Only for ES output, which is loaded by browsers.
+  pub module_preload: bool,
+  /// The name of a global function, which is called if a dynamic import fails to load a chunk.
+  pub chunk_load_error_handler: Option<String>,
+  /// The global variable the exports of the entry are assigned to in IIFE output.
+  pub name: Option<String>,
+  /// Globals of external imports in IIFE output, like `{ "react": "React" }`.
+  pub globals: HashMap<String, String>,
+  /// Modules imported for built-ins the target lacks, like
+  /// `{ "Object.fromEntries": "core-js/actual/object/from-entries" }`.
+  pub polyfills: HashMap<String, String>,
+  /// Prepended to every JS chunk, like a license comment.
+  pub banner: Option<String>,
+  /// Legal comments and the banner don't change the hashes of chunks.
+  pub hash_ignores_comments: bool,
 }
 
 impl Default for OutputOptions {
@@ -19,6 +62,24 @@ impl Default for OutputOptions {
       dir: None,
       format: ModuleFormat::Esm,
       export_mode: ExportMode::Auto,
+      minify: Default::default(),
+      preserve_modules: false,
+      manual_chunks: vec![],
+      target: None,
+      mode: Default::default(),
+      sourcemap: false,
+      source_root: None,
+      graph_file: None,
+      manifest_file: None,
+      asset_file_names: FileNameTemplate::from("[name]-[hash]".to_string()),
+      public_path: None,
+      module_preload: false,
+      chunk_load_error_handler: None,
+      name: None,
+      globals: Default::default(),
+      polyfills: Default::default(),
+      banner: None,
+      hash_ignores_comments: false,
     }
   }
 }
@@ -0,0 +1,2 @@
+export default 'lazy'
+export const named = 'named'
@@ -0,0 +1 @@
+export const load = () => import('./lazy.js')
@@ -0,0 +1,3 @@
+import { join } from 'path'
+console.log('a')
+export const a = join('a', 'b')
@@ -0,0 +1,3 @@
+import * as path from 'path'
+console.log('b')
+export const b = path.sep
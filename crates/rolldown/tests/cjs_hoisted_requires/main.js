@@ -0,0 +1,4 @@
+import path from 'path'
+import { a } from './a.js'
+import { b } from './b.js'
+console.log(path.basename(a), b)
@@ -4,7 +4,10 @@ use std::{
 };
 
 use rolldown::Bundler;
-use rolldown::{Asset, BuildResult, ExportMode, ModuleFormat, OutputOptions};
+use rolldown::{
+  Asset, BuildMode, BuildResult, ExportMode, FileNameTemplate, ManualChunk, ManualChunkTest,
+  MinifyOptions, ModuleFormat, OutputOptions, Target,
+};
 use rolldown_test_utils::tester::Tester;
 
 pub struct CompiledFixture {
@@ -68,6 +71,44 @@ pub async fn compile_fixture(test_config_path: &Path) -> CompiledFixture {
       // dir: Some(fixture_path.join("dist").to_string_lossy().to_string()),
       format: ModuleFormat::from_str(&tester.config.output.format).unwrap(),
       export_mode: ExportMode::from_str(&tester.config.output.export_mode).unwrap(),
+      chunk_file_names: FileNameTemplate::from(tester.config.output.chunk_file_names.clone()),
+      minify: MinifyOptions {
+        syntax: tester.config.output.minify_syntax,
+        identifiers: tester.config.output.minify_identifiers,
+        hoist_strings: tester.config.output.minify_hoist_strings,
+        inline_functions: tester.config.output.minify_inline_functions,
+        hoist_common_subexpressions: tester.config.output.minify_hoist_common_subexpressions,
+        enums: tester.config.output.minify_enums,
+      },
+      preserve_modules: tester.config.output.preserve_modules,
+      manual_chunks: tester
+        .config
+        .output
+        .manual_chunks
+        .iter()
+        .map(|manual_chunk| {
+          ManualChunk::new(
+            manual_chunk.name.clone(),
+            ManualChunkTest::Glob(manual_chunk.test.clone()),
+          )
+        })
+        .collect(),
+      target: tester
+        .config
+        .output
+        .target
+        .as_deref()
+        .map(|target| Target::from_str(target).unwrap()),
+      mode: tester
+        .config
+        .output
+        .mode
+        .as_deref()
+        .map(|mode| BuildMode::from_str(mode).unwrap())
+        .unwrap_or_default(),
+      name: tester.config.output.name.clone(),
+      globals: tester.config.output.globals.clone(),
+      polyfills: tester.config.output.polyfills.clone(),
       ..Default::default()
     })
     .await;
@@ -0,0 +1,2 @@
+/*! Apache License 2.0 */
+export const answer = 42
@@ -0,0 +1,2 @@
+/*! MIT License */
+export const answer = 42
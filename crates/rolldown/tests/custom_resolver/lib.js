@@ -0,0 +1 @@
+export const lib = 'lib'
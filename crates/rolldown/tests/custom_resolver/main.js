@@ -0,0 +1,4 @@
+import { magic } from 'magic:x'
+import { lib } from './lib'
+import { unknown } from 'magic:unknown'
+console.log(magic, lib, unknown)
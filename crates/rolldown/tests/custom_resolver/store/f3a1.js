@@ -0,0 +1 @@
+export const magic = 'magic'
@@ -0,0 +1,3 @@
+import { shared } from './shared'
+console.log('a', shared)
+import('./lazy').then(console.log)
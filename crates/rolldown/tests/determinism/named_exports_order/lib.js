@@ -0,0 +1,3 @@
+export const c = 1
+export const a = 2
+export const b = 3
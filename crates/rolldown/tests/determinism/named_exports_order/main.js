@@ -0,0 +1,6 @@
+import * as lib from './lib.js'
+
+export const zebra = 1
+export const apple = 2
+export function mango() {}
+export { lib }
@@ -0,0 +1 @@
+import './style.css'
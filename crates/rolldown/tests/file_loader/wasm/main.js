@@ -0,0 +1,2 @@
+import url from './add.wasm'
+console.log(url)
@@ -0,0 +1,2 @@
+export const square = (x) => Math.pow(x, 2)
+export const merge = (o) => Object.assign({}, o)
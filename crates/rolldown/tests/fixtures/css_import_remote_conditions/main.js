@@ -0,0 +1,2 @@
+import './main.css'
+console.log('main')
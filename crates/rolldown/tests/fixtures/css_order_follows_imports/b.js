@@ -0,0 +1,3 @@
+import './b.css'
+import './shared.css'
+console.log('b')
@@ -0,0 +1,2 @@
+import './button.css'
+export const label = 'button'
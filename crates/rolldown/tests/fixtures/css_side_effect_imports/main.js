@@ -0,0 +1,3 @@
+import { label } from './button.js'
+import './reset.css'
+console.log('main')
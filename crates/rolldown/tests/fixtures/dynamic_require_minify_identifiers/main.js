@@ -0,0 +1,5 @@
+const a = require('./a')
+const locale = process.env.LOCALE
+const messages = require('./locales/' + locale)
+
+console.log(a, messages)
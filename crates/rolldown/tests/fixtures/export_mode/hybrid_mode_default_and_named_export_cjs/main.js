@@ -0,0 +1,5 @@
+export default function greet(name) {
+  return 'hello ' + name
+}
+
+export const version = '1.0.0'
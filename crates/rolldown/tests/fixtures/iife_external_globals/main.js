@@ -0,0 +1,5 @@
+import React from 'react'
+import { debounce } from 'lodash'
+
+export const render = () => React.createElement('div', null, 'ready')
+export const onResize = debounce(render, 100)
@@ -0,0 +1,4 @@
+import 'polyfill'
+import React from 'react'
+
+export const render = () => React.createElement('div', null, 'ready')
@@ -0,0 +1,4 @@
+import { bold } from 'fmt/colors.js'
+import { info } from 'log'
+
+info(bold('hello'))
@@ -0,0 +1,5 @@
+import { Button } from './button'
+
+type Props = { label: string }
+
+export const App = ({ label }: Props) => <main><Button label={label} /></main>
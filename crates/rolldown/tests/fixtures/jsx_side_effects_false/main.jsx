@@ -0,0 +1,5 @@
+const x = <div />;
+
+console.log(<span />);
+
+export const List = ({ items }) => <ul>{items.map((item) => <li>{item}</li>)}</ul>;
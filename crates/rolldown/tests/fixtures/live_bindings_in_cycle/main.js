@@ -0,0 +1,7 @@
+import { read } from './reader.js'
+export let counter = 0
+export function increment() {
+  counter += 1
+}
+increment()
+console.log(read())
@@ -0,0 +1,4 @@
+import { counter } from './main.js'
+export function read() {
+  return counter
+}
@@ -0,0 +1,12 @@
+export const options = {}
+function state() {
+  return options
+}
+export function configure(next) {
+  state().mode ||= next.mode
+  state().retries &&= next.retries
+  state()[next.key()] ??= next.fallback
+}
+export let count
+count ??= 0
+export const reset = (cache) => cache.get().size ||= 0
@@ -0,0 +1,18 @@
+enum Direction {
+  Up,
+  Down,
+  Left = 10,
+  Right,
+}
+
+enum Level {
+  Low,
+  High,
+}
+
+export function turn(step: number) {
+  return step === Direction.Up ? Direction.Down : Direction['Right']
+}
+
+export const isLeft = (dir: number) => dir === Direction.Left
+export const levelName = (level: number) => Level[level]
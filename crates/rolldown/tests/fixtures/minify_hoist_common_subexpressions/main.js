@@ -0,0 +1,18 @@
+export class List {
+  render() {
+    const empty = this.state.items.length === 0
+    const many = this.state.items.length > 10
+    const half = this.state.items.length / 2
+    return [empty, many, half, this.state.items.length]
+  }
+}
+
+export function guarded(o) {
+  if (!o.a) return
+  return [o.a.b.c, o.a.b.c, o.a.b.c, o.a.b.c]
+}
+
+export function kept(o) {
+  log(o.a.b.c)
+  return [o.a.b.c, o.a.b.c, o.a.b.c, o.a.b.c]
+}
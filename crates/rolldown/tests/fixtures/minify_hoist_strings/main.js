@@ -0,0 +1,9 @@
+export function check(name, email, city, street, zip) {
+  if (!name) throw new TypeError('Expected a non-empty string')
+  if (!email) throw new TypeError('Expected a non-empty string')
+  if (!city) throw new TypeError('Expected a non-empty string')
+  if (!street) throw new TypeError('Expected a non-empty string')
+  if (!zip) throw new TypeError('Expected a non-empty string')
+}
+
+export const modes = ['on', 'off', 'on']
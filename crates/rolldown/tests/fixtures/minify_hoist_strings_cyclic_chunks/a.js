@@ -0,0 +1,4 @@
+import { b } from './b'
+
+// `b.js` runs first, and could call `a` before a hoisted constant is initialized.
+export const a = () => b('Repeated in a cycle', 'Repeated in a cycle', 'Repeated in a cycle')
@@ -0,0 +1,4 @@
+import { a } from './a'
+
+export const b = (...messages) => console.log(messages)
+export const run = () => a()
@@ -0,0 +1,8 @@
+function helper() {
+  const value = 1
+  return value
+}
+
+export function publicApi() {
+  return helper()
+}
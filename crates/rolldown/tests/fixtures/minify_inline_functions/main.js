@@ -0,0 +1,16 @@
+function add(a, b) {
+  return a + b
+}
+
+function scale(value, factor) {
+  return value * factor
+}
+
+// Called twice
+function double(n) {
+  return n * 2
+}
+
+export const sum = add(1, 2)
+export const scaled = scale(Math.random(), 2)
+export const twice = double(1) + double(2)
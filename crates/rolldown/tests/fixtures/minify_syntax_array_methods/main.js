@@ -0,0 +1,5 @@
+export const joined = [[1, 2, 3].join('-'), ['a', null, undefined, , true].join(), [].join('x')]
+export const concatenated = [[1, 2].concat([3]), [1].concat(2, [3, 4])]
+export const lengths = [['a', 'b'].length, [1, , 3].length]
+export const split = ['a,b'.split(',')[1], 'a,b'.split(',').length, 'abc'.split('')[2]]
+export const kept = [[f()].join(), [1].concat(a), 'a,b'.split(',').map(f), [g()].length]
@@ -0,0 +1,3 @@
+export const spread = [[...[1, 2, 3]], [0, ...[1, , 3]], [...[...a]]]
+export const from = [Array.from([1, 2, 3]), Array.from([1, , 3])]
+export const kept = [Array.from([1, 2], (x) => x * 2), Array.from(list), Array.from('ab'), [...list]]
@@ -0,0 +1,15 @@
+export const inc = (x) => {
+  return x + 1
+}
+console.log(inc)
+export const noop = () => {
+  return
+}
+console.log(noop)
+export const wrap = (x) => {
+  return { x }
+}
+console.log(wrap)
+export const has = () => {
+  return { a: 1 }.hasOwnProperty('a')
+}
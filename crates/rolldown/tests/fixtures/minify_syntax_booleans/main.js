@@ -0,0 +1,6 @@
+if (true) console.log('ready')
+export function isEmpty() {
+  return false
+}
+export const flags = { true: 1, enabled: true, name: 'true' }
+export const value = flags.true
@@ -0,0 +1,6 @@
+export const a = [String(123), String(true), String(null), String()]
+export const b = [Number('5'), Number('0x10'), Number(' 0b101\n'), Number(''), Number('1.5e3'), Number(true), Number(null), Number()]
+export const c = [Number('abc'), Number('inf'), Number('0x'), Number(undefined)]
+export const d = [Boolean(0), Boolean('x'), Boolean(''), Boolean(null), Boolean()]
+export const e = (x) => Boolean(x)
+export const f = (String) => String(123)
@@ -0,0 +1,17 @@
+export function update(c) {
+  if (c) {
+    f()
+    x()
+  } else {
+    g()
+    x()
+  }
+}
+export function reset(c) {
+  if (c) {
+    x()
+  } else {
+    g()
+    x()
+  }
+}
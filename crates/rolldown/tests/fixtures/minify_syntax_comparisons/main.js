@@ -0,0 +1,2 @@
+export const values = [1 < 2, 'a' === 'a', null == undefined, NaN === NaN, 0 == '', 0 == null, 'b' > 'a', 1 != '1']
+export const unknown = (x) => [x < 2, 'a' == 'abc' + x, 0 == 'a']
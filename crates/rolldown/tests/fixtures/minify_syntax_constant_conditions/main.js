@@ -0,0 +1,4 @@
+export const pick = (a) => a === 1 ? 'x' : 2 === 2 ? 'y' : 'z'
+export const nested = (a) => a ? 'x' : (a === 2 ? 'y' : ('b' == 'b' ? 'z' : 'w'))
+export const branches = [1 === 1 ? x : y, 'a' !== 'a' ? x : y, true ? x : y, null ? x : y, [] ? x : y]
+export const effects = void g() ? x : y
@@ -0,0 +1,15 @@
+export function compute() {
+  let x = 1
+  x = 2
+  return 3
+}
+export function load() {
+  let data = fetchData()
+  data = 'unused'
+  return 4
+}
+export function update() {
+  let y = 1
+  y = read()
+  return y
+}
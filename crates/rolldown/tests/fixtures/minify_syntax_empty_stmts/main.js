@@ -0,0 +1,9 @@
+export function run(c) {
+  if (c) {}
+  if (c) {} else g()
+  if (c) g(); else {}
+  {}
+  done: ;
+  g();;
+}
+export const noop = () => {}
@@ -0,0 +1,4 @@
+export const a = 2 ** 3
+export const b = Math.pow(2, 10)
+export const c = (x) => Math.pow(x, 2)
+export const d = (x, y) => Math.pow(x + 1, y)
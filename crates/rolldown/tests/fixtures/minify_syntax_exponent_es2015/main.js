@@ -0,0 +1,2 @@
+export const b = Math.pow(2, 10)
+export const c = (x) => Math.pow(x, 2)
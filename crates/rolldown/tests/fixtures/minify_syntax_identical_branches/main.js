@@ -0,0 +1,8 @@
+function check() {
+  return Math.random() > 0.5
+}
+
+export const pure = (cond, v) => cond ? v : v
+export const calls = (cond, a) => cond ? a() : a()
+export const effect = (v) => check() ? v : v
+export const different = (cond, a, b) => cond ? a : b
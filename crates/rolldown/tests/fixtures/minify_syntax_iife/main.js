@@ -0,0 +1,17 @@
+export const answer = (() => 42)()
+export const value = (function () {
+  return a
+})()
+export const nothing = (() => {})()
+
+// `x` would leak into the module scope
+export const scoped = (function () {
+  var x = read()
+  return x
+})()
+export const self = (function () {
+  return this
+})()
+export const named = (function f() {
+  return f
+})()
@@ -0,0 +1,3 @@
+export const objects = ['k' in { k: 1 }, 'z' in { a: 1 }, 1 in { 1: 'a' }, 'g' in { get g() {} }]
+export const arrays = [0 in [1], 1 in [1], 1 in [0, , 2], 'length' in []]
+export const kept = ['toString' in {}, 'a' in { a: f() }, 'a' in { ...o }, 'map' in [], [] instanceof Array]
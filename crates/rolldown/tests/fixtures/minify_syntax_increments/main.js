@@ -0,0 +1,10 @@
+export let i = 0
+i = i + 1
+export let a = 0
+a += 1
+export let label = ''
+label += 1
+export function step(n) {
+  n -= 1
+  return (i += 1) + n
+}
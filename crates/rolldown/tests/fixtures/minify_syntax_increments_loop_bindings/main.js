@@ -0,0 +1,8 @@
+// The bindings of the loops hold whatever is iterated, like strings
+export function concat(xs, obj) {
+  for (let x of xs) console.log((x += 1))
+  for (let key in obj) console.log((key += 1))
+}
+// Never assigned a number
+let unset
+export const bump = () => (unset += 1)
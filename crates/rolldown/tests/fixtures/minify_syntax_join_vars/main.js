@@ -0,0 +1,16 @@
+const a = 1
+const b = a + 1
+console.log(a, b)
+let c = 1
+console.log(c)
+let d = c
+var e = 1
+var f = 2
+console.log(e, f)
+var g
+function foo() {
+  let x = 1
+  let y = 2
+  return x + y
+}
+export { d, g, foo }
@@ -0,0 +1,3 @@
+export const strings = ['hello'.length, 'a😀b'.length, ''.length]
+export const arrays = [[1, 2].length, [1, , 3].length]
+export const kept = [[...a].length, [f()].length, s.length]
@@ -0,0 +1,4 @@
+export const a = [true && x, 'x' && x, false && x, null && x]
+export const b = [true || x, [] || x, false || x, '' || x]
+export const c = [null ?? x, undefined ?? x, void 0 ?? x, 0 ?? x, '' ?? x]
+export const d = [void g() || x, void g() ?? x, void g() && x, y && x]
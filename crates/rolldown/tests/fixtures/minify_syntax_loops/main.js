@@ -0,0 +1,26 @@
+export function spin() {
+  while (true) tick()
+}
+
+export function poll(ready) {
+  for (; ready(); ) tick()
+}
+
+export function wait() {
+  for (0; true; 0) {}
+}
+
+export function count() {
+  for (let i = 0; i < 10; i++) {}
+}
+
+export function drain(queue) {
+  for (;;) {
+    if (!queue.length) break
+    queue.pop()
+  }
+}
+
+export function idle(busy) {
+  while (busy()) {}
+}
@@ -0,0 +1,9 @@
+let mode = 'dev'
+let count = 0
+export const toggle = () => (mode = 'prod')
+export const add = () => (count += 2)
+export const checks = (x, y) => [mode === 'dev', count !== 0, typeof x === 'string', !x === !y]
+// `x` could be anything, and `label` becomes a number
+let label = '1'
+export const bump = () => label++
+export const kept = (x) => [x === 'x', label === '2', mode === count]
@@ -0,0 +1,4 @@
+// `x` holds whatever is iterated, like `'1'`
+export function check(xs) {
+  for (let x of xs) console.log(x === 1, x !== '1')
+}
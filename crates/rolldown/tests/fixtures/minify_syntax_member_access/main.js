@@ -0,0 +1,4 @@
+export const arrays = [[10, 20][0], [1, 2, 3][1], [10, 20][2], [1, , 3][1]]
+export const objects = [({ x: 5 }).x, { a: 1, a: 2 }['a'], ({ x: 5 }).toString]
+export const strings = ['abc'[0], 'abc'[3]]
+export const kept = [[g(), 2][1], [...a][0], [f][0](), { x: g() }.x]
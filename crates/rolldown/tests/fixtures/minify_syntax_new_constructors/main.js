@@ -0,0 +1,13 @@
+export const a = new Array(1, 2, 3)
+export const b = new Array(3)
+export const c = new Object()
+export const d = new Object({ x: 1 })
+export const e = new RegExp('a+', 'g')
+// Invalid patterns throw when the constructor runs, but would be early errors as literals
+export const f = new RegExp('(')
+export const g = new RegExp('[a')
+
+export function shadowed() {
+  const Array = function () {}
+  return new Array(1, 2)
+}
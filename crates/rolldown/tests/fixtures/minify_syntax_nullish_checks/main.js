@@ -0,0 +1,4 @@
+export const isNil = (x) => x === null || x === undefined
+export const isSet = (x) => x !== null && x !== undefined
+export const reordered = (x) => undefined === x || null === x
+export const kept = (x, y) => [x === null || y === undefined, x.a === null || x.a === undefined, x === null && x === undefined]
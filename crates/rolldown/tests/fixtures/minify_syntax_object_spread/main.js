@@ -0,0 +1,3 @@
+export const a = { ...{ a: 1 }, b: 2 }
+export const b = (o) => Object.assign({}, o)
+export const c = { ...{ get x() { return 1 } } }
@@ -0,0 +1 @@
+export const b = (o) => Object.assign({}, o)
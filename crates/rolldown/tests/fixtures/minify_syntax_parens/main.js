@@ -0,0 +1,5 @@
+export const a = (x) => ((x))
+export const b = (p, q, r) => (p + q) * r
+export const c = (p, q, r) => p + (q + r)
+export const d = (p) => (p?.q).r
+export const e = (p) => ({ p })
@@ -0,0 +1,6 @@
+export const possessive = 'it\'s Bob\'s friend\'s'
+export const quoted = "say \"hi\""
+export const tie = 'a"b\'c`d'
+export const lines = 'it\'s a "line"\nand another'
+export const substitution = 'it\'s "${not}"\nhere'
+export const keys = { 'it\'s': 1 }
@@ -0,0 +1,10 @@
+export function a(x) {
+  if (x) return undefined
+  console.log(x)
+  return void 0
+}
+
+export function b(undefined) {
+  if (b) return undefined
+  console.log(b)
+}
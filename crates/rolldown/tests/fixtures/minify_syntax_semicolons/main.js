@@ -0,0 +1,8 @@
+export function f() {};
+export class A {
+  m() {};
+  n() {}
+};
+export let a, b
+a = 1; b = 2;
+if (a) { f() };
@@ -0,0 +1,13 @@
+export function foo() {
+  a()
+  b()
+  c()
+  return d
+}
+
+export function bar() {
+  a()
+  let x = b()
+  c()
+  return x
+}
@@ -0,0 +1,7 @@
+const x = 1, y = 2
+export const shorthand = { x: x, 'y': y }
+export const method = { m: function () { return 1 } }
+export const named = { f: function f() { return this } }
+export const recursive = { f: function f(n) { return n && f(n - 1) } }
+export const renamed = { g: function f() { return 1 } }
+export const kept = { y: x, [x]: x, __proto__: x, a: () => 1 }
@@ -0,0 +1,3 @@
+var x = 1
+export var shorthand = { x: x }
+export var method = { m: function () { return 1 } }
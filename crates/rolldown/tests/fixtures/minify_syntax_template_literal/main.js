@@ -0,0 +1,5 @@
+export const a = `abc`
+export const b = `line one
+line two`
+export const c = `it's "quoted" ${1} ${true} ${'x'}`
+export const d = (x) => `a${x}b`
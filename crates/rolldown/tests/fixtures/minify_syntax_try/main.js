@@ -0,0 +1,15 @@
+try {
+  a()
+} catch (e) {}
+try {
+  b()
+} catch (e) {
+  console.log(e)
+} finally {}
+try {
+  c()
+} finally {}
+try {
+} catch (e) {
+  d()
+}
@@ -0,0 +1,12 @@
+// An optional dependency, which may not be installed
+let fsevents
+try {
+  fsevents = require('fsevents')
+} catch (e) {}
+
+let local
+try {
+  local = require('./local')
+} catch (e) {}
+
+console.log(fsevents, local)
@@ -0,0 +1,22 @@
+let a
+const b = 1
+
+try {
+  a = 1
+} catch {}
+try {
+  mayThrow()
+} catch {}
+try {
+  b = 2
+} catch {}
+try {
+  global = 4
+} catch {}
+try {
+  a = 5
+} catch {
+  a = 6
+}
+
+export { a, b }
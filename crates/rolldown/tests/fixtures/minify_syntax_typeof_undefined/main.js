@@ -0,0 +1,5 @@
+let cache
+
+export const isMissing = (value) => typeof value === 'undefined'
+export const isCached = () => 'undefined' != typeof cache
+export const hasWindow = typeof window !== 'undefined'
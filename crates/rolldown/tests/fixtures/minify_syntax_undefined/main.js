@@ -0,0 +1,6 @@
+export const isMissing = (x) => x === undefined
+export const orDefault = (x) => x !== undefined ? x : 'default'
+export const values = [undefined, typeof undefined, Boolean(undefined)]
+export function shadowed(undefined) {
+  return x === undefined
+}
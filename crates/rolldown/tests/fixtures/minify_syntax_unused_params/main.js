@@ -0,0 +1,22 @@
+function log() {
+  console.log('side effect')
+}
+
+export const f = (a, b) => a
+export const g = (a, b = log()) => a
+
+export function h(a, b, c) {
+  return a + b
+}
+
+export function k(a, b) {
+  return arguments.length
+}
+
+export function m(a, ...rest) {
+  return a
+}
+
+export function n(a, ...rest) {
+  return arguments[1]
+}
@@ -0,0 +1,5 @@
+void /* @__PURE__ */ f()
+export const a = void /* @__PURE__ */ f(1, 'x')
+export const b = void g()
+/* @__PURE__ */ f([])
+void 0
@@ -0,0 +1,4 @@
+import { hello } from './addon.node'
+const native = require('./native.node')
+
+console.log(hello(), native)
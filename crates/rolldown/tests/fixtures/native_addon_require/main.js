@@ -0,0 +1,3 @@
+const binding = require('./build/Release/binding.node')
+
+console.log(binding.hello())
@@ -0,0 +1,4 @@
+import { join } from 'node:path'
+import { dirname } from 'path'
+
+console.log(join('a', 'b'), dirname('a/b'))
@@ -0,0 +1,2 @@
+export const entries = new Map()
+export const empty = Object.fromEntries(new Map())
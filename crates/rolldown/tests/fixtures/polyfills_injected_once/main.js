@@ -0,0 +1,4 @@
+import { empty, entries } from './entries'
+
+export const config = Object.fromEntries(entries)
+console.log(empty)
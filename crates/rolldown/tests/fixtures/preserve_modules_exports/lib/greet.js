@@ -0,0 +1,5 @@
+import { name } from './name'
+
+export default function greet() {
+  return 'hello ' + name
+}
@@ -0,0 +1,4 @@
+import greet from './lib/greet'
+export { name as libName } from './lib/name'
+
+console.log(greet())
@@ -0,0 +1,2 @@
+export const foo = 'a'
+export const shared = 'a'
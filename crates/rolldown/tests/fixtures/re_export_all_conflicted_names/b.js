@@ -0,0 +1,2 @@
+export const bar = 'b'
+export const shared = 'b'
@@ -0,0 +1,4 @@
+import { shared } from './shared'
+const x = 1
+const y = 2
+console.log('a', shared, x, y)
@@ -0,0 +1,4 @@
+import { legacy } from 'legacy'
+import { utils } from './utils'
+
+console.log(legacy, utils)
@@ -0,0 +1 @@
+export const utils = 'utils'
@@ -0,0 +1 @@
+exports.format = 'cjs'
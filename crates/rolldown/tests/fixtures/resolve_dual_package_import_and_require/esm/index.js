@@ -0,0 +1 @@
+export const format = 'esm'
@@ -0,0 +1,4 @@
+import { format } from 'dual/lib'
+const cjs = require('dual/lib')
+
+console.log(format, cjs.format)
@@ -0,0 +1,4 @@
+import { version } from 'lodash'
+import { nestedVersion } from 'nested'
+
+console.log(version, nestedVersion)
@@ -0,0 +1,5 @@
+import { pkg } from '@scope/pkg'
+import { feature } from '@scope/pkg/feature'
+import { math } from '@scope/pkg/utils/math'
+
+console.log(pkg, feature, math)
@@ -0,0 +1,2 @@
+import { util } from 'my-pkg/util'
+console.log(util)
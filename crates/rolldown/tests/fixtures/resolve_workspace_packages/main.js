@@ -0,0 +1,5 @@
+import { ui } from '@acme/ui'
+import { button } from '@acme/ui/button'
+import { utils } from 'utils'
+
+console.log(ui, button, utils)
@@ -0,0 +1 @@
+export const button = 'button'
@@ -0,0 +1,4 @@
+#!/usr/bin/env node
+export function run() {
+  console.log('run')
+}
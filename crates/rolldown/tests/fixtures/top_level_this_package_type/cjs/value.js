@@ -0,0 +1,2 @@
+this.name = 'cjs'
+exports.isExports = this === module.exports
@@ -0,0 +1,4 @@
+export const esmThis = this
+export function method() {
+  return this
+}
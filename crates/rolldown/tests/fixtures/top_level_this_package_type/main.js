@@ -0,0 +1,4 @@
+export { esmThis, method } from './esm/value.js'
+
+const cjs = require('./cjs/value.js')
+export const cjsThis = cjs.isExports
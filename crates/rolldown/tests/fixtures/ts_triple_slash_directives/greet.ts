@@ -0,0 +1,4 @@
+/// <reference path="./not-exist.d.ts" />
+export function greet(name: string): string {
+  return 'hello ' + name
+}
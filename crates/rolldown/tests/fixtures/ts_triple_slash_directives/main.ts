@@ -0,0 +1,6 @@
+/// <reference path="./globals.d.ts" />
+/// <reference types="node" />
+/// <reference lib="es2017.string" />
+import { greet } from './greet'
+
+console.log(greet('rolldown'))
@@ -0,0 +1,4 @@
+/// Returns the greeting for `name`.
+export function greet(name: string): string {
+  return 'hello ' + name
+}
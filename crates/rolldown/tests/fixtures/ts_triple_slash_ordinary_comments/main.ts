@@ -0,0 +1,6 @@
+/// <amd-module name="main" />
+/// <summary>Not a directive, only a comment that looks like one</summary>
+/// Greets whoever runs the bundle.
+import { greet } from './greet'
+
+console.log(greet('rolldown'))
@@ -0,0 +1,4 @@
+import type { Options } from 'foo'
+
+const options: Options = { verbose: true }
+console.log(options)
@@ -0,0 +1,3 @@
+import { parse } from 'foo'
+
+console.log(parse('verbose'))
@@ -0,0 +1,4 @@
+import { Shared } from './shared'
+const sealed = (target: any) => target
+@sealed
+export class B extends Shared {}
@@ -0,0 +1,3 @@
+const sealed = (target: any) => target
+@sealed
+export class Shared {}
@@ -0,0 +1,4 @@
+import { b } from './b.js'
+
+export const a = 'a'
+export const usesB = () => b
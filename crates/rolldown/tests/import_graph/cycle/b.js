@@ -0,0 +1,4 @@
+import { a } from './a.js'
+
+export const b = 'b'
+export const usesA = () => a
@@ -0,0 +1,4 @@
+import { a } from './a.js'
+
+console.log(a)
+import('./lazy.js')
@@ -0,0 +1,3 @@
+import './lazy.css'
+import { format } from './shared.js'
+export default format('lazy')
@@ -0,0 +1,4 @@
+import './main.css'
+import { format } from './shared.js'
+console.log(format('main'))
+export const load = () => import('./lazy.js')
@@ -1,11 +1,1348 @@
-use std::path::PathBuf;
+use std::{
+  path::{Path, PathBuf},
+  sync::Arc,
+};
 
+use futures::{future, FutureExt};
 use testing_macros::fixture;
 
 mod common;
-use common::run_test;
+use common::{compile_fixture, run_test};
+use rolldown::{
+  Bundler, CustomResolution, CustomResolver, FileNameTemplate, InputItem, InputOptions,
+  ManualChunk, ManualChunkTest, ModuleFormat, OutputOptions, RUNTIME_MODULE_ID,
+};
+use rolldown_plugin::{
+  async_trait, BuildPlugin, Context, LoadArgs, LoadOutput, LoadReturn, NamespaceResolveOptions,
+  PluginName, ResolveArgs, ResolveReturn, ResolvedId,
+};
+use rolldown_plugin_node_resolve::{NodeResolvePlugin, ResolverOptions};
+use rolldown_plugin_virtual_fs::VirtualFsPlugin;
+use rolldown_test_utils::{temp_dir::TempDir, tester::Tester};
+use sugar_path::SugarPath;
 
 #[fixture("./tests/fixtures/**/test.config.json")]
 fn test(path: PathBuf) {
   run_test(&path)
 }
+
+#[test]
+fn chunk_file_names_and_contents_are_deterministic() {
+  let config_path = PathBuf::from(env!("CARGO_MANIFEST_DIR"))
+    .join("tests/determinism/chunk_file_names_with_hash/test.config.json");
+  let runtime = tokio::runtime::Runtime::new().unwrap();
+  let build = || {
+    runtime
+      .block_on(compile_fixture(&config_path))
+      .output
+      .unwrap()
+      .into_iter()
+      .map(|asset| (asset.filename, asset.content))
+      .collect::<Vec<_>>()
+  };
+
+  let first = build();
+  assert!(first
+    .iter()
+    .any(|(filename, _)| filename.starts_with("shared-")));
+  assert!(first.iter().any(|(filename, _)| filename.starts_with("lazy-")));
+  assert!(first
+    .iter()
+    .any(|(filename, _)| filename.starts_with("shared-") && filename.ends_with(".css")));
+  for _ in 0..5 {
+    assert_eq!(first, build());
+  }
+}
+
+fn hashed_file_names(fixture: &str, output_options: OutputOptions) -> Vec<String> {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join(fixture);
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let mut filenames = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        entry_file_names: FileNameTemplate::from("[name]-[hash].js".to_string()),
+        chunk_file_names: FileNameTemplate::from("[name]-[hash].js".to_string()),
+        ..output_options
+      }),
+    )
+    .unwrap()
+    .into_iter()
+    .map(|asset| asset.filename)
+    .collect::<Vec<_>>();
+  filenames.sort();
+  filenames
+}
+
+fn hashed_file_name<'a>(filenames: &'a [String], prefix: &str) -> &'a str {
+  filenames
+    .iter()
+    .find(|filename| filename.starts_with(prefix) && filename.ends_with(".js"))
+    .unwrap()
+}
+
+#[test]
+fn chunk_hashes_follow_the_output_options() {
+  let fixture = "tests/determinism/chunk_file_names_with_hash";
+  let esm = hashed_file_names(fixture, Default::default());
+  let cjs = hashed_file_names(
+    fixture,
+    OutputOptions {
+      format: ModuleFormat::Cjs,
+      ..Default::default()
+    },
+  );
+
+  assert_eq!(esm, hashed_file_names(fixture, Default::default()));
+  for prefix in ["a-", "b-", "shared-", "lazy-"] {
+    assert_ne!(
+      hashed_file_name(&esm, prefix),
+      hashed_file_name(&cjs, prefix)
+    );
+  }
+}
+
+#[test]
+fn chunk_hashes_follow_the_file_names_of_imported_chunks() {
+  let original = hashed_file_names(
+    "tests/determinism/chunk_file_names_with_hash",
+    Default::default(),
+  );
+  // Only `lazy.js` differs, which `a.js` and `b.js` import.
+  let changed = hashed_file_names(
+    "tests/determinism/imported_chunk_changed",
+    Default::default(),
+  );
+
+  assert_ne!(
+    hashed_file_name(&original, "lazy-"),
+    hashed_file_name(&changed, "lazy-")
+  );
+  assert_ne!(
+    hashed_file_name(&original, "a-"),
+    hashed_file_name(&changed, "a-")
+  );
+  assert_ne!(
+    hashed_file_name(&original, "b-"),
+    hashed_file_name(&changed, "b-")
+  );
+  assert_eq!(
+    hashed_file_name(&original, "shared-"),
+    hashed_file_name(&changed, "shared-")
+  );
+}
+
+#[test]
+fn named_exports_are_sorted_and_deterministic() {
+  let config_path = PathBuf::from(env!("CARGO_MANIFEST_DIR"))
+    .join("tests/determinism/named_exports_order/test.config.json");
+  let runtime = tokio::runtime::Runtime::new().unwrap();
+  let build = || {
+    let mut assets = runtime
+      .block_on(compile_fixture(&config_path))
+      .output
+      .unwrap();
+    assert_eq!(assets.len(), 1);
+    assets.remove(0).content
+  };
+
+  let first = build();
+  let export_stmt = first
+    .lines()
+    .find(|line| line.starts_with("export {"))
+    .unwrap();
+  let exported_names = export_stmt
+    .trim_start_matches("export {")
+    .trim_end_matches("};")
+    .split(',')
+    .map(|spec| spec.split_whitespace().last().unwrap())
+    .collect::<Vec<_>>();
+  assert_eq!(exported_names, ["apple", "lib", "mango", "zebra"]);
+
+  // Keys of the namespace object are sorted too
+  let getters = ["get a ()", "get b ()", "get c ()"].map(|getter| first.find(getter).unwrap());
+  assert!(getters.windows(2).all(|pair| pair[0] < pair[1]));
+
+  for _ in 0..5 {
+    assert_eq!(first, build());
+  }
+}
+
+#[test]
+fn output_does_not_depend_on_concurrency() {
+  let fixture_path =
+    PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/determinism/chunk_file_names_with_hash");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let runtime = tokio::runtime::Runtime::new().unwrap();
+  let build = |concurrency: usize| {
+    let mut input_options = tester.input_options(fixture_path.clone());
+    input_options.concurrency = Some(concurrency);
+    runtime
+      .block_on(Bundler::new(input_options).generate(OutputOptions {
+        chunk_file_names: FileNameTemplate::from(tester.config.output.chunk_file_names.clone()),
+        ..Default::default()
+      }))
+      .unwrap()
+      .into_iter()
+      .map(|asset| (asset.filename, asset.content))
+      .collect::<Vec<_>>()
+  };
+
+  let sequential = build(1);
+  for _ in 0..5 {
+    assert_eq!(sequential, build(8));
+  }
+}
+
+#[test]
+fn rendered_lengths_sum_up_to_the_output_length() {
+  let config_path = PathBuf::from(env!("CARGO_MANIFEST_DIR"))
+    .join("tests/fixtures/rendered_modules_with_shared_chunk/test.config.json");
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(compile_fixture(&config_path))
+    .output
+    .unwrap();
+
+  for asset in assets {
+    assert_eq!(
+      asset
+        .rendered_modules
+        .iter()
+        .map(|m| m.rendered_length.unwrap())
+        .sum::<usize>(),
+      asset.content.len(),
+      "{}",
+      asset.filename
+    );
+    let contributors = asset
+      .rendered_modules
+      .iter()
+      .filter(|m| m.rendered_length.unwrap() > 0)
+      .map(|m| m.id.as_str())
+      .collect::<Vec<_>>();
+    match asset.filename.as_str() {
+      // Cross-chunk imports are attributed to the runtime
+      "a.js" => assert_eq!(contributors, ["a.js", RUNTIME_MODULE_ID]),
+      "b.js" => assert_eq!(contributors, ["b.js", RUNTIME_MODULE_ID]),
+      "shared.js" => assert_eq!(contributors, ["shared.js", RUNTIME_MODULE_ID]),
+      filename => panic!("Unexpected asset {filename}"),
+    }
+  }
+}
+
+#[test]
+fn rendered_lengths_of_modules_are_unknown_for_cjs_output() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR"))
+    .join("tests/fixtures/rendered_modules_with_shared_chunk");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        format: ModuleFormat::Cjs,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+
+  for asset in assets {
+    let (runtime, modules): (Vec<_>, Vec<_>) = asset
+      .rendered_modules
+      .iter()
+      .partition(|m| m.id == RUNTIME_MODULE_ID);
+    assert!(
+      modules.iter().all(|m| m.rendered_length.is_none()),
+      "{}",
+      asset.filename
+    );
+    assert_eq!(runtime[0].rendered_length, Some(asset.content.len()));
+  }
+}
+
+#[test]
+fn source_map_has_source_root_and_relative_sources() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/source_map/source_root");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let mut assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        sourcemap: true,
+        source_root: Some("webpack://app/".to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 1);
+  let asset = assets.remove(0);
+
+  assert!(asset
+    .content
+    .ends_with("\n//# sourceMappingURL=main.js.map"));
+  let map = sourcemap::SourceMap::from_slice(asset.map.unwrap().as_bytes()).unwrap();
+  assert_eq!(map.get_source_root(), Some("webpack://app/"));
+  let mut sources = map.sources().collect::<Vec<_>>();
+  sources.sort();
+  assert_eq!(sources, ["lib.js", "main.js"]);
+  // Contents are embedded even though the sources could be fetched from the root
+  assert!((0..map.get_source_count()).all(|id| map.get_source_contents(id).is_some()));
+}
+
+#[test]
+fn every_chunk_of_a_split_build_links_its_own_source_map() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/source_map/splitting");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        chunk_file_names: FileNameTemplate::from(tester.config.output.chunk_file_names.clone()),
+        sourcemap: true,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  // a.js, b.js, the shared chunk and the lazy chunk
+  assert_eq!(assets.len(), 4);
+
+  let mut sources_of_chunks = vec![];
+  for asset in assets {
+    let map_file = Path::new(&asset.filename)
+      .file_name()
+      .unwrap()
+      .to_string_lossy()
+      .to_string();
+    // The hash in the name of the chunk is already final when the comment is rendered.
+    assert!(
+      asset
+        .content
+        .ends_with(&format!("\n//# sourceMappingURL={map_file}.map")),
+      "{}",
+      asset.content
+    );
+    let map = sourcemap::SourceMap::from_slice(asset.map.unwrap().as_bytes()).unwrap();
+    assert_eq!(map.get_file(), Some(map_file.as_str()));
+
+    // Sources are relative to the map, which is written next to the chunk.
+    let map_dir = fixture_path.join("dist").join(&asset.filename);
+    let map_dir = map_dir.parent().unwrap();
+    let line_count = asset.content.lines().count() as u32;
+    for token in map.tokens() {
+      assert!(token.get_dst_line() < line_count);
+      let source = token.get_source().unwrap();
+      assert!(map_dir.join(source).normalize().exists(), "{source}");
+    }
+    let mut sources = map.sources().map(ToString::to_string).collect::<Vec<_>>();
+    sources.sort();
+    sources_of_chunks.push((asset.filename, sources));
+  }
+  sources_of_chunks.sort();
+
+  let shared_chunk = sources_of_chunks
+    .iter()
+    .find(|(filename, _)| filename.starts_with("chunks/shared-"))
+    .unwrap();
+  assert_eq!(shared_chunk.1, ["../../shared.js"]);
+  let lazy_chunk = sources_of_chunks
+    .iter()
+    .find(|(filename, _)| filename.starts_with("chunks/lazy-"))
+    .unwrap();
+  assert_eq!(lazy_chunk.1, ["../../lazy.js"]);
+  let entries = sources_of_chunks
+    .iter()
+    .filter(|(filename, _)| !filename.starts_with("chunks/"))
+    .collect::<Vec<_>>();
+  assert_eq!(
+    entries,
+    [
+      &("a.js".to_string(), vec!["../a.js".to_string()]),
+      &("b.js".to_string(), vec!["../b.js".to_string()]),
+    ]
+  );
+}
+
+#[test]
+fn css_source_map_maps_inlined_imports_to_their_files() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/source_map/css");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        sourcemap: true,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  let css = assets
+    .iter()
+    .find(|asset| asset.filename == "main.css")
+    .unwrap();
+
+  assert!(
+    css
+      .content
+      .ends_with("\n/*# sourceMappingURL=main.css.map */"),
+    "{}",
+    css.content
+  );
+  let map = sourcemap::SourceMap::from_slice(css.map.as_ref().unwrap().as_bytes()).unwrap();
+  let mut sources = map.sources().collect::<Vec<_>>();
+  sources.sort();
+  assert_eq!(sources, ["../base.css", "../main.css"]);
+
+  // `base.css` is inlined in place of the `@import` of `main.css`.
+  let lines = css.content.lines().collect::<Vec<_>>();
+  let origin_of = |text: &str| {
+    let line = lines.iter().position(|line| *line == text).unwrap() as u32;
+    let token = map.lookup_token(line, 0).unwrap();
+    assert_eq!(token.get_dst_line(), line);
+    (token.get_source().unwrap(), token.get_src_line())
+  };
+  assert_eq!(origin_of("body {"), ("../base.css", 0));
+  assert_eq!(origin_of("  margin: 0;"), ("../base.css", 1));
+  assert_eq!(origin_of(".app {"), ("../main.css", 2));
+  assert_eq!(origin_of("  color: red;"), ("../main.css", 3));
+  assert!((0..map.get_source_count()).all(|id| map.get_source_contents(id).is_some()));
+}
+
+#[test]
+fn bundles_a_graph_from_the_virtual_fs() {
+  // Nothing exists on disk
+  let cwd = PathBuf::from("/virtual-project");
+  let files = [
+    (
+      "main.js",
+      "import { lib } from './lib'\nimport { dir } from './dir'\nimport { pkg } from 'pkg'\nconsole.log(lib, dir, pkg)",
+    ),
+    ("lib.ts", "export const lib: string = 'lib'"),
+    ("dir/index.js", "export const dir = 'dir'"),
+    ("node_modules/pkg/package.json", r#"{ "main": "./src/main" }"#),
+    ("node_modules/pkg/src/main.js", "export const pkg = 'pkg'"),
+  ];
+  let mut assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::with_plugins(
+        InputOptions {
+          input: vec![InputItem {
+            name: "main".to_string(),
+            import: "./main.js".to_string(),
+          }],
+          cwd: cwd.clone(),
+          ..Default::default()
+        },
+        vec![VirtualFsPlugin::new_boxed(files, cwd)],
+      )
+      .generate(Default::default()),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 1);
+  let content = assets.remove(0).content;
+
+  for header in [
+    "// lib.ts",
+    "// dir/index.js",
+    "// node_modules/pkg/src/main.js",
+    "// main.js",
+  ] {
+    assert!(
+      content.contains(header),
+      "{header} is missing in\n{content}"
+    );
+  }
+  assert!(!content.contains("import "));
+}
+
+#[test]
+fn import_graph_has_cycles_and_tree_shaken_modules() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/import_graph/cycle");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let dir = TempDir::new("import_graph");
+  let graph_file = dir.join("graph.json");
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        graph_file: Some(graph_file.to_string_lossy().to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  // `b.js` is tree-shaken, but it's still in the graph.
+  assert!(assets
+    .iter()
+    .all(|asset| !asset.content.contains("// b.js")));
+
+  let graph: serde_json::Value =
+    serde_json::from_str(&std::fs::read_to_string(&graph_file).unwrap()).unwrap();
+  assert_eq!(
+    graph,
+    serde_json::json!({
+      "a.js": [{ "id": "b.js", "kind": "static" }],
+      "b.js": [{ "id": "a.js", "kind": "static" }],
+      "lazy.js": [],
+      "main.js": [
+        { "id": "a.js", "kind": "static" },
+        { "id": "lazy.js", "kind": "dynamic" },
+      ],
+    })
+  );
+}
+
+#[test]
+fn wasm_is_copied_and_imported_as_its_url() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/file_loader/wasm");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let dir = TempDir::new("file_loader");
+  let runtime = tokio::runtime::Runtime::new().unwrap();
+  let assets = runtime
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).write(OutputOptions {
+        dir: Some(dir.to_string_lossy().to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  let wasm = assets
+    .iter()
+    .find(|asset| asset.filename.ends_with(".wasm"))
+    .unwrap();
+  let hash = wasm
+    .filename
+    .strip_prefix("add-")
+    .and_then(|rest| rest.strip_suffix(".wasm"))
+    .unwrap();
+  assert_eq!(hash.len(), 8);
+  assert_eq!(
+    std::fs::read(dir.join(&wasm.filename)).unwrap(),
+    std::fs::read(fixture_path.join("add.wasm")).unwrap()
+  );
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+  assert!(
+    main.content.contains(&format!("\"./{}\"", wasm.filename)),
+    "{}",
+    main.content
+  );
+
+  let assets = runtime
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        asset_file_names: FileNameTemplate::from("assets/[name]-[hash]".to_string()),
+        public_path: Some("https://cdn.example.com".to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+  assert!(
+    main.content.contains(&format!(
+      "\"https://cdn.example.com/assets/add-{hash}.wasm\""
+    )),
+    "{}",
+    main.content
+  );
+}
+
+#[test]
+fn css_url_is_rewritten_to_the_public_path_of_the_copy() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/file_loader/css_url");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        public_path: Some("https://cdn.example.com/assets/".to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  let img = assets
+    .iter()
+    .find(|asset| asset.filename.ends_with(".png"))
+    .unwrap();
+  assert!(img.filename.starts_with("img-"), "{}", img.filename);
+  assert_eq!(
+    img.binary.as_deref().unwrap(),
+    std::fs::read(fixture_path.join("img.png")).unwrap()
+  );
+  let css = assets
+    .iter()
+    .find(|asset| asset.filename == "main.css")
+    .unwrap();
+  assert!(
+    css.content.contains(&format!(
+      "url(https://cdn.example.com/assets/{}) no-repeat",
+      img.filename
+    )),
+    "{}",
+    css.content
+  );
+  // Data URIs and remote URLs are left as they are
+  assert!(
+    css
+      .content
+      .contains("url(\"data:image/png;base64,iVBORw0KGgo=\")"),
+    "{}",
+    css.content
+  );
+  assert!(
+    css.content.contains("url('https://example.com/logo.png')"),
+    "{}",
+    css.content
+  );
+}
+
+#[test]
+fn node_modules_are_put_into_the_vendor_chunk() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/manual_chunks/vendor");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+        manual_chunks: vec![ManualChunk::new(
+          "vendor",
+          ManualChunkTest::Glob("**/node_modules/**".to_string()),
+        )],
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 2);
+  let vendor = assets
+    .iter()
+    .find(|asset| asset.filename.starts_with("vendor-"))
+    .unwrap();
+  assert!(vendor.filename.ends_with(".js"), "{}", vendor.filename);
+  assert!(
+    vendor.content.contains("function leftPad") && vendor.content.contains("red"),
+    "{}",
+    vendor.content
+  );
+  // Both modules export `default`, so the chunk exports them by different names.
+  assert!(
+    vendor.content.contains(" as default,") && vendor.content.contains(" as default$1 "),
+    "{}",
+    vendor.content
+  );
+
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+  assert!(main.content.contains("const format"), "{}", main.content);
+  assert!(
+    !main.content.contains("function leftPad"),
+    "{}",
+    main.content
+  );
+  assert!(main.content.contains(&vendor.filename), "{}", main.content);
+}
+
+#[cfg(unix)]
+#[test]
+fn packages_linked_into_several_places_are_bundled_once() {
+  let dir = TempDir::new("symlinked_packages");
+  dir.write("react/index.js", "export const context = { current: null }");
+  for app in ["a", "b"] {
+    std::fs::create_dir_all(dir.join(app).join("node_modules")).unwrap();
+    std::os::unix::fs::symlink(dir.join("react"), dir.join(app).join("node_modules/react"))
+      .unwrap();
+    dir.write(
+      format!("{app}/main.js"),
+      &format!("import {{ context }} from 'react'\nconsole.log('{app}', context)"),
+    );
+  }
+
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::with_plugins(
+        InputOptions {
+          input: ["a", "b"]
+            .into_iter()
+            .map(|app| InputItem {
+              name: app.to_string(),
+              import: format!("./{app}/main.js"),
+            })
+            .collect(),
+          cwd: dir.to_path_buf(),
+          preserve_symlinks: false,
+          ..Default::default()
+        },
+        // The plugin keeps the symlinked paths, so the bundler has to follow them.
+        vec![NodeResolvePlugin::new_boxed(
+          ResolverOptions {
+            symlinks: false,
+            ..Default::default()
+          },
+          dir.to_path_buf(),
+        )],
+      )
+      .generate(Default::default()),
+    )
+    .unwrap();
+
+  // a.js, b.js and the chunk of the shared package
+  assert_eq!(assets.len(), 3);
+  let chunks_with_react = assets
+    .iter()
+    .filter(|asset| asset.content.contains("current: null"))
+    .map(|asset| asset.filename.as_str())
+    .collect::<Vec<_>>();
+  assert_eq!(chunks_with_react.len(), 1, "{chunks_with_react:?}");
+  assert!(!["a.js", "b.js"].contains(&chunks_with_react[0]));
+}
+
+#[test]
+fn pnpm_store_packages_resolve_their_peers_to_their_own_store_entries() {
+  let dir = TempDir::new("pnpm_layout");
+  let store = dir.join("node_modules/.pnpm");
+  let link = |target: &str, path: PathBuf| {
+    std::fs::create_dir_all(path.parent().unwrap()).unwrap();
+    std::os::unix::fs::symlink(target, path).unwrap();
+  };
+
+  for version in ["17.0.0", "18.0.0"] {
+    dir.write(
+      store.join(format!("react@{version}/node_modules/react/index.js")),
+      &format!("export const version = '{version}'"),
+    );
+  }
+  // Both depend on the peer `react@18.0.0`, which is linked next to each of them in the store.
+  for package in ["plugin", "ui"] {
+    let entry = store.join(format!("{package}@1.0.0_react@18.0.0/node_modules"));
+    dir.write(
+      entry.join(package).join("index.js"),
+      &format!("import {{ version }} from 'react'\nexport const {package} = () => version"),
+    );
+    link("../../react@18.0.0/node_modules/react", entry.join("react"));
+    link(
+      &format!(".pnpm/{package}@1.0.0_react@18.0.0/node_modules/{package}"),
+      dir.join("node_modules").join(package),
+    );
+  }
+  // The app itself depends on `react@17.0.0`.
+  link(
+    ".pnpm/react@17.0.0/node_modules/react",
+    dir.join("node_modules/react"),
+  );
+  dir.write(
+    "main.js",
+    "import { plugin } from 'plugin'\nimport { ui } from 'ui'\nimport { version } from 'react'\nconsole.log(plugin(), ui(), version)",
+  );
+
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::with_plugins(
+        InputOptions {
+          input: vec![InputItem {
+            name: "main".to_string(),
+            import: "./main.js".to_string(),
+          }],
+          cwd: dir.to_path_buf(),
+          preserve_symlinks: false,
+          ..Default::default()
+        },
+        vec![NodeResolvePlugin::new_boxed(
+          ResolverOptions {
+            symlinks: false,
+            ..Default::default()
+          },
+          dir.to_path_buf(),
+        )],
+      )
+      .generate(Default::default()),
+    )
+    .unwrap();
+
+  assert_eq!(assets.len(), 1);
+  let content = &assets[0].content;
+  // Reached through the links of both packages, but bundled once by its path in the store
+  assert_eq!(
+    content
+      .matches("// node_modules/.pnpm/react@18.0.0/node_modules/react/index.js")
+      .count(),
+    1,
+    "{content}"
+  );
+  assert!(
+    content.contains("// node_modules/.pnpm/react@17.0.0/node_modules/react/index.js"),
+    "{content}"
+  );
+  assert!(
+    content
+      .contains("// node_modules/.pnpm/plugin@1.0.0_react@18.0.0/node_modules/plugin/index.js"),
+    "{content}"
+  );
+}
+
+#[test]
+fn content_hashes_ignore_legal_comments_and_banners_if_asked() {
+  let mit = "tests/content_hash/mit_license";
+  let apache = "tests/content_hash/apache_license";
+  let build = |fixture: &str, banner: &str, hash_ignores_comments: bool| {
+    hashed_file_names(
+      fixture,
+      OutputOptions {
+        banner: Some(banner.to_string()),
+        hash_ignores_comments,
+        ..Default::default()
+      },
+    )
+  };
+
+  assert_eq!(
+    build(mit, "/* v1 */", true),
+    build(apache, "/* v2 */", true)
+  );
+  assert_ne!(
+    build(mit, "/* v1 */", false),
+    build(apache, "/* v1 */", false)
+  );
+  assert_ne!(build(mit, "/* v1 */", false), build(mit, "/* v2 */", false));
+}
+
+#[test]
+fn legal_comments_and_banners_are_kept_in_the_output() {
+  let fixture_path =
+    PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/content_hash/mit_license");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  for (format, hash_ignores_comments) in [(ModuleFormat::Esm, true), (ModuleFormat::Iife, false)] {
+    let assets = tokio::runtime::Runtime::new()
+      .unwrap()
+      .block_on(
+        Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+          format,
+          banner: Some("/* banner */".to_string()),
+          hash_ignores_comments,
+          ..Default::default()
+        }),
+      )
+      .unwrap();
+    assert_eq!(assets.len(), 1);
+    let content = &assets[0].content;
+    assert!(content.starts_with("/* banner */\n"), "{content}");
+    assert!(content.contains("/*! MIT License */"), "{content}");
+  }
+}
+
+/// Names of the helpers of lowered syntax declared by the assets, like `_decorate`. Copies are
+/// renamed by deconflicting, like `_decorate$1`, which is cut off.
+fn declared_helpers(fixture: &str) -> (Vec<String>, Vec<(String, String)>) {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join(fixture);
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(Bundler::new(tester.input_options(fixture_path)).generate(Default::default()))
+    .unwrap();
+  let helpers = assets
+    .iter()
+    .flat_map(|asset| {
+      asset.content.match_indices("function _").map(|(idx, _)| {
+        let name = &asset.content[idx + "function ".len()..];
+        let name = &name[..name.find('(').unwrap()];
+        name.split('$').next().unwrap().to_string()
+      })
+    })
+    .collect();
+  let assets = assets
+    .into_iter()
+    .map(|asset| (asset.filename, asset.content))
+    .collect();
+  (helpers, assets)
+}
+
+#[test]
+fn helpers_inlined_by_several_modules_are_declared_once() {
+  let (helpers, assets) = declared_helpers("tests/helpers/single_chunk");
+  assert!(!helpers.is_empty(), "{assets:?}");
+  assert_eq!(
+    helpers
+      .iter()
+      .collect::<std::collections::HashSet<_>>()
+      .len(),
+    helpers.len(),
+    "{assets:?}"
+  );
+}
+
+#[test]
+fn helpers_of_split_builds_are_imported_from_a_shared_chunk() {
+  // The decorators of `a.ts`, `b.ts` and `shared.ts` are lowered with the same helpers, and both
+  // entries import the chunk of `shared.ts`.
+  let (helpers, assets) = declared_helpers("tests/helpers/split_build");
+  assert_eq!(assets.len(), 3, "{assets:?}");
+  assert!(!helpers.is_empty(), "{assets:?}");
+  assert_eq!(
+    helpers
+      .iter()
+      .collect::<std::collections::HashSet<_>>()
+      .len(),
+    helpers.len(),
+    "{assets:?}"
+  );
+
+  let (_, shared) = assets
+    .iter()
+    .find(|(filename, _)| filename.starts_with("shared-"))
+    .unwrap();
+  assert!(shared.contains("function _decorate("), "{shared}");
+  for entry in ["a.js", "b.js"] {
+    let (_, content) = assets
+      .iter()
+      .find(|(filename, _)| filename == entry)
+      .unwrap();
+    assert!(!content.contains("function _"), "{content}");
+    assert!(
+      content
+        .lines()
+        .any(|line| line.starts_with("import {") && line.contains("_decorate")),
+      "{content}"
+    );
+  }
+}
+
+#[test]
+fn changing_the_main_field_between_rebuilds_re_resolves_the_package() {
+  let dir = TempDir::new("package_json_changes");
+  dir.write("main.js", "import { value } from 'pkg'\nconsole.log(value)");
+  dir.write("node_modules/pkg/old.js", "export const value = 'old-main'");
+  dir.write("node_modules/pkg/new.js", "export const value = 'new-main'");
+  dir.write("node_modules/pkg/package.json", r#"{ "main": "old.js" }"#);
+
+  let mut bundler = Bundler::with_plugins(
+    InputOptions {
+      input: vec![InputItem {
+        name: "main".to_string(),
+        import: "./main.js".to_string(),
+      }],
+      cwd: dir.to_path_buf(),
+      ..Default::default()
+    },
+    vec![NodeResolvePlugin::new_boxed(
+      Default::default(),
+      dir.to_path_buf(),
+    )],
+  );
+  let runtime = tokio::runtime::Runtime::new().unwrap();
+  let first = runtime
+    .block_on(bundler.generate(Default::default()))
+    .unwrap();
+  dir.write("node_modules/pkg/package.json", r#"{ "main": "new.js" }"#);
+  let second = runtime
+    .block_on(bundler.generate(Default::default()))
+    .unwrap();
+
+  assert!(
+    first[0].content.contains("old-main"),
+    "{}",
+    first[0].content
+  );
+  assert!(
+    second[0].content.contains("new-main"),
+    "{}",
+    second[0].content
+  );
+}
+
+#[test]
+fn dynamic_import_in_cjs_output_resolves_to_a_namespace() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/cjs_dynamic_import");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        format: ModuleFormat::Cjs,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 2);
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+  let lazy = assets
+    .iter()
+    .find(|asset| asset.filename != "main.js")
+    .unwrap();
+
+  // Not a bare `require()`, which would be synchronous
+  assert!(!main.content.contains("import("), "{}", main.content);
+  assert!(
+    main.content.contains("Promise.resolve().then("),
+    "{}",
+    main.content
+  );
+  assert!(
+    main
+      .content
+      .contains(&format!("require(\"./{}\")", lazy.filename)),
+    "{}",
+    main.content
+  );
+  // The namespace is used as it is, instead of being wrapped as the default export
+  assert!(lazy.content.contains("__esModule"), "{}", lazy.content);
+  assert!(lazy.content.contains("named"), "{}", lazy.content);
+}
+
+#[test]
+fn externals_imported_by_several_modules_are_required_once_in_cjs_output() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/cjs_hoisted_requires");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        format: ModuleFormat::Cjs,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 1);
+  let content = &assets[0].content;
+
+  // Imports of the same external are merged, whether they are default, named or namespace ones.
+  assert_eq!(content.matches("require(").count(), 1, "{content}");
+  // Hoisted above the code of every module, like the imports of ES modules are
+  let require_idx = content.find("require(\"path\")").unwrap();
+  let first_code_idx = content.find("console.log(").unwrap();
+  assert!(require_idx < first_code_idx, "{content}");
+}
+
+#[test]
+fn custom_resolver_overrides_the_specifiers_it_handles() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/custom_resolver");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let magic_path = fixture_path.join("store/f3a1.js");
+  let resolver: CustomResolver = Arc::new(move |specifier, _importer| {
+    let resolution = (specifier == "magic:x").then(|| CustomResolution {
+      path: magic_path.to_string_lossy().to_string(),
+      ..Default::default()
+    });
+    future::ready(Ok(resolution)).boxed()
+  });
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(InputOptions {
+        resolver: Some(resolver),
+        ..tester.input_options(fixture_path)
+      })
+      .generate(Default::default()),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 1);
+  let content = &assets[0].content;
+
+  assert!(content.contains("// store/f3a1.js"), "{content}");
+  assert!(content.contains("'magic'"), "{content}");
+  // Everything else is resolved as usual
+  assert!(content.contains("'lib'"), "{content}");
+  assert!(content.contains("magic:unknown"), "{content}");
+  assert!(!content.contains("magic:x"), "{content}");
+}
+
+#[test]
+fn dynamic_import_preloads_the_static_dependencies_of_the_imported_chunk() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/module_preload");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        module_preload: true,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  // main.js, the lazy chunk and the shared chunk
+  assert_eq!(assets.len(), 3);
+  let filename_of = |prefix: &str| {
+    assets
+      .iter()
+      .find(|asset| asset.filename.starts_with(prefix))
+      .unwrap()
+      .filename
+      .clone()
+  };
+  let lazy = filename_of("lazy-");
+  let shared = filename_of("shared-");
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+
+  assert!(
+    main.content.contains(&format!(
+      "__preload(()=>import(\"./{lazy}\"), [\"./{lazy}\", \"./{shared}\"])"
+    )),
+    "{}",
+    main.content
+  );
+  assert!(
+    main.content.contains("function __preload(load, deps)"),
+    "{}",
+    main.content
+  );
+  // Chunks without dynamic imports don't need the helper.
+  let lazy = assets.iter().find(|asset| asset.filename == lazy).unwrap();
+  assert!(!lazy.content.contains("__preload"), "{}", lazy.content);
+}
+
+#[test]
+fn manifest_lists_the_files_css_and_async_chunks_of_every_chunk() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/manifest");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let dir = TempDir::new("manifest");
+  let manifest_file = dir.join("manifest.json");
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        manifest_file: Some(manifest_file.to_string_lossy().to_string()),
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  let filename_of = |prefix: &str, extension: &str| {
+    assets
+      .iter()
+      .find(|asset| asset.filename.starts_with(prefix) && asset.filename.ends_with(extension))
+      .unwrap()
+      .filename
+      .clone()
+  };
+  let lazy = filename_of("lazy-", ".js");
+  let lazy_css = filename_of("lazy-", ".css");
+  let shared = filename_of("shared-", ".js");
+
+  let manifest: serde_json::Value =
+    serde_json::from_str(&std::fs::read_to_string(&manifest_file).unwrap()).unwrap();
+  assert_eq!(
+    manifest,
+    serde_json::json!({
+      "main": {
+        "file": "main.js",
+        "isEntry": true,
+        "imports": [shared],
+        "css": ["main.css"],
+        "dynamicImports": ["lazy"],
+      },
+      "lazy": {
+        "file": lazy,
+        "isEntry": false,
+        "imports": [shared],
+        "css": [lazy_css],
+        "dynamicImports": [],
+      },
+      "shared": {
+        "file": shared,
+        "isEntry": false,
+        "imports": [],
+        "css": [],
+        "dynamicImports": [],
+      },
+    })
+  );
+}
+
+#[test]
+fn failed_chunk_loads_are_passed_to_the_error_handler() {
+  let fixture_path =
+    PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/chunk_load_error_handler");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  for format in [ModuleFormat::Esm, ModuleFormat::System] {
+    let assets = tokio::runtime::Runtime::new()
+      .unwrap()
+      .block_on(
+        Bundler::new(tester.input_options(fixture_path.clone())).generate(OutputOptions {
+          format,
+          chunk_load_error_handler: Some("onChunkLoadError".to_string()),
+          ..Default::default()
+        }),
+      )
+      .unwrap();
+    assert_eq!(assets.len(), 2);
+    let main = assets
+      .iter()
+      .find(|asset| asset.filename == "main.js")
+      .unwrap();
+    let lazy = assets
+      .iter()
+      .find(|asset| asset.filename != "main.js")
+      .unwrap();
+
+    // A rejected load is caught by the helper, which calls the handler with a retry.
+    assert!(
+      main.content.contains("return onError(error, load);"),
+      "{}",
+      main.content
+    );
+    assert!(
+      main
+        .content
+        .contains(&format!("\"./{}\")), onChunkLoadError)", lazy.filename)),
+      "{}",
+      main.content
+    );
+    assert!(
+      main.content.contains("__loadChunk(()=>"),
+      "{}",
+      main.content
+    );
+    assert!(!lazy.content.contains("__loadChunk"), "{}", lazy.content);
+  }
+}
+
+#[test]
+fn system_output_registers_live_exports() {
+  let fixture_path = PathBuf::from(env!("CARGO_MANIFEST_DIR")).join("tests/system_format");
+  let tester = Tester::from_config_path(&fixture_path.join("test.config.json"));
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::new(tester.input_options(fixture_path)).generate(OutputOptions {
+        format: ModuleFormat::System,
+        ..Default::default()
+      }),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 2);
+  let main = assets
+    .iter()
+    .find(|asset| asset.filename == "main.js")
+    .unwrap();
+  let lazy = assets
+    .iter()
+    .find(|asset| asset.filename != "main.js")
+    .unwrap();
+
+  // Externals are dependencies of the registration, whose bindings are set by the setters.
+  assert!(
+    main.content.starts_with("System.register([\"path\"]"),
+    "{}",
+    main.content
+  );
+  assert!(main.content.contains("setters: ["), "{}", main.content);
+  assert!(!main.content.contains("import "), "{}", main.content);
+  assert!(
+    main.content.contains("_export(\"default\", greet)"),
+    "{}",
+    main.content
+  );
+  // `count` is exported again whenever it's updated, so importers see the new value.
+  assert!(
+    main.content.matches("_export(\"count\"").count() >= 2,
+    "{}",
+    main.content
+  );
+  assert!(
+    main
+      .content
+      .contains(&format!("_context.import(\"./{}\")", lazy.filename)),
+    "{}",
+    main.content
+  );
+  assert!(
+    lazy.content.starts_with("System.register([]"),
+    "{}",
+    lazy.content
+  );
+  assert!(
+    lazy.content.contains("_export(\"lazy\""),
+    "{}",
+    lazy.content
+  );
+}
+
+/// Serves `virtual:main` as `virtual:src/main.js`, and loads the other modules of the namespace.
+#[derive(Debug)]
+struct VirtualNamespacePlugin {
+  files: Vec<(&'static str, &'static str)>,
+}
+
+#[async_trait::async_trait]
+impl BuildPlugin for VirtualNamespacePlugin {
+  fn name(&self) -> PluginName {
+    std::borrow::Cow::Borrowed("test:virtual-namespace")
+  }
+
+  fn namespaces(&self) -> Vec<NamespaceResolveOptions> {
+    vec![NamespaceResolveOptions {
+      namespace: "virtual".to_string(),
+      resolve_extensions: vec![".js".to_string()],
+    }]
+  }
+
+  async fn resolve(&self, _ctx: &mut Context, args: &mut ResolveArgs) -> ResolveReturn {
+    Ok((args.specifier == "virtual:main").then(|| ResolvedId {
+      id: "virtual:src/main.js".to_string(),
+      external: false,
+    }))
+  }
+
+  async fn load(&self, _ctx: &mut Context, args: &mut LoadArgs) -> LoadReturn {
+    let Some(path) = args.id.id().strip_prefix("virtual:") else {
+      return Ok(None);
+    };
+    Ok(
+      self
+        .files
+        .iter()
+        .find(|(file, _)| *file == path)
+        .map(|(_, code)| LoadOutput {
+          code: code.to_string(),
+          loader: None,
+        }),
+    )
+  }
+}
+
+#[test]
+fn relative_imports_stay_in_the_namespace_of_the_importer() {
+  let plugin = VirtualNamespacePlugin {
+    files: vec![
+      (
+        "src/main.js",
+        "import { a } from './a'\nimport { b } from '../b.js'\nconsole.log(a, b)",
+      ),
+      ("src/a.js", "export const a = 'virtual a'"),
+      ("b.js", "export const b = 'virtual b'"),
+    ],
+  };
+  // Nothing exists on disk, so probing the file system would fail.
+  let assets = tokio::runtime::Runtime::new()
+    .unwrap()
+    .block_on(
+      Bundler::with_plugins(
+        InputOptions {
+          input: vec![InputItem {
+            name: "main".to_string(),
+            import: "virtual:main".to_string(),
+          }],
+          cwd: PathBuf::from("/virtual-project"),
+          ..Default::default()
+        },
+        vec![Box::new(plugin)],
+      )
+      .generate(Default::default()),
+    )
+    .unwrap();
+  assert_eq!(assets.len(), 1);
+  let content = &assets[0].content;
+  assert!(content.contains("'virtual a'"), "{content}");
+  assert!(content.contains("'virtual b'"), "{content}");
+  assert!(!content.contains("import "), "{content}");
+}
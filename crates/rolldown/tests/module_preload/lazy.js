@@ -0,0 +1,2 @@
+import { format } from './shared.js'
+export default format('lazy')
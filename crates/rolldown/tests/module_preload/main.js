@@ -0,0 +1,3 @@
+import { format } from './shared.js'
+console.log(format('main'))
+export const load = () => import('./lazy.js')
@@ -0,0 +1 @@
+import './main.css'
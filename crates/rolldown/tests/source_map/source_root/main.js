@@ -0,0 +1,3 @@
+import { greet } from './lib.js'
+
+console.log(greet('world'))
@@ -0,0 +1,4 @@
+import { shared } from './shared.js'
+
+console.log('a', shared)
+import('./lazy.js').then(console.log)
@@ -0,0 +1 @@
+export const lazy = 'lazy'
@@ -0,0 +1,10 @@
+import { join } from 'path'
+
+export let count = 0
+export function increment() {
+  count++
+}
+export default function greet(name) {
+  return join('hello', name)
+}
+export const load = () => import('./lazy.js')
@@ -136,3 +136,47 @@ pub fn build_namespace_export_stmt(
     }))
   }
 }
+
+/// `export var require_a = __commonJS((exports, module) => { ...body })`
+pub fn build_commonjs_wrapper_stmt(
+  var_name: ast::Id,
+  exports: ast::Ident,
+  module: ast::Ident,
+  body: Vec<ast::Stmt>,
+) -> ast::ModuleItem {
+  use ast::*;
+  let wrapped = Expr::Arrow(ArrowExpr {
+    span: Default::default(),
+    params: vec![Pat::Ident(exports.into()), Pat::Ident(module.into())],
+    body: BlockStmtOrExpr::BlockStmt(BlockStmt {
+      span: Default::default(),
+      stmts: body,
+    })
+    .into(),
+    is_async: false,
+    is_generator: false,
+    type_params: None,
+    return_type: None,
+  });
+  ModuleItem::ModuleDecl(ast::ModuleDecl::ExportDecl(ast::ExportDecl {
+    span: Default::default(),
+    decl: Decl::Var(Box::new(VarDecl {
+      span: Default::default(),
+      kind: VarDeclKind::Var,
+      declare: false,
+      decls: vec![VarDeclarator {
+        span: Default::default(),
+        definite: false,
+        name: var_name.into(),
+        init: Some(Box::new(Expr::Call(CallExpr {
+          callee: Callee::Expr(quote_ident!("__commonJS").into()),
+          args: vec![ExprOrSpread {
+            expr: Box::new(wrapped),
+            spread: None,
+          }],
+          ..CallExpr::dummy()
+        }))),
+      }],
+    })),
+  }))
+}
@@ -0,0 +1,113 @@
+use crate::Target;
+
+/// The first version of each browser fully supporting each edition, from the oldest edition.
+/// Browsers not listed here, like `ie`, only get ES5.
+const COMPAT_TABLE: &[(&str, [&str; 8])] = &[
+  ("chrome", ["51", "52", "58", "64", "73", "80", "85", "94"]),
+  ("edge", ["15", "15", "16", "79", "79", "80", "85", "94"]),
+  ("firefox", ["54", "54", "54", "78", "78", "80", "80", "93"]),
+  (
+    "safari",
+    ["10", "10.1", "11", "12", "12.1", "14", "14.1", "16.4"],
+  ),
+  (
+    "ios_saf",
+    ["10", "10.3", "11", "12", "12.2", "14", "14.5", "16.4"],
+  ),
+  ("opera", ["38", "39", "45", "51", "60", "67", "71", "80"]),
+  (
+    "samsung",
+    ["5", "6.2", "6.2", "9.2", "11.1", "13", "14", "17"],
+  ),
+  ("node", ["6.5", "7", "8", "10", "12", "14", "15", "16.11"]),
+];
+
+/// Editions in the order of the columns of `COMPAT_TABLE`
+const EDITIONS: [Target; 8] = [
+  Target::Es2015,
+  Target::Es2016,
+  Target::Es2017,
+  Target::Es2018,
+  Target::Es2019,
+  Target::Es2020,
+  Target::Es2021,
+  Target::Es2022,
+];
+
+fn browser_name(name: &str) -> &str {
+  match name {
+    "and_chr" | "chromeandroid" => "chrome",
+    "and_ff" | "ff" | "fx" | "firefoxandroid" => "firefox",
+    "ios" => "ios_saf",
+    "explorer" => "ie",
+    "samsunginternet" => "samsung",
+    name => name,
+  }
+}
+
+/// `"10.1"` => `[10, 1]`
+fn parse_version(version: &str) -> Option<Vec<u32>> {
+  version
+    .split('.')
+    .map(|part| part.parse().ok())
+    .collect::<Option<Vec<_>>>()
+}
+
+/// The newest edition a version of a browser fully supports.
+fn target_of(browser: &str, version: &[u32]) -> Target {
+  COMPAT_TABLE
+    .iter()
+    .find(|(name, _)| *name == browser)
+    .and_then(|(_, versions)| {
+      versions
+        .iter()
+        .zip(EDITIONS)
+        .take_while(|(since, _)| parse_version(since).unwrap().as_slice() <= version)
+        .last()
+        .map(|(_, edition)| edition)
+    })
+    .unwrap_or(Target::Es5)
+}
+
+/// The oldest version of the browser a query matches, like `60` for `chrome 60` or `chrome 60-62`.
+/// Queries depending on usage data, like `> 1%` or `last 2 versions`, aren't supported.
+fn oldest_version_of(query: &str) -> Result<(String, Vec<u32>), String> {
+  let unsupported = || {
+    format!(
+      r#"The browserslist query "{query}" isn't supported. Only queries naming browser versions, like "chrome 60" or "safari >= 12", are."#
+    )
+  };
+  let lowercase = query.to_ascii_lowercase();
+  let mut parts = lowercase.split_whitespace();
+  let (Some(name), Some(version)) = (parts.next(), parts.next()) else {
+    return Err(unsupported());
+  };
+  let version = match (version, parts.next()) {
+    (">=" | ">", Some(version)) => version,
+    // Ancient versions support nothing but ES5.
+    ("<=" | "<", Some(_)) => "0",
+    (version, None) => version.split('-').next().unwrap(),
+    _ => return Err(unsupported()),
+  };
+  let version = parse_version(version).ok_or_else(unsupported)?;
+  Ok((browser_name(name).to_string(), version))
+}
+
+impl Target {
+  /// The newest edition every browser matched by the queries fully supports. `not` queries only
+  /// exclude browsers, so they are ignored, which is at worst more conservative than needed.
+  pub fn from_browserslist<S: AsRef<str>>(queries: &[S]) -> Result<Option<Target>, String> {
+    queries
+      .iter()
+      .map(|query| query.as_ref().trim())
+      .filter(|query| !query.is_empty() && !query.starts_with("not "))
+      .map(|query| {
+        let (browser, version) = oldest_version_of(query)?;
+        Ok(target_of(&browser, &version))
+      })
+      .try_fold(None, |min: Option<Target>, target| {
+        let target = target?;
+        Ok(Some(min.map_or(target, |min| min.min(target))))
+      })
+  }
+}
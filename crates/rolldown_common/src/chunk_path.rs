@@ -0,0 +1,27 @@
+/// The specifier used by the chunk `importer_filename` to import the chunk `importee_filename`.
+/// Both filenames are relative to the output dir.
+///
+/// - `("a.js", "shared.js")` => `./shared.js`
+/// - `("src/a.js", "src/b.js")` => `./b.js`
+/// - `("src/a.js", "lib/b.js")` => `../lib/b.js`
+pub fn relative_chunk_path(importer_filename: &str, importee_filename: &str) -> String {
+  let mut importer_dirs = importer_filename.split('/').collect::<Vec<_>>();
+  importer_dirs.pop();
+  let importee_segments = importee_filename.split('/').collect::<Vec<_>>();
+
+  let common_len = importer_dirs
+    .iter()
+    .zip(&importee_segments)
+    .take_while(|(a, b)| a == b)
+    .count()
+    // The last segment of the importee is the filename, which isn't a dir.
+    .min(importee_segments.len() - 1);
+
+  let ups = importer_dirs.len() - common_len;
+  let rest = importee_segments[common_len..].join("/");
+  if ups == 0 {
+    format!("./{rest}")
+  } else {
+    format!("{}{rest}", "../".repeat(ups))
+  }
+}
@@ -9,6 +9,11 @@ mod symbol;
 pub use symbol::*;
 mod loader;
 pub use loader::*;
+mod chunk_path;
+pub use chunk_path::*;
+mod target;
+mod browserslist;
+pub use target::*;
 
 #[derive(Debug, Hash, PartialEq, Eq, PartialOrd, Ord, Clone)]
 pub struct ChunkId(JsWord);
@@ -7,6 +7,9 @@ pub enum Loader {
   Ts,
   Tsx,
   Json,
+  Css,
+  /// Copied to the output as an asset. The module exports the URL of the asset.
+  File,
 }
 
 impl FromStr for Loader {
@@ -18,6 +21,8 @@ impl FromStr for Loader {
       "jsx" => Ok(Self::Jsx),
       "ts" => Ok(Self::Ts),
       "tsx" => Ok(Self::Tsx),
+      "css" => Ok(Self::Css),
+      "file" => Ok(Self::File),
       _ => Err(format!("Unknown loader value \"{}\"", s)),
     }
   }
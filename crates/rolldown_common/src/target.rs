@@ -0,0 +1,70 @@
+use std::str::FromStr;
+
+/// The ECMAScript version the output should run on. Syntax newer than the target is never emitted
+/// by the bundler itself, and logical assignments of the sources are lowered.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, PartialOrd, Ord, Hash, Default)]
+pub enum Target {
+  Es5,
+  Es2015,
+  Es2016,
+  Es2017,
+  Es2018,
+  Es2019,
+  Es2020,
+  Es2021,
+  Es2022,
+  #[default]
+  EsNext,
+}
+
+impl Target {
+  /// `a ** b`
+  pub fn supports_exponent_operator(self) -> bool {
+    self >= Target::Es2016
+  }
+
+  /// `{ ...a }`
+  pub fn supports_object_spread(self) -> bool {
+    self >= Target::Es2018
+  }
+
+  /// `catch {}`
+  pub fn supports_optional_catch_binding(self) -> bool {
+    self >= Target::Es2019
+  }
+
+  /// `a ||= b`
+  pub fn supports_logical_assignment(self) -> bool {
+    self >= Target::Es2021
+  }
+
+  /// `` `a` ``
+  pub fn supports_template_literals(self) -> bool {
+    self >= Target::Es2015
+  }
+
+  /// `{ a, f() {} }`
+  pub fn supports_object_shorthands(self) -> bool {
+    self >= Target::Es2015
+  }
+}
+
+impl FromStr for Target {
+  type Err = String;
+
+  fn from_str(value: &str) -> Result<Self, Self::Err> {
+    match value.to_ascii_lowercase().as_str() {
+      "es5" => Ok(Target::Es5),
+      "es6" | "es2015" => Ok(Target::Es2015),
+      "es2016" => Ok(Target::Es2016),
+      "es2017" => Ok(Target::Es2017),
+      "es2018" => Ok(Target::Es2018),
+      "es2019" => Ok(Target::Es2019),
+      "es2020" => Ok(Target::Es2020),
+      "es2021" => Ok(Target::Es2021),
+      "es2022" => Ok(Target::Es2022),
+      "esnext" => Ok(Target::EsNext),
+      _ => Err(format!("Invalid target: {value}")),
+    }
+  }
+}
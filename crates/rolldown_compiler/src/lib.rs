@@ -52,6 +52,34 @@ impl Compiler {
     String::from_utf8(output).map_err(Into::into)
   }
 
+  /// Lines and columns of the returned map are relative to the printed code.
+  pub fn print_with_source_map(
+    &self,
+    ast: &ast::Module,
+    comments: Option<&dyn Comments>,
+  ) -> anyhow::Result<(String, sourcemap::SourceMap)> {
+    let mut output = Vec::new();
+    let mut mappings = Vec::new();
+
+    let mut emitter = swc_ecma_codegen::Emitter {
+      cfg: swc_ecma_codegen::Config {
+        ..Default::default()
+      },
+      cm: self.cm.clone(),
+      comments: Some(&comments),
+      wr: Box::new(JsWriter::new(
+        self.cm.clone(),
+        "\n",
+        &mut output,
+        Some(&mut mappings),
+      )),
+    };
+
+    emitter.emit_module(ast)?;
+    let map = self.cm.build_source_map(&mut mappings);
+    Ok((String::from_utf8(output)?, map))
+  }
+
   pub fn print_module_item(
     &self,
     ast: &ast::ModuleItem,
@@ -1,10 +1,13 @@
 use rayon::prelude::*;
-use rustc_hash::FxHashMap as HashMap;
+use rolldown_common::ChunkId;
+use rustc_hash::{FxHashMap as HashMap, FxHashSet as HashSet};
 use tracing::instrument;
 
 use crate::{
-  Asset, BuildInputOptions, BuildOutputOptions, Chunk, CodeSplitter, FinalizeBundleContext, Graph,
-  ModuleRefMutById, SplitPointIdToChunkId, UnaryBuildResult,
+  chunk_hash::{fill_hashes, hash_placeholder, replace_hash_placeholders},
+  print_comment, Asset, BuildError, BuildInputOptions, BuildOutputOptions, Chunk, CodeSplitter,
+  FinalizeBundleContext, Graph, ManualChunkExports, ModuleRefMutById, SplitPointIdToChunkId,
+  UnaryBuildResult,
 };
 
 #[derive(Debug)]
@@ -12,7 +15,7 @@ pub struct Bundle<'a> {
   pub input_options: &'a BuildInputOptions,
   pub output_options: &'a BuildOutputOptions,
   pub graph: &'a mut Graph,
-  split_point_id_to_chunk_id: SplitPointIdToChunkId,
+  pub(crate) split_point_id_to_chunk_id: SplitPointIdToChunkId,
 }
 
 impl<'a> Bundle<'a> {
@@ -32,14 +35,23 @@ impl<'a> Bundle<'a> {
   #[instrument(skip_all)]
   pub fn generate(&mut self) -> UnaryBuildResult<Vec<Asset>> {
     let chunks = self.generate_chunks()?;
+    let manual_chunk_exports = ManualChunkExports::new(&chunks, self.graph);
     let mut chunk_by_id = chunks
       .into_iter()
       .map(|c| (c.id.clone(), c))
       .collect::<HashMap<_, _>>();
 
-    chunk_by_id.values_mut().par_bridge().for_each(|chunk| {
-      chunk.gen_file_name(self.output_options);
-    });
+    chunk_by_id
+      .values_mut()
+      .enumerate()
+      .par_bridge()
+      .for_each(|(index, chunk)| {
+        chunk.gen_file_name(
+          self.input_options,
+          self.output_options,
+          &hash_placeholder(index),
+        );
+      });
 
     let mut module_mut_ref_by_id = self
       .graph
@@ -47,11 +59,28 @@ impl<'a> Bundle<'a> {
       .iter_mut()
       .collect::<HashMap<_, _>>();
 
-    let chunk_filename_by_id = chunk_by_id
+    let mut chunk_filename_by_id = chunk_by_id
       .values()
       .map(|chunk| (chunk.id.clone(), chunk.filename.clone().unwrap()))
       .collect::<HashMap<_, _>>();
 
+    let preload_deps_by_chunk_id = (self.output_options.module_preload
+      && self.output_options.format.is_es())
+    .then(|| self.preload_deps_by_chunk_id(&chunk_by_id, &chunk_filename_by_id));
+
+    let cyclic_chunk_ids = self.cyclic_chunk_ids(&chunk_by_id);
+
+    let native_addon_names = self
+      .graph
+      .module_by_id
+      .values()
+      .filter_map(|module| module.as_ext())
+      .filter_map(|module| {
+        let asset_name = module.native_addon_asset_name(self.output_options)?;
+        Some((module.id.clone(), asset_name))
+      })
+      .collect::<HashMap<_, _>>();
+
     let chunk_and_modules = chunk_by_id
       .values_mut()
       .map(|chunk| {
@@ -78,34 +107,184 @@ impl<'a> Bundle<'a> {
           split_point_id_to_chunk_id: &self.split_point_id_to_chunk_id,
           chunk_filename_by_id: &chunk_filename_by_id,
           unresolved_ctxt: self.graph.unresolved_ctxt,
+          manual_chunk_exports: &manual_chunk_exports,
+          preload_deps_by_chunk_id: preload_deps_by_chunk_id.as_ref(),
+          cyclic_chunk_ids: &cyclic_chunk_ids,
+          native_addon_names: &native_addon_names,
         })
       },
     )?;
 
-    let chunks = chunk_by_id
+    self.share_helpers(&mut chunk_by_id);
+
+    let mut chunks = chunk_by_id
       .values()
       .map(|chunk| {
-        let code = chunk.render(
+        chunk.render(
           crate::RenderContext {},
           self.graph,
           self.input_options,
           self.output_options,
-        );
-
-        code.map(|code| Asset {
-          content: code,
-          filename: chunk.filename.clone().unwrap(),
-        })
+        )
       })
       .try_collect::<Vec<_>>()?;
 
+    chunks.extend(
+      chunk_by_id
+        .values()
+        .filter_map(|chunk| chunk.render_css(self.graph, self.input_options, self.output_options)),
+    );
+
+    let ignored_by_hash = if self.output_options.hash_ignores_comments {
+      self
+        .graph
+        .module_by_id
+        .values()
+        .filter_map(|module| module.as_norm())
+        .flat_map(|module| module.legal_comments())
+        .map(|comment| print_comment(&comment))
+        // The banner is followed by a newline.
+        .chain(
+          self
+            .output_options
+            .banner
+            .as_ref()
+            .map(|banner| format!("{banner}\n")),
+        )
+        .collect()
+    } else {
+      vec![]
+    };
+    let hash_by_placeholder = replace_hash_placeholders(&mut chunks, &ignored_by_hash);
+    chunk_by_id.values_mut().for_each(|chunk| {
+      let filename = fill_hashes(chunk.filename.as_ref().unwrap(), &hash_by_placeholder);
+      chunk_filename_by_id.insert(chunk.id.clone(), filename.clone());
+      chunk.filename = Some(filename);
+    });
+
+    chunks.extend(
+      chunk_by_id
+        .values()
+        .flat_map(|chunk| chunk.modules.iter())
+        .filter_map(|id| self.graph.module_by_id.get(id)?.as_norm())
+        .filter(|module| module.is_included())
+        .filter_map(|module| module.render_file_asset(self.output_options)),
+    );
+
+    chunks.extend(
+      chunk_by_id
+        .values()
+        .flat_map(|chunk| chunk.modules.iter())
+        .filter_map(|id| self.graph.module_by_id.get(id)?.as_norm())
+        .flat_map(|module| module.render_css_url_assets(self.output_options)),
+    );
+
+    chunks.extend(
+      self
+        .graph
+        .module_by_id
+        .values()
+        .filter_map(|module| module.as_ext())
+        .filter_map(|module| module.render_native_addon_asset(self.output_options)),
+    );
+
+    if let Some(manifest_file) = &self.output_options.manifest_file {
+      std::fs::write(
+        manifest_file,
+        self.manifest_json(&chunk_by_id, &chunk_filename_by_id),
+      )
+      .map_err(BuildError::io_error)?;
+    }
+
+    // The iteration order of `chunk_by_id` isn't stable, so sort the assets to keep the output stable.
+    chunks.sort_by(|a, b| a.filename.cmp(&b.filename));
+    // A file referenced by several modules is copied once. The hash in its name is of its content.
+    chunks.dedup_by(|a, b| a.filename == b.filename);
+
     Ok(chunks)
   }
 
+  /// The chunks each chunk imports statically, transitively, without the chunk itself.
+  pub(crate) fn static_imports_by_chunk_id(
+    &self,
+    chunk_by_id: &HashMap<ChunkId, Chunk>,
+  ) -> HashMap<ChunkId, Vec<ChunkId>> {
+    let imported_chunks_by_id = chunk_by_id
+      .values()
+      .map(|chunk| {
+        let imported_chunks = chunk
+          .modules
+          .iter()
+          .filter_map(|id| self.graph.module_by_id.get(id))
+          .flat_map(|module| module.dependencies())
+          .filter(|dep| !dep.is_external() && !chunk.modules.contains(*dep))
+          .filter_map(|dep| self.split_point_id_to_chunk_id.get(dep))
+          .collect::<HashSet<_>>();
+        (&chunk.id, imported_chunks)
+      })
+      .collect::<HashMap<_, _>>();
+
+    chunk_by_id
+      .keys()
+      .map(|chunk_id| {
+        let mut visited = HashSet::default();
+        let mut stack = vec![chunk_id];
+        while let Some(id) = stack.pop() {
+          if visited.insert(id) {
+            stack.extend(&imported_chunks_by_id[id]);
+          }
+        }
+        visited.remove(chunk_id);
+        (chunk_id.clone(), visited.into_iter().cloned().collect())
+      })
+      .collect()
+  }
+
+  /// Chunks importing themselves statically through other chunks.
+  fn cyclic_chunk_ids(&self, chunk_by_id: &HashMap<ChunkId, Chunk>) -> HashSet<ChunkId> {
+    let static_imports_by_chunk_id = self.static_imports_by_chunk_id(chunk_by_id);
+    static_imports_by_chunk_id
+      .iter()
+      .filter(|(chunk_id, imported_chunks)| {
+        imported_chunks
+          .iter()
+          .any(|id| static_imports_by_chunk_id[id].contains(chunk_id))
+      })
+      .map(|(chunk_id, _)| chunk_id.clone())
+      .collect()
+  }
+
+  /// The files to preload when a chunk is imported dynamically, which are the chunk and the chunks
+  /// it imports statically, transitively. The imported chunk comes first.
+  fn preload_deps_by_chunk_id(
+    &self,
+    chunk_by_id: &HashMap<ChunkId, Chunk>,
+    chunk_filename_by_id: &HashMap<ChunkId, String>,
+  ) -> HashMap<ChunkId, Vec<String>> {
+    self
+      .static_imports_by_chunk_id(chunk_by_id)
+      .into_iter()
+      .map(|(chunk_id, imported_chunks)| {
+        let mut deps = imported_chunks
+          .iter()
+          .map(|id| chunk_filename_by_id[id].clone())
+          .collect::<Vec<_>>();
+        deps.sort();
+        deps.insert(0, chunk_filename_by_id[&chunk_id].clone());
+        (chunk_id, deps)
+      })
+      .collect()
+  }
+
   #[instrument(skip_all)]
   fn generate_chunks(&mut self) -> UnaryBuildResult<Vec<Chunk>> {
-    let code_splitter =
-      CodeSplitter::new(self.graph.entries.clone(), self.graph, self.input_options);
+    let code_splitter = CodeSplitter::new(
+      self.graph.entries.clone(),
+      self.graph,
+      self.input_options,
+      self.output_options.preserve_modules,
+      &self.output_options.manual_chunks,
+    );
     let chunk_graph = code_splitter.split()?;
     chunk_graph.chunk_by_id.values().for_each(|chunk| {
       chunk.modules.iter().for_each(|module_id| {
@@ -13,10 +13,32 @@ pub struct BundlerCore {
   plugin_driver: SharedBuildPluginDriver,
 }
 
+/// Code that doesn't come from any module, such as runtime helpers and the imports and exports
+/// between chunks, is attributed to this id.
+pub const RUNTIME_MODULE_ID: &str = "<runtime>";
+
 #[derive(Debug)]
 pub struct Asset {
   pub filename: String,
   pub content: String,
+  /// The lengths sum up to the byte length of `content`.
+  pub rendered_modules: Vec<RenderedModule>,
+  /// The source map in JSON, written next to the asset with a `.map` extension appended.
+  pub map: Option<String>,
+  /// Content of a binary asset, like a file copied by the file loader. `content` is empty then.
+  pub binary: Option<Vec<u8>>,
+}
+
+#[derive(Debug)]
+pub struct RenderedModule {
+  /// Path relative to `cwd`, or [RUNTIME_MODULE_ID].
+  pub id: String,
+  /// Bytes contributed to the final output, after tree shaking and minifying. `None` for CommonJS,
+  /// IIFE and System output, whose chunks are printed again after being transformed, so the bytes
+  /// of each module are unknown. The rest of the output, like the imports, is attributed to
+  /// [RUNTIME_MODULE_ID](crate::RUNTIME_MODULE_ID), so the lengths always sum up to the length of
+  /// the output.
+  pub rendered_length: Option<usize>,
 }
 
 impl BundlerCore {
@@ -36,8 +58,12 @@ impl BundlerCore {
   pub async fn build(&mut self, output_opts: BuildOutputOptions) -> BuildResult<Vec<Asset>> {
     tracing::debug!("{:#?}", self.input_options);
     tracing::debug!("{:#?}", output_opts);
+    self.plugin_driver.read().await.build_start().await?;
     let mut graph = Graph::new(self.plugin_driver.clone(), self.input_options.clone());
     graph.generate_module_graph().await?;
+    if let Some(graph_file) = &output_opts.graph_file {
+      std::fs::write(graph_file, graph.import_graph_json())?;
+    }
     let mut bundle = Bundle::new(&self.input_options, &output_opts, &mut graph);
     let assets = bundle.generate()?;
     Ok(assets)
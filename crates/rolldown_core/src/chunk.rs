@@ -1,12 +1,20 @@
-use std::{collections::HashSet, path::PathBuf};
+use std::{
+  collections::HashSet,
+  path::{Path, PathBuf},
+};
 
 use hashlink::LinkedHashSet;
 use itertools::Itertools;
-use rayon::prelude::{IntoParallelIterator, IntoParallelRefIterator, ParallelIterator};
-use rolldown_common::{ChunkId, ExportedSpecifier, ImportedSpecifier, ModuleId, Symbol, UnionFind};
+use rayon::prelude::{
+  IntoParallelIterator, IntoParallelRefIterator, IntoParallelRefMutIterator, ParallelIterator,
+};
+use rolldown_common::{
+  relative_chunk_path, ChunkId, ExportedSpecifier, ImportedSpecifier, ModuleId, Symbol, UnionFind,
+};
 use rolldown_runtime_helpers::RuntimeHelpers;
-use rolldown_swc_visitors::FinalizeContext;
+use rolldown_swc_visitors::{CjsExportShim, FinalizeContext};
 use rustc_hash::{FxHashMap, FxHashSet};
+use sugar_path::{AsPath, SugarPath};
 use swc_core::{
   common::{comments::SingleThreadedComments, util::take::Take, Mark, SyntaxContext, GLOBALS},
   ecma::{
@@ -19,9 +27,11 @@ use swc_core::{
 use tracing::instrument;
 
 use crate::{
-  file_name, norm_or_ext::NormOrExt, preset_of_used_names, BuildError, BuildInputOptions,
-  BuildOutputOptions, ExportMode, Graph, MergedExports, ModuleById, ModuleRefMutById,
-  SplitPointIdToChunkId, UnaryBuildResult, COMPILER,
+  file_name, global_name_of_external, mangled_names, norm_or_ext::NormOrExt, preset_of_used_names,
+  relative_asset_path, sources_base, Asset, BuildError, BuildInputOptions, BuildOutputOptions,
+  ChunkSourceMapBuilder, ExportMode, Graph, ManualChunkExports, MergedExports, ModuleById,
+  ModuleRefMutById, RenderedModule, SplitPointIdToChunkId, UnaryBuildResult, COMPILER,
+  RUNTIME_MODULE_ID,
 };
 
 pub struct Chunk {
@@ -34,6 +44,11 @@ pub struct Chunk {
   pub(crate) after_module_items: Vec<ast::ModuleItem>,
   pub(crate) runtime_helpers: RuntimeHelpers,
   pub(crate) is_user_defined_entry: bool,
+  /// Created for `manualChunks`. The exports of `entry` aren't the exports of the chunk.
+  pub(crate) is_manual: bool,
+  /// Helpers of lowered syntax the chunk declares by their final names, with the modules declaring
+  /// them and their original names.
+  pub(crate) declared_helpers: FxHashMap<JsWord, (ModuleId, JsWord)>,
 }
 
 impl Chunk {
@@ -48,17 +63,32 @@ impl Chunk {
       filename: None,
       runtime_helpers: Default::default(),
       is_user_defined_entry,
+      is_manual: false,
+      declared_helpers: Default::default(),
     }
   }
 
-  pub(crate) fn gen_file_name(&mut self, output_options: &BuildOutputOptions) {
-    self.filename = Some(
-      output_options
-        .entry_file_names
-        .render(file_name::RenderOptions {
-          name: Some(self.id.as_ref()),
-        }),
-    )
+  /// `[hash]` is filled by `hash_placeholder` until the chunks are rendered.
+  pub(crate) fn gen_file_name(
+    &mut self,
+    input_options: &BuildInputOptions,
+    output_options: &BuildOutputOptions,
+    hash_placeholder: &str,
+  ) {
+    let template = if self.is_user_defined_entry || output_options.preserve_modules {
+      &output_options.entry_file_names
+    } else {
+      &output_options.chunk_file_names
+    };
+    let mut filename = template.render(file_name::RenderOptions {
+      name: Some(self.id.as_ref()),
+      hash: Some(hash_placeholder),
+    });
+    // The output is only valid as JSX.
+    if input_options.builtins.jsx.is_preserve() && filename.ends_with(".js") {
+      filename.push('x');
+    }
+    self.filename = Some(filename);
   }
 
   fn ordered_modules<'m>(&self, module_by_id: &'m ModuleById) -> Vec<&'m NormOrExt> {
@@ -80,10 +110,18 @@ impl Chunk {
     graph: &Graph,
     input_options: &BuildInputOptions,
     output_options: &BuildOutputOptions,
-  ) -> UnaryBuildResult<String> {
+  ) -> UnaryBuildResult<Asset> {
     let mut runtime_code = self.runtime_helpers.generate_helpers().join("\n");
     runtime_code.push('\n');
 
+    // Only the entry of the chunk could be executed directly.
+    let shebang = self
+      .is_user_defined_entry
+      .then(|| graph.module_by_id[&self.entry].as_norm()?.shebang.as_ref())
+      .flatten()
+      .map(|shebang| format!("#!{shebang}\n"))
+      .unwrap_or_default();
+
     let before_code = self
       .before_module_items
       .iter()
@@ -96,41 +134,297 @@ impl Chunk {
       .map(|item| COMPILER.print_module_item(item, None).unwrap())
       .join("\n");
 
-    let code = self
+    let rendered_modules = self
       .ordered_modules(&graph.module_by_id)
       .iter()
       .filter_map(|m| m.as_norm())
-      .filter(|m| m.is_included())
-      .map(|module| module.render(&ctx, input_options))
-      .collect::<Vec<_>>()
+      .filter(|m| m.is_included() && m.css.is_none())
+      .map(|module| {
+        let id = module
+          .id
+          .as_path()
+          .relative(&input_options.cwd)
+          .to_string_lossy()
+          .to_string();
+        if output_options.sourcemap {
+          let (code, map) = module.render_with_source_map(&ctx, input_options);
+          (id, code, Some(map))
+        } else {
+          (id, module.render(&ctx, input_options), None)
+        }
+      })
+      .collect::<Vec<_>>();
+
+    let code = rendered_modules
+      .iter()
+      .map(|(_, code, _)| code.as_str())
       .join("\n");
 
-    let mut code = before_code + runtime_code.as_ref() + code.as_ref() + after_code.as_ref();
+    let filename = self.filename.clone().unwrap();
+    let map_file = Path::new(&filename)
+      .file_name()
+      .unwrap()
+      .to_string_lossy()
+      .to_string();
+    let sources_base = sources_base(&filename, input_options, output_options);
+    let new_map_builder = || {
+      ChunkSourceMapBuilder::new(
+        &map_file,
+        output_options.source_root.as_deref(),
+        &sources_base,
+      )
+    };
 
-    if output_options.format.is_cjs() {
-      // Workaround for cjs output
+    let mut map = output_options.sourcemap.then(|| {
+      let mut builder = new_map_builder();
+      // Modules start after the shebang, the imports and the runtime helpers, and are joined by a
+      // newline.
+      let mut line_offset = [&shebang, &before_code, &runtime_code]
+        .iter()
+        .map(|code| code.matches('\n').count() as u32)
+        .sum::<u32>();
+      rendered_modules.iter().for_each(|(_, code, map)| {
+        builder.add_module(map.as_ref().unwrap(), line_offset);
+        line_offset += code.matches('\n').count() as u32 + 1;
+      });
+      builder.into_source_map()
+    });
+
+    let mut code =
+      shebang + before_code.as_ref() + runtime_code.as_ref() + code.as_ref() + after_code.as_ref();
+
+    if !output_options.format.is_es() {
+      // Workaround for cjs, system and iife output
       let comments = SingleThreadedComments::default();
       let fm = COMPILER.create_source_file(PathBuf::from(self.id.value().to_string()), code);
       let mut program = COMPILER
         .parse_with_comments(
           fm.clone(),
-          swc_core::ecma::parser::Syntax::Es(Default::default()),
+          swc_core::ecma::parser::Syntax::Es(swc_core::ecma::parser::EsConfig {
+            jsx: input_options.builtins.jsx.is_preserve(),
+            ..Default::default()
+          }),
           Some(&comments),
         )
         .map_err(|e| BuildError::parse_js_failed(fm.clone(), e))?;
 
-      program = GLOBALS.set(&Default::default(), || {
-        rolldown_swc_visitors::to_cjs(
-          program,
-          Mark::new(),
-          &comments,
-          self.export_mode.is_default() && self.is_user_defined_entry,
+      let has_exports = program.body.iter().any(|item| {
+        matches!(
+          item,
+          ast::ModuleItem::ModuleDecl(
+            ast::ModuleDecl::ExportNamed(_) | ast::ModuleDecl::ExportAll(_)
+          )
         )
       });
+      if output_options.format.is_iife() && output_options.name.is_none() && has_exports {
+        (input_options.on_warn)(BuildError::missing_name_option_for_iife_export());
+      }
+
+      program = GLOBALS.set(&Default::default(), || {
+        if output_options.format.is_system() {
+          rolldown_swc_visitors::to_system(program, Mark::new(), &comments)
+        } else if output_options.format.is_iife() {
+          rolldown_swc_visitors::to_iife(
+            program,
+            Mark::new(),
+            &comments,
+            output_options.name.as_deref(),
+            |specifier| global_name_of_external(&output_options.globals, specifier),
+          )
+        } else {
+          let export_shim = match self.export_mode {
+            _ if !self.is_user_defined_entry => None,
+            ExportMode::Default => Some(CjsExportShim::Default),
+            ExportMode::Hybrid => Some(CjsExportShim::Hybrid),
+            _ => None,
+          };
+          rolldown_swc_visitors::to_cjs(program, Mark::new(), &comments, export_shim)
+        }
+      });
+
+      code = match map.take() {
+        Some(intermediate) => {
+          let (code, cjs_map) = COMPILER.print_with_source_map(&program, Some(&comments))?;
+          let mut builder = new_map_builder();
+          builder.add_remapped(&cjs_map, &intermediate);
+          map = Some(builder.into_source_map());
+          code
+        }
+        None => COMPILER.print(&program, Some(&comments))?,
+      };
+    }
+
+    let mut rendered_modules = rendered_modules
+      .into_iter()
+      .map(|(id, rendered, _)| RenderedModule {
+        id,
+        // The whole chunk is printed again after being transformed.
+        rendered_length: output_options.format.is_es().then_some(rendered.len()),
+      })
+      .collect_vec();
+
+    if let Some(banner) = &output_options.banner {
+      // After the shebang, and outside of the wrapper of IIFE or System output.
+      let start = if code.starts_with("#!") {
+        code.find('\n').map_or(code.len(), |end| end + 1)
+      } else {
+        0
+      };
+      code.insert_str(start, &format!("{banner}\n"));
+      map = map.map(|intermediate| {
+        let mut builder = new_map_builder();
+        builder.add_shifted(&intermediate, banner.matches('\n').count() as u32 + 1);
+        builder.into_source_map()
+      });
+    }
+
+    let map = map
+      .map(|map| {
+        let mut json = vec![];
+        map
+          .to_writer(&mut json)
+          .map_err(|err| BuildError::panic(err.to_string()))?;
+        code.push_str(&format!("\n//# sourceMappingURL={map_file}.map"));
+        UnaryBuildResult::Ok(String::from_utf8(json).unwrap())
+      })
+      .transpose()?;
+
+    let rendered_length_of_modules = rendered_modules
+      .iter()
+      .filter_map(|m| m.rendered_length)
+      .sum::<usize>();
+    rendered_modules.push(RenderedModule {
+      id: RUNTIME_MODULE_ID.to_string(),
+      rendered_length: Some(code.len() - rendered_length_of_modules),
+    });
+
+    Ok(Asset {
+      filename,
+      content: code,
+      rendered_modules,
+      map,
+      binary: None,
+    })
+  }
+
+  /// CSS imported by modules of the chunk is concatenated in the execution order of the modules,
+  /// so the cascade is the same as the order of imports.
+  /// The CSS of the modules is emitted next to the chunk, with the same name.
+  pub(crate) fn css_filename(&self) -> String {
+    Path::new(self.filename.as_ref().unwrap())
+      .with_extension("css")
+      .to_string_lossy()
+      .to_string()
+  }
 
-      code = COMPILER.print(&program, Some(&comments))?;
+  pub(crate) fn has_css(&self, module_by_id: &ModuleById) -> bool {
+    self
+      .modules
+      .iter()
+      .filter_map(|id| module_by_id.get(id)?.as_norm())
+      .any(|module| module.css.is_some())
+  }
+
+  pub(crate) fn render_css(
+    &self,
+    graph: &Graph,
+    input_options: &BuildInputOptions,
+    output_options: &BuildOutputOptions,
+  ) -> Option<Asset> {
+    let filename = self.css_filename();
+    let rendered_modules = self
+      .ordered_modules(&graph.module_by_id)
+      .into_iter()
+      .filter_map(|m| m.as_norm())
+      .filter_map(|module| {
+        let id = module
+          .id
+          .as_path()
+          .relative(&input_options.cwd)
+          .to_string_lossy()
+          .to_string();
+        let css = module.render_css(&filename, output_options)?;
+        let rendered = format!("/* {id} */\n{}\n", css.trim());
+        // Lines of `css` which are trimmed
+        let leading_lines = css[..css.len() - css.trim_start().len()]
+          .matches('\n')
+          .count();
+        Some((id, rendered, module, leading_lines))
+      })
+      .collect_vec();
+
+    if rendered_modules.is_empty() {
+      return None;
     }
-    Ok(code)
+
+    let mut content = rendered_modules
+      .iter()
+      .map(|(_, rendered, ..)| rendered.as_str())
+      .join("\n");
+
+    let map = output_options.sourcemap.then(|| {
+      let map_file = Path::new(&filename)
+        .file_name()
+        .unwrap()
+        .to_string_lossy()
+        .to_string();
+      let mut builder = ChunkSourceMapBuilder::new(
+        &map_file,
+        output_options.source_root.as_deref(),
+        &sources_base(&filename, input_options, output_options),
+      );
+      let mut line_offset = 0;
+      rendered_modules
+        .iter()
+        .for_each(|(_, rendered, module, leading_lines)| {
+          // The `/* id */` line comes first, and the CSS ends with a newline.
+          let line_count = rendered.matches('\n').count() as u32;
+          module
+            .css_lines
+            .iter()
+            .skip(*leading_lines)
+            .take(line_count as usize - 1)
+            .enumerate()
+            .for_each(|(idx, origin)| {
+              if let Some(origin) = origin {
+                builder.add_line(line_offset + 1 + idx as u32, &origin.path, origin.line);
+              }
+            });
+          // Modules are joined by a newline.
+          line_offset += line_count + 1;
+        });
+      let mut json = vec![];
+      builder.into_source_map().to_writer(&mut json).unwrap();
+      // The CSS already ends with a newline.
+      content.push_str(&format!("/*# sourceMappingURL={map_file}.map */"));
+      String::from_utf8(json).unwrap()
+    });
+
+    let mut rendered_modules = rendered_modules
+      .into_iter()
+      .map(|(id, rendered, ..)| RenderedModule {
+        id,
+        rendered_length: Some(rendered.len()),
+      })
+      .collect_vec();
+    rendered_modules.push(RenderedModule {
+      id: RUNTIME_MODULE_ID.to_string(),
+      rendered_length: Some(
+        content.len()
+          - rendered_modules
+            .iter()
+            .filter_map(|m| m.rendered_length)
+            .sum::<usize>(),
+      ),
+    });
+
+    Some(Asset {
+      filename,
+      content,
+      rendered_modules,
+      map,
+      binary: None,
+    })
   }
 
   /// Deconflicting is to rename identifiers to avoid conflicts.
@@ -151,11 +445,40 @@ impl Chunk {
       .collect::<FxHashSet<_>>();
 
     used_names.extend(preset_of_used_names(&ctx.output_options.format));
+    used_names.extend(self.runtime_helpers.used_names().into_iter().map(JsWord::from));
+    // The handler is a global, which must not be shadowed by a top-level binding.
+    used_names.extend(
+      ctx
+        .output_options
+        .chunk_load_error_handler
+        .as_deref()
+        .map(JsWord::from),
+    );
+
+    let minify_identifiers = ctx.output_options.minify.identifiers;
+    if minify_identifiers {
+      // A short name must not be shadowed by a declaration in a nested scope.
+      used_names.extend(
+        ordered_modules
+          .iter()
+          .filter_map(|m| m.as_norm().map(|m| m.declared_scoped_names.clone()))
+          .flatten(),
+      );
+    }
+    let mut mangled_names = mangled_names();
 
     let mut id_to_name = FxHashMap::default();
     let mut root_id_to_name = FxHashMap::default();
 
     let mut create_conflictless_name = |original: JsWord| {
+      if minify_identifiers {
+        // Exported names are kept by the exports statement, like `export { a as publicApi }`.
+        let name = mangled_names
+          .find(|name| !used_names.contains(name))
+          .unwrap();
+        used_names.insert(name.clone());
+        return name;
+      }
       let mut name = original.clone();
       let mut count = 1;
       while used_names.contains(&name) {
@@ -247,6 +570,7 @@ impl Chunk {
   #[instrument(skip_all)]
   pub(crate) fn finalize(&mut self, mut ctx: FinalizeBundleContext) -> UnaryBuildResult<()> {
     self.generate_cross_chunk_links(&mut ctx)?;
+    self.inject_polyfills(&ctx);
     let ordered_modules = {
       let mut modules = ctx.modules.values_mut().collect::<Vec<_>>();
       modules.sort_by_key(|m| m.exec_order());
@@ -264,6 +588,22 @@ impl Chunk {
       .flatten()
       .collect::<FxHashSet<_>>();
 
+    let chunk_load_error_handler = ctx
+      .output_options
+      .chunk_load_error_handler
+      .as_deref()
+      .filter(|_| !ctx.output_options.format.is_cjs());
+
+    // Marked before deconflicting, so the names of the helpers are reserved.
+    if self.imports_chunks_dynamically(&ctx) {
+      if ctx.preload_deps_by_chunk_id.is_some() {
+        self.runtime_helpers.preload();
+      }
+      if chunk_load_error_handler.is_some() {
+        self.runtime_helpers.load_chunk();
+      }
+    }
+
     let id_to_name = self.deconflict(&mut ctx);
 
     tracing::debug!("id_to_name: {:#?}", id_to_name);
@@ -275,13 +615,16 @@ impl Chunk {
     };
 
     let top_level_names = &id_to_name.values().collect();
+    let chunk_filename = self.filename.as_deref().unwrap();
 
     {
       // Finalize module items in chunk
       let finalize_ctx = FinalizeContext {
         chunk_filename_by_id: ctx.chunk_filename_by_id,
+        chunk_filename,
         // Since there's no dynamic import expressions to rewrite, we can use empty set.
         resolved_ids: &Default::default(),
+        required_modules: &Default::default(),
         // No scoped names to rewrite
         declared_scoped_names: &Default::default(),
         unresolved_ctxt: ctx.unresolved_ctxt,
@@ -289,6 +632,8 @@ impl Chunk {
         top_level_id_to_final_name: &id_to_name,
         split_point_id_to_chunk_id: ctx.split_point_id_to_chunk_id,
         top_level_names,
+        preload_deps_by_chunk_id: ctx.preload_deps_by_chunk_id,
+        chunk_load_error_handler,
       };
 
       self
@@ -296,8 +641,10 @@ impl Chunk {
         .visit_mut_with(&mut rolldown_swc_visitors::finalizer(finalize_ctx));
       let finalize_ctx = FinalizeContext {
         chunk_filename_by_id: ctx.chunk_filename_by_id,
+        chunk_filename,
         // Since there's no dynamic import expressions to rewrite, we can use empty set.
         resolved_ids: &Default::default(),
+        required_modules: &Default::default(),
         // No scoped names to rewrite
         declared_scoped_names: &Default::default(),
         unresolved_ctxt: ctx.unresolved_ctxt,
@@ -305,6 +652,8 @@ impl Chunk {
         top_level_id_to_final_name: &id_to_name,
         split_point_id_to_chunk_id: ctx.split_point_id_to_chunk_id,
         top_level_names,
+        preload_deps_by_chunk_id: ctx.preload_deps_by_chunk_id,
+        chunk_load_error_handler,
       };
       self
         .after_module_items
@@ -315,23 +664,84 @@ impl Chunk {
       .into_par_iter()
       .filter_map(|m| m.as_norm_mut())
       .for_each(|m| {
+        m.render_file_url(chunk_filename, ctx.output_options);
+        m.render_native_addon_paths(chunk_filename, ctx.native_addon_names, ctx.output_options);
         let finalize_ctx = FinalizeContext {
           chunk_filename_by_id: ctx.chunk_filename_by_id,
+          chunk_filename,
           resolved_ids: &m.resolved_module_ids,
+          required_modules: &m.required_modules,
           declared_scoped_names: &declared_scoped_names,
           unresolved_ctxt: ctx.unresolved_ctxt,
           top_level_ctxt_set: &top_level_ctxt_set,
           top_level_id_to_final_name: &id_to_name,
           split_point_id_to_chunk_id: ctx.split_point_id_to_chunk_id,
           top_level_names,
+          preload_deps_by_chunk_id: ctx.preload_deps_by_chunk_id,
+          chunk_load_error_handler,
         };
 
         m.ast
           .visit_mut_with(&mut rolldown_swc_visitors::finalizer(finalize_ctx));
       });
+
+    let mut modules = ctx
+      .modules
+      .values_mut()
+      .filter_map(|m| m.as_norm_mut())
+      .filter(|m| m.is_included() && m.css.is_none())
+      .sorted_by_key(|m| m.exec_order)
+      .collect_vec();
+
+    self.dedupe_helpers(&mut modules, &id_to_name);
+
+    // Lowered before minifying, so the temporaries are minified as well.
+    if !ctx.output_options.target.supports_logical_assignment() {
+      modules.par_iter_mut().for_each(|m| {
+        rolldown_swc_visitors::lower_logical_assignment(&mut m.ast, top_level_names);
+      });
+    }
+
+    // Comparisons of the members are folded by `minify.syntax`.
+    if ctx.output_options.minify.enums {
+      self.inline_enums(&mut modules);
+    }
+
+    // Inlined bodies are minified along with their call sites.
+    if ctx.output_options.minify.inline_functions {
+      self.inline_functions(&mut modules);
+    }
+
+    if ctx.output_options.minify.syntax {
+      modules.par_iter_mut().for_each(|m| {
+        m.ast.visit_mut_with(&mut rolldown_swc_visitors::minify_syntax(
+          ctx.unresolved_ctxt,
+          ctx.output_options.target,
+          &m.comments,
+        ));
+      });
+    }
+
+    if ctx.output_options.minify.hoist_common_subexpressions {
+      self.hoist_common_subexpressions(&mut modules);
+    }
+
+    if ctx.output_options.minify.hoist_strings && !ctx.cyclic_chunk_ids.contains(&self.id) {
+      self.hoist_strings(&mut modules);
+    }
     Ok(())
   }
 
+  fn imports_chunks_dynamically(&self, ctx: &FinalizeBundleContext) -> bool {
+    ctx
+      .modules
+      .values()
+      .filter_map(|m| m.as_norm())
+      .filter(|m| m.is_included())
+      .flat_map(|m| m.dyn_dependencies.iter())
+      .any(|id| ctx.split_point_id_to_chunk_id.contains_key(id))
+  }
+
   /// We only care about modules out of the chunk.
   /// - ExternalModule are considered out of the chunk.
   /// - NormalModule in other chunks are considered out of the chunk.
@@ -343,6 +753,15 @@ impl Chunk {
         NormOrExt::Normal(m) => {
           m.dependencies
             .iter()
+            // Imports are redirected to the owner of the imported symbol while linking, which might
+            // not be a direct dependency.
+            .chain(
+              m.linked_imports
+                .iter()
+                .filter(|(importee, specs)| !importee.is_external() && !specs.is_empty())
+                .map(|(importee, _)| importee)
+                .sorted(),
+            )
             .filter(|id| is_out_of_chunk(id))
             .for_each(|dep| {
               if !deps.contains(dep) {
@@ -404,12 +823,14 @@ impl Chunk {
     let re_export_all: FxHashSet<&ModuleId> = entry_module
       .re_export_all
       .iter()
-      .filter(|id| id.is_external())
+      .filter(|id| id.is_external() && !self.is_manual)
       .collect();
 
+    // The exports of a manual chunk are the bindings other chunks import from it instead.
     entry_module
       .linked_exports
       .iter()
+      .filter(|_| !self.is_manual)
       .for_each(|(exported_name, spec)| {
         if self.modules.contains(&spec.owner) && !spec.owner.is_external() {
           exports_in_scope.insert(exported_name.clone(), spec.clone());
@@ -427,7 +848,13 @@ impl Chunk {
       .flat_map(|chunk_dep_id| {
         let mut imported = false;
         let mut module_items = vec![];
-        let src = if chunk_dep_id.is_external() {
+        let src = if let Some(asset_name) = ctx.native_addon_names.get(*chunk_dep_id) {
+          box quote_str!(relative_asset_path(
+            asset_name,
+            self.filename.as_ref().unwrap(),
+            ctx.output_options
+          ))
+        } else if chunk_dep_id.is_external() {
           box quote_str!(chunk_dep_id.id())
         } else {
           let dep_chunk_id = ctx
@@ -441,9 +868,42 @@ impl Chunk {
               )
             });
           let imported_chunk_filename = ctx.chunk_filename_by_id.get(dep_chunk_id).unwrap();
-          box quote_str!(format!("./{imported_chunk_filename}"))
+          box quote_str!(relative_chunk_path(
+            self.filename.as_ref().unwrap(),
+            imported_chunk_filename
+          ))
         };
-        if let Some(specifiers) = imports_map.get(chunk_dep_id) {
+        let specifiers = imports_map.get(chunk_dep_id);
+        if let Some(specifiers) =
+          specifiers.filter(|_| ctx.manual_chunk_exports.contains(chunk_dep_id))
+        {
+          // Bindings are imported by the names the manual chunk exports them by.
+          imported = true;
+          module_items.push(ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(
+            ast::ImportDecl {
+              src: src.clone(),
+              specifiers: specifiers
+                .iter()
+                .filter_map(|spec| {
+                  let name = ctx
+                    .manual_chunk_exports
+                    .import_name(chunk_dep_id, &spec.imported)?;
+                  Some((name, spec))
+                })
+                .sorted_by_key(|(name, _)| *name)
+                .map(|(name, spec)| {
+                  ast::ImportSpecifier::Named(ast::ImportNamedSpecifier {
+                    local: Ident::from(spec.imported_as.clone().to_id()),
+                    imported: Some(quote_ident!(name.clone()).into()),
+                    span: Default::default(),
+                    is_type_only: false,
+                  })
+                })
+                .collect(),
+              ..ast::ImportDecl::dummy()
+            },
+          )));
+        } else if let Some(specifiers) = specifiers {
           let mut specifiers = specifiers
             .iter()
             .map(|spec| (&spec.imported, spec))
@@ -510,11 +970,15 @@ impl Chunk {
                       name: quote_ident!(spec_id.local_id.name()).into(),
                     })
                   } else {
+                    let orig = match ctx.manual_chunk_exports.name_of_symbol(&spec_id.local_id) {
+                      Some(name) => quote_ident!(name.clone()),
+                      None => spec_id.local_id.clone().to_id().into(),
+                    };
                     ast::ExportSpecifier::Named(ast::ExportNamedSpecifier {
                       span: Default::default(),
-                      orig: ast::ModuleExportName::Ident(spec_id.local_id.clone().to_id().into()),
-                      exported: (*exported_name != spec_id.local_id.name())
+                      exported: (**exported_name != orig.sym)
                         .then(|| quote_ident!((*exported_name).clone()).into()),
+                      orig: ast::ModuleExportName::Ident(orig),
                       is_type_only: false,
                     })
                   }
@@ -557,13 +1021,19 @@ impl Chunk {
       self.validate_export_mode(ctx.output_options, &exports_in_scope)?;
     }
 
-    if !exports_in_scope.is_empty() {
-      let exports = rolldown_ast_template::build_exports_stmt(
-        exports_in_scope
+    let exports = exports_in_scope
+      .iter()
+      .map(|(exported_name, spec)| (exported_name.clone(), spec.local_id.clone().to_id()))
+      .chain(
+        ctx
+          .manual_chunk_exports
+          .exports_of(&self.id)
           .iter()
-          .map(|(exported_name, spec)| (exported_name.clone(), spec.local_id.clone().to_id()))
-          .collect(),
-      );
+          .map(|(exported_name, symbol)| (exported_name.clone(), symbol.clone().to_id())),
+      )
+      .collect_vec();
+    if !exports.is_empty() {
+      let exports = rolldown_ast_template::build_exports_stmt(exports);
       self.after_module_items.push(exports);
     }
     Ok(())
@@ -611,6 +1081,17 @@ impl Chunk {
             self.export_mode = ExportMode::Named;
           }
         }
+        ExportMode::Hybrid => {
+          if !exports.contains_key(&js_word!("default")) {
+            return Err(BuildError::incompatible_export_option_value(
+              "hybrid",
+              exports.keys().map(|s| s.to_string()).collect(),
+              self.entry.as_ref(),
+            ));
+          } else {
+            self.export_mode = ExportMode::Hybrid;
+          }
+        }
         ExportMode::Named => {
           // Don't need to do anything
         }
@@ -631,4 +1112,11 @@ pub(crate) struct FinalizeBundleContext<'me> {
   // pub unresolved_mark: Mark,
   pub unresolved_ctxt: SyntaxContext,
   pub output_options: &'me BuildOutputOptions,
+  pub manual_chunk_exports: &'me ManualChunkExports,
+  /// Set if `module_preload` is enabled for the format.
+  pub preload_deps_by_chunk_id: Option<&'me FxHashMap<ChunkId, Vec<String>>>,
+  /// Chunks importing themselves statically through other chunks.
+  pub cyclic_chunk_ids: &'me FxHashSet<ChunkId>,
+  /// Native addons => the paths of their copies relative to the output directory
+  pub native_addon_names: &'me FxHashMap<ModuleId, String>,
 }
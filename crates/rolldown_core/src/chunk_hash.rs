@@ -0,0 +1,131 @@
+use std::hash::{Hash, Hasher};
+
+use rustc_hash::{FxHashMap, FxHashSet, FxHasher};
+
+use crate::Asset;
+
+const PLACEHOLDER_PREFIX: &str = "!~{";
+/// The length of a hash, so source maps stay valid once placeholders are replaced.
+const PLACEHOLDER_LEN: usize = 8;
+/// Placeholders are hashed as this, so the hash of a chunk doesn't depend on which placeholders
+/// the other chunks of the build got.
+const NORMALIZED_PLACEHOLDER: &str = "!~{~~~~}";
+
+/// Stands for `[hash]` in the filename of a chunk until the chunks are rendered, since the hash is
+/// computed from the rendered code, which has the filenames of the imported chunks.
+pub(crate) fn hash_placeholder(index: usize) -> String {
+  format!("{PLACEHOLDER_PREFIX}{index:04x}}}")
+}
+
+fn find_placeholders(text: &str) -> impl Iterator<Item = &str> {
+  text
+    .match_indices(PLACEHOLDER_PREFIX)
+    .filter_map(|(start, _)| {
+      let placeholder = text.get(start..start + PLACEHOLDER_LEN)?;
+      let index = placeholder
+        .strip_prefix(PLACEHOLDER_PREFIX)?
+        .strip_suffix('}')?;
+      index
+        .bytes()
+        .all(|b| b.is_ascii_hexdigit())
+        .then_some(placeholder)
+    })
+}
+
+fn replace_placeholders(text: &str, replacement_of: impl Fn(&str) -> Option<String>) -> String {
+  find_placeholders(text)
+    .collect::<FxHashSet<_>>()
+    .into_iter()
+    .fold(text.to_string(), |text, placeholder| {
+      match replacement_of(placeholder) {
+        Some(replacement) => text.replace(placeholder, &replacement),
+        None => text,
+      }
+    })
+}
+
+/// The text with the placeholders replaced by their hashes.
+pub(crate) fn fill_hashes(text: &str, hash_by_placeholder: &FxHashMap<String, String>) -> String {
+  replace_placeholders(text, |placeholder| {
+    hash_by_placeholder.get(placeholder).cloned()
+  })
+}
+
+/// Replaces the placeholders in the filenames, the code and the source maps of the assets with the
+/// hashes of their chunks, and returns the hash of each placeholder.
+///
+/// A chunk is hashed from its final code, along with the CSS emitted next to it, and from the
+/// hashes of the chunks it refers to, transitively. So its filename changes whenever its output
+/// or the filename of an imported chunk does, and stays the same otherwise, even if chunks are
+/// added to or removed from the build. The `ignored` texts, like legal comments, are removed from
+/// the code before it's hashed.
+pub(crate) fn replace_hash_placeholders(
+  assets: &mut [Asset],
+  ignored: &[String],
+) -> FxHashMap<String, String> {
+  let mut hasher_by_placeholder = FxHashMap::<String, FxHasher>::default();
+  let mut deps_by_placeholder = FxHashMap::<String, FxHashSet<String>>::default();
+  assets.iter().for_each(|asset| {
+    let Some(placeholder) = find_placeholders(&asset.filename).next() else {
+      return;
+    };
+    ignored
+      .iter()
+      .fold(
+        replace_placeholders(&asset.content, |_| Some(NORMALIZED_PLACEHOLDER.to_string())),
+        |content, ignored| content.replace(ignored.as_str(), ""),
+      )
+      .hash(
+        hasher_by_placeholder
+          .entry(placeholder.to_string())
+          .or_default(),
+      );
+    deps_by_placeholder
+      .entry(placeholder.to_string())
+      .or_default()
+      .extend(
+        find_placeholders(&asset.content)
+          .filter(|dep| *dep != placeholder)
+          .map(|dep| dep.to_string()),
+      );
+  });
+  let own_hash_by_placeholder = hasher_by_placeholder
+    .into_iter()
+    .map(|(placeholder, hasher)| (placeholder, hasher.finish()))
+    .collect::<FxHashMap<_, _>>();
+
+  let hash_by_placeholder = own_hash_by_placeholder
+    .iter()
+    .map(|(placeholder, own_hash)| {
+      let mut visited = FxHashSet::default();
+      let mut stack = vec![placeholder];
+      while let Some(placeholder) = stack.pop() {
+        if visited.insert(placeholder) {
+          stack.extend(deps_by_placeholder.get(placeholder).into_iter().flatten());
+        }
+      }
+      visited.remove(placeholder);
+      let mut dep_hashes = visited
+        .into_iter()
+        // Placeholders of chunks without `[hash]` don't exist.
+        .filter_map(|dep| own_hash_by_placeholder.get(dep))
+        .collect::<Vec<_>>();
+      dep_hashes.sort();
+
+      let mut hasher = FxHasher::default();
+      own_hash.hash(&mut hasher);
+      dep_hashes.hash(&mut hasher);
+      let hash = format!("{:016x}", hasher.finish())[..PLACEHOLDER_LEN].to_string();
+      (placeholder.clone(), hash)
+    })
+    .collect::<FxHashMap<_, _>>();
+
+  assets.iter_mut().for_each(|asset| {
+    asset.filename = fill_hashes(&asset.filename, &hash_by_placeholder);
+    asset.content = fill_hashes(&asset.content, &hash_by_placeholder);
+    if let Some(map) = &asset.map {
+      asset.map = Some(fill_hashes(map, &hash_by_placeholder));
+    }
+  });
+  hash_by_placeholder
+}
@@ -0,0 +1,156 @@
+use std::path::{Path, PathBuf};
+
+use rustc_hash::FxHashSet;
+use sourcemap::{SourceMap, SourceMapBuilder, Token};
+use sugar_path::SugarPath;
+use swc_core::common::FileName;
+
+use crate::{BuildInputOptions, BuildOutputOptions, COMPILER};
+
+/// Entries of `sources` are relative to the map, which is written next to `filename`. With
+/// `sourceRoot`, they are relative to `cwd` instead, since the root usually maps to the project.
+pub(crate) fn sources_base(
+  filename: &str,
+  input_options: &BuildInputOptions,
+  output_options: &BuildOutputOptions,
+) -> PathBuf {
+  match &output_options.source_root {
+    Some(_) => input_options.cwd.clone(),
+    None => output_options
+      .dir
+      .join(filename)
+      .parent()
+      .unwrap()
+      .to_path_buf(),
+  }
+}
+
+/// Merges the source maps of the modules in a chunk into the source map of the chunk.
+pub(crate) struct ChunkSourceMapBuilder<'a> {
+  builder: SourceMapBuilder,
+  /// Entries of `sources` are relative to this directory.
+  sources_base: &'a Path,
+  sources_with_contents: FxHashSet<u32>,
+}
+
+impl<'a> ChunkSourceMapBuilder<'a> {
+  pub(crate) fn new(file: &str, source_root: Option<&str>, sources_base: &'a Path) -> Self {
+    let mut builder = SourceMapBuilder::new(Some(file));
+    builder.set_source_root(source_root);
+    Self {
+      builder,
+      sources_base,
+      sources_with_contents: Default::default(),
+    }
+  }
+
+  /// `line_offset` is the line of the chunk where the printed module starts. Sources of the map are
+  /// the absolute paths of the original files.
+  pub(crate) fn add_module(&mut self, map: &SourceMap, line_offset: u32) {
+    map.tokens().for_each(|token| {
+      let Some(source) = token.get_source() else {
+        return;
+      };
+      let relative_source = self.relative_source(Path::new(source));
+      let contents = COMPILER
+        .cm
+        .get_source_file(&FileName::Real(PathBuf::from(source)))
+        .map(|file| file.src.to_string());
+      self.add_token(
+        token.get_dst_line() + line_offset,
+        token.get_dst_col(),
+        &token,
+        &relative_source,
+        contents.as_deref(),
+      );
+    });
+  }
+
+  /// `map` maps the chunk to an intermediate code, which is mapped to the originals by
+  /// `intermediate`. This happens when the chunk is transformed after being rendered, such as
+  /// converting to CommonJS.
+  pub(crate) fn add_remapped(&mut self, map: &SourceMap, intermediate: &SourceMap) {
+    map.tokens().for_each(|token| {
+      let Some(original) = intermediate.lookup_token(token.get_src_line(), token.get_src_col())
+      else {
+        return;
+      };
+      let Some(source) = original.get_source() else {
+        return;
+      };
+      self.add_token(
+        token.get_dst_line(),
+        token.get_dst_col(),
+        &original,
+        source,
+        intermediate.get_source_contents(original.get_src_id()),
+      );
+    });
+  }
+
+  /// `map` is a map of the chunk, which moved down by `line_offset` lines, like when a banner is
+  /// prepended.
+  pub(crate) fn add_shifted(&mut self, map: &SourceMap, line_offset: u32) {
+    map.tokens().for_each(|token| {
+      let Some(source) = token.get_source() else {
+        return;
+      };
+      self.add_token(
+        token.get_dst_line() + line_offset,
+        token.get_dst_col(),
+        &token,
+        source,
+        map.get_source_contents(token.get_src_id()),
+      );
+    });
+  }
+
+  /// Maps line `dst_line` of the chunk to line `src_line` of `source`, which is an absolute path.
+  /// CSS is mapped line by line, and the files are read again for the contents.
+  pub(crate) fn add_line(&mut self, dst_line: u32, source: &Path, src_line: u32) {
+    let relative_source = self.relative_source(source);
+    let raw = self
+      .builder
+      .add(dst_line, 0, src_line, 0, Some(&relative_source), None);
+    if self.sources_with_contents.insert(raw.src_id) {
+      let contents = std::fs::read_to_string(source).ok();
+      self
+        .builder
+        .set_source_contents(raw.src_id, contents.as_deref());
+    }
+  }
+
+  fn relative_source(&self, source: &Path) -> String {
+    source
+      .relative(self.sources_base)
+      .to_string_lossy()
+      .replace('\\', "/")
+  }
+
+  fn add_token(
+    &mut self,
+    dst_line: u32,
+    dst_col: u32,
+    original: &Token,
+    source: &str,
+    contents: Option<&str>,
+  ) {
+    let raw = self.builder.add(
+      dst_line,
+      dst_col,
+      original.get_src_line(),
+      original.get_src_col(),
+      Some(source),
+      original.get_name(),
+    );
+    if self.sources_with_contents.insert(raw.src_id) {
+      // Contents are embedded no matter whether `sourceRoot` is set, so the originals are
+      // available even if they can't be fetched from the root.
+      self.builder.set_source_contents(raw.src_id, contents);
+    }
+  }
+
+  pub(crate) fn into_source_map(self) -> SourceMap {
+    self.builder.into_sourcemap()
+  }
+}
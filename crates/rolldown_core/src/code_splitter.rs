@@ -1,6 +1,6 @@
 use std::path::Component;
 
-use hashlink::LinkedHashSet;
+use hashlink::{LinkedHashMap, LinkedHashSet};
 use itertools::Itertools;
 // use petgraph::stable_graph::NodeIndex;
 use rolldown_common::{ChunkId, ModuleId};
@@ -34,7 +34,7 @@ pub fn uri_to_chunk_name(root: &str, uri: &str) -> String {
   name
 }
 
-use crate::{BuildInputOptions, Chunk, ChunkGraph, Graph, UnaryBuildResult};
+use crate::{BuildInputOptions, Chunk, ChunkGraph, Graph, ManualChunk, UnaryBuildResult};
 
 pub(crate) struct CodeSplitter<'me> {
   opts: &'me BuildInputOptions,
@@ -46,6 +46,8 @@ pub(crate) struct CodeSplitter<'me> {
   mod_to_chunks: FxHashMap<ModuleId, FxHashSet<ChunkId>>,
   // The order is only to make the output stable.
   dynamic_entries: LinkedHashSet<ModuleId>,
+  preserve_modules: bool,
+  manual_chunks: &'me [ManualChunk],
 }
 
 impl<'me> CodeSplitter<'me> {
@@ -53,6 +55,8 @@ impl<'me> CodeSplitter<'me> {
     entries: Vec<ModuleId>,
     graph: &'me mut Graph,
     opts: &'me BuildInputOptions,
+    preserve_modules: bool,
+    manual_chunks: &'me [ManualChunk],
   ) -> Self {
     Self {
       opts,
@@ -69,11 +73,15 @@ impl<'me> CodeSplitter<'me> {
       dynamic_entries: graph
         .module_by_id
         .values()
+        // Modules are inserted into `module_by_id` in the order they finish loading, which isn't stable.
+        .sorted_by_key(|m| m.exec_order())
         .flat_map(|m| m.dynamic_dependencies())
         // Ignore external module
         .filter(|m| !m.is_external())
         .cloned()
         .collect::<LinkedHashSet<_>>(),
+      preserve_modules,
+      manual_chunks,
     }
   }
 }
@@ -130,11 +138,7 @@ impl<'me> CodeSplitter<'me> {
   pub fn analyze_entries(&mut self, mut entries: Vec<ModuleId>, is_entry_chunk: bool) {
     while let Some(entry) = entries.pop() {
       let _exec_order = self.graph.module_by_id[&entry].exec_order();
-      let chunk = Chunk::new(
-        uri_to_chunk_name(&self.opts.cwd.to_string_lossy(), entry.as_ref()),
-        entry.clone(),
-        is_entry_chunk,
-      );
+      let chunk = Chunk::new(self.chunk_name(&entry), entry.clone(), is_entry_chunk);
       self
         .split_point_module_to_chunk
         .insert(entry.clone(), chunk.id.clone());
@@ -167,6 +171,41 @@ impl<'me> CodeSplitter<'me> {
     }
   }
 
+  fn chunk_name(&self, entry: &ModuleId) -> String {
+    if self.preserve_modules {
+      // Keep the directory structure, so `src/util.js` would be emitted as `src/util.js`.
+      let mut relative = entry.as_path().relative(&self.opts.cwd);
+      relative.set_extension("");
+      relative
+        .components()
+        .filter_map(|com| match com {
+          Component::Normal(seg) => seg.to_str(),
+          _ => None,
+        })
+        .join("/")
+    } else {
+      uri_to_chunk_name(&self.opts.cwd.to_string_lossy(), entry.as_ref())
+    }
+  }
+
+  /// In `preserveModules` mode, every module is a split point.
+  fn split_every_module(&mut self) {
+    let module_ids = self
+      .graph
+      .module_by_id
+      .values()
+      .filter_map(|m| m.as_norm())
+      .sorted_by_key(|m| m.exec_order)
+      .map(|m| m.id.clone())
+      .collect_vec();
+    module_ids.iter().for_each(|module_id| {
+      if !self.split_point_module_to_chunk.contains_key(module_id) {
+        self.analyze_entries(vec![module_id.clone()], false);
+      }
+      self.remove_duplicated_module(module_id);
+    });
+  }
+
   fn collect_shared_modules(&self) -> Vec<ModuleId> {
     self
       .mod_to_chunks
@@ -174,6 +213,7 @@ impl<'me> CodeSplitter<'me> {
       .filter(|(_, chunks)| chunks.len() > 1)
       .filter(|(module_id, _)| !module_id.is_external())
       .map(|(module_id, _)| module_id.clone())
+      .sorted_by_key(|module_id| self.graph.module_by_id[module_id].exec_order())
       .collect()
   }
 
@@ -204,6 +244,85 @@ impl<'me> CodeSplitter<'me> {
       FxHashSet::from_iter([owner_chunk_id.clone()]);
   }
 
+  /// Modules matching a manual chunk are moved into it, along with their dependencies not matching
+  /// other manual chunks. Entries keep their own chunks. Chunks whose modules are all moved are
+  /// removed.
+  fn split_manual_chunks(&mut self) {
+    let manual_chunks = self.manual_chunks;
+    let module_ids = self
+      .graph
+      .module_by_id
+      .values()
+      .filter_map(|m| m.as_norm())
+      .sorted_by_key(|m| m.exec_order)
+      .map(|m| m.id.clone())
+      .collect_vec();
+    let is_movable =
+      |id: &ModuleId| !self.entries.contains(id) && !self.dynamic_entries.contains(id);
+    let manual_chunk_of = |id: &ModuleId| {
+      manual_chunks
+        .iter()
+        .find(|manual_chunk| manual_chunk.matches(id.as_ref()))
+    };
+
+    let mut manual_chunk_by_module: LinkedHashMap<ModuleId, &str> = module_ids
+      .iter()
+      .filter(|id| is_movable(id))
+      .filter_map(|id| Some((id.clone(), manual_chunk_of(id)?.name.as_str())))
+      .collect();
+    let matched = manual_chunk_by_module
+      .iter()
+      .map(|(id, name)| (id.clone(), *name))
+      .collect_vec();
+    matched.into_iter().for_each(|(id, name)| {
+      let mut stack = vec![id];
+      while let Some(module_id) = stack.pop() {
+        let module = &self.graph.module_by_id[&module_id];
+        module
+          .dependencies()
+          .iter()
+          .filter(|dep| !dep.is_external() && is_movable(dep))
+          .filter(|dep| {
+            !manual_chunk_by_module.contains_key(*dep) && manual_chunk_of(dep).is_none()
+          })
+          .cloned()
+          .collect_vec()
+          .into_iter()
+          .for_each(|dep| {
+            manual_chunk_by_module.insert(dep.clone(), name);
+            stack.push(dep);
+          });
+      }
+    });
+
+    let manual_chunk_by_module = manual_chunk_by_module
+      .into_iter()
+      .sorted_by_key(|(id, _)| self.graph.module_by_id[id].exec_order())
+      .collect_vec();
+    for (module_id, name) in manual_chunk_by_module {
+      let chunk_id = ChunkId::from(name.to_string());
+      let chunks = self.mod_to_chunks.get_mut(&module_id).unwrap();
+      for old_chunk_id in chunks.drain() {
+        if let Some(old_chunk) = self.chunk_by_id.get_mut(&old_chunk_id) {
+          old_chunk.modules.remove(&module_id);
+        }
+      }
+      chunks.insert(chunk_id.clone());
+      let chunk = self.chunk_by_id.entry(chunk_id.clone()).or_insert_with(|| {
+        let mut chunk = Chunk::new(chunk_id.clone(), module_id.clone(), false);
+        chunk.is_manual = true;
+        chunk
+      });
+      chunk.modules.insert(module_id.clone());
+      // Other chunks import the bindings of the module from the manual chunk.
+      self.split_point_module_to_chunk.insert(module_id, chunk_id);
+    }
+
+    self
+      .chunk_by_id
+      .retain(|_, chunk| !chunk.modules.is_empty());
+  }
+
   #[instrument(skip_all)]
   pub(crate) fn split(mut self) -> UnaryBuildResult<ChunkGraph> {
     self.analyze_entries(self.entries.clone(), true);
@@ -214,6 +333,9 @@ impl<'me> CodeSplitter<'me> {
     self.dynamic_entries.clone().iter().for_each(|entry| {
       self.remove_duplicated_module(entry);
     });
+    if self.preserve_modules {
+      self.split_every_module();
+    }
 
     let mut shared_modules = self.collect_shared_modules();
     while let Some(shared_module_id) = shared_modules.pop() {
@@ -226,6 +348,10 @@ impl<'me> CodeSplitter<'me> {
       }
     }
 
+    if !self.preserve_modules && !self.manual_chunks.is_empty() {
+      self.split_manual_chunks();
+    }
+
     Ok(ChunkGraph {
       chunk_by_id: self.chunk_by_id,
       split_point_to_chunk: self.split_point_module_to_chunk,
@@ -0,0 +1,513 @@
+use std::{
+  ops::Range,
+  path::{Path, PathBuf},
+  sync::Arc,
+};
+
+use futures::{future::BoxFuture, FutureExt};
+use itertools::Itertools;
+use rolldown_error::BuildError;
+use sugar_path::SugarPath;
+
+use crate::{find_css_urls, is_local_url, strip_url_suffix, MappedCss, UnaryBuildResult};
+
+/// `@import "./a.css" screen;`
+struct CssImport<'a> {
+  /// The whole rule, with the `;`.
+  range: Range<usize>,
+  url: &'a str,
+  /// The URL as written, like `url("./a.css")`.
+  url_token: &'a str,
+  /// Everything between the URL and the `;`, like `layer(base) supports(display: grid) print`.
+  conditions: &'a str,
+}
+
+/// The end of a quoted string starting at `start`, after the closing quote.
+fn skip_string(bytes: &[u8], start: usize) -> usize {
+  let quote = bytes[start];
+  let mut idx = start + 1;
+  while idx < bytes.len() && bytes[idx] != quote {
+    idx += if bytes[idx] == b'\\' { 2 } else { 1 };
+  }
+  (idx + 1).min(bytes.len())
+}
+
+/// The end of the `(...)` starting at `start`, after the closing paren. Strings in it are skipped.
+fn skip_parens(bytes: &[u8], start: usize) -> usize {
+  let mut depth = 0;
+  let mut idx = start;
+  while idx < bytes.len() {
+    match bytes[idx] {
+      b'"' | b'\'' => {
+        idx = skip_string(bytes, idx);
+        continue;
+      }
+      b'(' => depth += 1,
+      b')' => {
+        depth -= 1;
+        if depth == 0 {
+          return idx + 1;
+        }
+      }
+      _ => {}
+    }
+    idx += 1;
+  }
+  bytes.len()
+}
+
+/// The end of the statement at `start`, after the `;`.
+fn skip_statement(bytes: &[u8], start: usize) -> usize {
+  let mut idx = start;
+  while idx < bytes.len() {
+    match bytes[idx] {
+      b'"' | b'\'' => idx = skip_string(bytes, idx),
+      b'(' => idx = skip_parens(bytes, idx),
+      b';' => return idx + 1,
+      _ => idx += 1,
+    }
+  }
+  bytes.len()
+}
+
+fn starts_with_keyword(s: &str, keyword: &str) -> bool {
+  s.get(..keyword.len())
+    .map_or(false, |prefix| prefix.eq_ignore_ascii_case(keyword))
+}
+
+/// `"./a.css"`, `'./a.css'`, `url(./a.css)` or `url("./a.css")`, and what follows it.
+fn split_import_url(rule: &str) -> Option<(&str, &str)> {
+  let bytes = rule.as_bytes();
+  match bytes.first()? {
+    &quote @ (b'"' | b'\'') => {
+      let end = skip_string(bytes, 0);
+      (end > 1 && bytes[end - 1] == quote).then(|| (&rule[1..end - 1], &rule[end..]))
+    }
+    _ if starts_with_keyword(rule, "url(") => {
+      let end = skip_parens(bytes, 3);
+      if bytes[end - 1] != b')' {
+        return None;
+      }
+      let url = rule[4..end - 1].trim();
+      let url = match url.as_bytes().first() {
+        Some(b'"' | b'\'') => &url[1..url.len() - 1],
+        _ => url,
+      };
+      Some((url, &rule[end..]))
+    }
+    _ => None,
+  }
+}
+
+/// The `@import` rules of the stylesheet. They are only allowed before any other rule, after
+/// `@charset` and `@layer` statements, so scanning stops at the first of the other rules.
+fn find_css_imports(css: &str) -> Vec<CssImport> {
+  let bytes = css.as_bytes();
+  let mut imports = vec![];
+  let mut idx = 0;
+  while idx < bytes.len() {
+    if bytes[idx].is_ascii_whitespace() {
+      idx += 1;
+      continue;
+    }
+    if bytes[idx..].starts_with(b"/*") {
+      idx = css[idx + 2..]
+        .find("*/")
+        .map_or(bytes.len(), |end| idx + 2 + end + 2);
+      continue;
+    }
+    let rest = &css[idx..];
+    let end = skip_statement(bytes, idx);
+    if starts_with_keyword(rest, "@import") {
+      let body = css[idx + "@import".len()..end].trim_end_matches(';').trim();
+      let Some((url, conditions)) = split_import_url(body) else {
+        break;
+      };
+      imports.push(CssImport {
+        range: idx..end,
+        url,
+        url_token: body[..body.len() - conditions.len()].trim_end(),
+        conditions: conditions.trim(),
+      });
+    } else if !starts_with_keyword(rest, "@charset") && !starts_with_keyword(rest, "@layer") {
+      break;
+    } else if css[idx..end].contains('{') {
+      // `@layer base { ... }` is a rule, not a statement.
+      break;
+    }
+    idx = end;
+  }
+  imports
+}
+
+fn wrap_in_block(prelude: &str, css: MappedCss) -> MappedCss {
+  let mut wrapped = MappedCss::default();
+  wrapped.push_added(&format!("{prelude} {{\n"));
+  wrapped.push(&css);
+  wrapped.push_added("\n}");
+  wrapped
+}
+
+/// The conditions of an `@import`, like `layer(base) supports(display: grid) print`.
+#[derive(Default)]
+struct Conditions {
+  /// An empty name for an anonymous layer.
+  layer: Option<String>,
+  /// A condition, like `(display: grid)`.
+  supports: Option<String>,
+  media: Option<String>,
+}
+
+/// The parts of `s` separated by commas which aren't in parens or strings.
+fn split_top_level_commas(s: &str) -> Vec<&str> {
+  let bytes = s.as_bytes();
+  let mut parts = vec![];
+  let mut start = 0;
+  let mut idx = 0;
+  while idx < bytes.len() {
+    match bytes[idx] {
+      b'"' | b'\'' => idx = skip_string(bytes, idx),
+      b'(' => idx = skip_parens(bytes, idx),
+      b',' => {
+        parts.push(s[start..idx].trim());
+        idx += 1;
+        start = idx;
+      }
+      _ => idx += 1,
+    }
+  }
+  parts.push(s[start..].trim());
+  parts
+}
+
+/// `screen and (min-width: 900px)` => `(Some("screen"), Some("(min-width: 900px)"))`. `None` for
+/// queries with `not`.
+fn split_media_query(query: &str) -> Option<(Option<&str>, Option<&str>)> {
+  if starts_with_keyword(query, "not ") {
+    return None;
+  }
+  let query = if starts_with_keyword(query, "only ") {
+    query["only ".len()..].trim_start()
+  } else {
+    query
+  };
+  if query.starts_with('(') {
+    return Some((None, Some(query)));
+  }
+  let (media_type, rest) = query
+    .split_once(|c: char| c.is_ascii_whitespace())
+    .unwrap_or((query, ""));
+  let media_type = (!media_type.eq_ignore_ascii_case("all")).then_some(media_type);
+  let rest = rest.trim_start();
+  if rest.is_empty() {
+    return Some((media_type, None));
+  }
+  starts_with_keyword(rest, "and ").then(|| (media_type, Some(rest["and ".len()..].trim())))
+}
+
+/// A query matching where both `outer` and `inner` match, or `None` if they never do, like
+/// `screen` and `print`. A query with `not` can't be combined, so `inner` is kept as is.
+fn combine_media_queries(outer: &str, inner: &str) -> Option<String> {
+  let (Some((outer_type, outer_condition)), Some((inner_type, inner_condition))) =
+    (split_media_query(outer), split_media_query(inner))
+  else {
+    return Some(inner.to_string());
+  };
+  let media_type = match (outer_type, inner_type) {
+    (Some(outer), Some(inner)) if !outer.eq_ignore_ascii_case(inner) => return None,
+    (outer, inner) => outer.or(inner),
+  };
+  let conditions = [outer_condition, inner_condition]
+    .into_iter()
+    .flatten()
+    .map(|condition| {
+      if condition.to_ascii_lowercase().contains(" or ") {
+        format!("({condition})")
+      } else {
+        condition.to_string()
+      }
+    });
+  Some(
+    media_type
+      .map(str::to_string)
+      .into_iter()
+      .chain(conditions)
+      .join(" and "),
+  )
+}
+
+/// Whether `condition` is a single `(...)`, like `(display: grid)`.
+fn is_in_parens(condition: &str) -> bool {
+  condition.starts_with('(') && skip_parens(condition.as_bytes(), 0) == condition.len()
+}
+
+/// `condition` in parens, unless it's in parens already.
+fn in_parens(condition: &str) -> String {
+  if is_in_parens(condition) {
+    condition.to_string()
+  } else {
+    format!("({condition})")
+  }
+}
+
+impl Conditions {
+  fn parse(conditions: &str) -> Self {
+    let mut rest = conditions;
+    let mut layer = None;
+    if starts_with_keyword(rest, "layer(") {
+      let end = skip_parens(rest.as_bytes(), "layer".len());
+      layer = Some(rest["layer(".len()..end - 1].trim().to_string());
+      rest = rest[end..].trim_start();
+    } else if starts_with_keyword(rest, "layer")
+      && rest[5..]
+        .chars()
+        .next()
+        .map_or(true, |c| c.is_ascii_whitespace())
+    {
+      layer = Some(String::new());
+      rest = rest[5..].trim_start();
+    }
+    let mut supports = None;
+    if starts_with_keyword(rest, "supports(") {
+      let end = skip_parens(rest.as_bytes(), "supports".len());
+      let condition = rest["supports(".len()..end - 1].trim();
+      // A declaration like `display: grid` is only a condition in parens.
+      let is_condition = condition.starts_with('(')
+        || starts_with_keyword(condition, "not ")
+        || starts_with_keyword(condition, "selector(");
+      supports = Some(if is_condition {
+        condition.to_string()
+      } else {
+        format!("({condition})")
+      });
+      rest = rest[end..].trim_start();
+    }
+    Self {
+      layer,
+      supports,
+      media: (!rest.is_empty()).then(|| rest.to_string()),
+    }
+  }
+
+  fn is_empty(&self) -> bool {
+    self.layer.is_none() && self.supports.is_none() && self.media.is_none()
+  }
+
+  /// The conditions of an `@import` in a file imported with `outer`, or `None` if it never
+  /// applies. Layers nest, like `layer(base.reset)`, but an anonymous layer has no name to nest
+  /// in, so the outer layer is kept.
+  fn within(&self, outer: &Conditions) -> Option<Conditions> {
+    let layer = match (&outer.layer, &self.layer) {
+      (Some(outer), Some(inner)) if !outer.is_empty() && !inner.is_empty() => {
+        Some(format!("{outer}.{inner}"))
+      }
+      (outer, inner) => outer.clone().or_else(|| inner.clone()),
+    };
+    let supports = match (&outer.supports, &self.supports) {
+      (Some(outer), Some(inner)) => Some(format!("{} and {}", in_parens(outer), in_parens(inner))),
+      (outer, inner) => outer.clone().or_else(|| inner.clone()),
+    };
+    let media = match (&outer.media, &self.media) {
+      (Some(outer), Some(inner)) => {
+        let queries = split_top_level_commas(outer)
+          .into_iter()
+          .cartesian_product(split_top_level_commas(inner))
+          .filter_map(|(outer, inner)| combine_media_queries(outer, inner))
+          .collect_vec();
+        if queries.is_empty() {
+          return None;
+        }
+        Some(queries.join(", "))
+      }
+      (outer, inner) => outer.clone().or_else(|| inner.clone()),
+    };
+    Some(Conditions {
+      layer,
+      supports,
+      media,
+    })
+  }
+
+  /// The conditions as written after the URL of an `@import`.
+  fn prelude(&self) -> String {
+    let layer = self.layer.as_ref().map(|layer| {
+      if layer.is_empty() {
+        "layer".to_string()
+      } else {
+        format!("layer({layer})")
+      }
+    });
+    let supports = self.supports.as_ref().map(|supports| {
+      // `supports(display: grid)` rather than `supports((display: grid))`.
+      if is_in_parens(supports) {
+        format!("supports({})", &supports[1..supports.len() - 1])
+      } else {
+        format!("supports({supports})")
+      }
+    });
+    layer
+      .into_iter()
+      .chain(supports)
+      .chain(self.media.clone())
+      .join(" ")
+  }
+}
+
+/// The CSS of an imported file in the blocks of the conditions of its `@import`:
+///
+/// - `print` => `@media print { ... }`
+/// - `supports(display: grid)` => `@supports (display: grid) { ... }`
+/// - `layer(base)` => `@layer base { ... }`
+fn wrap_in_conditions(css: MappedCss, conditions: &Conditions) -> MappedCss {
+  let mut wrapped = css;
+  if let Some(media) = &conditions.media {
+    wrapped = wrap_in_block(&format!("@media {media}"), wrapped);
+  }
+  if let Some(supports) = &conditions.supports {
+    wrapped = wrap_in_block(&format!("@supports {supports}"), wrapped);
+  }
+  match conditions.layer.as_deref() {
+    Some("") => wrap_in_block("@layer", wrapped),
+    Some(layer) => wrap_in_block(&format!("@layer {layer}"), wrapped),
+    None => wrapped,
+  }
+}
+
+/// The URLs of local files in `css`, which is in `from_dir`, made relative to `to_dir`.
+fn rebase_css_urls(css: &str, from_dir: &Path, to_dir: &Path) -> String {
+  let mut rebased = String::with_capacity(css.len());
+  let mut last_end = 0;
+  find_css_urls(css).into_iter().for_each(|range| {
+    let url = &css[range.clone()];
+    if !is_local_url(url) {
+      return;
+    }
+    let path = strip_url_suffix(url);
+    let relative = from_dir.join(path).normalize().relative(to_dir);
+    let relative = relative.to_string_lossy().replace('\\', "/");
+    rebased.push_str(&css[last_end..range.start]);
+    if !relative.starts_with('.') {
+      rebased.push_str("./");
+    }
+    rebased.push_str(&relative);
+    rebased.push_str(&url[path.len()..]);
+    last_end = range.end;
+  });
+  rebased.push_str(&css[last_end..]);
+  rebased
+}
+
+/// `remote` collects the remote imports of the imported files, with the conditions they are
+/// imported with, since `@import` is only allowed at the top of the stylesheet.
+fn inline_css_imports_of<'a>(
+  css_path: &'a Path,
+  css: String,
+  conditions: &'a Conditions,
+  importers: &'a mut Vec<PathBuf>,
+  remote: &'a mut Vec<MappedCss>,
+) -> BoxFuture<'a, UnaryBuildResult<MappedCss>> {
+  async move {
+    let imports = find_css_imports(&css);
+    let css = MappedCss::original(css, &Arc::from(css_path));
+    let Some(last) = imports.last() else {
+      return Ok(css);
+    };
+    let is_root = importers.len() == 1;
+    let dir = css_path.parent().unwrap();
+    let mut blocks = vec![];
+    for import in &imports {
+      if !is_local_url(import.url) {
+        if conditions.is_empty() {
+          remote.push(css.slice(import.range.clone()));
+        } else if let Some(conditions) = Conditions::parse(import.conditions).within(conditions) {
+          // The rule is on the line of the original one.
+          let mut rule = css.slice(import.range.start..import.range.start);
+          rule.push_added(&format!(
+            "@import {} {};",
+            import.url_token,
+            conditions.prelude()
+          ));
+          remote.push(rule);
+        }
+        continue;
+      }
+      let path = dir.join(strip_url_suffix(import.url)).normalize();
+      // A file importing one of its importers gets the rules once, from the first import.
+      if importers.contains(&path) {
+        continue;
+      }
+      let import_conditions = Conditions::parse(import.conditions);
+      // The rules of an import which never applies are dropped.
+      let Some(nested_conditions) = import_conditions.within(conditions) else {
+        continue;
+      };
+      let content = tokio::fs::read_to_string(&path)
+        .await
+        .map_err(BuildError::io_error)
+        .map_err(|e| {
+          e.context(format!(
+            "Read file: {}, imported by @import in {}",
+            path.display(),
+            css_path.display()
+          ))
+        })?;
+      importers.push(path.clone());
+      let content =
+        inline_css_imports_of(&path, content, &nested_conditions, importers, remote).await?;
+      importers.pop();
+      let content = content.map_code(|code| rebase_css_urls(code, path.parent().unwrap(), dir));
+      blocks.push(wrap_in_conditions(content.trim(), &import_conditions));
+    }
+    let mut kept = MappedCss::default();
+    if is_root {
+      for (idx, rule) in remote.drain(..).enumerate() {
+        if idx > 0 {
+          kept.push_added("\n");
+        }
+        kept.push(&rule);
+      }
+    }
+    let mut inlined = css.slice(0..imports[0].range.start);
+    let after = css.slice(last.range.end..css.code.len()).trim();
+    let parts = [kept]
+      .into_iter()
+      .chain(blocks)
+      .chain([after])
+      .filter(|part| !part.code.is_empty())
+      .collect::<Vec<_>>();
+    for (idx, part) in parts.iter().enumerate() {
+      if idx > 0 {
+        inlined.push_added("\n\n");
+      }
+      inlined.push(part);
+    }
+    inlined.push_added("\n");
+    Ok(inlined)
+  }
+  .boxed()
+}
+
+/// Replaces the `@import`s of local files with their CSS, in the blocks of the conditions of the
+/// `@import`. Imports in the imported files are inlined as well, so their conditions compose:
+/// `@import "./a.css" screen` in a file imported with `supports(display: grid)` is
+/// `@supports (display: grid) { @media screen { ... } }`.
+///
+/// Remote imports, of the imported files too, are kept before the inlined rules, with the
+/// conditions of the files importing them: `@import "https://a.css" print` in a file imported
+/// with `layer(base)` is `@import "https://a.css" layer(base) print`. Every line remembers the
+/// file it comes from, for the source map of the CSS.
+pub(crate) async fn inline_css_imports(
+  css_path: &Path,
+  css: String,
+) -> UnaryBuildResult<MappedCss> {
+  let mut importers = vec![css_path.to_path_buf()];
+  let mut remote = vec![];
+  inline_css_imports_of(
+    css_path,
+    css,
+    &Conditions::default(),
+    &mut importers,
+    &mut remote,
+  )
+  .await
+}
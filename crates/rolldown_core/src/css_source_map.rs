@@ -0,0 +1,97 @@
+use std::{ops::Range, path::Path, sync::Arc};
+
+/// The file and the line a line of bundled CSS comes from.
+#[derive(Debug, Clone)]
+pub(crate) struct CssLineOrigin {
+  pub(crate) path: Arc<Path>,
+  pub(crate) line: u32,
+}
+
+/// CSS with the origin of every line, so files inlined by `@import` still map to their own lines.
+/// CSS is only concatenated, never rewritten across lines, so lines are enough for devtools to show
+/// the original rules.
+#[derive(Debug, Clone)]
+pub(crate) struct MappedCss {
+  pub(crate) code: String,
+  /// One entry per line of `code`. `None` for lines we added, like the `@media` around an inlined
+  /// file.
+  pub(crate) lines: Vec<Option<CssLineOrigin>>,
+}
+
+impl Default for MappedCss {
+  fn default() -> Self {
+    Self {
+      code: String::new(),
+      lines: vec![None],
+    }
+  }
+}
+
+impl MappedCss {
+  /// The content of the file at `path`, line by line.
+  pub(crate) fn original(code: String, path: &Arc<Path>) -> Self {
+    let lines = (0..code.matches('\n').count() as u32 + 1)
+      .map(|line| {
+        Some(CssLineOrigin {
+          path: path.clone(),
+          line,
+        })
+      })
+      .collect();
+    Self { code, lines }
+  }
+
+  fn line_of(&self, offset: usize) -> usize {
+    self.code[..offset].matches('\n').count()
+  }
+
+  pub(crate) fn slice(&self, range: Range<usize>) -> Self {
+    let lines = self.lines[self.line_of(range.start)..=self.line_of(range.end)].to_vec();
+    Self {
+      code: self.code[range].to_string(),
+      lines,
+    }
+  }
+
+  pub(crate) fn trim(&self) -> Self {
+    let start = self.code.len() - self.code.trim_start().len();
+    let end = start + self.code.trim().len();
+    self.slice(start..end)
+  }
+
+  /// Appends `other` to the current line. The line keeps its origin, unless it had none yet or
+  /// only has whitespace so far.
+  pub(crate) fn push(&mut self, other: &MappedCss) {
+    let mut lines = other.lines.iter().cloned();
+    let first = lines.next().flatten();
+    let is_blank = self
+      .code
+      .rsplit('\n')
+      .next()
+      .map_or(true, |line| line.trim().is_empty());
+    let last = self.lines.last_mut().unwrap();
+    if last.is_none() || (is_blank && first.is_some()) {
+      *last = first;
+    }
+    self.lines.extend(lines);
+    self.code.push_str(&other.code);
+  }
+
+  /// Appends code written by us, which has no origin.
+  pub(crate) fn push_added(&mut self, code: &str) {
+    self
+      .lines
+      .extend(std::iter::repeat(None).take(code.matches('\n').count()));
+    self.code.push_str(code);
+  }
+
+  /// Rewrites the code of each line, keeping the lines.
+  pub(crate) fn map_code(self, f: impl FnOnce(&str) -> String) -> Self {
+    let code = f(&self.code);
+    debug_assert_eq!(code.matches('\n').count(), self.code.matches('\n').count());
+    Self {
+      code,
+      lines: self.lines,
+    }
+  }
+}
@@ -0,0 +1,135 @@
+use std::{
+  ops::Range,
+  path::{Path, PathBuf},
+};
+
+use itertools::Itertools;
+use sugar_path::SugarPath;
+
+use crate::{asset_name_of_file, asset_url, Asset, BuildOutputOptions, NormalModule};
+
+/// A file referenced by `url()` in CSS. It's copied to the output like a module loaded with the
+/// file loader.
+pub(crate) struct CssUrlFile {
+  pub(crate) path: PathBuf,
+  pub(crate) content: Vec<u8>,
+}
+
+/// Data URIs, absolute paths, remote URLs and fragments like `url(#gradient)` are left as they are.
+pub(crate) fn is_local_url(url: &str) -> bool {
+  let has_scheme = url.split_once(':').map_or(false, |(scheme, _)| {
+    !scheme.is_empty()
+      && scheme
+        .chars()
+        .all(|c| c.is_ascii_alphanumeric() || matches!(c, '+' | '-' | '.'))
+  });
+  !(url.is_empty() || url.starts_with('#') || url.starts_with('/') || has_scheme)
+}
+
+/// `./font.woff2?#iefix` => `./font.woff2`. The query and the fragment are kept in the rewritten URL.
+pub(crate) fn strip_url_suffix(url: &str) -> &str {
+  url
+    .find(|c| matches!(c, '?' | '#'))
+    .map_or(url, |idx| &url[..idx])
+}
+
+/// Ranges of the URLs in `url(./a.png)`, `url("./a.png")` and `url('./a.png')`, without quotes.
+/// `url(` in comments is skipped.
+pub(crate) fn find_css_urls(css: &str) -> Vec<Range<usize>> {
+  let bytes = css.as_bytes();
+  let mut ranges = vec![];
+  let mut idx = 0;
+  while idx < bytes.len() {
+    if bytes[idx..].starts_with(b"/*") {
+      idx = css[idx + 2..]
+        .find("*/")
+        .map_or(bytes.len(), |end| idx + 2 + end + 2);
+      continue;
+    }
+    let is_url_fn = bytes
+      .get(idx..idx + 4)
+      .map_or(false, |name| name.eq_ignore_ascii_case(b"url("))
+      && (idx == 0
+        || !matches!(bytes[idx - 1], b'a'..=b'z' | b'A'..=b'Z' | b'0'..=b'9' | b'-' | b'_'));
+    if !is_url_fn {
+      idx += 1;
+      continue;
+    }
+    idx += 4;
+    while idx < bytes.len() && bytes[idx].is_ascii_whitespace() {
+      idx += 1;
+    }
+    let range = match bytes.get(idx) {
+      Some(&quote @ (b'"' | b'\'')) => {
+        let start = idx + 1;
+        let mut end = start;
+        while end < bytes.len() && bytes[end] != quote {
+          end += if bytes[end] == b'\\' { 2 } else { 1 };
+        }
+        start..end.min(bytes.len())
+      }
+      _ => {
+        let start = idx;
+        let end = css[start..]
+          .find(')')
+          .map_or(bytes.len(), |end| start + end);
+        start..start + css[start..end].trim_end().len()
+      }
+    };
+    idx = range.end;
+    ranges.push(range);
+  }
+  ranges
+}
+
+/// Paths of the local files referenced by `url()`, relative to the CSS file.
+pub(crate) fn css_url_paths(css_path: &Path, css: &str) -> Vec<(String, PathBuf)> {
+  let dir = css_path.parent().unwrap();
+  find_css_urls(css)
+    .into_iter()
+    .map(|range| strip_url_suffix(&css[range]))
+    .filter(|url| is_local_url(url))
+    .unique()
+    .map(|url| (url.to_string(), dir.join(url).normalize()))
+    .collect()
+}
+
+impl NormalModule {
+  /// The CSS with URLs of local files rewritten to the URLs of their copies. `css_filename` is the
+  /// CSS file of the chunk.
+  pub(crate) fn render_css(
+    &self,
+    css_filename: &str,
+    output_options: &BuildOutputOptions,
+  ) -> Option<String> {
+    let css = self.css.as_ref()?;
+    let mut rendered = String::with_capacity(css.len());
+    let mut last_end = 0;
+    find_css_urls(css).into_iter().for_each(|range| {
+      let url = &css[range.clone()];
+      let path = strip_url_suffix(url);
+      if let Some(file) = self.css_url_files.get(path) {
+        let asset_name = asset_name_of_file(&file.path, &file.content, output_options);
+        rendered.push_str(&css[last_end..range.start]);
+        rendered.push_str(&asset_url(&asset_name, css_filename, output_options));
+        rendered.push_str(&url[path.len()..]);
+        last_end = range.end;
+      }
+    });
+    rendered.push_str(&css[last_end..]);
+    Some(rendered)
+  }
+
+  pub(crate) fn render_css_url_assets<'a>(
+    &'a self,
+    output_options: &'a BuildOutputOptions,
+  ) -> impl Iterator<Item = Asset> + 'a {
+    self.css_url_files.values().map(|file| Asset {
+      filename: asset_name_of_file(&file.path, &file.content, output_options),
+      content: String::new(),
+      rendered_modules: vec![],
+      map: None,
+      binary: Some(file.content.clone()),
+    })
+  }
+}
@@ -0,0 +1,477 @@
+use itertools::Itertools;
+use rolldown_common::{relative_chunk_path, ChunkId, ModuleId};
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::{
+  common::{util::take::Take, SyntaxContext, DUMMY_SP},
+  ecma::{
+    ast::{self, Id},
+    atoms::JsWord,
+    utils::{find_pat_ids, quote_ident, quote_str},
+    visit::{noop_visit_mut_type, noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+use crate::{Bundle, Chunk, ModuleById, NormalModule, COMPILER};
+
+fn as_fn_decl(item: &ast::ModuleItem) -> Option<&ast::FnDecl> {
+  match item {
+    ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl))) => Some(decl),
+    _ => None,
+  }
+}
+
+/// The code of a helper under its original name, so copies renamed by deconflicting, like
+/// `_decorate$1`, have the same code.
+fn helper_code(decl: &ast::FnDecl, original_name: &JsWord) -> String {
+  let mut decl = decl.clone();
+  decl.ident.sym = original_name.clone();
+  COMPILER
+    .print_module_item(
+      &ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl))),
+      None,
+    )
+    .unwrap()
+}
+
+/// The code of a helper with the helpers of its chunk under their original names, so copies in
+/// chunks calling `_define_properties$1` or `_define_properties` have the same code.
+fn canonical_helper_code(decl: &ast::FnDecl, original_names: &FxHashMap<JsWord, JsWord>) -> String {
+  let mut decl = decl.clone();
+  decl.visit_mut_with(&mut HelperRenamer {
+    renamed: original_names,
+  });
+  COMPILER
+    .print_module_item(
+      &ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl))),
+      None,
+    )
+    .unwrap()
+}
+
+fn helper_decl<'a>(
+  module_by_id: &'a ModuleById,
+  module_id: &ModuleId,
+  name: &JsWord,
+) -> Option<&'a ast::FnDecl> {
+  module_by_id
+    .get(module_id)?
+    .as_norm()?
+    .ast
+    .body
+    .iter()
+    .filter_map(as_fn_decl)
+    .find(|decl| decl.ident.sym == *name)
+}
+
+fn remove_helper_decl(module_by_id: &mut ModuleById, module_id: &ModuleId, name: &JsWord) {
+  if let Some(module) = module_by_id
+    .get_mut(module_id)
+    .and_then(|module| module.as_norm_mut())
+  {
+    module
+      .ast
+      .body
+      .retain(|item| as_fn_decl(item).map_or(true, |decl| decl.ident.sym != *name));
+  }
+}
+
+/// The items of a chunk, in the order they are rendered.
+fn chunk_items<'a>(
+  chunk: &'a Chunk,
+  module_by_id: &'a ModuleById,
+) -> impl Iterator<Item = &'a ast::ModuleItem> {
+  chunk
+    .before_module_items
+    .iter()
+    .chain(
+      chunk
+        .modules
+        .iter()
+        .filter_map(|id| module_by_id.get(id)?.as_norm())
+        .flat_map(|module| module.ast.body.iter()),
+    )
+    .chain(chunk.after_module_items.iter())
+}
+
+/// Top-level bindings declared by an item of a chunk.
+fn declared_names(item: &ast::ModuleItem) -> Vec<JsWord> {
+  let decl = match item {
+    ast::ModuleItem::Stmt(ast::Stmt::Decl(decl)) => decl,
+    ast::ModuleItem::ModuleDecl(ast::ModuleDecl::ExportDecl(export)) => &export.decl,
+    ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(import)) => {
+      return import
+        .specifiers
+        .iter()
+        .map(|specifier| match specifier {
+          ast::ImportSpecifier::Named(named) => named.local.sym.clone(),
+          ast::ImportSpecifier::Default(default) => default.local.sym.clone(),
+          ast::ImportSpecifier::Namespace(namespace) => namespace.local.sym.clone(),
+        })
+        .collect();
+    }
+    _ => return vec![],
+  };
+  match decl {
+    ast::Decl::Fn(decl) => vec![decl.ident.sym.clone()],
+    ast::Decl::Class(decl) => vec![decl.ident.sym.clone()],
+    ast::Decl::Var(decl) => find_pat_ids::<_, Id>(&decl.decls)
+      .into_iter()
+      .map(|(sym, _)| sym)
+      .collect(),
+    _ => vec![],
+  }
+}
+
+/// Names exported by a chunk.
+fn exported_names(chunk: &Chunk) -> FxHashSet<JsWord> {
+  chunk
+    .before_module_items
+    .iter()
+    .chain(chunk.after_module_items.iter())
+    .filter_map(|item| match item {
+      ast::ModuleItem::ModuleDecl(ast::ModuleDecl::ExportNamed(export)) => Some(export),
+      _ => None,
+    })
+    .flat_map(|export| export.specifiers.iter())
+    .filter_map(|specifier| match specifier {
+      ast::ExportSpecifier::Named(named) => {
+        Some(match named.exported.as_ref().unwrap_or(&named.orig) {
+          ast::ModuleExportName::Ident(ident) => ident.sym.clone(),
+          ast::ModuleExportName::Str(str) => str.value.clone(),
+        })
+      }
+      _ => None,
+    })
+    .collect()
+}
+
+/// `import { _helper as name } from "./host.js"`
+fn helper_import(name: &JsWord, host_name: &JsWord, src: String) -> ast::ModuleItem {
+  ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(ast::ImportDecl {
+    src: Box::new(quote_str!(src)),
+    specifiers: vec![ast::ImportSpecifier::Named(ast::ImportNamedSpecifier {
+      span: DUMMY_SP,
+      local: quote_ident!(name.clone()),
+      imported: (name != host_name).then(|| quote_ident!(host_name.clone()).into()),
+      is_type_only: false,
+    })],
+    ..ast::ImportDecl::dummy()
+  }))
+}
+
+/// Names a node refers to, without the names of properties.
+#[derive(Default)]
+struct ReferencedNames {
+  names: FxHashSet<JsWord>,
+}
+
+impl Visit for ReferencedNames {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    self.names.insert(ident.sym.clone());
+  }
+
+  fn visit_member_prop(&mut self, prop: &ast::MemberProp) {
+    if let ast::MemberProp::Computed(computed) = prop {
+      computed.visit_with(self);
+    }
+  }
+
+  fn visit_prop_name(&mut self, name: &ast::PropName) {
+    if let ast::PropName::Computed(computed) = name {
+      computed.visit_with(self);
+    }
+  }
+
+  fn visit_import_named_specifier(&mut self, specifier: &ast::ImportNamedSpecifier) {
+    // `imported` is a name of the other chunk
+    specifier.local.visit_with(self);
+  }
+}
+
+fn referenced_names<N: VisitWith<ReferencedNames>>(node: &N) -> FxHashSet<JsWord> {
+  let mut finder = ReferencedNames::default();
+  node.visit_with(&mut finder);
+  finder.names
+}
+
+/// Helpers only called by helpers the chunk imports now are removed.
+fn remove_unused_helpers(chunk: &mut Chunk, module_by_id: &mut ModuleById) {
+  loop {
+    let referenced = chunk_items(chunk, module_by_id)
+      .flat_map(|item| {
+        let mut names = referenced_names(item);
+        // A recursive helper doesn't keep itself
+        if let Some(decl) =
+          as_fn_decl(item).filter(|decl| chunk.declared_helpers.contains_key(&decl.ident.sym))
+        {
+          names.remove(&decl.ident.sym);
+        }
+        names
+      })
+      .collect::<FxHashSet<_>>();
+    let unused = chunk
+      .declared_helpers
+      .keys()
+      .filter(|name| !referenced.contains(*name))
+      .cloned()
+      .collect_vec();
+    if unused.is_empty() {
+      return;
+    }
+    unused.iter().for_each(|name| {
+      let (module_id, _) = chunk.declared_helpers.remove(name).unwrap();
+      remove_helper_decl(module_by_id, &module_id, name);
+    });
+  }
+}
+
+/// Top-level names of a chunk are unique and never shadowed once they are finalized, so references
+/// are found by their names.
+struct HelperRenamer<'a> {
+  renamed: &'a FxHashMap<JsWord, JsWord>,
+}
+
+impl VisitMut for HelperRenamer<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_ident(&mut self, ident: &mut ast::Ident) {
+    if let Some(name) = self.renamed.get(&ident.sym) {
+      ident.sym = name.clone();
+    }
+  }
+
+  fn visit_mut_member_prop(&mut self, prop: &mut ast::MemberProp) {
+    if let ast::MemberProp::Computed(computed) = prop {
+      computed.visit_mut_with(self);
+    }
+  }
+
+  fn visit_mut_prop_name(&mut self, name: &mut ast::PropName) {
+    if let ast::PropName::Computed(computed) = name {
+      computed.visit_mut_with(self);
+    }
+  }
+
+  fn visit_mut_prop(&mut self, prop: &mut ast::Prop) {
+    // `{ _decorate }` keeps its key
+    if let ast::Prop::Shorthand(ident) = prop {
+      if let Some(name) = self.renamed.get(&ident.sym) {
+        *prop = ast::Prop::KeyValue(ast::KeyValueProp {
+          key: ast::PropName::Ident(ident.clone()),
+          value: Box::new(ast::Expr::Ident(ast::Ident::new(name.clone(), ident.span))),
+        });
+        return;
+      }
+    }
+    prop.visit_mut_children_with(self);
+  }
+}
+
+impl Chunk {
+  /// SWC inlines the helpers of lowered syntax, like `_decorate` for decorators, into every module
+  /// using them. A helper is only declared by the first module of the chunk declaring it, and the
+  /// later modules call that one. Helpers used by several chunks are shared by
+  /// [`Bundle::share_helpers`] once every chunk is finalized.
+  pub(crate) fn dedupe_helpers(
+    &mut self,
+    modules: &mut [&mut NormalModule],
+    id_to_name: &FxHashMap<Id, JsWord>,
+  ) {
+    // The code of declared helpers => their final names
+    let mut declared = FxHashMap::default();
+    modules
+      .iter_mut()
+      .filter(|module| !module.helper_names.is_empty())
+      .for_each(|module| {
+        // Final names => original names
+        let helpers = module
+          .helper_names
+          .iter()
+          .filter_map(|name| {
+            let final_name = id_to_name.get(&(name.clone(), module.top_level_ctxt))?;
+            Some((final_name.clone(), name.clone()))
+          })
+          .collect::<FxHashMap<_, _>>();
+
+        // A helper calling another one, like `_create_class` calling `_define_properties`, only
+        // has the same code once the other one is renamed.
+        loop {
+          let renamed = module
+            .ast
+            .body
+            .iter()
+            .filter_map(as_fn_decl)
+            .filter_map(|decl| {
+              let original_name = helpers.get(&decl.ident.sym)?;
+              let kept: &JsWord = declared.get(&helper_code(decl, original_name))?;
+              Some((decl.ident.sym.clone(), kept.clone()))
+            })
+            .collect::<FxHashMap<_, _>>();
+          if renamed.is_empty() {
+            break;
+          }
+          module.ast.body.retain(|item| {
+            as_fn_decl(item).map_or(true, |decl| !renamed.contains_key(&decl.ident.sym))
+          });
+          module
+            .ast
+            .visit_mut_with(&mut HelperRenamer { renamed: &renamed });
+        }
+
+        module
+          .ast
+          .body
+          .iter()
+          .filter_map(as_fn_decl)
+          .for_each(|decl| {
+            if let Some(original_name) = helpers.get(&decl.ident.sym) {
+              declared
+                .entry(helper_code(decl, original_name))
+                .or_insert_with(|| {
+                  self.declared_helpers.insert(
+                    decl.ident.sym.clone(),
+                    (module.id.clone(), original_name.clone()),
+                  );
+                  decl.ident.sym.clone()
+                });
+            }
+          });
+      });
+  }
+}
+
+impl Bundle<'_> {
+  /// A helper declared by several chunks of a split build is only declared by one of them, the
+  /// host, if the others import it statically. They import the helper from the host then, which
+  /// is loaded before them anyway.
+  ///
+  /// - A helper shorter than the import is still declared by every chunk.
+  /// - A helper referring to other top-level bindings than helpers, like strings hoisted by
+  ///   minifying, isn't shared, since the bindings could be different in every chunk.
+  /// - User-defined entries don't host helpers, since their exports are the API.
+  pub(crate) fn share_helpers(&mut self, chunk_by_id: &mut FxHashMap<ChunkId, Chunk>) {
+    let static_imports_by_chunk_id = self.static_imports_by_chunk_id(chunk_by_id);
+    let imports_host = |chunk_id: &ChunkId, host_id: &ChunkId| {
+      chunk_id != host_id && static_imports_by_chunk_id[chunk_id].contains(host_id)
+    };
+    let module_by_id = &mut self.graph.module_by_id;
+
+    // The code of helpers => the chunks declaring them, with the names they are declared by
+    let mut declarations_by_code = FxHashMap::<String, Vec<(ChunkId, JsWord)>>::default();
+    chunk_by_id
+      .values()
+      .sorted_by_key(|chunk| &chunk.id)
+      .for_each(|chunk| {
+        let top_level_names = chunk_items(chunk, module_by_id)
+          .flat_map(declared_names)
+          .collect::<FxHashSet<_>>();
+        let original_names = chunk
+          .declared_helpers
+          .iter()
+          .map(|(name, (_, original_name))| (name.clone(), original_name.clone()))
+          .collect::<FxHashMap<_, _>>();
+        chunk
+          .declared_helpers
+          .iter()
+          .sorted_by_key(|(name, _)| *name)
+          .for_each(|(name, (module_id, _))| {
+            // Inlined by minifying
+            let Some(decl) = helper_decl(module_by_id, module_id, name) else {
+              return;
+            };
+            let refers_to_other_bindings = referenced_names(decl)
+              .iter()
+              .any(|name| top_level_names.contains(name) && !original_names.contains_key(name));
+            if !refers_to_other_bindings {
+              declarations_by_code
+                .entry(canonical_helper_code(decl, &original_names))
+                .or_default()
+                .push((chunk.id.clone(), name.clone()));
+            }
+          });
+      });
+
+    let mut imports_by_chunk_id = FxHashMap::<ChunkId, Vec<(JsWord, ast::ModuleItem)>>::default();
+    let mut exports_by_chunk_id = FxHashMap::<ChunkId, Vec<(JsWord, Id)>>::default();
+    for (code, declarations) in declarations_by_code {
+      let Some((host_id, host_name)) = declarations.iter().find(|(host_id, host_name)| {
+        let host = &chunk_by_id[host_id];
+        !host.is_user_defined_entry
+          && !exported_names(host).contains(host_name)
+          && declarations
+            .iter()
+            .any(|(chunk_id, _)| imports_host(chunk_id, host_id))
+      }) else {
+        continue;
+      };
+      let host_filename = chunk_by_id[host_id].filename.as_deref().unwrap();
+      let mut is_imported = false;
+      declarations
+        .iter()
+        .filter(|(chunk_id, _)| imports_host(chunk_id, host_id))
+        .for_each(|(chunk_id, name)| {
+          let import = helper_import(
+            name,
+            host_name,
+            relative_chunk_path(
+              chunk_by_id[chunk_id].filename.as_deref().unwrap(),
+              host_filename,
+            ),
+          );
+          if COMPILER.print_module_item(&import, None).unwrap().len() < code.len() {
+            is_imported = true;
+            imports_by_chunk_id
+              .entry(chunk_id.clone())
+              .or_default()
+              .push((name.clone(), import));
+          }
+        });
+      if is_imported {
+        exports_by_chunk_id
+          .entry(host_id.clone())
+          .or_default()
+          .push((
+            host_name.clone(),
+            (host_name.clone(), SyntaxContext::empty()),
+          ));
+      }
+    }
+
+    exports_by_chunk_id
+      .into_iter()
+      .for_each(|(host_id, exports)| {
+        let host = chunk_by_id.get_mut(&host_id).unwrap();
+        host
+          .after_module_items
+          .push(rolldown_ast_template::build_exports_stmt(exports));
+      });
+    imports_by_chunk_id
+      .into_iter()
+      .for_each(|(chunk_id, mut imports)| {
+        let chunk = chunk_by_id.get_mut(&chunk_id).unwrap();
+        imports.sort_by(|(a, _), (b, _)| a.cmp(b));
+        imports.iter().for_each(|(name, _)| {
+          let (module_id, _) = chunk.declared_helpers.remove(name).unwrap();
+          remove_helper_decl(module_by_id, &module_id, name);
+        });
+        // After the imports of the other chunks
+        let position = chunk
+          .before_module_items
+          .iter()
+          .position(|item| {
+            !matches!(
+              item,
+              ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(_))
+            )
+          })
+          .unwrap_or(chunk.before_module_items.len());
+        chunk.before_module_items.splice(
+          position..position,
+          imports.into_iter().map(|(_, import)| import),
+        );
+        remove_unused_helpers(chunk, module_by_id);
+      });
+  }
+}
@@ -12,6 +12,8 @@ pub struct ExternalModule {
   pub(crate) top_level_ctxt: SyntaxContext,
   pub(crate) runtime_helpers: RuntimeHelpers,
   pub(crate) exports: FxHashMap<JsWord, Symbol>,
+  /// The content of a native addon, which is copied to the output since it can't be bundled.
+  pub(crate) native_addon: Option<Vec<u8>>,
 }
 
 impl ExternalModule {
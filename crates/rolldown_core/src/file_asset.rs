@@ -0,0 +1,182 @@
+use std::hash::{Hash, Hasher};
+use std::path::Path;
+
+use rolldown_common::ModuleId;
+use rolldown_swc_visitors::RequiredModule;
+use rustc_hash::{FxHashMap, FxHasher};
+use sugar_path::SugarPath;
+use swc_core::ecma::{
+  ast,
+  visit::{VisitMut, VisitMutWith},
+};
+
+use crate::{file_name, Asset, BuildOutputOptions, ExternalModule, NormalModule};
+
+/// The URL of a copied file depends on output options, so it's only known once the chunk of the
+/// module is finalized.
+const FILE_URL_PLACEHOLDER: &str = "__ROLLDOWN_FILE_URL__";
+
+/// The code of a module loaded with the file loader.
+pub(crate) fn file_module_code() -> String {
+  format!("export default \"{FILE_URL_PLACEHOLDER}\";")
+}
+
+struct FileUrlReplacer<'a> {
+  url: &'a str,
+}
+
+impl VisitMut for FileUrlReplacer<'_> {
+  fn visit_mut_str(&mut self, str: &mut ast::Str) {
+    if &*str.value == FILE_URL_PLACEHOLDER {
+      str.value = self.url.into();
+      str.raw = None;
+    }
+  }
+}
+
+/// A `.node` file resolved from an import or a `require` call. Externals marked by the user are
+/// left as they are, even if they end with `.node`.
+pub(crate) fn is_native_addon(id: &ModuleId) -> bool {
+  id.is_external() && id.id().ends_with(".node") && Path::new(id.as_ref()).is_absolute()
+}
+
+/// The path of a copied file relative to the output directory.
+pub(crate) fn asset_name_of_file(
+  path: &Path,
+  content: &[u8],
+  output_options: &BuildOutputOptions,
+) -> String {
+  let mut hasher = FxHasher::default();
+  content.hash(&mut hasher);
+  let hash = &format!("{:016x}", hasher.finish())[..8];
+  let mut filename = output_options
+    .asset_file_names
+    .render(file_name::RenderOptions {
+      name: path.file_stem().and_then(|stem| stem.to_str()),
+      hash: Some(hash),
+    });
+  if let Some(ext) = path.extension().and_then(|ext| ext.to_str()) {
+    filename.push('.');
+    filename.push_str(ext);
+  }
+  filename
+}
+
+/// The URL is `public_path` followed by the name of the copied file if it's set, or the path of
+/// the copied file relative to the output file referencing it otherwise.
+pub(crate) fn asset_url(
+  asset_name: &str,
+  output_filename: &str,
+  output_options: &BuildOutputOptions,
+) -> String {
+  match &output_options.public_path {
+    Some(public_path) if public_path.ends_with('/') => format!("{public_path}{asset_name}"),
+    Some(public_path) => format!("{public_path}/{asset_name}"),
+    None => relative_asset_path(asset_name, output_filename, output_options),
+  }
+}
+
+/// The path of a copied file relative to the output file referencing it, like `./a-1a2b3c4d.png`.
+pub(crate) fn relative_asset_path(
+  asset_name: &str,
+  output_filename: &str,
+  output_options: &BuildOutputOptions,
+) -> String {
+  let output_file = output_options.dir.join(output_filename);
+  let relative = output_options
+    .dir
+    .join(asset_name)
+    .relative(output_file.parent().unwrap())
+    .to_string_lossy()
+    .replace('\\', "/");
+  if relative.starts_with('.') {
+    relative
+  } else {
+    format!("./{relative}")
+  }
+}
+
+impl NormalModule {
+  /// The path of the copied file relative to the output directory.
+  pub(crate) fn file_asset_name(&self, output_options: &BuildOutputOptions) -> Option<String> {
+    let content = self.file.as_ref()?;
+    Some(asset_name_of_file(
+      Path::new(self.id.as_ref()),
+      content,
+      output_options,
+    ))
+  }
+
+  pub(crate) fn render_file_url(
+    &mut self,
+    chunk_filename: &str,
+    output_options: &BuildOutputOptions,
+  ) {
+    let Some(asset_name) = self.file_asset_name(output_options) else {
+      return;
+    };
+    let url = asset_url(&asset_name, chunk_filename, output_options);
+    self.ast.visit_mut_with(&mut FileUrlReplacer { url: &url });
+  }
+
+  /// `require("./native.node")` => `require("./native-1a2b3c4d.node")`, the copy of the native addon
+  /// relative to `chunk_filename`. It's loaded from the disk, so `public_path` isn't used.
+  pub(crate) fn render_native_addon_paths(
+    &mut self,
+    chunk_filename: &str,
+    native_addon_names: &FxHashMap<ModuleId, String>,
+    output_options: &BuildOutputOptions,
+  ) {
+    self
+      .resolved_require_ids
+      .iter()
+      .for_each(|(specifier, id)| {
+        if let Some(asset_name) = native_addon_names.get(id) {
+          let path = relative_asset_path(asset_name, chunk_filename, output_options);
+          self
+            .required_modules
+            .insert(specifier.clone(), RequiredModule::External(path.into()));
+        }
+      });
+  }
+
+  pub(crate) fn render_file_asset(&self, output_options: &BuildOutputOptions) -> Option<Asset> {
+    let content = self.file.clone()?;
+    Some(Asset {
+      filename: self.file_asset_name(output_options)?,
+      content: String::new(),
+      rendered_modules: vec![],
+      map: None,
+      binary: Some(content),
+    })
+  }
+}
+
+impl ExternalModule {
+  /// The path of the copied native addon relative to the output directory.
+  pub(crate) fn native_addon_asset_name(
+    &self,
+    output_options: &BuildOutputOptions,
+  ) -> Option<String> {
+    let content = self.native_addon.as_ref()?;
+    Some(asset_name_of_file(
+      Path::new(self.id.as_ref()),
+      content,
+      output_options,
+    ))
+  }
+
+  pub(crate) fn render_native_addon_asset(
+    &self,
+    output_options: &BuildOutputOptions,
+  ) -> Option<Asset> {
+    let content = self.native_addon.clone()?;
+    Some(Asset {
+      filename: self.native_addon_asset_name(output_options)?,
+      content: String::new(),
+      rendered_modules: vec![],
+      map: None,
+      binary: Some(content),
+    })
+  }
+}
@@ -5,6 +5,7 @@ use itertools::Itertools;
 use rayon::prelude::{ParallelBridge, ParallelIterator};
 use rolldown_common::{ExportedSpecifier, ImportedSpecifier, ModuleId, Symbol, UnionFind};
 use rolldown_resolver::Resolver;
+use rolldown_swc_visitors::RequiredModule;
 use rolldown_tracing::ContextedTracer;
 use rustc_hash::FxHashSet as HashSet;
 use rustc_hash::{FxHashMap, FxHashSet};
@@ -15,7 +16,8 @@ use tracing::instrument;
 
 use crate::module_loader::ModuleLoader;
 use crate::{
-  norm_or_ext::NormOrExt, normal_module::NormalModule, ModuleById, UnaryBuildResult, SWC_GLOBALS,
+  norm_or_ext::NormOrExt, normal_module::NormalModule, ModuleById, UnaryBuildResult,
+  COMMONJS_WRAPPER_NAME, SWC_GLOBALS,
 };
 use crate::{BuildError, BuildResult, SharedBuildInputOptions, SharedBuildPluginDriver};
 
@@ -195,12 +197,69 @@ impl Graph {
       .collect::<Vec<_>>();
     order_modules.sort_unstable_by_key(|id| self.module_by_id[id].exec_order());
 
+    self.link_requires(&order_modules);
     self.link_exports(&order_modules)?;
     self.link_imports(&order_modules)?;
 
     Ok(())
   }
 
+  /// `require("./a")` is linked like an import of `require_a`, the wrapper of the CommonJS module,
+  /// or of the namespace if `./a` is an ES module. Externals are left to be required at runtime.
+  #[instrument(skip_all)]
+  fn link_requires(&mut self, order_modules: &[ModuleId]) {
+    order_modules
+      .iter()
+      .filter(|importer_id| !importer_id.is_external())
+      .for_each(|importer_id| {
+        let importer = Self::fetch_normal_module_mut(&mut self.module_by_id, importer_id);
+        let requires = std::mem::take(&mut importer.requires)
+          .into_iter()
+          .map(|(specifier, symbol)| {
+            let importee_id = importer.resolved_require_ids[&specifier].clone();
+            (specifier, symbol, importee_id)
+          })
+          .collect_vec();
+
+        requires
+          .into_iter()
+          .for_each(|(specifier, symbol, importee_id)| {
+            let required = match Self::fetch_module_mut(&mut self.module_by_id, &importee_id) {
+              NormOrExt::Normal(importee) if importee.is_commonjs => {
+                importee.wrap_commonjs(self.unresolved_ctxt);
+                Some((
+                  RequiredModule::CommonJs(symbol.clone()),
+                  JsWord::from(COMMONJS_WRAPPER_NAME),
+                ))
+              }
+              NormOrExt::Normal(importee) => {
+                importee.suggest_name(&js_word!("*"), &importee.file_stem().into());
+                Some((RequiredModule::Esm(symbol.clone()), js_word!("*")))
+              }
+              NormOrExt::External(_) => None,
+            };
+
+            let importer = Self::fetch_normal_module_mut(&mut self.module_by_id, importer_id);
+            if let Some((required, imported)) = required {
+              importer
+                .imports
+                .entry(importee_id)
+                .or_default()
+                .push(ImportedSpecifier {
+                  imported_as: symbol,
+                  imported,
+                });
+              importer.required_modules.insert(specifier, required);
+            } else {
+              // The call is kept, so the symbol is never declared.
+              importer.parts.parts.iter_mut().for_each(|part| {
+                part.referenced.remove(&symbol);
+              });
+            }
+          });
+      });
+  }
+
   /// Example
   /// ```ts
   /// // index.ts
@@ -338,39 +397,43 @@ impl Graph {
             .cloned()
             .collect::<Vec<_>>();
 
-        let non_conflicted_names = {
-          use std::collections::hash_map::Entry;
-          let mut tmp: FxHashMap<&JsWord, Option<&ExportedSpecifier>> = FxHashMap::default();
+        // A name is conflicted if it's exported by more than one importee and the bindings are different.
+        // Per spec, conflicted names are ambiguous and excluded from exports of the importer.
+        let (non_conflicted_names, conflicted_names) = {
+          let mut sources_by_name: FxHashMap<&JsWord, Vec<(&ModuleId, &ExportedSpecifier)>> =
+            FxHashMap::default();
           importee_of_being_re_exported_all
             .iter()
             .filter_map(|importee_id| Self::fetch_module(&self.module_by_id, importee_id).as_norm())
-            .flat_map(|each_importee| each_importee.linked_exports.iter())
-            .for_each(|(exported_name, spec)| match tmp.entry(exported_name) {
-              Entry::Occupied(mut entry) => {
-                match entry.get() {
-                  Some(existed_spec) => {
-                    // The name is not first seen, we need to check if the specifiers are the same
-                    if *existed_spec == spec {
-                      // The specifiers are the same, so it's ok
-                    } else {
-                      // Mark the name as conflicted
-                      entry.insert(None);
-                    }
-                  }
-                  None => {
-                    // Already conflicted, just ignore the name
-                  }
-                }
-              }
-              Entry::Vacant(entry) => {
-                // The name is first seen, so it's ok
-                entry.insert(Some(spec));
-              }
+            .for_each(|each_importee| {
+              each_importee
+                .linked_exports
+                .iter()
+                .for_each(|(exported_name, spec)| {
+                  sources_by_name
+                    .entry(exported_name)
+                    .or_default()
+                    .push((&each_importee.id, spec));
+                })
             });
-          tmp
-            .into_iter()
-            .filter_map(|(name, spec)| spec.map(|_| name.clone()))
-            .collect::<FxHashSet<_>>()
+
+          let mut non_conflicted_names = FxHashSet::default();
+          let mut conflicted_names = vec![];
+          sources_by_name.into_iter().for_each(|(name, sources)| {
+            let (_, first_spec) = sources[0];
+            if sources.iter().all(|(_, spec)| *spec == first_spec) {
+              non_conflicted_names.insert(name.clone());
+            } else {
+              conflicted_names.push((
+                name.clone(),
+                sources
+                  .into_iter()
+                  .map(|(id, _)| id.as_path().to_path_buf())
+                  .collect_vec(),
+              ));
+            }
+          });
+          (non_conflicted_names, conflicted_names)
         };
 
         let importer = Self::fetch_module(&self.module_by_id, importer_id).expect_norm();
@@ -381,6 +444,21 @@ impl Graph {
           .cloned()
           .collect::<FxHashSet<_>>();
 
+        conflicted_names
+          .into_iter()
+          // `default` is never re-exported by `export *` and explicit exports shadow conflicted names,
+          // so they are not ambiguous.
+          .filter(|(name, _)| {
+            name != "default" && !explicit_exported_names_of_importer.contains(name)
+          })
+          .for_each(|(name, sources)| {
+            (self.input_options.on_warn)(BuildError::namespace_conflict(
+              name.to_string(),
+              importer_id.as_path().to_path_buf(),
+              sources,
+            ));
+          });
+
         importee_of_being_re_exported_all
           .iter()
           .for_each(|importee_id| {
@@ -468,7 +546,8 @@ impl Graph {
   }
 
   /// two things
-  /// 1. Union symbol
+  /// 1. Union symbol. The imported name becomes the same binding as the exported one instead of a
+  ///    copy of it, so mutations are seen by importers, which circular imports rely on.
   /// 2. Generate real ImportedSpecifier for each import and add to `linked_imports`
   #[instrument(skip_all)]
   fn link_imports(&mut self, order_modules: &[ModuleId]) -> UnaryBuildResult<()> {
@@ -0,0 +1,443 @@
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::{
+  common::DUMMY_SP,
+  ecma::{
+    ast::{self, Id},
+    atoms::JsWord,
+    visit::{noop_visit_mut_type, noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+use crate::{mangled_names, saved_bytes, Chunk, NormalModule};
+
+/// `this.state.items` is `(None, ["state", "items"])`, where `None` is `this`.
+#[derive(Debug, Clone, PartialEq, Eq, Hash)]
+struct ChainKey {
+  root: Option<Id>,
+  props: Vec<JsWord>,
+}
+
+impl ChainKey {
+  /// The length of the printed chain.
+  fn len(&self) -> usize {
+    let root_len = self
+      .root
+      .as_ref()
+      .map_or("this".len(), |(sym, _)| sym.len());
+    root_len + self.props.iter().map(|prop| prop.len() + 1).sum::<usize>()
+  }
+}
+
+/// Plain property accesses on `this` or an identifier, at least two levels deep, like `a.b.c`.
+/// Computed and private properties aren't chains.
+fn as_chain(expr: &ast::Expr) -> Option<ChainKey> {
+  let mut props = vec![];
+  let mut expr = expr;
+  loop {
+    let root = match expr {
+      ast::Expr::Member(ast::MemberExpr {
+        obj,
+        prop: ast::MemberProp::Ident(prop),
+        ..
+      }) => {
+        props.push(prop.sym.clone());
+        expr = obj;
+        continue;
+      }
+      ast::Expr::This(_) => None,
+      ast::Expr::Ident(ident) => Some(ident.to_id()),
+      _ => return None,
+    };
+    if props.len() < 2 {
+      return None;
+    }
+    props.reverse();
+    return Some(ChainKey { root, props });
+  }
+}
+
+/// Whether anything in a function body could change the value of a chain between two reads of it.
+/// Nested functions don't run unless they are called, which is an effect already.
+#[derive(Default)]
+struct EffectScanner {
+  has_effects: bool,
+  /// Bindings assigned or declared in the body. A chain on one of them could read another value
+  /// where it's hoisted to.
+  written: FxHashSet<Id>,
+}
+
+impl EffectScanner {
+  fn write(&mut self, target: &ast::Expr) {
+    match target {
+      ast::Expr::Ident(ident) => {
+        self.written.insert(ident.to_id());
+      }
+      _ => self.has_effects = true,
+    }
+  }
+}
+
+impl Visit for EffectScanner {
+  noop_visit_type!();
+
+  fn visit_function(&mut self, _: &ast::Function) {}
+
+  fn visit_arrow_expr(&mut self, _: &ast::ArrowExpr) {}
+
+  /// Computed keys and static blocks run when the class is evaluated.
+  fn visit_class(&mut self, _: &ast::Class) {
+    self.has_effects = true;
+  }
+
+  fn visit_call_expr(&mut self, _: &ast::CallExpr) {
+    self.has_effects = true;
+  }
+
+  fn visit_opt_call(&mut self, _: &ast::OptCall) {
+    self.has_effects = true;
+  }
+
+  fn visit_new_expr(&mut self, _: &ast::NewExpr) {
+    self.has_effects = true;
+  }
+
+  fn visit_tagged_tpl(&mut self, _: &ast::TaggedTpl) {
+    self.has_effects = true;
+  }
+
+  fn visit_await_expr(&mut self, _: &ast::AwaitExpr) {
+    self.has_effects = true;
+  }
+
+  fn visit_yield_expr(&mut self, _: &ast::YieldExpr) {
+    self.has_effects = true;
+  }
+
+  /// Iterators are called by `for...of` and spreads.
+  fn visit_for_of_stmt(&mut self, _: &ast::ForOfStmt) {
+    self.has_effects = true;
+  }
+
+  fn visit_spread_element(&mut self, _: &ast::SpreadElement) {
+    self.has_effects = true;
+  }
+
+  fn visit_expr_or_spread(&mut self, expr: &ast::ExprOrSpread) {
+    if expr.spread.is_some() {
+      self.has_effects = true;
+    }
+    expr.visit_children_with(self);
+  }
+
+  fn visit_with_stmt(&mut self, _: &ast::WithStmt) {
+    self.has_effects = true;
+  }
+
+  fn visit_unary_expr(&mut self, unary: &ast::UnaryExpr) {
+    if unary.op == ast::UnaryOp::Delete {
+      self.has_effects = true;
+    }
+    unary.visit_children_with(self);
+  }
+
+  fn visit_update_expr(&mut self, update: &ast::UpdateExpr) {
+    self.write(&update.arg);
+  }
+
+  fn visit_assign_expr(&mut self, assign: &ast::AssignExpr) {
+    match &assign.left {
+      ast::PatOrExpr::Expr(target) => self.write(target),
+      ast::PatOrExpr::Pat(pat) => match &**pat {
+        ast::Pat::Ident(ident) => {
+          self.written.insert(ident.to_id());
+        }
+        ast::Pat::Expr(target) => self.write(target),
+        // Destructuring could assign to properties.
+        _ => self.has_effects = true,
+      },
+    }
+    assign.right.visit_with(self);
+  }
+
+  fn visit_binding_ident(&mut self, ident: &ast::BindingIdent) {
+    self.written.insert(ident.to_id());
+  }
+
+  fn visit_fn_decl(&mut self, decl: &ast::FnDecl) {
+    self.written.insert(decl.ident.to_id());
+  }
+}
+
+/// The outermost chains of a statement, and whether each of them might not be evaluated when the
+/// statement runs.
+#[derive(Default)]
+struct ChainCollector {
+  is_conditional: bool,
+  uses: Vec<(ChainKey, ast::Expr, bool)>,
+}
+
+impl ChainCollector {
+  fn conditionally(&mut self, node: &impl VisitWith<Self>) {
+    let is_conditional = std::mem::replace(&mut self.is_conditional, true);
+    node.visit_with(self);
+    self.is_conditional = is_conditional;
+  }
+}
+
+impl Visit for ChainCollector {
+  noop_visit_type!();
+
+  // Nested functions hoist their own chains.
+  fn visit_function(&mut self, _: &ast::Function) {}
+
+  fn visit_arrow_expr(&mut self, _: &ast::ArrowExpr) {}
+
+  fn visit_class(&mut self, _: &ast::Class) {}
+
+  fn visit_opt_chain_expr(&mut self, _: &ast::OptChainExpr) {}
+
+  fn visit_expr(&mut self, expr: &ast::Expr) {
+    match as_chain(expr) {
+      Some(key) => self.uses.push((key, expr.clone(), self.is_conditional)),
+      None => expr.visit_children_with(self),
+    }
+  }
+
+  fn visit_cond_expr(&mut self, cond: &ast::CondExpr) {
+    cond.test.visit_with(self);
+    self.conditionally(&cond.cons);
+    self.conditionally(&cond.alt);
+  }
+
+  fn visit_bin_expr(&mut self, bin: &ast::BinExpr) {
+    bin.left.visit_with(self);
+    match bin.op {
+      ast::BinaryOp::LogicalAnd | ast::BinaryOp::LogicalOr | ast::BinaryOp::NullishCoalescing => {
+        self.conditionally(&bin.right)
+      }
+      _ => bin.right.visit_with(self),
+    }
+  }
+
+  fn visit_assign_expr(&mut self, assign: &ast::AssignExpr) {
+    assign.left.visit_with(self);
+    match assign.op {
+      ast::AssignOp::AndAssign | ast::AssignOp::OrAssign | ast::AssignOp::NullishAssign => {
+        self.conditionally(&assign.right)
+      }
+      _ => assign.right.visit_with(self),
+    }
+  }
+
+  fn visit_if_stmt(&mut self, stmt: &ast::IfStmt) {
+    stmt.test.visit_with(self);
+    self.conditionally(&stmt.cons);
+    self.conditionally(&stmt.alt);
+  }
+
+  fn visit_switch_stmt(&mut self, stmt: &ast::SwitchStmt) {
+    stmt.discriminant.visit_with(self);
+    self.conditionally(&stmt.cases);
+  }
+
+  /// The test is evaluated at least once.
+  fn visit_for_stmt(&mut self, stmt: &ast::ForStmt) {
+    stmt.init.visit_with(self);
+    stmt.test.visit_with(self);
+    self.conditionally(&stmt.update);
+    self.conditionally(&stmt.body);
+  }
+
+  fn visit_while_stmt(&mut self, stmt: &ast::WhileStmt) {
+    stmt.test.visit_with(self);
+    self.conditionally(&stmt.body);
+  }
+
+  fn visit_do_while_stmt(&mut self, stmt: &ast::DoWhileStmt) {
+    self.conditionally(&stmt.body);
+    self.conditionally(&stmt.test);
+  }
+
+  fn visit_for_in_stmt(&mut self, stmt: &ast::ForInStmt) {
+    self.conditionally(&stmt.left);
+    stmt.right.visit_with(self);
+    self.conditionally(&stmt.body);
+  }
+
+  fn visit_try_stmt(&mut self, stmt: &ast::TryStmt) {
+    self.conditionally(&stmt.block);
+    self.conditionally(&stmt.handler);
+    self.conditionally(&stmt.finalizer);
+  }
+}
+
+struct ChainReplacer<'a> {
+  names: &'a FxHashMap<ChainKey, JsWord>,
+}
+
+impl VisitMut for ChainReplacer<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_function(&mut self, _: &mut ast::Function) {}
+
+  fn visit_mut_arrow_expr(&mut self, _: &mut ast::ArrowExpr) {}
+
+  fn visit_mut_class(&mut self, _: &mut ast::Class) {}
+
+  fn visit_mut_opt_chain_expr(&mut self, _: &mut ast::OptChainExpr) {}
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    match as_chain(expr) {
+      Some(key) => {
+        if let Some(name) = self.names.get(&key) {
+          *expr = ast::Expr::Ident(ast::Ident::new(name.clone(), DUMMY_SP));
+        }
+      }
+      None => expr.visit_mut_children_with(self),
+    }
+  }
+}
+
+#[derive(Default)]
+struct NameCollector {
+  names: FxHashSet<JsWord>,
+}
+
+impl Visit for NameCollector {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    self.names.insert(ident.sym.clone());
+  }
+}
+
+struct Candidate {
+  /// The first use, copied into the declaration.
+  expr: ast::Expr,
+  count: usize,
+  /// The declaration is inserted before the first statement using the chain.
+  first_stmt: usize,
+  /// The chain is only hoisted if the first statement surely evaluates it, so hoisting it can't
+  /// throw where the original code wouldn't, like in `if (a.b) return a.b.c`.
+  is_evaluated_by_first_stmt: bool,
+}
+
+/// `used_names` are all names in the function, so the new bindings can't shadow any reference.
+fn hoist_in_body(stmts: &mut Vec<ast::Stmt>, used_names: &FxHashSet<JsWord>) {
+  let mut scanner = EffectScanner::default();
+  stmts.visit_with(&mut scanner);
+  if scanner.has_effects {
+    return;
+  }
+
+  let mut candidates: Vec<(ChainKey, Candidate)> = vec![];
+  let mut idx_of_key: FxHashMap<ChainKey, usize> = FxHashMap::default();
+  for (stmt_idx, stmt) in stmts.iter().enumerate() {
+    let mut collector = ChainCollector::default();
+    stmt.visit_with(&mut collector);
+    for (key, expr, is_conditional) in collector.uses {
+      let idx = *idx_of_key.entry(key.clone()).or_insert_with(|| {
+        candidates.push((
+          key,
+          Candidate {
+            expr,
+            count: 0,
+            first_stmt: stmt_idx,
+            is_evaluated_by_first_stmt: false,
+          },
+        ));
+        candidates.len() - 1
+      });
+      let candidate = &mut candidates[idx].1;
+      candidate.count += 1;
+      if candidate.first_stmt == stmt_idx && !is_conditional {
+        candidate.is_evaluated_by_first_stmt = true;
+      }
+    }
+  }
+
+  let mut names = mangled_names()
+    .filter(|name| !used_names.contains(name))
+    .peekable();
+  let mut name_by_key = FxHashMap::default();
+  let mut decls = vec![];
+  for (key, candidate) in candidates {
+    let is_root_written = key
+      .root
+      .as_ref()
+      .map_or(false, |root| scanner.written.contains(root));
+    if !candidate.is_evaluated_by_first_stmt || is_root_written {
+      continue;
+    }
+    // `const ` and `;` of the declaration
+    if saved_bytes(candidate.count, key.len(), names.peek().unwrap().len(), 7) <= 0 {
+      continue;
+    }
+    let name = names.next().unwrap();
+    decls.push((
+      candidate.first_stmt,
+      ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+        span: DUMMY_SP,
+        kind: ast::VarDeclKind::Const,
+        declare: false,
+        decls: vec![ast::VarDeclarator {
+          span: DUMMY_SP,
+          name: ast::Pat::Ident(ast::Ident::new(name.clone(), DUMMY_SP).into()),
+          init: Some(Box::new(candidate.expr)),
+          definite: false,
+        }],
+      }))),
+    ));
+    name_by_key.insert(key, name);
+  }
+  if name_by_key.is_empty() {
+    return;
+  }
+
+  stmts.visit_mut_with(&mut ChainReplacer {
+    names: &name_by_key,
+  });
+  // From the back, so the indices of the statements before stay the same.
+  decls.into_iter().rev().for_each(|(idx, decl)| {
+    stmts.insert(idx, decl);
+  });
+}
+
+/// Nested functions are handled before the functions containing them.
+struct Hoister;
+
+impl VisitMut for Hoister {
+  noop_visit_mut_type!();
+
+  fn visit_mut_function(&mut self, function: &mut ast::Function) {
+    function.visit_mut_children_with(self);
+    let mut collector = NameCollector::default();
+    function.visit_with(&mut collector);
+    if let Some(body) = &mut function.body {
+      hoist_in_body(&mut body.stmts, &collector.names);
+    }
+  }
+
+  fn visit_mut_arrow_expr(&mut self, arrow: &mut ast::ArrowExpr) {
+    arrow.visit_mut_children_with(self);
+    let mut collector = NameCollector::default();
+    arrow.visit_with(&mut collector);
+    if let ast::BlockStmtOrExpr::BlockStmt(body) = &mut arrow.body {
+      hoist_in_body(&mut body.stmts, &collector.names);
+    }
+  }
+}
+
+impl Chunk {
+  /// A chain like `this.state.items.length` used several times in a function is read once into a
+  /// local, like `const a = this.state.items.length;`, if it makes the chunk smaller.
+  ///
+  /// Getters could return another value on every read, which we can't know, so it's opt-in. It's
+  /// skipped for functions that could change the value between reads otherwise, such as by calls
+  /// or assignments to properties.
+  pub(crate) fn hoist_common_subexpressions(&self, modules: &mut [&mut NormalModule]) {
+    modules
+      .iter_mut()
+      .for_each(|module| module.ast.visit_mut_with(&mut Hoister));
+  }
+}
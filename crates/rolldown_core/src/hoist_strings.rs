@@ -0,0 +1,203 @@
+use itertools::Itertools;
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::{
+  common::DUMMY_SP,
+  ecma::{
+    ast,
+    atoms::JsWord,
+    visit::{noop_visit_mut_type, noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+use crate::{mangled_names, saved_bytes, Chunk, NormalModule};
+
+/// `"use strict"` and other directives must stay literals.
+fn is_directive_like(stmt: &ast::ExprStmt) -> bool {
+  matches!(&*stmt.expr, ast::Expr::Lit(ast::Lit::Str(_)))
+}
+
+/// Specifiers of `require("x")` and `import("x")` are kept, so they could still be analyzed
+/// statically after bundling.
+fn is_static_load(call: &ast::CallExpr) -> bool {
+  match &call.callee {
+    ast::Callee::Import(_) => true,
+    ast::Callee::Expr(callee) => {
+      matches!(&**callee, ast::Expr::Ident(ident) if &*ident.sym == "require")
+    }
+    ast::Callee::Super(_) => false,
+  }
+}
+
+fn raw_len(str: &ast::Str) -> usize {
+  str
+    .raw
+    .as_ref()
+    .map_or(str.value.len() + 2, |raw| raw.len())
+}
+
+#[derive(Default)]
+struct StringCounter {
+  /// The first occurrence is kept to reuse its quotes.
+  counts: FxHashMap<JsWord, (usize, ast::Str)>,
+  used_names: FxHashSet<JsWord>,
+}
+
+impl Visit for StringCounter {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    self.used_names.insert(ident.sym.clone());
+  }
+
+  fn visit_expr(&mut self, expr: &ast::Expr) {
+    if let ast::Expr::Lit(ast::Lit::Str(str)) = expr {
+      self
+        .counts
+        .entry(str.value.clone())
+        .or_insert_with(|| (0, str.clone()))
+        .0 += 1;
+    }
+    expr.visit_children_with(self);
+  }
+
+  fn visit_expr_stmt(&mut self, stmt: &ast::ExprStmt) {
+    if !is_directive_like(stmt) {
+      stmt.visit_children_with(self);
+    }
+  }
+
+  fn visit_call_expr(&mut self, call: &ast::CallExpr) {
+    if is_static_load(call) {
+      call.callee.visit_with(self);
+    } else {
+      call.visit_children_with(self);
+    }
+  }
+}
+
+struct StringReplacer<'a> {
+  names: &'a FxHashMap<JsWord, JsWord>,
+}
+
+impl VisitMut for StringReplacer<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    if let ast::Expr::Lit(ast::Lit::Str(str)) = expr {
+      if let Some(name) = self.names.get(&str.value) {
+        *expr = ast::Expr::Ident(ast::Ident::new(name.clone(), DUMMY_SP));
+        return;
+      }
+    }
+    expr.visit_mut_children_with(self);
+  }
+
+  fn visit_mut_expr_stmt(&mut self, stmt: &mut ast::ExprStmt) {
+    if !is_directive_like(stmt) {
+      stmt.visit_mut_children_with(self);
+    }
+  }
+
+  fn visit_mut_call_expr(&mut self, call: &mut ast::CallExpr) {
+    if is_static_load(call) {
+      call.callee.visit_mut_with(self);
+    } else {
+      call.visit_mut_children_with(self);
+    }
+  }
+}
+
+impl Chunk {
+  /// Repeated strings are declared once before the modules, like `const a = "message";`, and uses
+  /// of them are replaced with the constant. A string is only hoisted if it makes the chunk
+  /// smaller.
+  ///
+  /// The constants are named after identifiers that don't appear anywhere in the chunk, so no
+  /// binding in a nested scope could shadow them. Chunks in an import cycle are skipped by the
+  /// caller, since the other chunks of the cycle could call into the chunk before the constants are
+  /// initialized.
+  pub(crate) fn hoist_strings(&mut self, modules: &mut [&mut NormalModule]) {
+    let mut counter = StringCounter::default();
+    modules
+      .iter()
+      .for_each(|module| module.ast.visit_with(&mut counter));
+    self
+      .before_module_items
+      .iter()
+      .chain(self.after_module_items.iter())
+      .for_each(|item| item.visit_with(&mut counter));
+
+    let candidates = counter
+      .counts
+      .into_iter()
+      .filter(|(_, (count, _))| *count > 1)
+      .sorted_by(|(a_value, (a_count, a_str)), (b_value, (b_count, b_str))| {
+        saved_bytes(*b_count, raw_len(b_str), 1, 1)
+          .cmp(&saved_bytes(*a_count, raw_len(a_str), 1, 1))
+          .then_with(|| a_value.cmp(b_value))
+      });
+
+    let used_names = counter.used_names;
+    let mut names = mangled_names()
+      .filter(|name| !used_names.contains(name))
+      .peekable();
+
+    let mut total_saved = 0;
+    let mut decls = vec![];
+    let mut value_to_name = FxHashMap::default();
+    for (value, (count, str)) in candidates {
+      let name = names.peek().unwrap();
+      // The `,` after `name=raw` in the declaration
+      let saved = saved_bytes(count, raw_len(&str), name.len(), 1);
+      if saved <= 0 {
+        continue;
+      }
+      total_saved += saved;
+      let name = names.next().unwrap();
+      decls.push(ast::VarDeclarator {
+        span: DUMMY_SP,
+        name: ast::Pat::Ident(ast::Ident::new(name.clone(), DUMMY_SP).into()),
+        init: Some(Box::new(ast::Expr::Lit(ast::Lit::Str(str)))),
+        definite: false,
+      });
+      value_to_name.insert(value, name);
+    }
+
+    // `const ;` of the declaration
+    if total_saved <= 7 {
+      return;
+    }
+
+    let mut replacer = StringReplacer {
+      names: &value_to_name,
+    };
+    modules
+      .iter_mut()
+      .for_each(|module| module.ast.visit_mut_with(&mut replacer));
+    self
+      .before_module_items
+      .iter_mut()
+      .chain(self.after_module_items.iter_mut())
+      .for_each(|item| item.visit_mut_with(&mut replacer));
+    // After the imports, but before anything else using the constants.
+    let position = self
+      .before_module_items
+      .iter()
+      .position(|item| {
+        !matches!(
+          item,
+          ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(_))
+        )
+      })
+      .unwrap_or(self.before_module_items.len());
+    self.before_module_items.insert(
+      position,
+      ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+        span: DUMMY_SP,
+        kind: ast::VarDeclKind::Const,
+        declare: false,
+        decls,
+      })))),
+    );
+  }
+}
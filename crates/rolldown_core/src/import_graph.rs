@@ -0,0 +1,53 @@
+use itertools::Itertools;
+use rolldown_common::ModuleId;
+use serde_json::{json, Map, Value};
+use sugar_path::{AsPath, SugarPath};
+
+use crate::Graph;
+
+impl Graph {
+  fn display_id(&self, id: &ModuleId) -> String {
+    if id.is_external() {
+      id.id().to_string()
+    } else {
+      id.as_path()
+        .relative(&self.input_options.cwd)
+        .to_string_lossy()
+        .replace('\\', "/")
+    }
+  }
+
+  /// Every normal module and what it imports, keyed by paths relative to `cwd`. Modules removed by
+  /// tree shaking are included, since this describes the sources rather than the output. `require`
+  /// calls aren't listed, since only ES module syntax is scanned.
+  ///
+  /// ```json
+  /// { "main.js": [{ "id": "lib.js", "kind": "static" }, { "id": "lazy.js", "kind": "dynamic" }] }
+  /// ```
+  pub(crate) fn import_graph_json(&self) -> String {
+    let graph = self
+      .module_by_id
+      .values()
+      .filter_map(|module| module.as_norm())
+      .map(|module| {
+        let imports = module
+          .dependencies
+          .iter()
+          .map(|id| (id, "static"))
+          .chain(module.dyn_dependencies.iter().map(|id| (id, "dynamic")))
+          .map(|(id, kind)| {
+            let mut import = json!({ "id": self.display_id(id), "kind": kind });
+            if id.is_external() {
+              import["external"] = Value::Bool(true);
+            }
+            import
+          })
+          .collect::<Vec<_>>();
+        (self.display_id(&module.id), Value::Array(imports))
+      })
+      // Keys are sorted for deterministic output.
+      .sorted_by(|(a, _), (b, _)| a.cmp(b))
+      .collect::<Map<_, _>>();
+    serde_json::to_string_pretty(&graph).unwrap()
+  }
+}
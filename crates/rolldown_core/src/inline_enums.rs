@@ -0,0 +1,332 @@
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::ecma::{
+  ast,
+  atoms::JsWord,
+  visit::{noop_visit_mut_type, noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+};
+
+use crate::{Chunk, NormalModule};
+
+fn unparen(mut expr: &ast::Expr) -> &ast::Expr {
+  while let ast::Expr::Paren(paren) = expr {
+    expr = &paren.expr;
+  }
+  expr
+}
+
+fn as_member_target(left: &ast::PatOrExpr) -> Option<&ast::MemberExpr> {
+  let target = match left {
+    ast::PatOrExpr::Expr(target) => target,
+    ast::PatOrExpr::Pat(pat) => match &**pat {
+      ast::Pat::Expr(target) => target,
+      _ => return None,
+    },
+  };
+  target.as_member()
+}
+
+fn is_ident_of(expr: &ast::Expr, name: &JsWord) -> bool {
+  matches!(expr, ast::Expr::Ident(ident) if ident.sym == *name)
+}
+
+/// `E.A` and `E["A"]` => `("E", "A")`
+fn as_member_access(member: &ast::MemberExpr) -> Option<(&JsWord, &JsWord)> {
+  let ast::Expr::Ident(obj) = &*member.obj else {
+    return None;
+  };
+  let prop = match &member.prop {
+    ast::MemberProp::Ident(prop) => &prop.sym,
+    ast::MemberProp::Computed(computed) => match &*computed.expr {
+      ast::Expr::Lit(ast::Lit::Str(str)) => &str.value,
+      _ => return None,
+    },
+    ast::MemberProp::PrivateName(_) => return None,
+  };
+  Some((&obj.sym, prop))
+}
+
+/// `1` and `-1`
+fn is_numeric(expr: &ast::Expr) -> bool {
+  match expr {
+    ast::Expr::Lit(ast::Lit::Num(_)) => true,
+    ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::Minus,
+      arg,
+      ..
+    }) => matches!(&**arg, ast::Expr::Lit(ast::Lit::Num(_))),
+    _ => false,
+  }
+}
+
+/// `E[E["A"] = 0] = "A"` => `("A", 0)`, where `E` is the parameter of the IIFE.
+fn as_member_init<'a>(stmt: &'a ast::Stmt, param: &JsWord) -> Option<(JsWord, &'a ast::Expr)> {
+  let ast::Expr::Assign(reverse) = &*stmt.as_expr()?.expr else {
+    return None;
+  };
+  let reverse_target = as_member_target(&reverse.left)?;
+  if reverse.op != ast::AssignOp::Assign || !is_ident_of(&reverse_target.obj, param) {
+    return None;
+  }
+  let ast::MemberProp::Computed(computed) = &reverse_target.prop else {
+    return None;
+  };
+  let ast::Expr::Assign(forward) = unparen(&computed.expr) else {
+    return None;
+  };
+  let (obj, name) = as_member_access(as_member_target(&forward.left)?)?;
+  let is_reverse_of_name = matches!(
+    &*reverse.right,
+    ast::Expr::Lit(ast::Lit::Str(str)) if str.value == *name
+  );
+  if forward.op != ast::AssignOp::Assign
+    || obj != param
+    || !is_reverse_of_name
+    || !is_numeric(&forward.right)
+  {
+    return None;
+  }
+  Some((name.clone(), &forward.right))
+}
+
+/// `(function (E) { ... })(E || (E = {}))` => the members of `E`
+fn as_enum_iife(stmt: &ast::Stmt, name: &JsWord) -> Option<FxHashMap<JsWord, ast::Expr>> {
+  let ast::Expr::Call(call) = unparen(&stmt.as_expr()?.expr) else {
+    return None;
+  };
+  let ast::Expr::Fn(ast::FnExpr { function, .. }) = unparen(call.callee.as_expr()?) else {
+    return None;
+  };
+  let [param] = function.params.as_slice() else {
+    return None;
+  };
+  let param = &param.pat.as_ident()?.id.sym;
+
+  let [arg] = call.args.as_slice() else {
+    return None;
+  };
+  let ast::Expr::Bin(ast::BinExpr {
+    op: ast::BinaryOp::LogicalOr,
+    left,
+    right,
+    ..
+  }) = &*arg.expr
+  else {
+    return None;
+  };
+  let ast::Expr::Assign(init) = unparen(right) else {
+    return None;
+  };
+  let is_empty_object = matches!(&*init.right, ast::Expr::Object(obj) if obj.props.is_empty());
+  let is_init_of_name = match &init.left {
+    ast::PatOrExpr::Pat(pat) => matches!(&**pat, ast::Pat::Ident(ident) if ident.id.sym == *name),
+    ast::PatOrExpr::Expr(target) => is_ident_of(target, name),
+  };
+  if !is_ident_of(left, name) || !is_init_of_name || !is_empty_object {
+    return None;
+  }
+
+  function
+    .body
+    .as_ref()?
+    .stmts
+    .iter()
+    .map(|stmt| as_member_init(stmt, param).map(|(member, value)| (member, value.clone())))
+    .collect()
+}
+
+/// `var E;` => `E`
+fn as_enum_var(stmt: &ast::Stmt) -> Option<&JsWord> {
+  let var = stmt.as_decl()?.as_var()?;
+  let [decl] = var.decls.as_slice() else {
+    return None;
+  };
+  if var.kind != ast::VarDeclKind::Var || decl.init.is_some() {
+    return None;
+  }
+  Some(&decl.name.as_ident()?.id.sym)
+}
+
+/// Tells which enums are only read by their members, like `E.A`.
+struct UsageChecker<'a> {
+  enums: &'a FxHashMap<JsWord, FxHashMap<JsWord, ast::Expr>>,
+  /// Referenced in a way other than reading a member, like `E[x]`, `E.A = 1` or passing `E`.
+  escaped: FxHashSet<JsWord>,
+}
+
+impl UsageChecker<'_> {
+  fn is_member_read(&self, member: &ast::MemberExpr) -> bool {
+    as_member_access(member).map_or(false, |(name, member)| {
+      self
+        .enums
+        .get(name)
+        .map_or(false, |members| members.contains_key(member))
+    })
+  }
+
+  fn write(&mut self, target: &ast::Expr) {
+    if let ast::Expr::Member(member) = target {
+      self.write_member(member);
+    }
+  }
+
+  fn write_member(&mut self, member: &ast::MemberExpr) {
+    if let ast::Expr::Ident(obj) = &*member.obj {
+      self.escaped.insert(obj.sym.clone());
+    }
+  }
+}
+
+impl Visit for UsageChecker<'_> {
+  noop_visit_type!();
+
+  fn visit_expr(&mut self, expr: &ast::Expr) {
+    match expr {
+      ast::Expr::Member(member) if self.is_member_read(member) => {}
+      _ => expr.visit_children_with(self),
+    }
+  }
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    // Any other use, including a binding shadowing the enum
+    if self.enums.contains_key(&ident.sym) {
+      self.escaped.insert(ident.sym.clone());
+    }
+  }
+
+  fn visit_member_prop(&mut self, prop: &ast::MemberProp) {
+    if let ast::MemberProp::Computed(computed) = prop {
+      computed.visit_with(self);
+    }
+  }
+
+  fn visit_prop_name(&mut self, name: &ast::PropName) {
+    if let ast::PropName::Computed(computed) = name {
+      computed.visit_with(self);
+    }
+  }
+
+  fn visit_assign_expr(&mut self, assign: &ast::AssignExpr) {
+    if let Some(target) = as_member_target(&assign.left) {
+      self.write_member(target);
+    }
+    assign.visit_children_with(self);
+  }
+
+  fn visit_update_expr(&mut self, update: &ast::UpdateExpr) {
+    self.write(&update.arg);
+    update.visit_children_with(self);
+  }
+
+  fn visit_unary_expr(&mut self, unary: &ast::UnaryExpr) {
+    if unary.op == ast::UnaryOp::Delete {
+      self.write(&unary.arg);
+    }
+    unary.visit_children_with(self);
+  }
+}
+
+struct MemberInliner<'a> {
+  enums: &'a FxHashMap<JsWord, FxHashMap<JsWord, ast::Expr>>,
+}
+
+impl VisitMut for MemberInliner<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    if let ast::Expr::Member(member) = expr {
+      let value = as_member_access(member)
+        .and_then(|(name, member)| self.enums.get(name)?.get(member))
+        .cloned();
+      if let Some(value) = value {
+        *expr = value;
+        return;
+      }
+    }
+    expr.visit_mut_children_with(self);
+  }
+}
+
+impl Chunk {
+  /// Members of numeric enums only read as `E.A` are inlined, like `E.A` => `0`, and the enums
+  /// are removed.
+  ///
+  /// Enums are compiled to `var E; (function (E) { E[E["A"] = 0] = "A"; })(E || (E = {}))`. Any
+  /// other use of `E`, like exporting it, passing it around or reading `E[x]` to get the name of a
+  /// member, keeps the enum as is.
+  pub(crate) fn inline_enums(&mut self, modules: &mut [&mut NormalModule]) {
+    // The indexes of the `var` statements
+    let mut locations = vec![];
+    let mut enums = FxHashMap::default();
+    let mut declared_twice = FxHashSet::default();
+    modules.iter().enumerate().for_each(|(module_idx, module)| {
+      let body = &module.ast.body;
+      for (idx, pair) in body.windows(2).enumerate() {
+        let (ast::ModuleItem::Stmt(var), ast::ModuleItem::Stmt(iife)) = (&pair[0], &pair[1]) else {
+          continue;
+        };
+        let Some(name) = as_enum_var(var) else {
+          continue;
+        };
+        let Some(members) = as_enum_iife(iife, name) else {
+          continue;
+        };
+        // Like merged declarations of an enum
+        if enums.insert(name.clone(), members).is_some() {
+          declared_twice.insert(name.clone());
+        }
+        locations.push((module_idx, idx, name.clone()));
+      }
+    });
+    if enums.is_empty() {
+      return;
+    }
+
+    let mut checker = UsageChecker {
+      enums: &enums,
+      escaped: declared_twice,
+    };
+    modules.iter().enumerate().for_each(|(module_idx, module)| {
+      let declarations = locations
+        .iter()
+        .filter(|(idx, ..)| *idx == module_idx)
+        .flat_map(|(_, idx, _)| [*idx, *idx + 1])
+        .collect::<FxHashSet<_>>();
+      module
+        .ast
+        .body
+        .iter()
+        .enumerate()
+        .filter(|(idx, _)| !declarations.contains(idx))
+        .for_each(|(_, item)| item.visit_with(&mut checker));
+    });
+    self
+      .before_module_items
+      .iter()
+      .chain(self.after_module_items.iter())
+      .for_each(|item| item.visit_with(&mut checker));
+    let escaped = checker.escaped;
+    enums.retain(|name, _| !escaped.contains(name));
+    if enums.is_empty() {
+      return;
+    }
+
+    modules
+      .iter_mut()
+      .enumerate()
+      .for_each(|(module_idx, module)| {
+        let removed = locations
+          .iter()
+          .filter(|(idx, _, name)| *idx == module_idx && enums.contains_key(name))
+          .flat_map(|(_, idx, _)| [*idx, *idx + 1])
+          .collect::<FxHashSet<_>>();
+        let mut idx = 0;
+        module.ast.body.retain(|_| {
+          idx += 1;
+          !removed.contains(&(idx - 1))
+        });
+        module
+          .ast
+          .visit_mut_with(&mut MemberInliner { enums: &enums });
+      });
+  }
+}
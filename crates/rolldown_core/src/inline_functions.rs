@@ -0,0 +1,443 @@
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::{
+    ast,
+    atoms::JsWord,
+    visit::{noop_visit_mut_type, noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+use crate::{mangled_names, Chunk, NormalModule};
+
+/// A top-level `function f(a, b) { return a + b }` that's only referenced by one call.
+struct Candidate {
+  params: Vec<JsWord>,
+  /// `None` for `function f() {}`, which returns `undefined`.
+  body: Option<Box<ast::Expr>>,
+  /// The body can't observe the order in which its parameters are read, so identifiers could be
+  /// substituted for them. Calls, for example, could reassign them.
+  is_plain: bool,
+}
+
+fn void_0() -> ast::Expr {
+  ast::Expr::Unary(ast::UnaryExpr {
+    span: DUMMY_SP,
+    op: ast::UnaryOp::Void,
+    arg: Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+      span: DUMMY_SP,
+      value: 0.0,
+      raw: None,
+    }))),
+  })
+}
+
+/// Copies of it can't be told apart. Each evaluation of a regular expression creates a new object.
+fn is_constant(expr: &ast::Expr) -> bool {
+  matches!(expr, ast::Expr::Lit(lit) if !matches!(lit, ast::Lit::Regex(_)))
+}
+
+/// How often each name appears in the chunk, and which names are declared in a nested scope.
+#[derive(Default)]
+struct ChunkScanner {
+  ident_counts: FxHashMap<JsWord, usize>,
+  nested_bindings: FxHashSet<JsWord>,
+  depth: usize,
+}
+
+impl ChunkScanner {
+  fn nested(&mut self, node: &impl VisitWith<Self>) {
+    self.depth += 1;
+    node.visit_children_with(self);
+    self.depth -= 1;
+  }
+
+  fn declare(&mut self, ident: &ast::Ident) {
+    if self.depth > 0 {
+      self.nested_bindings.insert(ident.sym.clone());
+    }
+  }
+}
+
+impl Visit for ChunkScanner {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    *self.ident_counts.entry(ident.sym.clone()).or_default() += 1;
+  }
+
+  fn visit_binding_ident(&mut self, ident: &ast::BindingIdent) {
+    self.declare(&ident.id);
+    ident.visit_children_with(self);
+  }
+
+  fn visit_fn_decl(&mut self, decl: &ast::FnDecl) {
+    self.declare(&decl.ident);
+    decl.visit_children_with(self);
+  }
+
+  fn visit_class_decl(&mut self, decl: &ast::ClassDecl) {
+    self.declare(&decl.ident);
+    decl.visit_children_with(self);
+  }
+
+  fn visit_fn_expr(&mut self, expr: &ast::FnExpr) {
+    if let Some(ident) = &expr.ident {
+      self.nested_bindings.insert(ident.sym.clone());
+    }
+    expr.visit_children_with(self);
+  }
+
+  fn visit_class_expr(&mut self, expr: &ast::ClassExpr) {
+    if let Some(ident) = &expr.ident {
+      self.nested_bindings.insert(ident.sym.clone());
+    }
+    expr.visit_children_with(self);
+  }
+
+  fn visit_function(&mut self, function: &ast::Function) {
+    self.nested(function);
+  }
+
+  fn visit_arrow_expr(&mut self, arrow: &ast::ArrowExpr) {
+    self.nested(arrow);
+  }
+
+  fn visit_class(&mut self, class: &ast::Class) {
+    self.nested(class);
+  }
+
+  fn visit_block_stmt(&mut self, block: &ast::BlockStmt) {
+    self.nested(block);
+  }
+
+  fn visit_catch_clause(&mut self, clause: &ast::CatchClause) {
+    self.nested(clause);
+  }
+
+  fn visit_for_stmt(&mut self, stmt: &ast::ForStmt) {
+    self.nested(stmt);
+  }
+
+  fn visit_for_in_stmt(&mut self, stmt: &ast::ForInStmt) {
+    self.nested(stmt);
+  }
+
+  fn visit_for_of_stmt(&mut self, stmt: &ast::ForOfStmt) {
+    self.nested(stmt);
+  }
+}
+
+/// Rejects bodies whose meaning depends on where they are, like ones using `this`, and bodies
+/// with closures, which would capture the arguments instead of copies of them.
+struct BodyChecker<'a> {
+  params: &'a [JsWord],
+  nested_bindings: &'a FxHashSet<JsWord>,
+  is_inlinable: bool,
+  is_plain: bool,
+}
+
+impl Visit for BodyChecker<'_> {
+  noop_visit_type!();
+
+  fn visit_expr(&mut self, expr: &ast::Expr) {
+    match expr {
+      ast::Expr::This(_)
+      | ast::Expr::SuperProp(_)
+      | ast::Expr::MetaProp(_)
+      | ast::Expr::Fn(_)
+      | ast::Expr::Arrow(_)
+      | ast::Expr::Class(_)
+      | ast::Expr::Yield(_)
+      | ast::Expr::Await(_)
+      | ast::Expr::Assign(_)
+      | ast::Expr::Update(_) => self.is_inlinable = false,
+      ast::Expr::Call(_)
+      | ast::Expr::New(_)
+      | ast::Expr::TaggedTpl(_)
+      | ast::Expr::Member(_)
+      | ast::Expr::OptChain(_)
+      | ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Delete,
+        ..
+      }) => self.is_plain = false,
+      _ => {}
+    }
+    expr.visit_children_with(self);
+  }
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    // A binding in a scope around the call site could shadow what the body refers to.
+    if &*ident.sym == "arguments"
+      || (!self.params.contains(&ident.sym) && self.nested_bindings.contains(&ident.sym))
+    {
+      self.is_inlinable = false;
+    }
+  }
+
+  fn visit_prop(&mut self, prop: &ast::Prop) {
+    // `{ a }` would need to be expanded to `{ a: 1 }`.
+    if matches!(prop, ast::Prop::Shorthand(_)) {
+      self.is_inlinable = false;
+    }
+    prop.visit_children_with(self);
+  }
+}
+
+fn as_candidate(
+  decl: &ast::FnDecl,
+  ident_counts: &FxHashMap<JsWord, usize>,
+  nested_bindings: &FxHashSet<JsWord>,
+) -> Option<Candidate> {
+  let function = &decl.function;
+  // The declaration and the call
+  if ident_counts.get(&decl.ident.sym) != Some(&2) || function.is_async || function.is_generator {
+    return None;
+  }
+  let params = function
+    .params
+    .iter()
+    .map(|param| match &param.pat {
+      ast::Pat::Ident(ident) if param.decorators.is_empty() => Some(ident.id.sym.clone()),
+      _ => None,
+    })
+    .collect::<Option<Vec<_>>>()?;
+  if params
+    .iter()
+    .enumerate()
+    .any(|(idx, param)| params[..idx].contains(param))
+  {
+    return None;
+  }
+  let body = match function.body.as_ref()?.stmts.as_slice() {
+    [] | [ast::Stmt::Return(ast::ReturnStmt { arg: None, .. })] => None,
+    [ast::Stmt::Return(ast::ReturnStmt { arg: Some(arg), .. })] => Some(arg.clone()),
+    _ => return None,
+  };
+  let mut checker = BodyChecker {
+    params: &params,
+    nested_bindings,
+    is_inlinable: true,
+    is_plain: true,
+  };
+  body.visit_with(&mut checker);
+  checker.is_inlinable.then_some(Candidate {
+    params,
+    body,
+    is_plain: checker.is_plain,
+  })
+}
+
+struct ParamSubstituter<'a> {
+  values: &'a FxHashMap<JsWord, ast::Expr>,
+}
+
+impl VisitMut for ParamSubstituter<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    if let ast::Expr::Ident(ident) = expr {
+      if let Some(value) = self.values.get(&ident.sym) {
+        *expr = value.clone();
+        return;
+      }
+    }
+    expr.visit_mut_children_with(self);
+  }
+}
+
+struct Inliner<'a> {
+  candidates: &'a FxHashMap<JsWord, Candidate>,
+  inlined: &'a mut FxHashSet<JsWord>,
+  temp_names: &'a mut dyn Iterator<Item = JsWord>,
+  /// Declared as `var` at the top of the module
+  temps: Vec<JsWord>,
+  depth: usize,
+}
+
+impl Inliner<'_> {
+  fn nested(&mut self, node: &mut impl VisitMutWith<Self>) {
+    self.depth += 1;
+    node.visit_mut_children_with(self);
+    self.depth -= 1;
+  }
+
+  /// Arguments are substituted for the parameters if that's safe, and stored in temporary
+  /// variables otherwise, so each one is still evaluated once and in order:
+  /// `add(f(), g())` => `(a = f(), b = g(), a + b)`.
+  fn inline_call(&mut self, call: &mut ast::CallExpr, candidate: &Candidate) -> Option<ast::Expr> {
+    if call.args.len() > candidate.params.len() || call.args.iter().any(|arg| arg.spread.is_some())
+    {
+      return None;
+    }
+    let is_substitutable =
+      |expr: &ast::Expr| is_constant(expr) || (candidate.is_plain && expr.is_ident());
+    let needs_temps = call.args.iter().any(|arg| !is_substitutable(&arg.expr));
+    // The temporary variables are shared by every run of the call site, which may overlap if it's
+    // in a function.
+    if needs_temps && self.depth > 0 {
+      return None;
+    }
+
+    let mut values = FxHashMap::default();
+    let mut exprs = vec![];
+    for (idx, param) in candidate.params.iter().enumerate() {
+      let value = match call.args.get_mut(idx) {
+        Some(arg) if needs_temps && !is_constant(&arg.expr) => {
+          let temp = ast::Ident::new(self.temp_names.next().unwrap(), DUMMY_SP);
+          self.temps.push(temp.sym.clone());
+          exprs.push(Box::new(ast::Expr::Assign(ast::AssignExpr {
+            span: DUMMY_SP,
+            op: ast::AssignOp::Assign,
+            left: ast::PatOrExpr::Pat(Box::new(ast::Pat::Ident(temp.clone().into()))),
+            right: arg.expr.take(),
+          })));
+          ast::Expr::Ident(temp)
+        }
+        Some(arg) => *arg.expr.take(),
+        None => void_0(),
+      };
+      values.insert(param.clone(), value);
+    }
+
+    let mut body = candidate.body.clone().unwrap_or_else(|| Box::new(void_0()));
+    body.visit_mut_with(&mut ParamSubstituter { values: &values });
+    exprs.push(body);
+    let expr = if exprs.len() == 1 {
+      exprs.pop().unwrap()
+    } else {
+      Box::new(ast::Expr::Seq(ast::SeqExpr {
+        span: DUMMY_SP,
+        exprs,
+      }))
+    };
+    // The fixer of `minify.syntax` drops them if they aren't needed.
+    Some(ast::Expr::Paren(ast::ParenExpr {
+      span: call.span,
+      expr,
+    }))
+  }
+}
+
+impl VisitMut for Inliner<'_> {
+  noop_visit_mut_type!();
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    expr.visit_mut_children_with(self);
+    let ast::Expr::Call(call) = expr else {
+      return;
+    };
+    let Some(callee) = call.callee.as_expr().and_then(|callee| callee.as_ident()) else {
+      return;
+    };
+    let name = callee.sym.clone();
+    let candidates = self.candidates;
+    let Some(candidate) = candidates.get(&name) else {
+      return;
+    };
+    if self.inlined.contains(&name) {
+      return;
+    }
+    if let Some(inlined) = self.inline_call(call, candidate) {
+      *expr = inlined;
+      self.inlined.insert(name);
+    }
+  }
+
+  fn visit_mut_fn_decl(&mut self, decl: &mut ast::FnDecl) {
+    // Bodies of candidates are inlined as they were collected.
+    if !self.candidates.contains_key(&decl.ident.sym) {
+      decl.visit_mut_children_with(self);
+    }
+  }
+
+  fn visit_mut_function(&mut self, function: &mut ast::Function) {
+    self.nested(function);
+  }
+
+  fn visit_mut_arrow_expr(&mut self, arrow: &mut ast::ArrowExpr) {
+    self.nested(arrow);
+  }
+
+  fn visit_mut_class(&mut self, class: &mut ast::Class) {
+    self.nested(class);
+  }
+}
+
+impl Chunk {
+  /// Top-level functions with a single `return` that are called once are inlined into the call, like
+  /// `add(1, 2)` => `(1 + 2)`, and their declarations are removed.
+  ///
+  /// Only functions referring to their parameters, top-level bindings and globals are inlined,
+  /// since a binding around the call site could shadow anything else.
+  pub(crate) fn inline_functions(&mut self, modules: &mut [&mut NormalModule]) {
+    let mut scanner = ChunkScanner::default();
+    modules
+      .iter()
+      .for_each(|module| module.ast.visit_with(&mut scanner));
+    self
+      .before_module_items
+      .iter()
+      .chain(self.after_module_items.iter())
+      .for_each(|item| item.visit_with(&mut scanner));
+
+    let candidates = modules
+      .iter()
+      .flat_map(|module| module.ast.body.iter())
+      .filter_map(|item| match item {
+        ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl))) => {
+          let candidate = as_candidate(decl, &scanner.ident_counts, &scanner.nested_bindings)?;
+          Some((decl.ident.sym.clone(), candidate))
+        }
+        _ => None,
+      })
+      .collect::<FxHashMap<_, _>>();
+    if candidates.is_empty() {
+      return;
+    }
+
+    let ident_counts = scanner.ident_counts;
+    let mut temp_names = mangled_names().filter(|name| !ident_counts.contains_key(name));
+    let mut inlined = FxHashSet::default();
+    modules.iter_mut().for_each(|module| {
+      let mut inliner = Inliner {
+        candidates: &candidates,
+        inlined: &mut inlined,
+        temp_names: &mut temp_names,
+        temps: vec![],
+        depth: 0,
+      };
+      module.ast.visit_mut_with(&mut inliner);
+      let temps = inliner.temps;
+      if !temps.is_empty() {
+        module.ast.body.insert(
+          0,
+          ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+            span: DUMMY_SP,
+            kind: ast::VarDeclKind::Var,
+            declare: false,
+            decls: temps
+              .into_iter()
+              .map(|temp| ast::VarDeclarator {
+                span: DUMMY_SP,
+                name: ast::Pat::Ident(ast::Ident::new(temp, DUMMY_SP).into()),
+                init: None,
+                definite: false,
+              })
+              .collect(),
+          })))),
+        );
+      }
+    });
+
+    modules.iter_mut().for_each(|module| {
+      module.ast.body.retain(|item| {
+        !matches!(
+          item,
+          ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl)))
+            if inlined.contains(&decl.ident.sym)
+        )
+      })
+    });
+  }
+}
@@ -8,6 +8,25 @@ mod bundler;
 pub use bundler::*;
 mod chunk;
 pub use chunk::*;
+mod chunk_hash;
+mod chunk_source_map;
+pub(crate) use chunk_source_map::*;
+mod css_import;
+pub(crate) use css_import::*;
+mod css_source_map;
+pub(crate) use css_source_map::*;
+mod css_url;
+pub(crate) use css_url::*;
+mod dedupe_helpers;
+mod file_asset;
+pub(crate) use file_asset::*;
+mod hoist_common_subexpressions;
+mod hoist_strings;
+mod inline_enums;
+mod inline_functions;
+mod manifest;
+mod manual_chunk_exports;
+pub(crate) use manual_chunk_exports::*;
 mod normal_module;
 pub use normal_module::*;
 mod external_module;
@@ -15,6 +34,7 @@ pub use external_module::*;
 mod options;
 pub use options::*;
 mod graph;
+mod import_graph;
 pub use graph::*;
 mod module_loader;
 use rolldown_common::{ChunkId, ExportedSpecifier, ModuleId};
@@ -23,6 +43,7 @@ use rustc_hash::FxHashMap;
 use swc_core::common::{FilePathMapping, Globals, SourceMap};
 mod bundle;
 mod norm_or_ext;
+mod polyfills;
 pub use bundle::*;
 use swc_core::ecma::atoms::JsWord;
 mod code_splitter;
@@ -0,0 +1,75 @@
+use itertools::Itertools;
+use rolldown_common::ChunkId;
+use rustc_hash::FxHashMap as HashMap;
+use serde_json::{json, Map, Value};
+
+use crate::{Bundle, Chunk};
+
+impl Bundle<'_> {
+  /// What a server needs to render the tags of every chunk, keyed by the names of the chunks.
+  /// `imports` are the chunks to preload, which are imported statically, transitively. `css` has
+  /// the CSS of the chunk and of those imports. `dynamicImports` are the names of the chunks it
+  /// imports dynamically, which are keys of the manifest as well.
+  ///
+  /// ```json
+  /// {
+  ///   "main": {
+  ///     "file": "main.js",
+  ///     "isEntry": true,
+  ///     "imports": ["shared-1a2b3c4d.js"],
+  ///     "css": ["main.css"],
+  ///     "dynamicImports": ["lazy"]
+  ///   }
+  /// }
+  /// ```
+  pub(crate) fn manifest_json(
+    &self,
+    chunk_by_id: &HashMap<ChunkId, Chunk>,
+    chunk_filename_by_id: &HashMap<ChunkId, String>,
+  ) -> String {
+    let static_imports_by_chunk_id = self.static_imports_by_chunk_id(chunk_by_id);
+    let manifest = chunk_by_id
+      .values()
+      .map(|chunk| {
+        let static_imports = static_imports_by_chunk_id[&chunk.id]
+          .iter()
+          .sorted_by_key(|id| &chunk_filename_by_id[*id])
+          .collect_vec();
+        let imports = static_imports
+          .iter()
+          .map(|id| chunk_filename_by_id[*id].clone())
+          .collect_vec();
+        // The CSS of the chunk comes last, so its rules win over the ones it depends on.
+        let css = static_imports
+          .iter()
+          .map(|id| &chunk_by_id[*id])
+          .chain([chunk])
+          .filter(|chunk| chunk.has_css(&self.graph.module_by_id))
+          .map(|chunk| chunk.css_filename())
+          .collect_vec();
+        let dynamic_imports = chunk
+          .modules
+          .iter()
+          .filter_map(|id| self.graph.module_by_id.get(id))
+          .flat_map(|module| module.dynamic_dependencies())
+          .filter_map(|dep| self.split_point_id_to_chunk_id.get(dep))
+          .filter(|id| **id != chunk.id)
+          .map(|id| id.value().to_string())
+          .unique()
+          .sorted()
+          .collect_vec();
+        let entry = json!({
+          "file": chunk_filename_by_id[&chunk.id],
+          "isEntry": chunk.is_user_defined_entry,
+          "imports": imports,
+          "css": css,
+          "dynamicImports": dynamic_imports,
+        });
+        (chunk.id.value().to_string(), entry)
+      })
+      // Keys are sorted for deterministic output.
+      .sorted_by(|(a, _), (b, _)| a.cmp(b))
+      .collect::<Map<_, Value>>();
+    serde_json::to_string_pretty(&manifest).unwrap()
+  }
+}
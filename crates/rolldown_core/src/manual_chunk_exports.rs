@@ -0,0 +1,147 @@
+use itertools::Itertools;
+use rolldown_common::{ChunkId, ModuleId, Symbol};
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::ecma::atoms::JsWord;
+
+use crate::{Chunk, Graph, COMMONJS_WRAPPER_NAME};
+
+/// A manual chunk has no entry module whose exports could be reused, so the bindings other chunks
+/// import from its modules are exported by names unique in the chunk. Two modules in it could both
+/// export `default`, for example.
+#[derive(Debug, Default)]
+pub(crate) struct ManualChunkExports {
+  modules: FxHashSet<ModuleId>,
+  /// `(module, name exported by the module)` => name exported by the manual chunk
+  name_by_import: FxHashMap<(ModuleId, JsWord), JsWord>,
+  name_by_symbol: FxHashMap<Symbol, JsWord>,
+  exports_by_chunk: FxHashMap<ChunkId, Vec<(JsWord, Symbol)>>,
+  used_names_by_chunk: FxHashMap<ChunkId, FxHashSet<JsWord>>,
+}
+
+impl ManualChunkExports {
+  pub(crate) fn new(chunks: &[Chunk], graph: &Graph) -> Self {
+    let mut exports = Self::default();
+    let chunk_of_module = chunks
+      .iter()
+      .flat_map(|chunk| chunk.modules.iter().map(move |id| (id, chunk)))
+      .filter(|(id, _)| !id.is_external())
+      .collect::<FxHashMap<_, _>>();
+    exports.modules = chunk_of_module
+      .iter()
+      .filter(|(_, chunk)| chunk.is_manual)
+      .map(|(id, _)| (*id).clone())
+      .collect();
+    if exports.modules.is_empty() {
+      return exports;
+    }
+    // A binding imported from a module in another chunk, which is a manual chunk.
+    let manual_chunk_of = |importer: &ModuleId, owner: &ModuleId| {
+      let owner_chunk = chunk_of_module.get(owner)?;
+      (owner_chunk.is_manual && chunk_of_module.get(importer)?.id != owner_chunk.id)
+        .then_some(owner_chunk.id.clone())
+    };
+
+    graph
+      .module_by_id
+      .values()
+      .filter_map(|m| m.as_norm())
+      .sorted_by_key(|m| m.exec_order)
+      .for_each(|importer| {
+        importer
+          .linked_imports
+          .iter()
+          .sorted_by_key(|(owner, _)| *owner)
+          .for_each(|(owner, specs)| {
+            let Some(chunk_id) = manual_chunk_of(&importer.id, owner) else {
+              return;
+            };
+            let owner_module = graph.module_by_id[owner].expect_norm();
+            specs
+              .iter()
+              .sorted_by_key(|spec| &spec.imported)
+              .for_each(|spec| {
+                if let Some(exported) = owner_module.find_exported(&spec.imported) {
+                  let name = exports.add(&chunk_id, &spec.imported, &exported.local_id);
+                  exports
+                    .name_by_import
+                    .insert((owner.clone(), spec.imported.clone()), name);
+                }
+              });
+          });
+
+        // Entries re-export the bindings of other chunks, see `Chunk::generate_cross_chunk_links`.
+        let is_entry_of_chunk = chunk_of_module.get(&importer.id).map_or(false, |chunk| {
+          chunk.entry == importer.id && !chunk.is_manual
+        });
+        if !is_entry_of_chunk {
+          return;
+        }
+        importer
+          .linked_exports
+          .iter()
+          .sorted_by_key(|(exported_as, _)| *exported_as)
+          .for_each(|(exported_as, spec)| {
+            if let Some(chunk_id) = manual_chunk_of(&importer.id, &spec.owner) {
+              exports.add(&chunk_id, exported_as, &spec.local_id);
+            }
+          });
+      });
+    exports
+  }
+
+  /// The name of the symbol exported by the chunk, like `default$1` for the second `default`.
+  fn add(&mut self, chunk_id: &ChunkId, preferred_name: &JsWord, symbol: &Symbol) -> JsWord {
+    if let Some(name) = self.name_by_symbol.get(symbol) {
+      return name.clone();
+    }
+    // Neither is a real export.
+    let base_name = if preferred_name == "*" || preferred_name == COMMONJS_WRAPPER_NAME {
+      symbol.name()
+    } else {
+      preferred_name
+    };
+    let used_names = self
+      .used_names_by_chunk
+      .entry(chunk_id.clone())
+      .or_default();
+    let name = (0..)
+      .map(|idx| {
+        if idx == 0 {
+          base_name.clone()
+        } else {
+          format!("{base_name}${idx}").into()
+        }
+      })
+      .find(|name| !used_names.contains(name))
+      .unwrap();
+    used_names.insert(name.clone());
+    self
+      .exports_by_chunk
+      .entry(chunk_id.clone())
+      .or_default()
+      .push((name.clone(), symbol.clone()));
+    self.name_by_symbol.insert(symbol.clone(), name.clone());
+    name
+  }
+
+  pub(crate) fn contains(&self, module_id: &ModuleId) -> bool {
+    self.modules.contains(module_id)
+  }
+
+  /// The name the export `imported` of `owner` is imported by from its manual chunk.
+  pub(crate) fn import_name(&self, owner: &ModuleId, imported: &JsWord) -> Option<&JsWord> {
+    self.name_by_import.get(&(owner.clone(), imported.clone()))
+  }
+
+  pub(crate) fn name_of_symbol(&self, symbol: &Symbol) -> Option<&JsWord> {
+    self.name_by_symbol.get(symbol)
+  }
+
+  pub(crate) fn exports_of(&self, chunk_id: &ChunkId) -> &[(JsWord, Symbol)] {
+    self
+      .exports_by_chunk
+      .get(chunk_id)
+      .map(Vec::as_slice)
+      .unwrap_or_default()
+  }
+}
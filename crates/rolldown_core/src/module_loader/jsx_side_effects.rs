@@ -0,0 +1,82 @@
+use swc_core::common::comments::{Comment, Comments};
+use swc_core::common::BytePos;
+use swc_core::ecma::ast;
+use swc_core::ecma::visit::{Visit, VisitWith};
+use swc_node_comments::SwcComments;
+
+/// Collects the start position of every outermost JSX expression. The react transform keeps the
+/// span of a JSX element on the factory call it generates, so these positions are where the
+/// `/*#__PURE__*/` annotation of the outermost calls lives once the transform is done. JSX inside
+/// a function, like the callback of `items.map(() => <li />)`, is outermost again, since it is
+/// evaluated when the function runs rather than as an argument of the enclosing call.
+pub(crate) fn collect_jsx_roots(ast: &ast::Module) -> Vec<BytePos> {
+  let mut collector = JsxRootCollector::default();
+  ast.visit_with(&mut collector);
+  collector.roots
+}
+
+/// With `jsx_side_effects` enabled, the generated calls are treated like any other call, so the
+/// annotations added by the react transform are dropped. Otherwise the outermost call of every JSX
+/// expression is marked as pure, which lets tree shaking remove unused component trees.
+pub(crate) fn annotate_jsx_roots(
+  comments: &SwcComments,
+  roots: &[BytePos],
+  jsx_side_effects: bool,
+) {
+  fn is_pure_annotation(comment: &Comment) -> bool {
+    let text = comment.text.trim();
+    text == "#__PURE__" || text == "@__PURE__"
+  }
+
+  roots.iter().for_each(|pos| {
+    if jsx_side_effects {
+      if let Some(mut comments) = comments.leading.get_mut(pos) {
+        comments.retain(|c| !is_pure_annotation(c));
+      }
+    } else {
+      comments.add_pure_comment(*pos);
+    }
+  });
+}
+
+#[derive(Default)]
+struct JsxRootCollector {
+  roots: Vec<BytePos>,
+  in_jsx: bool,
+}
+
+impl JsxRootCollector {
+  fn visit_jsx<N: VisitWith<Self>>(&mut self, lo: BytePos, n: &N) {
+    // Children of a JSX expression become arguments of the outermost call.
+    if !self.in_jsx {
+      self.roots.push(lo);
+    }
+    let in_jsx = std::mem::replace(&mut self.in_jsx, true);
+    n.visit_children_with(self);
+    self.in_jsx = in_jsx;
+  }
+
+  fn visit_fn_body<N: VisitWith<Self>>(&mut self, n: &N) {
+    let in_jsx = std::mem::replace(&mut self.in_jsx, false);
+    n.visit_children_with(self);
+    self.in_jsx = in_jsx;
+  }
+}
+
+impl Visit for JsxRootCollector {
+  fn visit_jsx_element(&mut self, n: &ast::JSXElement) {
+    self.visit_jsx(n.span.lo, n);
+  }
+
+  fn visit_jsx_fragment(&mut self, n: &ast::JSXFragment) {
+    self.visit_jsx(n.span.lo, n);
+  }
+
+  fn visit_function(&mut self, n: &ast::Function) {
+    self.visit_fn_body(n);
+  }
+
+  fn visit_arrow_expr(&mut self, n: &ast::ArrowExpr) {
+    self.visit_fn_body(n);
+  }
+}
@@ -1,21 +1,26 @@
 use std::collections::HashSet;
+use std::sync::Arc;
 
 use futures::future::join_all;
 use rolldown_common::{ExportedSpecifier, ModuleId};
 use rolldown_error::Errors;
+use rolldown_resolver::ImportKind;
 use rustc_hash::{FxHashMap, FxHashSet};
 use swc_core::common::{Mark, SyntaxContext, GLOBALS};
 
+mod jsx_side_effects;
 pub(crate) mod module_task;
 
 use module_task::{ModuleTask, TaskResult};
 use swc_core::ecma::atoms::js_word;
 use tracing::instrument;
 
-use crate::{norm_or_ext::NormOrExt, BuildInputOptions, Graph, NormalModule, SWC_GLOBALS};
 use crate::{
-  resolve_id, BuildError, BuildResult, ExternalModule, SharedBuildInputOptions,
-  SharedBuildPluginDriver, SharedResolver, StatementParts,
+  is_native_addon, norm_or_ext::NormOrExt, BuildInputOptions, Graph, NormalModule, SWC_GLOBALS,
+};
+use crate::{
+  resolve_id, resolve_id_by_custom_resolver, BuildError, BuildResult, ExternalModule,
+  SharedBuildInputOptions, SharedBuildPluginDriver, SharedResolver, StatementParts,
 };
 
 pub(crate) struct ModuleLoader<'a> {
@@ -29,6 +34,10 @@ pub(crate) struct ModuleLoader<'a> {
   resolver: SharedResolver,
   errors: Vec<BuildError>,
   dynamic_imported_modules: FxHashSet<ModuleId>,
+  /// Limits how many module tasks run at the same time. Results are added to the graph in
+  /// completion order, but everything after loading is driven by the execution order, so the
+  /// output doesn't depend on it.
+  task_permits: Arc<tokio::sync::Semaphore>,
 }
 
 #[derive(Debug)]
@@ -45,6 +54,9 @@ impl<'a> ModuleLoader<'a> {
     input_options: SharedBuildInputOptions,
   ) -> Self {
     let (tx, rx) = tokio::sync::mpsc::unbounded_channel::<Msg>();
+    let task_permits = Arc::new(tokio::sync::Semaphore::new(
+      input_options.concurrency.max(1),
+    ));
     Self {
       graph,
       loaded_modules: Default::default(),
@@ -56,6 +68,7 @@ impl<'a> ModuleLoader<'a> {
       build_plugin_driver: plugin_driver,
       dynamic_imported_modules: Default::default(),
       input_options,
+      task_permits,
     }
   }
 
@@ -64,15 +77,25 @@ impl<'a> ModuleLoader<'a> {
     let futs = input_opts.input.iter().cloned().map(|input_item| {
       let build_plugin_driver = self.build_plugin_driver.clone();
       let resolver = self.resolver.clone();
+      let preserve_symlinks = input_opts.preserve_symlinks;
+      let custom_resolver = input_opts.resolver.clone();
       tokio::spawn(async move {
-        let resolve_id = resolve_id(
-          &resolver,
-          &input_item.import,
-          None,
-          false,
-          &build_plugin_driver,
-        )
-        .await?;
+        let custom_resolved =
+          resolve_id_by_custom_resolver(custom_resolver.as_ref(), &input_item.import, None).await?;
+        let resolve_id = match custom_resolved {
+          Some(resolved) => Some(resolved),
+          None => {
+            resolve_id(
+              &resolver,
+              &input_item.import,
+              None,
+              ImportKind::Import,
+              preserve_symlinks,
+              &build_plugin_driver,
+            )
+            .await?
+          }
+        };
 
         let Some(resolve_id) = resolve_id else {
             return Err(BuildError::unresolved_entry(input_item.import))
@@ -180,7 +203,14 @@ impl<'a> ModuleLoader<'a> {
       is_external: self.input_options.is_external.clone(),
       input_options: self.input_options.clone(),
     };
-    tokio::spawn(task.run());
+    let task_permits = self.task_permits.clone();
+    tokio::spawn(async move {
+      let _permit = task_permits
+        .acquire_owned()
+        .await
+        .expect("The semaphore should never be closed");
+      task.run().await
+    });
   }
 
   #[instrument(skip_all)]
@@ -188,34 +218,59 @@ impl<'a> ModuleLoader<'a> {
     let module_id = result.module_id;
     let scan_result = result.scan_result;
     let resolved_ids = result.resolved_ids;
-
-    resolved_ids.values().for_each(|id| {
-      if self.loaded_modules.contains(id) {
-        return;
-      }
-      self.loaded_modules.insert(id.clone());
-      let top_level_ctxt = GLOBALS.set(&SWC_GLOBALS, || {
-        SyntaxContext::empty().apply_mark(Mark::new())
+    let resolved_require_ids = result.resolved_require_ids;
+
+    resolved_ids
+      .values()
+      .chain(resolved_require_ids.values())
+      .for_each(|id| {
+        if self.loaded_modules.contains(id) {
+          return;
+        }
+        self.loaded_modules.insert(id.clone());
+        let top_level_ctxt = GLOBALS.set(&SWC_GLOBALS, || {
+          SyntaxContext::empty().apply_mark(Mark::new())
+        });
+        if id.is_external() {
+          let native_addon = is_native_addon(id)
+            .then(|| std::fs::read(id.as_ref()))
+            .transpose()
+            .unwrap_or_else(|e| {
+              self.errors.push(
+                BuildError::io_error(e).context(format!("Read native addon: {}", id.as_ref())),
+              );
+              None
+            });
+          let external_module = ExternalModule {
+            exec_order: usize::MAX,
+            id: id.clone(),
+            top_level_ctxt,
+            runtime_helpers: Default::default(),
+            exports: Default::default(),
+            native_addon,
+          };
+          self.graph.add_module(NormOrExt::External(external_module));
+        } else {
+          self.spawn_new_module_task(id.clone(), false);
+        }
       });
-      if id.is_external() {
-        let external_module = ExternalModule {
-          exec_order: usize::MAX,
-          id: id.clone(),
-          top_level_ctxt,
-          runtime_helpers: Default::default(),
-          exports: Default::default(),
-        };
-        self.graph.add_module(NormOrExt::External(external_module));
-      } else {
-        self.spawn_new_module_task(id.clone(), false);
-      }
-    });
 
-    let dependencies = scan_result
+    let mut dependencies: Vec<ModuleId> = scan_result
       .dependencies
       .iter()
       .map(|id| resolved_ids[id].clone())
       .collect();
+    // Required modules are executed first, so their wrappers are declared before being called.
+    scan_result
+      .requires
+      .keys()
+      .map(|specifier| &resolved_require_ids[specifier])
+      .filter(|id| !id.is_external())
+      .for_each(|id| {
+        if !dependencies.contains(id) {
+          dependencies.push(id.clone());
+        }
+      });
 
     let dyn_dependencies: Vec<ModuleId> = scan_result
       .dyn_dependencies
@@ -278,11 +333,22 @@ impl<'a> ModuleLoader<'a> {
       re_exported_ids,
       re_export_all,
       resolved_module_ids: resolved_ids,
+      resolved_require_ids,
       declared_scoped_names: scan_result.declared_scoped_names,
       id: module_id,
       runtime_helpers: Default::default(),
       parts: StatementParts::from_parts(scan_result.statement_parts),
       missing_exports: Default::default(),
+      css: result.css,
+      css_lines: result.css_lines,
+      css_url_files: result.css_url_files,
+      file: result.file,
+      shebang: result.shebang,
+      helper_names: result.helper_names,
+      is_commonjs: result.is_commonjs,
+      commonjs_wrapper: None,
+      requires: scan_result.requires,
+      required_modules: Default::default(),
     };
     self.graph.add_module(NormOrExt::Normal(normal_module));
   }
@@ -1,17 +1,19 @@
-use std::path::PathBuf;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
 
 use derivative::Derivative;
 use futures::future::join_all;
 use rolldown_common::{Loader, ModuleId};
 use rolldown_error::Errors;
-use rolldown_resolver::Resolver;
+use rolldown_resolver::{node_builtin_name, ImportKind, PackageType, Resolver};
 use rolldown_swc_visitors::{clean_ast, ScanResult};
-use rustc_hash::FxHashMap;
+use rustc_hash::{FxHashMap, FxHashSet};
 use sugar_path::AsPath;
 use swc_core::common::pass::Optional;
+use swc_core::common::comments::{Comment, CommentKind};
 use swc_core::common::{chain, Mark, SyntaxContext, GLOBALS};
 use swc_core::ecma::ast;
-use swc_core::ecma::atoms::JsWord;
+use swc_core::ecma::atoms::{Atom, JsWord};
 use swc_core::ecma::parser::{EsConfig, Syntax, TsConfig};
 use swc_core::ecma::transforms::base::fixer::fixer;
 use swc_core::ecma::transforms::base::helpers::{inject_helpers, HELPERS};
@@ -24,11 +26,13 @@ use swc_core::ecma::visit::FoldWith;
 use swc_node_comments::SwcComments;
 use tracing::instrument;
 
+use super::jsx_side_effects::{annotate_jsx_roots, collect_jsx_roots};
 use super::Msg;
 use crate::{
-  extract_loader_by_path, resolve_id, BuildError, BuildResult, IsExternal, ResolvedModuleIds,
-  SharedBuildInputOptions, SharedBuildPluginDriver, SharedResolver, UnaryBuildResult, COMPILER,
-  SWC_GLOBALS,
+  css_url_paths, extract_loader_by_path, file_module_code, inline_css_imports, resolve_id,
+  resolve_id_by_custom_resolver, BuildError, BuildInputOptions, BuildResult, CssLineOrigin,
+  CssUrlFile, IsExternal, MappedCss, ResolvedModuleIds, SharedBuildInputOptions,
+  SharedBuildPluginDriver, SharedResolver, UnaryBuildResult, COMPILER, SWC_GLOBALS,
 };
 
 pub(crate) struct ModuleTask {
@@ -52,18 +56,74 @@ impl ModuleTask {
     resolver: &Resolver,
     importer: &ModuleId,
     specifier: &str,
+    kind: ImportKind,
     plugin_driver: &SharedBuildPluginDriver,
     is_external: &IsExternal,
+    input_options: &BuildInputOptions,
   ) -> UnaryBuildResult<ModuleId> {
+    let custom_resolved =
+      resolve_id_by_custom_resolver(input_options.resolver.as_ref(), specifier, Some(importer))
+        .await?;
+    if let Some(resolved) = custom_resolved {
+      return Ok(resolved);
+    }
+
     let is_marked_as_external = is_external(specifier, Some(importer.id()), false).await?;
 
     if is_marked_as_external {
       return Ok(ModuleId::new(specifier, true));
     }
 
-    let resolved_id = resolve_id(resolver, specifier, Some(importer), false, plugin_driver).await?;
+    // Builtins win over packages with the same name only when targeting node.
+    let builtin_name = node_builtin_name(specifier).filter(|_| input_options.platform.is_node());
+    if let Some(name) = builtin_name {
+      return Ok(if input_options.prefix_node_builtins {
+        ModuleId::new(format!("node:{name}"), true)
+      } else {
+        ModuleId::new(specifier, true)
+      });
+    }
+
+    let mapped = input_options
+      .resolve_overrides
+      .resolve(Some(importer.id()), specifier)
+      .or_else(|| {
+        input_options
+          .import_map
+          .as_ref()
+          .and_then(|import_map| import_map.resolve(Some(importer.id()), specifier))
+      })
+      .or_else(|| {
+        input_options
+          .tsconfig_paths
+          .resolve(resolver, importer.id(), specifier, kind)
+      });
+    let specifier = match &mapped {
+      // Deno and browsers load it from the URL.
+      Some(address) if !Path::new(address).is_absolute() => {
+        return Ok(ModuleId::new(address.as_str(), true));
+      }
+      Some(address) => address.as_str(),
+      None => specifier,
+    };
+
+    let resolved_id = resolve_id(
+      resolver,
+      specifier,
+      Some(importer),
+      kind,
+      input_options.preserve_symlinks,
+      plugin_driver,
+    )
+    .await?;
 
     if let Some(resolved) = resolved_id {
+      // Native addons can't be bundled. They are copied to the output and loaded from the copy at
+      // runtime.
+      if resolved.id().ends_with(".node") {
+        return Ok(ModuleId::new(resolved.id().clone(), true));
+      }
+
       let is_resolved_marked_as_external =
         is_external(resolved.id(), Some(importer.id()), true).await?;
 
@@ -72,6 +132,15 @@ impl ModuleTask {
         is_resolved_marked_as_external,
       ))
     } else {
+      // `import type` is erased before dependencies are resolved, so this is a value import, which
+      // is surely broken at runtime.
+      if let Some(types_package) = resolver.find_types_only_package(importer.id(), specifier) {
+        (input_options.on_warn)(BuildError::types_only_package(
+          specifier.to_string(),
+          importer.as_path().to_path_buf(),
+          types_package,
+        ));
+      }
       // TODO: emit warnings like https://rollupjs.org/guide/en#warning-treating-module-as-external-dependency
       Ok(ModuleId::new(specifier, true))
     }
@@ -90,51 +159,66 @@ impl ModuleTask {
     }
   }
 
+  /// Imports and requires are resolved separately, since the same specifier can resolve to
+  /// different modules of a dual package.
   async fn resolve_dependencies(
     &self,
     result: &ScanResult,
-  ) -> BuildResult<FxHashMap<JsWord, ModuleId>> {
-    let dependencies = result
+  ) -> BuildResult<(ResolvedModuleIds, ResolvedModuleIds)> {
+    let imports = result
       .dependencies
       .iter()
-      .chain(result.dyn_dependencies.iter());
+      .chain(result.dyn_dependencies.iter())
+      .map(|specifier| (specifier.clone(), ImportKind::Import));
+    let requires = result
+      .requires
+      .keys()
+      .map(|specifier| (specifier.clone(), ImportKind::Require));
 
-    let jobs = dependencies.cloned().map(|specifier| {
+    let jobs = imports.chain(requires).map(|(specifier, kind)| {
       let resolver = self.resolver.clone();
       let plugin_driver = self.plugin_driver.clone();
       let importer = self.id.clone();
       let is_external = self.is_external.clone();
+      let input_options = self.input_options.clone();
 
       tokio::spawn(async move {
         Self::resolve_id(
           &resolver,
           &importer,
           &specifier,
+          kind,
           &plugin_driver,
           &is_external,
+          &input_options,
         )
         .await
-        .map(|id| (specifier.clone(), id))
+        .map(|id| (specifier.clone(), kind, id))
       })
     });
 
     let resolved_ids = join_all(jobs).await;
 
     let mut errors = vec![];
+    let mut imported_ids = ResolvedModuleIds::default();
+    let mut required_ids = ResolvedModuleIds::default();
 
-    let ret: FxHashMap<JsWord, ModuleId> = resolved_ids
+    resolved_ids
       .into_iter()
-      .filter_map(|handle| match handle.unwrap() {
-        Ok(id) => Some(id),
+      .for_each(|handle| match handle.unwrap() {
+        Ok((specifier, ImportKind::Import, id)) => {
+          imported_ids.insert(specifier, id);
+        }
+        Ok((specifier, ImportKind::Require, id)) => {
+          required_ids.insert(specifier, id);
+        }
         Err(e) => {
           errors.push(e);
-          None
         }
-      })
-      .collect();
+      });
 
     if errors.is_empty() {
-      Ok(ret)
+      Ok((imported_ids, required_ids))
     } else {
       Err(Errors::from_vec(errors))
     }
@@ -142,9 +226,20 @@ impl ModuleTask {
 
   async fn run_inner(self) -> BuildResult<TaskResult> {
     let loaded = self.plugin_driver.read().await.load(&self.id).await?;
+    let is_loaded_by_plugin = loaded.is_some();
+    let default_loader = || {
+      if self.input_options.builtins.detect_loader_by_ext {
+        extract_loader_by_path(self.id.as_path())
+      } else {
+        Loader::Js
+      }
+    };
     // load hook
     let (code, loader) = if loaded.is_some() {
       loaded.map(|l| (l.code, l.loader)).unwrap()
+    } else if matches!(default_loader(), Loader::File) {
+      // Files may be binary, so they are read as bytes below.
+      (String::new(), Some(Loader::File))
     } else {
       let code = tokio::fs::read_to_string(self.id.as_ref())
         .await
@@ -154,28 +249,69 @@ impl ModuleTask {
       (code, None)
     };
 
-    let mut loader = loader.unwrap_or_else(|| {
-      if self.input_options.builtins.detect_loader_by_ext {
-        extract_loader_by_path(self.id.as_path())
+    let mut loader = loader.unwrap_or_else(default_loader);
+
+    // A file is copied to the output as it is, so it's not transformed. In the module graph, it's a
+    // module exporting the URL of the copy.
+    let (code, file) = if matches!(loader, Loader::File) {
+      let content = if is_loaded_by_plugin {
+        code.into_bytes()
       } else {
-        Loader::Js
-      }
-    });
+        tokio::fs::read(self.id.as_ref())
+          .await
+          .map_err(BuildError::io_error)
+          .map_err(|e| e.context(format!("Read file: {}", self.id.as_ref())))?
+      };
+      loader = Loader::Js;
+      (file_module_code(), Some(content))
+    } else {
+      let code = self
+        .plugin_driver
+        .read()
+        .await
+        .transform(&self.id, code, &mut loader)
+        .await?;
+      (code, None)
+    };
 
-    let code = self
-      .plugin_driver
-      .read()
-      .await
-      .transform(&self.id, code, &mut loader)
-      .await?;
+    // CSS is bundled separately. In the module graph, it's an empty JavaScript module.
+    let (code, css) = if matches!(loader, Loader::Css) {
+      loader = Loader::Js;
+      let path = Path::new(self.id.as_ref());
+      let css = if path.is_absolute() {
+        inline_css_imports(path, code).await?
+      } else {
+        MappedCss::original(code, &Arc::from(path))
+      };
+      (String::new(), Some(css))
+    } else {
+      (code, None)
+    };
+    let (css, css_lines) = css
+      .map(|css| (Some(css.code), css.lines))
+      .unwrap_or_default();
+    let css_url_files = match &css {
+      Some(css) if Path::new(self.id.as_ref()).is_absolute() => {
+        load_css_url_files(Path::new(self.id.as_ref()), css).await?
+      }
+      _ => Default::default(),
+    };
 
-    let (mut ast, comments) = parse_to_js_ast(&self.id, code, loader, &self.input_options)?;
+    let (mut ast, comments, helper_names) =
+      parse_to_js_ast(&self.id, code, loader, &self.input_options)?;
+    // It would be printed in the middle of the chunk otherwise.
+    let shebang = ast.shebang.take();
 
     // No matter what, the ast should be a pure valid JavaScript in this phrase
     GLOBALS.set(&SWC_GLOBALS, || {
       rolldown_swc_visitors::resolve(&mut ast, self.unresolved_mark, self.top_level_mark);
     });
 
+    let module_type = module_type(&self.id, &self.resolver);
+    if module_type == Some(PackageType::Module) {
+      rolldown_swc_visitors::replace_top_level_this(&mut ast);
+    }
+
     let result = rolldown_swc_visitors::scan(
       &mut ast,
       self.top_level_ctxt,
@@ -183,7 +319,22 @@ impl ModuleTask {
       self.id.clone(),
     );
 
-    let resolved_ids = self.resolve_dependencies(&result).await?;
+    // Modules importing or exporting are ES modules, even if they use `module` or `exports`.
+    let is_commonjs = module_type != Some(PackageType::Module)
+      && !ast.body.iter().any(ast::ModuleItem::is_module_decl)
+      && (module_type == Some(PackageType::CommonJs)
+        || result
+          .visited_global_names
+          .iter()
+          .any(|name| name == "module" || name == "exports"));
+
+    if result.has_dynamic_require {
+      (self.input_options.on_warn)(BuildError::unresolvable_dynamic_require(
+        self.id.as_path().to_path_buf(),
+      ));
+    }
+
+    let (resolved_ids, resolved_require_ids) = self.resolve_dependencies(&result).await?;
 
     Ok(TaskResult {
       module_id: self.id,
@@ -192,8 +343,16 @@ impl ModuleTask {
       top_level_ctxt: self.top_level_ctxt,
       scan_result: result,
       resolved_ids,
+      resolved_require_ids,
       comments,
       is_user_defined_entry: self.is_user_defined_entry,
+      css,
+      css_lines,
+      css_url_files,
+      file,
+      shebang,
+      helper_names,
+      is_commonjs,
     })
   }
 }
@@ -207,9 +366,59 @@ pub(crate) struct TaskResult {
   pub top_level_ctxt: SyntaxContext,
   pub scan_result: ScanResult,
   pub resolved_ids: ResolvedModuleIds,
+  /// Specifiers of `require` calls, which are resolved with the `require` condition.
+  pub resolved_require_ids: ResolvedModuleIds,
   #[derivative(Debug = "ignore")]
   pub comments: SwcComments,
   pub is_user_defined_entry: bool,
+  pub css: Option<String>,
+  #[derivative(Debug = "ignore")]
+  pub css_lines: Vec<Option<CssLineOrigin>>,
+  #[derivative(Debug = "ignore")]
+  pub css_url_files: FxHashMap<String, CssUrlFile>,
+  #[derivative(Debug = "ignore")]
+  pub file: Option<Vec<u8>>,
+  pub shebang: Option<Atom>,
+  pub helper_names: FxHashSet<JsWord>,
+  pub is_commonjs: bool,
+}
+
+/// The files are read when the CSS is loaded, like modules loaded with the file loader, so a missing
+/// file fails the build instead of breaking in the browser.
+async fn load_css_url_files(
+  css_path: &Path,
+  css: &str,
+) -> UnaryBuildResult<FxHashMap<String, CssUrlFile>> {
+  let mut files = FxHashMap::default();
+  for (url, path) in css_url_paths(css_path, css) {
+    let content = tokio::fs::read(&path)
+      .await
+      .map_err(BuildError::io_error)
+      .map_err(|e| {
+        e.context(format!(
+          "Read file: {}, referenced by url({url}) in {}",
+          path.display(),
+          css_path.display()
+        ))
+      })?;
+    files.insert(url, CssUrlFile { path, content });
+  }
+  Ok(files)
+}
+
+/// `.mjs` is always an ES module and `.cjs` always a CommonJS module, while `.js` follows the `type`
+/// of the nearest `package.json`. `None` if it's ambiguous, like for virtual modules.
+fn module_type(id: &ModuleId, resolver: &Resolver) -> Option<PackageType> {
+  let path = Path::new(id.as_ref());
+  if !path.is_absolute() {
+    return None;
+  }
+  match path.extension()?.to_str()? {
+    "mjs" | "mts" => Some(PackageType::Module),
+    "cjs" | "cts" => Some(PackageType::CommonJs),
+    "js" => resolver.package_type(id.as_ref()),
+    _ => None,
+  }
 }
 
 /// This function should emit valid JavaScript AST(with JSX)
@@ -218,7 +427,7 @@ fn parse_to_js_ast(
   source: String,
   loader: Loader,
   input_options: &SharedBuildInputOptions,
-) -> UnaryBuildResult<(ast::Module, SwcComments)> {
+) -> UnaryBuildResult<(ast::Module, SwcComments, FxHashSet<JsWord>)> {
   match loader {
     Loader::Js | Loader::Jsx | Loader::Ts | Loader::Tsx => {
       let is_jsx_or_tsx = matches!(loader, Loader::Jsx | Loader::Tsx);
@@ -243,9 +452,26 @@ fn parse_to_js_ast(
         .parse_with_comments(fm.clone(), syntax, Some(&comments))
         .map_err(|e| BuildError::parse_js_failed(fm, e).context(format!("{loader:?}")))?;
 
+      if is_ts_or_tsx {
+        remove_triple_slash_directives(&comments);
+      }
+
+      // Types of TSX are stripped either way.
+      let transforms_jsx = is_jsx_or_tsx && !input_options.builtins.jsx.is_preserve();
+
+      let jsx_roots = if transforms_jsx {
+        collect_jsx_roots(&ast)
+      } else {
+        vec![]
+      };
+
       let need_resolve = is_ts_or_tsx;
       let need_inject_helpers = is_ts_or_tsx;
 
+      // Helpers are inlined as top-level functions, which are told apart from the functions of the
+      // module by their names, so the ones inlined by several modules are only declared once.
+      let mut helper_names = FxHashSet::default();
+
       // It's ok to use a new GLOBALS here, since the SyntaxContext information won't be used in bundler.
       // Bundler will resolve SyntaxContext for its own usage.
       let ast = GLOBALS.set(&Default::default(), || {
@@ -287,7 +513,7 @@ fn parse_to_js_ast(
             ),
           },
           Optional {
-            enabled: is_jsx_or_tsx,
+            enabled: transforms_jsx,
             visitor: react::react(
               COMPILER.cm.clone(),
               Some(&comments),
@@ -308,23 +534,67 @@ fn parse_to_js_ast(
             enabled: is_ts_or_tsx,
             // Ensure that we have enough parenthesis.
             visitor: fixer(None),
-          },
-          Optional {
-            enabled: need_inject_helpers,
-            // Ensure that we have enough parenthesis.
-            visitor: inject_helpers(unresolved_mark),
-          },
-          Optional {
-            enabled: need_resolve,
-            visitor: clean_ast()
           }
         );
 
-        HELPERS.set(&Default::default(), || ast.fold_with(&mut folders))
+        HELPERS.set(&Default::default(), || {
+          let ast = ast.fold_with(&mut folders);
+          let declared = top_level_fn_names(&ast);
+          let ast = ast.fold_with(&mut Optional {
+            enabled: need_inject_helpers,
+            visitor: inject_helpers(unresolved_mark),
+          });
+          helper_names = top_level_fn_names(&ast)
+            .difference(&declared)
+            .cloned()
+            .collect();
+          ast.fold_with(&mut Optional {
+            enabled: need_resolve,
+            visitor: clean_ast(),
+          })
+        })
       });
 
-      Ok((ast, comments))
+      annotate_jsx_roots(
+        &comments,
+        &jsx_roots,
+        input_options.builtins.jsx_side_effects,
+      );
+
+      Ok((ast, comments, helper_names))
     }
     Loader::Json => unimplemented!(),
+    Loader::Css => unreachable!("CSS should be turned into an empty JavaScript module"),
+    Loader::File => unreachable!("A file should be turned into a module exporting its URL"),
+  }
+}
+
+/// `function f() {}` at the top level => `f`
+fn top_level_fn_names(ast: &ast::Module) -> FxHashSet<JsWord> {
+  ast
+    .body
+    .iter()
+    .filter_map(|item| match item {
+      ast::ModuleItem::Stmt(ast::Stmt::Decl(ast::Decl::Fn(decl))) => Some(decl.ident.sym.clone()),
+      _ => None,
+    })
+    .collect()
+}
+
+/// Triple-slash directives, such as `/// <reference path="./globals.d.ts" />`, only make sense to
+/// the type checker. They are neither resolved nor kept in the output. Other `///` comments are
+/// ordinary comments and are left alone.
+fn remove_triple_slash_directives(comments: &SwcComments) {
+  fn is_triple_slash_directive(comment: &Comment) -> bool {
+    comment.kind == CommentKind::Line
+      && comment.text.strip_prefix('/').map_or(false, |text| {
+        let text = text.trim_start();
+        text.starts_with("<reference") || text.starts_with("<amd-")
+      })
   }
+
+  comments
+    .leading
+    .iter_mut()
+    .for_each(|mut comments| comments.retain(|c| !is_triple_slash_directive(c)));
 }
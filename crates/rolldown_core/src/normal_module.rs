@@ -1,31 +1,36 @@
 use derivative::Derivative;
-use hashlink::LinkedHashSet;
+use hashlink::{LinkedHashMap, LinkedHashSet};
 use itertools::Itertools;
 use rolldown_common::{
   ExportedSpecifier, ImportedSpecifier, ModuleId, ReExportedSpecifier, Symbol,
 };
 use rolldown_runtime_helpers::RuntimeHelpers;
-use rolldown_swc_visitors::StatementPart;
+use rolldown_swc_visitors::{RequiredModule, StatementPart};
 use rustc_hash::{FxHashMap as HashMap, FxHashSet as HashSet};
 use sugar_path::{AsPath, SugarPath};
 use swc_core::{
   common::{
     comments::{Comment, CommentKind, Comments, SingleThreadedComments},
     util::take::Take,
-    Spanned, SyntaxContext,
+    Spanned, SyntaxContext, DUMMY_SP,
   },
   ecma::{
     ast::{self, Ident},
-    atoms::{js_word, JsWord},
+    atoms::{js_word, Atom, JsWord},
   },
 };
 use swc_node_comments::SwcComments;
 use tracing::instrument;
 
 use crate::{
-  make_legal, BuildInputOptions, MergedExports, RenderContext, ResolvedModuleIds, COMPILER,
+  make_legal, BuildInputOptions, CssLineOrigin, CssUrlFile, MergedExports, RenderContext, ResolvedModuleIds,
+  COMPILER,
 };
 
+/// The name the wrapper of a required CommonJS module is imported by. Like `*`, it's never a real
+/// export, so it's not exported by chunks either.
+pub(crate) const COMMONJS_WRAPPER_NAME: &str = "*require*";
+
 #[derive(Derivative)]
 #[derivative(Debug)]
 pub struct NormalModule {
@@ -54,6 +59,8 @@ pub struct NormalModule {
   pub(crate) extra_top_level_symbols: HashSet<Symbol>,
 
   pub(crate) resolved_module_ids: ResolvedModuleIds,
+  /// `require("./a")` => the module `./a` is resolved to with the `require` condition
+  pub(crate) resolved_require_ids: ResolvedModuleIds,
   pub(crate) is_user_defined_entry: bool,
   pub(crate) suggested_names: HashMap<JsWord, JsWord>,
 
@@ -79,6 +86,36 @@ pub struct NormalModule {
 
   /// Key is missing exported name
   pub(crate) missing_exports: HashMap<JsWord, Symbol>,
+
+  /// Source of a CSS module, whose `ast` is always empty.
+  pub(crate) css: Option<String>,
+
+  /// Where every line of `css` comes from, including the files inlined by `@import`.
+  #[derivative(Debug = "ignore")]
+  pub(crate) css_lines: Vec<Option<CssLineOrigin>>,
+
+  /// Local files referenced by `url()` in `css`, keyed by the URL without its query and fragment.
+  #[derivative(Debug = "ignore")]
+  pub(crate) css_url_files: HashMap<String, CssUrlFile>,
+
+  /// Content of a module loaded with the file loader, whose `ast` only exports the URL.
+  #[derivative(Debug = "ignore")]
+  pub(crate) file: Option<Vec<u8>>,
+
+  /// `#!` line of the source without the `#!`. It's only emitted if the module is an entry.
+  pub(crate) shebang: Option<Atom>,
+
+  /// Top-level functions inlined by SWC for lowered syntax, like `_decorate`.
+  pub(crate) helper_names: HashSet<JsWord>,
+
+  /// It neither imports nor exports, and uses `module` or `exports`, or it's a `.cjs` file.
+  pub(crate) is_commonjs: bool,
+  /// `require_a` of `var require_a = __commonJS(...)`, set once the CommonJS module is required.
+  pub(crate) commonjs_wrapper: Option<ExportedSpecifier>,
+  /// `require("./a")` => the symbol the call is rewritten to, until the requires are linked.
+  pub(crate) requires: LinkedHashMap<JsWord, Symbol>,
+  /// What the `require` calls of bundled modules are rewritten to, keyed by the specifier.
+  pub(crate) required_modules: HashMap<JsWord, RequiredModule>,
 }
 
 impl NormalModule {
@@ -173,6 +210,8 @@ impl NormalModule {
   pub(crate) fn find_exported(&self, exported_name: &JsWord) -> Option<&ExportedSpecifier> {
     if exported_name == "*" {
       Some(&self.facade_id_for_namespace)
+    } else if exported_name == COMMONJS_WRAPPER_NAME {
+      self.commonjs_wrapper.as_ref()
     } else {
       self.linked_exports.get(exported_name)
     }
@@ -312,8 +351,79 @@ impl NormalModule {
     }
   }
 
+  /// `module.exports = 'a'` => `var require_a = __commonJS((exports, module) => { module.exports = 'a' })`.
+  /// Like the first `require("./a")`, the first call of `require_a()` evaluates the module.
+  pub(crate) fn wrap_commonjs(&mut self, unresolved_ctxt: SyntaxContext) {
+    debug_assert!(self.is_commonjs);
+    if self.commonjs_wrapper.is_some() {
+      return;
+    }
+    self.runtime_helpers.common_js();
+
+    let wrapper = self.create_top_level_symbol(&format!("require_{}", self.file_stem()).into());
+    let mut body = self
+      .ast
+      .body
+      .take()
+      .into_iter()
+      .filter_map(ast::ModuleItem::stmt)
+      .collect();
+    // `module` and `exports` are left unresolved, so they are neither renamed nor shadowed.
+    let param = |name: &str| Ident::new(name.into(), DUMMY_SP.with_ctxt(unresolved_ctxt));
+    let exports = param("exports");
+    rolldown_swc_visitors::replace_top_level_this_with_exports(&mut body, &exports);
+    self
+      .ast
+      .body
+      .push(rolldown_ast_template::build_commonjs_wrapper_stmt(
+        wrapper.clone().to_id(),
+        exports,
+        param("module"),
+        body,
+      ));
+
+    // The statements are in the only statement now, which is included if the wrapper is used.
+    let mut part = StatementPart {
+      declared: HashSet::from_iter([wrapper.clone()]),
+      referenced: Default::default(),
+      is_included: false.into(),
+      side_effect: false,
+    };
+    std::mem::take(&mut self.parts.parts)
+      .into_iter()
+      .for_each(|wrapped| {
+        part.declared.extend(wrapped.declared);
+        part.referenced.extend(wrapped.referenced);
+      });
+    self.parts = StatementParts::from_parts(vec![part]);
+
+    self.commonjs_wrapper = Some(ExportedSpecifier {
+      exported_as: COMMONJS_WRAPPER_NAME.into(),
+      local_id: wrapper,
+      owner: self.id.clone(),
+    });
+  }
+
   #[instrument(skip_all)]
   pub(crate) fn render(&self, _ctx: &RenderContext, options: &BuildInputOptions) -> String {
+    COMPILER
+      .print(&self.ast, Some(&self.header_comment(options)))
+      .unwrap()
+  }
+
+  #[instrument(skip_all)]
+  pub(crate) fn render_with_source_map(
+    &self,
+    _ctx: &RenderContext,
+    options: &BuildInputOptions,
+  ) -> (String, sourcemap::SourceMap) {
+    COMPILER
+      .print_with_source_map(&self.ast, Some(&self.header_comment(options)))
+      .unwrap()
+  }
+
+  /// `// path/to/module.js` before the code of the module, followed by its legal comments
+  fn header_comment(&self, options: &BuildInputOptions) -> SingleThreadedComments {
     let comments = SingleThreadedComments::default();
 
     let mut text = String::new();
@@ -327,8 +437,27 @@ impl NormalModule {
         text: text.into(),
       },
     );
+    self
+      .legal_comments()
+      .into_iter()
+      .for_each(|comment| comments.add_leading(self.ast.span_lo(), comment));
+    comments
+  }
 
-    COMPILER.print(&self.ast, Some(&comments)).unwrap()
+  /// Comments starting with `!` or containing `@license` or `@preserve`, in the order of the
+  /// source. They are rendered at the top of the module, since the code they are attached to could
+  /// be moved or removed.
+  pub(crate) fn legal_comments(&self) -> Vec<Comment> {
+    self
+      .comments
+      .leading
+      .iter()
+      .chain(self.comments.trailing.iter())
+      .flat_map(|comments| comments.value().clone())
+      .filter(is_legal_comment)
+      .sorted_by_key(|comment| comment.span.lo)
+      .unique_by(|comment| comment.text.clone())
+      .collect()
   }
 
   pub(crate) fn suggested_name_for(&self, sym: &JsWord) -> Option<JsWord> {
@@ -339,27 +468,43 @@ impl NormalModule {
       .map(|s| make_legal(&s).into());
 
     if ret.as_ref().is_none() && sym == "default" {
-      return Some(
-        make_legal(
-          &self
-            .id
-            .as_path()
-            .file_stem()
-            .map(|s| s.to_string_lossy().to_string())
-            .unwrap(),
-        )
-        .into(),
-      );
+      return Some(self.file_stem().into());
     }
 
     ret
   }
 
+  /// `path/to/foo.js` => `foo`, as a legal name
+  pub(crate) fn file_stem(&self) -> String {
+    make_legal(
+      &self
+        .id
+        .as_path()
+        .file_stem()
+        .map(|s| s.to_string_lossy().to_string())
+        .unwrap(),
+    )
+  }
+
   pub(crate) fn is_included(&self) -> bool {
     !self.ast.body.is_empty()
   }
 }
 
+fn is_legal_comment(comment: &Comment) -> bool {
+  comment.text.starts_with('!')
+    || comment.text.contains("@license")
+    || comment.text.contains("@preserve")
+}
+
+/// A comment as it's printed.
+pub(crate) fn print_comment(comment: &Comment) -> String {
+  match comment.kind {
+    CommentKind::Line => format!("//{}", comment.text),
+    CommentKind::Block => format!("/*{}*/", comment.text),
+  }
+}
+
 #[derive(Debug)]
 pub(crate) struct StatementParts {
   pub(crate) parts: Vec<StatementPart>,
@@ -0,0 +1,29 @@
+use std::str::FromStr;
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum JsxMode {
+  /// JSX is compiled to calls of the React runtime.
+  #[default]
+  Transform,
+  /// JSX is kept as it is, for tools compiling the output further. Types of TSX are still stripped,
+  /// and the chunks are named `.jsx` instead of `.js`.
+  Preserve,
+}
+
+impl JsxMode {
+  pub fn is_preserve(&self) -> bool {
+    matches!(self, JsxMode::Preserve)
+  }
+}
+
+impl FromStr for JsxMode {
+  type Err = String;
+
+  fn from_str(value: &str) -> Result<Self, Self::Err> {
+    match value {
+      "transform" => Ok(JsxMode::Transform),
+      "preserve" => Ok(JsxMode::Preserve),
+      _ => Err(format!("Invalid JSX mode: {value}")),
+    }
+  }
+}
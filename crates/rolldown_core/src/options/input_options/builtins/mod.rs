@@ -1,5 +1,7 @@
+mod jsx;
 mod typescript;
 use derivative::Derivative;
+pub use jsx::*;
 pub use typescript::*;
 
 #[derive(Derivative)]
@@ -9,6 +11,10 @@ pub struct BuiltinsOptions {
   pub tsconfig: TsConfig,
   // TODO: Should come up with a better name before exposing this option.
   pub detect_loader_by_ext: bool,
+  /// Whether calls generated from JSX may have side effects. When `false`, they are annotated with
+  /// `/*#__PURE__*/` so unused JSX can be tree-shaken.
+  pub jsx_side_effects: bool,
+  pub jsx: JsxMode,
 }
 
 impl Default for BuiltinsOptions {
@@ -16,6 +22,8 @@ impl Default for BuiltinsOptions {
     Self {
       tsconfig: Default::default(),
       detect_loader_by_ext: true,
+      jsx_side_effects: true,
+      jsx: Default::default(),
     }
   }
 }
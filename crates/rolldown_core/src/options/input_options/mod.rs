@@ -2,6 +2,7 @@ use std::{path::PathBuf, pin::Pin, sync::Arc};
 
 use derivative::Derivative;
 use futures::{future, Future, FutureExt};
+pub use rolldown_resolver::{ImportMap, ResolveOverrides, TsConfigPaths};
 
 use crate::{UnaryBuildResult, WarningHandler};
 
@@ -9,6 +10,10 @@ mod input_item;
 pub use input_item::*;
 mod builtins;
 pub use builtins::*;
+mod platform;
+pub use platform::*;
+mod resolver;
+pub use resolver::*;
 
 type PinFutureBox<T> = Pin<Box<dyn Future<Output = T> + Send>>;
 
@@ -28,6 +33,27 @@ pub struct BuildInputOptions {
   pub shim_missing_exports: bool,
   pub preserve_symlinks: bool,
   pub builtins: BuiltinsOptions,
+  /// The maximum number of modules loaded, parsed and transformed at the same time.
+  pub concurrency: usize,
+  /// With `Platform::Node`, builtin modules such as `fs` and `node:fs` are treated as external.
+  pub platform: Platform,
+  /// Rewrite imports of node builtins to their `node:` prefixed form, such as `fs` to `node:fs`.
+  pub prefix_node_builtins: bool,
+  /// Specifiers are mapped through it before being resolved, like in Deno and browsers.
+  pub import_map: Option<ImportMap>,
+  /// Bare specifiers forced to fixed paths, applied before the import map.
+  pub resolve_overrides: ResolveOverrides,
+  /// `paths` of the tsconfig, applied after the import map.
+  pub tsconfig_paths: TsConfigPaths,
+  /// Overrides the resolution of every specifier it handles, before plugins, externals and the
+  /// options above are applied.
+  #[derivative(Debug = "ignore")]
+  pub resolver: Option<CustomResolver>,
+}
+
+/// One module per available core.
+pub fn default_concurrency() -> usize {
+  std::thread::available_parallelism().map_or(1, |n| n.get())
 }
 
 impl Default for BuildInputOptions {
@@ -43,6 +69,13 @@ impl Default for BuildInputOptions {
       shim_missing_exports: false,
       builtins: Default::default(),
       preserve_symlinks: true,
+      concurrency: default_concurrency(),
+      platform: Default::default(),
+      prefix_node_builtins: false,
+      import_map: None,
+      resolve_overrides: Default::default(),
+      tsconfig_paths: Default::default(),
+      resolver: None,
     }
   }
 }
@@ -0,0 +1,26 @@
+use std::str::FromStr;
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Default)]
+pub enum Platform {
+  #[default]
+  Browser,
+  Node,
+}
+
+impl Platform {
+  pub fn is_node(&self) -> bool {
+    matches!(self, Platform::Node)
+  }
+}
+
+impl FromStr for Platform {
+  type Err = String;
+
+  fn from_str(value: &str) -> Result<Self, Self::Err> {
+    match value {
+      "browser" => Ok(Platform::Browser),
+      "node" => Ok(Platform::Node),
+      _ => Err(format!("Invalid platform: {value}")),
+    }
+  }
+}
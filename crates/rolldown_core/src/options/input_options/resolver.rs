@@ -0,0 +1,33 @@
+use std::sync::Arc;
+
+use rolldown_common::ModuleId;
+
+use super::PinFutureBox;
+use crate::UnaryBuildResult;
+
+/// What a [`CustomResolver`] resolves a specifier to.
+#[derive(Debug, Clone, Default)]
+pub struct CustomResolution {
+  pub path: String,
+  /// Modules of a namespace are loaded by the plugins of it, like `virtual:src/main.js`.
+  pub namespace: Option<String>,
+  pub external: bool,
+}
+
+impl CustomResolution {
+  pub(crate) fn into_module_id(self) -> ModuleId {
+    let id = match self.namespace {
+      Some(namespace) => format!("{namespace}:{}", self.path),
+      None => self.path,
+    };
+    ModuleId::new(id, self.external)
+  }
+}
+
+/// Called with the specifier and the importer before anything else resolves them. `None` falls
+/// back to the built-in resolution.
+pub type CustomResolver = Arc<
+  dyn Fn(&str, Option<&str>) -> PinFutureBox<UnaryBuildResult<Option<CustomResolution>>>
+    + Send
+    + Sync,
+>;
@@ -8,6 +8,11 @@ pub enum ExportMode {
   Named,
   Default,
   None,
+  /// `module.exports` is the default export in CJS output, with the named exports attached to it
+  /// as properties, so both `require('lib')` and `require('lib').named` work. The properties are
+  /// assigned once, without live bindings. If the default export is a primitive or `null`,
+  /// `module.exports` has the exports as in `Named` mode instead.
+  Hybrid,
 }
 
 impl ExportMode {
@@ -26,6 +31,10 @@ impl ExportMode {
   pub fn is_none(&self) -> bool {
     matches!(self, ExportMode::None)
   }
+
+  pub fn is_hybrid(&self) -> bool {
+    matches!(self, ExportMode::Hybrid)
+  }
 }
 
 impl FromStr for ExportMode {
@@ -37,6 +46,7 @@ impl FromStr for ExportMode {
       "named" => Ok(ExportMode::Named),
       "default" => Ok(ExportMode::Default),
       "none" => Ok(ExportMode::None),
+      "hybrid" => Ok(ExportMode::Hybrid),
       _ => Err(BuildError::invalid_export_option_value(value.to_string())),
     }
   }
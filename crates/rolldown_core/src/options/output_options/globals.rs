@@ -0,0 +1,49 @@
+use std::collections::HashMap;
+
+/// Globals of popular packages whose names can't be guessed from the package name.
+const KNOWN_GLOBALS: &[(&str, &str)] = &[
+  ("jquery", "$"),
+  ("lodash", "_"),
+  ("lodash-es", "_"),
+  ("react", "React"),
+  ("react-dom", "ReactDOM"),
+  ("react-dom/client", "ReactDOM"),
+  ("three", "THREE"),
+  ("underscore", "_"),
+  ("vue", "Vue"),
+];
+
+/// `@scope/my-pkg` => `scopeMyPkg`. Characters that can't be in a name start a new word.
+fn camelize(specifier: &str) -> String {
+  let mut name = String::with_capacity(specifier.len());
+  let mut is_word_start = false;
+  for c in specifier.chars() {
+    if c.is_ascii_alphanumeric() || c == '_' || c == '$' {
+      if is_word_start && !name.is_empty() {
+        name.push(c.to_ascii_uppercase());
+      } else {
+        name.push(c);
+      }
+      is_word_start = false;
+    } else {
+      is_word_start = true;
+    }
+  }
+  if name.is_empty() || name.starts_with(|c: char| c.is_ascii_digit()) {
+    name.insert(0, '_');
+  }
+  name
+}
+
+/// The global variable an external import refers to in IIFE output. `globals` wins, then the
+/// globals of popular packages, like `React` for `react`, and the camelized package name
+/// otherwise.
+pub fn global_name_of_external(globals: &HashMap<String, String>, specifier: &str) -> String {
+  if let Some(global) = globals.get(specifier) {
+    return global.clone();
+  }
+  KNOWN_GLOBALS
+    .iter()
+    .find(|(package, _)| *package == specifier)
+    .map_or_else(|| camelize(specifier), |(_, global)| global.to_string())
+}
@@ -0,0 +1,57 @@
+use std::sync::Arc;
+
+use derivative::Derivative;
+
+pub type ManualChunkFn = Arc<dyn Fn(&str) -> bool + Send + Sync>;
+
+/// Which modules are put into a manual chunk, by their ids.
+#[derive(Derivative, Clone)]
+#[derivative(Debug)]
+pub enum ManualChunkTest {
+  /// Like `**/node_modules/**`. `*` and `?` don't match `/`, while `**` matches any directories.
+  Glob(String),
+  Fn(#[derivative(Debug = "ignore")] ManualChunkFn),
+}
+
+/// Modules matching the test are put into the chunk named `name`, regardless of how they are
+/// imported, like `manualChunks` of rollup.
+#[derive(Debug, Clone)]
+pub struct ManualChunk {
+  pub name: String,
+  pub test: ManualChunkTest,
+}
+
+impl ManualChunk {
+  pub fn new(name: impl Into<String>, test: ManualChunkTest) -> Self {
+    Self {
+      name: name.into(),
+      test,
+    }
+  }
+
+  pub fn matches(&self, module_id: &str) -> bool {
+    match &self.test {
+      ManualChunkTest::Glob(glob) => glob_match(glob.as_bytes(), module_id.as_bytes()),
+      ManualChunkTest::Fn(test) => test(module_id),
+    }
+  }
+}
+
+fn glob_match(pattern: &[u8], text: &[u8]) -> bool {
+  match pattern {
+    [] => text.is_empty(),
+    [b'*', b'*', rest @ ..] => {
+      // `**/a` matches `a` as well.
+      let rest_without_slash = rest.strip_prefix(b"/");
+      (0..=text.len()).any(|start| {
+        glob_match(rest, &text[start..])
+          || rest_without_slash.map_or(false, |rest| glob_match(rest, &text[start..]))
+      })
+    }
+    [b'*', rest @ ..] => (0..=text.len())
+      .take_while(|&end| !text[..end].contains(&b'/'))
+      .any(|end| glob_match(rest, &text[end..])),
+    [b'?', rest @ ..] => matches!(text, [c, text @ ..] if *c != b'/' && glob_match(rest, text)),
+    [c, rest @ ..] => matches!(text, [t, text @ ..] if t == c && glob_match(rest, text)),
+  }
+}
@@ -0,0 +1,19 @@
+#[derive(Debug, Default, Clone, Copy)]
+pub struct MinifyOptions {
+  /// Rewrite the syntax into shorter equivalent forms, such as joining adjacent declarations.
+  pub syntax: bool,
+  /// Rename top-level bindings to short names. Exported names are kept, since they are the API of
+  /// the chunk.
+  pub identifiers: bool,
+  /// Declare strings repeated in the chunk once as constants, if it makes the chunk smaller.
+  pub hoist_strings: bool,
+  /// Inline top-level functions called only once into the call. It only handles functions with a
+  /// single `return`.
+  pub inline_functions: bool,
+  /// Read member chains like `this.state.items` repeated in a function once into a local. Getters
+  /// could return another value on every read, so it's opt-in.
+  pub hoist_common_subexpressions: bool,
+  /// Inline members of local numeric enums read only as `E.A`, and remove the enums. Reading
+  /// `E[x]` could get the name of any member, which keeps the enum.
+  pub enums: bool,
+}
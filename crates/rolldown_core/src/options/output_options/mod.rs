@@ -1,9 +1,16 @@
-use std::str::FromStr;
+use std::{collections::HashMap, path::PathBuf, str::FromStr};
 
 use derivative::Derivative;
 
 mod export_mode;
 pub use export_mode::*;
+mod globals;
+pub use globals::*;
+mod manual_chunks;
+pub use manual_chunks::*;
+mod minify;
+pub use minify::*;
+pub use rolldown_common::Target;
 
 use self::file_name::FileNameTemplate;
 
@@ -11,6 +18,11 @@ use self::file_name::FileNameTemplate;
 pub enum ModuleFormat {
   Esm,
   Cjs,
+  /// `System.register` modules, loaded by SystemJS.
+  System,
+  /// A script running the chunk in a function, for `<script>` tags. Exports of the entry are
+  /// assigned to the global variable `name`, and external imports are read from globals.
+  Iife,
   // AMD,
   // UMD,
 }
@@ -23,6 +35,14 @@ impl ModuleFormat {
   pub fn is_cjs(self) -> bool {
     self == ModuleFormat::Cjs
   }
+
+  pub fn is_system(self) -> bool {
+    self == ModuleFormat::System
+  }
+
+  pub fn is_iife(self) -> bool {
+    self == ModuleFormat::Iife
+  }
 }
 
 impl FromStr for ModuleFormat {
@@ -32,6 +52,8 @@ impl FromStr for ModuleFormat {
     match value {
       "esm" => Ok(ModuleFormat::Esm),
       "cjs" => Ok(ModuleFormat::Cjs),
+      "system" | "systemjs" => Ok(ModuleFormat::System),
+      "iife" => Ok(ModuleFormat::Iife),
       _ => Err(format!("Invalid module format: {value}")),
     }
   }
@@ -44,6 +66,52 @@ pub struct BuildOutputOptions {
   pub chunk_file_names: FileNameTemplate,
   pub format: ModuleFormat,
   pub export_mode: ExportMode,
+  pub minify: MinifyOptions,
+  /// Create a chunk for every module, keeping the directory structure of the inputs.
+  pub preserve_modules: bool,
+  /// Modules matching a manual chunk are put into it. The first matching one wins.
+  pub manual_chunks: Vec<ManualChunk>,
+  pub target: Target,
+  /// The directory assets are written to.
+  pub dir: PathBuf,
+  /// Generate a source map for every chunk and its CSS, referenced by a `sourceMappingURL` comment.
+  pub sourcemap: bool,
+  /// Written to the `sourceRoot` of source maps. If it's set, `sources` are relative to `cwd`, so
+  /// they could be resolved against the root. Otherwise they are relative to the source map. The
+  /// contents of sources are embedded either way.
+  pub source_root: Option<String>,
+  /// Write the import graph of the modules as JSON to this path, see `Graph::import_graph_json`.
+  /// It's written even if the assets aren't, since it describes the inputs.
+  pub graph_file: Option<PathBuf>,
+  /// Write the files of every chunk as JSON to this path, for servers rendering the tags that load
+  /// them, see `Bundle::manifest_json`.
+  pub manifest_file: Option<PathBuf>,
+  /// Names of files copied by the file loader. The extension of the file is appended.
+  pub asset_file_names: FileNameTemplate,
+  /// Prepended to the URLs of copied files. Otherwise the URLs are relative to the chunk.
+  pub public_path: Option<String>,
+  /// Dynamic imports of ES chunks preload the chunks the imported one depends on statically, so
+  /// they are fetched in parallel instead of one after another.
+  pub module_preload: bool,
+  /// A global function called with the error and a retry function if a dynamic import of a chunk
+  /// fails, like `onChunkLoadError(error, retry)`. Its result is what the import resolves to. CJS
+  /// output doesn't fetch chunks, so it's only used by the other formats.
+  pub chunk_load_error_handler: Option<String>,
+  /// The global variable the exports of the entry are assigned to in IIFE output.
+  pub name: Option<String>,
+  /// The globals external imports refer to in IIFE output, keyed by their specifiers. Externals
+  /// without one default to `global_name_of_external`.
+  pub globals: HashMap<String, String>,
+  /// Modules imported for built-ins the target lacks if the chunk uses them, keyed by the
+  /// built-ins, like `{ "Object.fromEntries": "core-js/actual/object/from-entries" }`. Only the
+  /// built-ins known to the bundler are detected.
+  pub polyfills: HashMap<String, String>,
+  /// Prepended to every JS chunk, after the shebang and outside of the wrapper of the format, like
+  /// a license comment.
+  pub banner: Option<String>,
+  /// Hash chunks without their legal comments and the banner, so changing only those doesn't change
+  /// the filenames. They are still in the output.
+  pub hash_ignores_comments: bool,
 }
 
 impl Default for BuildOutputOptions {
@@ -53,6 +121,24 @@ impl Default for BuildOutputOptions {
       chunk_file_names: FileNameTemplate::from("[name]-[hash].js".to_string()),
       format: ModuleFormat::Esm,
       export_mode: ExportMode::Auto,
+      minify: Default::default(),
+      preserve_modules: false,
+      manual_chunks: vec![],
+      target: Default::default(),
+      dir: PathBuf::from("dist"),
+      sourcemap: false,
+      source_root: None,
+      graph_file: None,
+      manifest_file: None,
+      asset_file_names: FileNameTemplate::from("[name]-[hash]".to_string()),
+      public_path: None,
+      module_preload: false,
+      chunk_load_error_handler: None,
+      name: None,
+      globals: Default::default(),
+      polyfills: Default::default(),
+      banner: None,
+      hash_ignores_comments: false,
     }
   }
 }
@@ -78,14 +164,22 @@ pub mod file_name {
   #[derive(Debug, Default)]
   pub struct RenderOptions<'me> {
     pub name: Option<&'me str>,
+    pub hash: Option<&'me str>,
   }
 
   impl FileNameTemplate {
+    pub fn has_hash_pattern(&self) -> bool {
+      self.template.contains("[hash]")
+    }
+
     pub fn render(&self, options: RenderOptions) -> String {
       let mut tmp = self.template.clone();
       if let Some(name) = options.name {
         tmp = tmp.replace("[name]", name);
       }
+      if let Some(hash) = options.hash {
+        tmp = tmp.replace("[hash]", hash);
+      }
       tmp
     }
   }
@@ -1,9 +1,11 @@
-use std::sync::Arc;
+use std::{path::Path, sync::Arc};
 
 use rolldown_common::{Loader, ModuleId};
 use rolldown_plugin::{
-  BuildPlugin, Context, LoadArgs, LoadReturn, ResolveArgs, ResolveReturn, TransformArgs,
+  BuildPlugin, BuildStartReturn, Context, LoadArgs, LoadReturn, NamespaceResolveOptions,
+  ResolveArgs, ResolveReturn, TransformArgs,
 };
+use sugar_path::SugarPath;
 use tokio::sync::RwLock;
 
 use crate::UnaryBuildResult;
@@ -13,17 +15,32 @@ pub(crate) type SharedBuildPluginDriver = Arc<RwLock<BuildPluginDriver>>;
 #[derive(Debug, Default)]
 pub(crate) struct BuildPluginDriver {
   pub plugins: Vec<Box<dyn BuildPlugin>>,
+  namespaces: Vec<NamespaceResolveOptions>,
 }
 
 impl BuildPluginDriver {
   pub(crate) fn new(plugins: Vec<Box<dyn BuildPlugin>>) -> Self {
-    Self { plugins }
+    let namespaces = plugins
+      .iter()
+      .flat_map(|plugin| plugin.namespaces())
+      .collect();
+    Self {
+      plugins,
+      namespaces,
+    }
   }
 
   pub(crate) fn into_shared(self) -> SharedBuildPluginDriver {
     Arc::new(RwLock::new(self))
   }
 
+  pub(crate) async fn build_start(&self) -> BuildStartReturn {
+    for plugin in &self.plugins {
+      plugin.build_start(&mut Context::new()).await?;
+    }
+    Ok(())
+  }
+
   pub(crate) async fn load(&self, id: &ModuleId) -> LoadReturn {
     let mut load_args = LoadArgs { id };
     for plugin in &self.plugins {
@@ -45,6 +62,42 @@ impl BuildPluginDriver {
     Ok(None)
   }
 
+  /// `css-module:dir/a.css` imports `./b` as `css-module:dir/b.css` if a plugin loads it. `None`
+  /// if the specifier isn't relative or the importer isn't in a namespace of the plugins.
+  pub(crate) async fn resolve_in_namespace(
+    &self,
+    importer: Option<&ModuleId>,
+    specifier: &str,
+  ) -> UnaryBuildResult<Option<ModuleId>> {
+    let Some(importer) = importer.filter(|_| specifier.starts_with('.')) else {
+      return Ok(None);
+    };
+    let Some((options, importer_path)) = self.namespaces.iter().find_map(|options| {
+      let path = importer
+        .id()
+        .strip_prefix(options.namespace.as_str())?
+        .strip_prefix(':')?;
+      Some((options, path))
+    }) else {
+      return Ok(None);
+    };
+    let path = Path::new(importer_path)
+      .parent()
+      .unwrap_or_else(|| Path::new(""))
+      .join(specifier)
+      .normalize();
+    let id = format!("{}:{}", options.namespace, path.display());
+    let exts = std::iter::once("").chain(options.resolve_extensions.iter().map(String::as_str));
+    for ext in exts {
+      let candidate = ModuleId::new(format!("{id}{ext}"), false);
+      if self.load(&candidate).await?.is_some() {
+        return Ok(Some(candidate));
+      }
+    }
+    // No plugin loads any of them, which is reported when the module is loaded.
+    Ok(Some(ModuleId::new(id, false)))
+  }
+
   pub(crate) async fn transform(
     &self,
     id: &ModuleId,
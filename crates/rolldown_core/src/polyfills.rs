@@ -0,0 +1,156 @@
+use rustc_hash::FxHashSet;
+use swc_core::{
+  common::{util::take::Take, SyntaxContext},
+  ecma::{
+    ast,
+    utils::quote_str,
+    visit::{noop_visit_type, Visit, VisitWith},
+  },
+};
+
+use crate::{Chunk, FinalizeBundleContext, Target};
+
+/// Built-ins that can't be lowered syntactically, with the first target that has them. The ones of
+/// a prototype are detected by calls of the method on anything, like `list.flat()`.
+const FEATURES: &[(&str, Target)] = &[
+  ("Array.from", Target::Es2015),
+  ("Array.prototype.find", Target::Es2015),
+  ("Object.assign", Target::Es2015),
+  ("Array.prototype.includes", Target::Es2016),
+  ("Object.entries", Target::Es2017),
+  ("Object.values", Target::Es2017),
+  ("String.prototype.padEnd", Target::Es2017),
+  ("String.prototype.padStart", Target::Es2017),
+  ("Promise.prototype.finally", Target::Es2018),
+  ("Array.prototype.flat", Target::Es2019),
+  ("Array.prototype.flatMap", Target::Es2019),
+  ("Object.fromEntries", Target::Es2019),
+  ("String.prototype.trimEnd", Target::Es2019),
+  ("String.prototype.trimStart", Target::Es2019),
+  ("Promise.allSettled", Target::Es2020),
+  ("String.prototype.matchAll", Target::Es2020),
+  ("Promise.any", Target::Es2021),
+  ("String.prototype.replaceAll", Target::Es2021),
+  ("Array.prototype.at", Target::Es2022),
+  ("Object.hasOwn", Target::Es2022),
+];
+
+enum Usage<'a> {
+  /// `Object.fromEntries`, read from the global `Object`
+  Static { global: &'a str, prop: &'a str },
+  /// `x.flat()`
+  Method(&'a str),
+}
+
+fn usage_of(feature: &str) -> Option<Usage> {
+  match feature.split_once(".prototype.") {
+    Some((_, method)) => Some(Usage::Method(method)),
+    None => {
+      let (global, prop) = feature.split_once('.')?;
+      Some(Usage::Static { global, prop })
+    }
+  }
+}
+
+fn prop_name(prop: &ast::MemberProp) -> Option<&str> {
+  match prop {
+    ast::MemberProp::Ident(ident) => Some(&ident.sym),
+    ast::MemberProp::Computed(computed) => match &*computed.expr {
+      ast::Expr::Lit(ast::Lit::Str(str)) => Some(&str.value),
+      _ => None,
+    },
+    ast::MemberProp::PrivateName(_) => None,
+  }
+}
+
+struct UsageDetector<'a> {
+  /// Features the target lacks that have a polyfill
+  features: Vec<(&'a str, Usage<'a>)>,
+  unresolved_ctxt: SyntaxContext,
+  used: FxHashSet<&'a str>,
+}
+
+impl Visit for UsageDetector<'_> {
+  noop_visit_type!();
+
+  fn visit_member_expr(&mut self, member: &ast::MemberExpr) {
+    if let (ast::Expr::Ident(obj), Some(name)) = (&*member.obj, prop_name(&member.prop)) {
+      // A local binding named `Object` isn't the global one.
+      if obj.span.ctxt == self.unresolved_ctxt {
+        for (feature, usage) in &self.features {
+          let is_used = match usage {
+            Usage::Static { global, prop } => *global == &*obj.sym && *prop == name,
+            Usage::Method(_) => false,
+          };
+          if is_used {
+            self.used.insert(*feature);
+          }
+        }
+      }
+    }
+    member.visit_children_with(self);
+  }
+
+  fn visit_call_expr(&mut self, call: &ast::CallExpr) {
+    let callee = call.callee.as_expr().and_then(|callee| callee.as_member());
+    if let Some(name) = callee.and_then(|callee| prop_name(&callee.prop)) {
+      for (feature, usage) in &self.features {
+        if matches!(usage, Usage::Method(method) if *method == name) {
+          self.used.insert(*feature);
+        }
+      }
+    }
+    call.visit_children_with(self);
+  }
+}
+
+impl Chunk {
+  /// Imports the modules of `polyfills` for built-ins used by the chunk that the target lacks,
+  /// like `import "core-js/actual/object/from-entries"` for `Object.fromEntries` with ES2018. They
+  /// come before any other import, so they run first. A module shared by several features is
+  /// imported once.
+  pub(crate) fn inject_polyfills(&mut self, ctx: &FinalizeBundleContext) {
+    let output_options = ctx.output_options;
+    if output_options.polyfills.is_empty() {
+      return;
+    }
+    let features = FEATURES
+      .iter()
+      .filter(|(feature, target)| {
+        output_options.target < *target && output_options.polyfills.contains_key(*feature)
+      })
+      .filter_map(|(feature, _)| Some((*feature, usage_of(feature)?)))
+      .collect::<Vec<_>>();
+    if features.is_empty() {
+      return;
+    }
+
+    let mut detector = UsageDetector {
+      features,
+      unresolved_ctxt: ctx.unresolved_ctxt,
+      used: FxHashSet::default(),
+    };
+    ctx
+      .modules
+      .values()
+      .filter_map(|m| m.as_norm())
+      .filter(|m| m.is_included() && m.css.is_none())
+      .for_each(|m| m.ast.visit_with(&mut detector));
+
+    let mut imported = FxHashSet::default();
+    let imports = FEATURES
+      .iter()
+      .filter(|(feature, _)| detector.used.contains(feature))
+      .map(|(feature, _)| &output_options.polyfills[*feature])
+      .filter(|module| imported.insert(*module))
+      .map(|module| {
+        ast::ModuleItem::ModuleDecl(ast::ModuleDecl::Import(ast::ImportDecl {
+          src: Box::new(quote_str!(module.as_str())),
+          specifiers: vec![],
+          ..ast::ImportDecl::dummy()
+        }))
+      })
+      .collect::<Vec<_>>();
+    self.before_module_items.splice(0..0, imports);
+  }
+}
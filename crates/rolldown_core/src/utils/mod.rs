@@ -11,6 +11,10 @@ use rolldown_common::Loader;
 pub fn extract_loader_by_path(p: &Path) -> Loader {
   p.extension()
     .and_then(|ext| ext.to_str())
+    .map(|ext| match ext {
+      "wasm" => "file",
+      ext => ext,
+    })
     // Unknown extension should treat like JavaScript for Rollup-compatibility
     .map(Loader::from_str)
     .map(|l| l.unwrap_or(Loader::Js))
@@ -1,5 +1,6 @@
 use once_cell::sync::Lazy;
 use phf::{phf_set, Set};
+use swc_core::ecma::atoms::JsWord;
 
 pub static RESERVED_NAMES: Set<&'static str> = phf_set! {
     "await",
@@ -57,7 +58,7 @@ fn starts_with_digit(s: &str) -> bool {
   s.chars().next().map_or(false, |c| c.is_ascii_digit())
 }
 
-fn need_escape(s: &str) -> bool {
+pub(crate) fn need_escape(s: &str) -> bool {
   starts_with_digit(s) || RESERVED_NAMES.contains(s) || s == "arguments"
 }
 
@@ -80,3 +81,40 @@ pub fn make_legal(value: &str) -> String {
 
   ret
 }
+
+const MANGLED_HEAD: &[u8] = b"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$";
+const MANGLED_TAIL: &[u8] = b"abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ_$0123456789";
+
+/// The `index`th shortest identifier: `a`, `b`, ..., `$`, `aa`, `ba`, ... It may need escaping,
+/// like `do`, which `mangled_names` skips.
+pub(crate) fn mangled_name(index: usize) -> String {
+  let mut name = String::new();
+  name.push(MANGLED_HEAD[index % MANGLED_HEAD.len()] as char);
+  let mut rest = index / MANGLED_HEAD.len();
+  while rest > 0 {
+    rest -= 1;
+    name.push(MANGLED_TAIL[rest % MANGLED_TAIL.len()] as char);
+    rest /= MANGLED_TAIL.len();
+  }
+  name
+}
+
+/// The shortest identifiers, in order, without the names that `need_escape`.
+pub(crate) fn mangled_names() -> impl Iterator<Item = JsWord> {
+  (0..)
+    .map(mangled_name)
+    .filter(|name| !need_escape(name))
+    .map(JsWord::from)
+}
+
+/// Bytes saved by replacing `count` copies of a `value_len` bytes expression with a `name_len`
+/// bytes name, counting `name=value` of the declaration and the `overhead` bytes around it
+/// against it.
+pub(crate) fn saved_bytes(
+  count: usize,
+  value_len: usize,
+  name_len: usize,
+  overhead: usize,
+) -> isize {
+  (count * value_len) as isize - (count * name_len + name_len + 1 + value_len + overhead) as isize
+}
@@ -13,6 +13,11 @@ pub(crate) fn preset_of_used_names(format: &ModuleFormat) -> Vec<JsWord> {
       preset.push("__filename".into());
       preset.push("__dirname".into());
     }
+    ModuleFormat::System => {
+      preset.push("System".into());
+    }
+    // Globals of externals are parameters of the function, renamed if they conflict.
+    ModuleFormat::Iife => {}
   }
 
   preset
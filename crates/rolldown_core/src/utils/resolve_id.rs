@@ -1,15 +1,45 @@
 use rolldown_common::ModuleId;
+use rolldown_error::ErrorKind;
 use rolldown_plugin::ResolveArgs;
-use rolldown_resolver::Resolver;
+use rolldown_resolver::{ImportKind, Resolver};
 use sugar_path::AsPath;
 
-use crate::{SharedBuildPluginDriver, UnaryBuildResult};
+use crate::{CustomResolver, SharedBuildPluginDriver, UnaryBuildResult};
 
+/// Without `preserve_symlinks`, a module reached through a symlink is the module at its real path,
+/// so a package linked into several places is bundled once. Ids that aren't paths are kept.
+fn real_path(id: String, preserve_symlinks: bool) -> String {
+  if preserve_symlinks {
+    return id;
+  }
+  match std::fs::canonicalize(&id) {
+    Ok(path) => path.to_string_lossy().to_string(),
+    Err(_) => id,
+  }
+}
+
+/// What the `resolver` of the input options resolves `specifier` to, if one is set and handles it.
+/// It's final, so import maps, externals and the checks of built-in resolution are skipped.
+pub(crate) async fn resolve_id_by_custom_resolver(
+  resolver: Option<&CustomResolver>,
+  specifier: &str,
+  importer: Option<&ModuleId>,
+) -> UnaryBuildResult<Option<ModuleId>> {
+  let Some(resolver) = resolver else {
+    return Ok(None);
+  };
+  let resolution = resolver(specifier, importer.map(|id| id.as_ref())).await?;
+  Ok(resolution.map(|resolution| resolution.into_module_id()))
+}
+
+/// `kind` is how `specifier` is reached, so a dual package is bundled as its ES module when imported
+/// and as its CommonJS module when required. Plugins resolve both alike.
 pub(crate) async fn resolve_id(
   resolver: &Resolver,
   specifier: &str,
   importer: Option<&ModuleId>,
-  _preserve_symlinks: bool,
+  kind: ImportKind,
+  preserve_symlinks: bool,
   plugin_driver: &SharedBuildPluginDriver,
 ) -> UnaryBuildResult<Option<ModuleId>> {
   let plugin_result = plugin_driver
@@ -21,20 +51,43 @@ pub(crate) async fn resolve_id(
     })
     .await?;
 
-  if plugin_result.is_some() {
-    return Ok(
-      plugin_result.map(|plugin_result| ModuleId::new(plugin_result.id, plugin_result.external)),
-    );
+  if let Some(plugin_result) = plugin_result {
+    let id = if plugin_result.external {
+      plugin_result.id
+    } else {
+      real_path(plugin_result.id, preserve_symlinks)
+    };
+    return Ok(Some(ModuleId::new(id, plugin_result.external)));
   }
 
-  let importer = importer.map(|id| id.as_ref());
-  // external modules (non-entry modules that start with neither '.' or '/')
-  // are skipped at this stage.
-  if importer.is_some() && !specifier.as_path().is_absolute() && !specifier.starts_with('.') {
-    return Ok(None);
+  // Modules of a virtual namespace don't exist on disk, nor do their relative imports.
+  let resolved = plugin_driver
+    .read()
+    .await
+    .resolve_in_namespace(importer, specifier)
+    .await?;
+  if resolved.is_some() {
+    return Ok(resolved);
   }
 
-  let resolved = resolver.resolve(importer, specifier)?;
+  let importer = importer.map(|id| id.as_ref());
+  // Packages are resolved like relative imports, through workspaces, `exports` and entry fields.
+  // Ones that aren't installed, like optional peer dependencies, are left as externals.
+  let is_bare = !specifier.as_path().is_absolute() && !specifier.starts_with('.');
+  let resolved = match resolver.resolve_with_kind(importer, specifier, kind) {
+    Ok(resolved) => resolved,
+    Err(err)
+      if is_bare
+        && importer.is_some()
+        && matches!(err.kind, ErrorKind::UnresolvedImport { .. }) =>
+    {
+      return Ok(None);
+    }
+    Err(err) => return Err(err),
+  };
 
-  Ok(Some(ModuleId::new(resolved, false)))
+  Ok(Some(ModuleId::new(
+    real_path(resolved, preserve_symlinks),
+    false,
+  )))
 }
@@ -69,6 +69,18 @@ impl Error {
     })
   }
 
+  pub fn namespace_conflict(
+    binding: impl Into<StaticStr>,
+    reexporting_module: PathBuf,
+    sources: Vec<PathBuf>,
+  ) -> Self {
+    Self::with_kind(ErrorKind::NamespaceConflict {
+      reexporting_module,
+      binding: binding.into(),
+      sources,
+    })
+  }
+
   pub fn unresolved_entry(unresolved_id: impl AsRef<Path>) -> Self {
     Self::with_kind(ErrorKind::UnresolvedEntry {
       unresolved_id: unresolved_id.as_ref().to_path_buf(),
@@ -133,6 +145,10 @@ impl Error {
     })
   }
 
+  pub fn missing_name_option_for_iife_export() -> Self {
+    Self::with_kind(ErrorKind::MissingNameOptionForIifeExport)
+  }
+
   // --- rolldown specific
 
   pub fn parse_js_failed(
@@ -145,6 +161,43 @@ impl Error {
     })
   }
 
+  pub fn invalid_package_exports(
+    specifier: impl Into<StaticStr>,
+    importer: PathBuf,
+    package_json: PathBuf,
+    reason: impl Into<StaticStr>,
+  ) -> Self {
+    Self::with_kind(ErrorKind::InvalidPackageExports {
+      specifier: specifier.into(),
+      importer,
+      package_json,
+      reason: reason.into(),
+    })
+  }
+
+  pub fn invalid_browserslist_config(config: PathBuf, reason: impl Into<StaticStr>) -> Self {
+    Self::with_kind(ErrorKind::InvalidBrowserslistConfig {
+      config,
+      reason: reason.into(),
+    })
+  }
+
+  pub fn types_only_package(
+    specifier: impl Into<StaticStr>,
+    importer: PathBuf,
+    types_package: PathBuf,
+  ) -> Self {
+    Self::with_kind(ErrorKind::TypesOnlyPackage {
+      specifier: specifier.into(),
+      importer,
+      types_package,
+    })
+  }
+
+  pub fn unresolvable_dynamic_require(importer: PathBuf) -> Self {
+    Self::with_kind(ErrorKind::UnresolvableDynamicRequire { importer })
+  }
+
   // --- TODO: we should remove following errors
 
   pub fn io_error(e: std::io::Error) -> Self {
@@ -71,3 +71,7 @@ pub const VALIDATION_ERROR: &str = "VALIDATION_ERROR";
 
 pub const PANIC: &str = "PANIC";
 pub const IO_ERROR: &str = "IO_ERROR";
+pub const INVALID_PACKAGE_EXPORTS: &str = "INVALID_PACKAGE_EXPORTS";
+pub const INVALID_BROWSERSLIST_CONFIG: &str = "INVALID_BROWSERSLIST_CONFIG";
+pub const TYPES_ONLY_PACKAGE: &str = "TYPES_ONLY_PACKAGE";
+pub const UNRESOLVABLE_DYNAMIC_REQUIRE: &str = "UNRESOLVABLE_DYNAMIC_REQUIRE";
@@ -32,6 +32,11 @@ pub enum ErrorKind {
     binding: StaticStr,
     sources: Vec<PathBuf>,
   },
+  NamespaceConflict {
+    reexporting_module: PathBuf,
+    binding: StaticStr,
+    sources: Vec<PathBuf>,
+  },
   CircularDependency(Vec<PathBuf>),
   InvalidExportOptionValue(StaticStr),
   IncompatibleExportOptionValue {
@@ -52,6 +57,7 @@ pub enum ErrorKind {
     specifier: StaticStr,
     importer: PathBuf,
   },
+  MissingNameOptionForIifeExport,
 
   // --- Rolldown specific
   ParseJsFailed {
@@ -59,6 +65,34 @@ pub enum ErrorKind {
     source: swc_core::ecma::parser::error::Error,
   },
 
+  /// The `exports` of the package is malformed, which is likely why the specifier is unresolvable.
+  InvalidPackageExports {
+    specifier: StaticStr,
+    importer: PathBuf,
+    package_json: PathBuf,
+    reason: StaticStr,
+  },
+
+  /// The browserslist config used to infer the target can't be mapped to a target. It's reported
+  /// as a warning, and the default target is used.
+  InvalidBrowserslistConfig {
+    config: PathBuf,
+    reason: StaticStr,
+  },
+
+  /// A value is imported from a package that only has type declarations in `@types`, so there's no
+  /// runtime code to bundle.
+  TypesOnlyPackage {
+    specifier: StaticStr,
+    importer: PathBuf,
+    types_package: PathBuf,
+  },
+
+  /// `require()` is called with a non-literal argument, which is left for the runtime to resolve.
+  UnresolvableDynamicRequire {
+    importer: PathBuf,
+  },
+
   /// This error means that rolldown panics because unrecoverable error happens.
   ///
   /// This error is also used to emulate plain error `throw`ed by rollup.
@@ -103,8 +137,18 @@ impl Display for ErrorKind {
         format_quoted_strings(&sources.iter().map(|p| p.may_display_relative()).collect::<Vec<_>>()),
         used_module.may_display_relative(),
       ),
+      ErrorKind::NamespaceConflict {
+        binding,
+        reexporting_module,
+        sources,
+      } => write!(
+        f,
+        "Conflicting namespaces: \"{}\" re-exports \"{binding}\" from one of the modules {} (will be ignored).",
+        reexporting_module.may_display_relative(),
+        format_quoted_strings(&sources.iter().map(|p| p.may_display_relative()).collect::<Vec<_>>()),
+      ),
       ErrorKind::CircularDependency(path) => write!(f, "Circular dependency: {}", path.iter().map(|p| p.may_display_relative()).collect::<Vec<_>>().join(" -> ")),
-      ErrorKind::InvalidExportOptionValue(value) =>  write!(f, r#""output.exports" must be "default", "named", "none", "auto", or left unspecified (defaults to "auto"), received "{value}"."#),
+      ErrorKind::InvalidExportOptionValue(value) =>  write!(f, r#""output.exports" must be "default", "named", "none", "hybrid", "auto", or left unspecified (defaults to "auto"), received "{value}"."#),
       ErrorKind::IncompatibleExportOptionValue { option_value, exported_keys, entry_module } => {
         let mut exported_keys = exported_keys.iter().collect::<Vec<_>>();
         exported_keys.sort();
@@ -113,12 +157,35 @@ impl Display for ErrorKind {
       ErrorKind::ShimmedExport { binding, exporter } => write!(f, r#"Missing export "{binding}" has been shimmed in module "{}"."#, exporter.may_display_relative()),
       ErrorKind::CircularReexport { export_name, exporter } => write!(f, r#""{export_name}" cannot be exported from "{}" as it is a reexport that references itself."#, exporter.may_display_relative()),
       ErrorKind::UnresolvedImport { specifier, importer } => write!(f, r#"Could not resolve "{specifier}" from "{}""#, importer.may_display_relative()),
+      ErrorKind::MissingNameOptionForIifeExport => write!(f, r#"If you do not supply "output.name", you may not be able to access the exports of an IIFE bundle."#),
       // Rolldown specific
       ErrorKind::Panic { source } => source.fmt(f),
       ErrorKind::Napi { status, reason } => write!(f, "Napi error: {} {}", status, reason),
       ErrorKind::ParseJsFailed { source_file, .. } => {
         write!(f, "Parse failed: {}", source_file.name )
       }
+      ErrorKind::InvalidPackageExports { specifier, importer, package_json, reason } => write!(
+        f,
+        r#"Could not resolve "{specifier}" from "{}", because "exports" in "{}" is invalid: {reason}"#,
+        importer.may_display_relative(),
+        package_json.may_display_relative(),
+      ),
+      ErrorKind::InvalidBrowserslistConfig { config, reason } => write!(
+        f,
+        r#"Could not infer the target from "{}": {reason}"#,
+        config.may_display_relative(),
+      ),
+      ErrorKind::TypesOnlyPackage { specifier, importer, types_package } => write!(
+        f,
+        r#""{specifier}" imported by "{}" only has type declarations in "{}", so it's treated as external."#,
+        importer.may_display_relative(),
+        types_package.may_display_relative(),
+      ),
+      ErrorKind::UnresolvableDynamicRequire { importer } => write!(
+        f,
+        r#""{}" calls "require" with a non-literal argument, so it's left as it is to be resolved at runtime."#,
+        importer.may_display_relative(),
+      ),
       ErrorKind::IoError(e) => e.fmt(f),
     }
   }
@@ -138,15 +205,21 @@ impl ErrorKind {
       ErrorKind::ExternalEntry { .. } => error_code::UNRESOLVED_ENTRY,
       ErrorKind::MissingExport { .. } => error_code::MISSING_EXPORT,
       ErrorKind::AmbiguousExternalNamespaces { .. } => error_code::AMBIGUOUS_EXTERNAL_NAMESPACES,
+      ErrorKind::NamespaceConflict { .. } => error_code::NAMESPACE_CONFLICT,
       ErrorKind::CircularDependency(_) => error_code::CIRCULAR_DEPENDENCY,
       ErrorKind::InvalidExportOptionValue(_) => error_code::INVALID_EXPORT_OPTION,
       ErrorKind::IncompatibleExportOptionValue { .. } => error_code::INVALID_EXPORT_OPTION,
       ErrorKind::ShimmedExport { .. } => error_code::SHIMMED_EXPORT,
       ErrorKind::CircularReexport { .. } => error_code::CIRCULAR_REEXPORT,
       ErrorKind::UnresolvedImport { .. } => error_code::UNRESOLVED_IMPORT,
+      ErrorKind::MissingNameOptionForIifeExport => error_code::MISSING_NAME_OPTION_FOR_IIFE_EXPORT,
       // Rolldown specific
       ErrorKind::Panic { .. } => error_code::PANIC,
       ErrorKind::IoError(_) => error_code::IO_ERROR,
+      ErrorKind::InvalidPackageExports { .. } => error_code::INVALID_PACKAGE_EXPORTS,
+      ErrorKind::InvalidBrowserslistConfig { .. } => error_code::INVALID_BROWSERSLIST_CONFIG,
+      ErrorKind::TypesOnlyPackage { .. } => error_code::TYPES_ONLY_PACKAGE,
+      ErrorKind::UnresolvableDynamicRequire { .. } => error_code::UNRESOLVABLE_DYNAMIC_REQUIRE,
       ErrorKind::Napi {
         status: _,
         reason: _,
@@ -37,7 +37,7 @@ export interface OutputOptions {
   chunkFileNames?: string
   dir?: string
   exports?: 'default' | 'named' | 'none' | 'auto'
-  format?: 'esm' | 'cjs'
+  format?: 'esm' | 'cjs' | 'system' | 'iife'
 }
 export interface OutputChunk {
   code: string
@@ -10,4 +10,7 @@ pub use tsconfig::*;
 #[derivative(Debug)]
 pub struct BuiltinsOptions {
   pub tsconfig: Option<TsConfigOptions>,
+  pub jsx_side_effects: Option<bool>,
+  /// "transform" or "preserve"
+  pub jsx: Option<String>,
 }
@@ -1,4 +1,4 @@
-use std::{collections::HashMap, path::PathBuf};
+use std::{collections::HashMap, path::PathBuf, str::FromStr};
 
 use napi_derive::*;
 use rolldown::default_warning_handler;
@@ -50,6 +50,16 @@ pub struct InputOptions {
   // extra
   pub cwd: String,
   pub builtins: BuiltinsOptions,
+  pub concurrency: Option<u32>,
+  /// "browser" or "node"
+  pub platform: Option<String>,
+  pub prefix_node_builtins: Option<bool>,
+  /// Path of an import map file, relative to `cwd`
+  pub import_map: Option<String>,
+  /// Bare specifiers forced to paths relative to `cwd`, like `{ "lodash": "./vendor/lodash" }`
+  pub resolve_overrides: Option<HashMap<String, String>>,
+  /// `paths` of the tsconfig, with targets relative to `cwd` as its `baseUrl`
+  pub tsconfig_paths: Option<HashMap<String, Vec<String>>>,
 }
 
 pub fn resolve_input_options(
@@ -66,6 +76,21 @@ pub fn resolve_input_options(
 
   let is_external = resolve_external(opts.external)?;
 
+  let import_map = opts
+    .import_map
+    .map(|path| {
+      let path = cwd.join(path);
+      let json = std::fs::read_to_string(&path)
+        .map_err(|e| napi::Error::from_reason(format!("Read {}: {e}", path.display())))?;
+      rolldown::ImportMap::from_json(&json, path.parent().unwrap())
+        .map_err(napi::Error::from_reason)
+    })
+    .transpose()?;
+
+  let resolve_overrides =
+    rolldown::ResolveOverrides::new(opts.resolve_overrides.unwrap_or_default(), &cwd);
+  let tsconfig_paths = rolldown::TsConfigPaths::new(opts.tsconfig_paths.unwrap_or_default(), &cwd);
+
   Ok((
     rolldown::InputOptions {
       input: opts
@@ -81,9 +106,31 @@ pub fn resolve_input_options(
         tsconfig: opts.builtins.tsconfig.map(|opts| rolldown::TsConfig {
           use_define_for_class_fields: opts.use_define_for_class_fields,
         }),
+        jsx_side_effects: opts.builtins.jsx_side_effects.unwrap_or(true),
+        jsx: opts
+          .builtins
+          .jsx
+          .as_deref()
+          .map(rolldown::JsxMode::from_str)
+          .transpose()
+          .map_err(napi::Error::from_reason)?
+          .unwrap_or_default(),
       },
       on_warn: default_warning_handler(),
       shim_missing_exports: opts.shim_missing_exports,
+      concurrency: opts.concurrency.map(|n| n as usize),
+      platform: opts
+        .platform
+        .as_deref()
+        .map(rolldown::Platform::from_str)
+        .transpose()
+        .map_err(napi::Error::from_reason)?
+        .unwrap_or_default(),
+      prefix_node_builtins: opts.prefix_node_builtins.unwrap_or(false),
+      import_map,
+      resolve_overrides,
+      tsconfig_paths,
+      resolver: None,
     },
     plugins,
   ))
@@ -1,4 +1,4 @@
-use std::str::FromStr;
+use std::{collections::HashMap, str::FromStr};
 
 use napi_derive::*;
 use rolldown::ModuleFormat;
@@ -16,22 +16,27 @@ pub struct OutputOptions {
 
   // amd: NormalizedAmdOptions;
   // assetFileNames: string | ((chunkInfo: PreRenderedAsset) => string);
+  pub asset_file_names: Option<String>,
   // banner: () => string | Promise<string>;
+  pub banner: Option<String>,
   // chunkFileNames: string | ((chunkInfo: PreRenderedChunk) => string);
   // compact: boolean;
   pub dir: Option<String>,
   // pub entry_file_names: String, // | ((chunkInfo: PreRenderedChunk) => string)
   // esModule: boolean;
-  #[napi(ts_type = "'default' | 'named' | 'none' | 'auto'")]
+  #[napi(ts_type = "'default' | 'named' | 'none' | 'hybrid' | 'auto'")]
   pub exports: Option<String>,
   // extend: boolean;
   // externalLiveBindings: boolean;
   // footer: () => string | Promise<string>;
-  #[napi(ts_type = "'esm' | 'cjs'")]
+  #[napi(ts_type = "'esm' | 'cjs' | 'system' | 'iife'")]
   pub format: Option<String>,
+  pub graph_file: Option<String>,
+  pub manifest_file: Option<String>,
   // freeze: boolean;
   // generatedCode: NormalizedGeneratedCodeOptions;
-  // globals: GlobalsOption;
+  /// Globals of externals in IIFE output, keyed by their specifiers
+  pub globals: Option<HashMap<String, String>>,
   // hoistTransitiveImports: boolean;
   // indent: true | string;
   // inlineDynamicImports: boolean;
@@ -39,7 +44,7 @@ pub struct OutputOptions {
   // intro: () => string | Promise<string>;
   // manualChunks: ManualChunksOption;
   // minifyInternalExports: boolean;
-  // name: string | undefined;
+  pub name: Option<String>,
   // namespaceToStringTag: boolean;
   // noConflict: boolean;
   // outro: () => string | Promise<string>;
@@ -48,8 +53,11 @@ pub struct OutputOptions {
   // preferConst: boolean;
   // preserveModules: boolean;
   // preserveModulesRoot: string | undefined;
+  pub public_path: Option<String>,
   // sanitizeFileName: (fileName: string) => string;
   // sourcemap: boolean | 'inline' | 'hidden';
+  pub sourcemap: Option<bool>,
+  pub source_root: Option<String>,
   // sourcemapExcludeSources: boolean;
   // sourcemapFile: string | undefined;
   // sourcemapPathTransform: SourcemapPathTransformOption | undefined;
@@ -58,6 +66,14 @@ pub struct OutputOptions {
   // validate: boolean;
   // --- Enhanced options
   // pub minify: bool,
+  pub module_preload: Option<bool>,
+  pub chunk_load_error_handler: Option<String>,
+  /// Modules imported for built-ins the target lacks, keyed by the built-ins
+  pub polyfills: Option<HashMap<String, String>>,
+  /// `production` or `development`, which picks the env sections of the browserslist config
+  pub mode: Option<String>,
+  /// Legal comments and the banner don't change the hashes of chunks
+  pub hash_ignores_comments: Option<bool>,
 }
 
 pub fn resolve_output_options(opts: OutputOptions) -> napi::Result<rolldown::OutputOptions> {
@@ -80,6 +96,24 @@ pub fn resolve_output_options(opts: OutputOptions) -> napi::Result<rolldown::Out
   }
 
   defaults.dir = opts.dir;
+  defaults.sourcemap = opts.sourcemap.unwrap_or_default();
+  defaults.source_root = opts.source_root;
+  defaults.graph_file = opts.graph_file;
+  defaults.manifest_file = opts.manifest_file;
+  if let Some(asset_file_names) = opts.asset_file_names {
+    defaults.asset_file_names = asset_file_names.into()
+  }
+  defaults.public_path = opts.public_path;
+  defaults.module_preload = opts.module_preload.unwrap_or_default();
+  defaults.chunk_load_error_handler = opts.chunk_load_error_handler;
+  defaults.name = opts.name;
+  defaults.globals = opts.globals.unwrap_or_default();
+  defaults.polyfills = opts.polyfills.unwrap_or_default();
+  defaults.banner = opts.banner;
+  defaults.hash_ignores_comments = opts.hash_ignores_comments.unwrap_or_default();
+  if let Some(mode) = opts.mode {
+    defaults.mode = rolldown::BuildMode::from_str(&mode).map_err(napi::Error::from_reason)?;
+  }
 
   Ok(defaults)
 }
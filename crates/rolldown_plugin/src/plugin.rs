@@ -8,6 +8,18 @@ pub struct ResolvedId {
   pub external: bool,
 }
 
+/// Resolution rules of a namespace introduced by a plugin, like `css-module`. Ids of its modules
+/// look like `css-module:dir/a.css`, and relative imports of them stay in the namespace instead of
+/// going through the file system.
+#[derive(Debug, Clone)]
+pub struct NamespaceResolveOptions {
+  pub namespace: String,
+  /// Appended in order to a relative specifier until a plugin loads the id, like extensions are
+  /// probed on disk.
+  pub resolve_extensions: Vec<String>,
+}
+
+pub type BuildStartReturn = rolldown_error::Result<()>;
 pub type ResolveReturn = rolldown_error::Result<Option<ResolvedId>>;
 pub type TransformReturn = rolldown_error::Result<Option<TransformOutput>>;
 pub type LoadReturn = rolldown_error::Result<Option<LoadOutput>>;
@@ -17,6 +29,17 @@ pub type PluginName<'a> = Cow<'a, str>;
 pub trait BuildPlugin: Debug + Send + Sync {
   fn name(&self) -> PluginName;
 
+  /// Namespaces whose modules are served by the plugin.
+  fn namespaces(&self) -> Vec<NamespaceResolveOptions> {
+    vec![]
+  }
+
+  /// Called before the modules of every build are loaded. A plugin reused for a rebuild can drop
+  /// what it cached about files that changed since the last build.
+  async fn build_start(&self, _ctx: &mut Context) -> BuildStartReturn {
+    Ok(())
+  }
+
   async fn load(&self, _ctx: &mut Context, _args: &mut LoadArgs) -> LoadReturn {
     Ok(None)
   }
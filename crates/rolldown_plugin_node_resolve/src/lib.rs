@@ -1,20 +1,56 @@
-use std::path::{Path, PathBuf};
+use std::{
+  collections::HashMap,
+  path::{Path, PathBuf},
+  sync::{Mutex, RwLock},
+};
 
 use nodejs_resolver::{ResolveResult, Resolver};
-use rolldown_plugin::{async_trait, BuildPlugin, Context, ResolveArgs, ResolveReturn, ResolvedId};
+use rolldown_plugin::{
+  async_trait, BuildPlugin, BuildStartReturn, Context, ResolveArgs, ResolveReturn, ResolvedId,
+};
 
 #[derive(Debug)]
 pub struct NodeResolvePlugin {
-  resolver: Resolver,
+  options: ResolverOptions,
+  /// Memoizes the `package.json` files it reads.
+  resolver: RwLock<Resolver>,
   cwd: PathBuf,
+  /// The `package.json` of every resolved module, with its content when it was read. `None` if it
+  /// couldn't be read.
+  package_jsons: Mutex<HashMap<PathBuf, Option<Vec<u8>>>>,
 }
 
 pub use nodejs_resolver::Options as ResolverOptions;
 
+/// The `package.json` of the package containing `path`.
+fn find_package_json(path: &Path) -> Option<PathBuf> {
+  path
+    .ancestors()
+    .skip(1)
+    .map(|dir| dir.join("package.json"))
+    .find(|package_json| package_json.is_file())
+}
+
 impl NodeResolvePlugin {
   pub fn new_boxed(options: ResolverOptions, cwd: PathBuf) -> Box<dyn BuildPlugin> {
-    let resolver = Resolver::new(options);
-    Box::new(Self { resolver, cwd })
+    let resolver = Resolver::new(options.clone());
+    Box::new(Self {
+      options,
+      resolver: RwLock::new(resolver),
+      cwd,
+      package_jsons: Default::default(),
+    })
+  }
+
+  fn track_package_json(&self, resolved: &Path) {
+    let Some(package_json) = find_package_json(resolved) else {
+      return;
+    };
+    let mut package_jsons = self.package_jsons.lock().unwrap();
+    if !package_jsons.contains_key(&package_json) {
+      let content = std::fs::read(&package_json).ok();
+      package_jsons.insert(package_json, content);
+    }
   }
 }
 
@@ -24,12 +60,30 @@ impl BuildPlugin for NodeResolvePlugin {
     std::borrow::Cow::Borrowed("builtin:node-resolve")
   }
 
+  /// Entries of the resolver can't be dropped one by one, so it's replaced if any `package.json`
+  /// it resolved through was changed since the last build, like its `main` or `exports`.
+  async fn build_start(&self, _ctx: &mut Context) -> BuildStartReturn {
+    let mut package_jsons = self.package_jsons.lock().unwrap();
+    let is_changed = package_jsons
+      .iter()
+      .any(|(path, content)| &std::fs::read(path).ok() != content);
+    if is_changed {
+      *self.resolver.write().unwrap() = Resolver::new(self.options.clone());
+      package_jsons.clear();
+    }
+    Ok(())
+  }
+
   async fn resolve(&self, _ctx: &mut Context, args: &mut ResolveArgs) -> ResolveReturn {
     let importer = args
       .importer
       .map(|importer| Path::new(importer.as_ref()).parent().unwrap())
       .unwrap_or_else(|| Path::new(&self.cwd));
-    let s = self.resolver.resolve(importer, args.specifier);
+    let s = self
+      .resolver
+      .read()
+      .unwrap()
+      .resolve(importer, args.specifier);
     if s.is_err() {
       // println!("{args:#?}");
       // println!(
@@ -45,10 +99,13 @@ impl BuildPlugin for NodeResolvePlugin {
     }
     let s = s.unwrap();
     match s {
-      ResolveResult::Info(info) => Ok(Some(ResolvedId {
-        id: info.path().to_string_lossy().to_string(),
-        external: false,
-      })),
+      ResolveResult::Info(info) => {
+        self.track_package_json(info.path());
+        Ok(Some(ResolvedId {
+          id: info.path().to_string_lossy().to_string(),
+          external: false,
+        }))
+      }
       ResolveResult::Ignored => Ok(None),
     }
   }
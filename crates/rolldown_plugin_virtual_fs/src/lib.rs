@@ -0,0 +1,129 @@
+use std::{
+  collections::HashMap,
+  path::{Path, PathBuf},
+};
+
+use rolldown_plugin::{
+  async_trait, BuildPlugin, Context, LoadArgs, LoadOutput, LoadReturn, ResolveArgs, ResolveReturn,
+  ResolvedId,
+};
+use sugar_path::SugarPath;
+
+/// Same as the extensions probed by `rolldown_resolver`.
+const EXTENSIONS: &[&str] = &[".js", ".jsx", ".ts", ".tsx"];
+
+/// Serves modules from memory. Specifiers that can't be resolved to a virtual file are left to the
+/// following plugins and the real file system.
+#[derive(Debug)]
+pub struct VirtualFsPlugin {
+  /// Keyed by normalized absolute paths.
+  files: HashMap<PathBuf, String>,
+  cwd: PathBuf,
+}
+
+impl VirtualFsPlugin {
+  /// Relative paths of `files` are relative to `cwd`.
+  pub fn new_boxed(
+    files: impl IntoIterator<Item = (impl AsRef<Path>, impl Into<String>)>,
+    cwd: PathBuf,
+  ) -> Box<dyn BuildPlugin> {
+    let files = files
+      .into_iter()
+      .map(|(path, code)| (cwd.join(path).normalize(), code.into()))
+      .collect();
+    Box::new(Self { files, cwd })
+  }
+
+  fn is_file(&self, path: &Path) -> bool {
+    self.files.contains_key(path)
+  }
+
+  /// Directories only exist as prefixes of files.
+  fn is_dir(&self, path: &Path) -> bool {
+    self
+      .files
+      .keys()
+      .any(|file| file.starts_with(path) && file != path)
+  }
+
+  fn resolve_file(&self, path: &Path) -> Option<PathBuf> {
+    if self.is_file(path) {
+      return Some(path.to_path_buf());
+    }
+    EXTENSIONS
+      .iter()
+      .map(|ext| PathBuf::from(format!("{}{ext}", path.display())))
+      .find(|path| self.is_file(path))
+  }
+
+  /// `module` wins over `main` in `package.json`, then it falls back to `index`.
+  fn resolve_dir(&self, dir: &Path) -> Option<PathBuf> {
+    if !self.is_dir(dir) {
+      return None;
+    }
+    let entry = self
+      .files
+      .get(&dir.join("package.json"))
+      .and_then(|package_json| serde_json::from_str::<serde_json::Value>(package_json).ok())
+      .and_then(|package_json| {
+        ["module", "main"]
+          .iter()
+          .find_map(|field| package_json.get(field)?.as_str().map(ToString::to_string))
+      });
+    entry
+      .and_then(|entry| {
+        let path = dir.join(entry).normalize();
+        self
+          .resolve_file(&path)
+          .or_else(|| self.resolve_file(&path.join("index")))
+      })
+      .or_else(|| self.resolve_file(&dir.join("index")))
+  }
+
+  fn resolve_path(&self, path: &Path) -> Option<PathBuf> {
+    self.resolve_file(path).or_else(|| self.resolve_dir(path))
+  }
+
+  fn resolve_specifier(&self, importer_dir: &Path, specifier: &str) -> Option<PathBuf> {
+    if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+      return self.resolve_path(&importer_dir.join(specifier).normalize());
+    }
+    importer_dir
+      .ancestors()
+      .find_map(|dir| self.resolve_path(&dir.join("node_modules").join(specifier).normalize()))
+  }
+}
+
+#[async_trait::async_trait]
+impl BuildPlugin for VirtualFsPlugin {
+  fn name(&self) -> rolldown_plugin::PluginName {
+    std::borrow::Cow::Borrowed("builtin:virtual-fs")
+  }
+
+  async fn resolve(&self, _ctx: &mut Context, args: &mut ResolveArgs) -> ResolveReturn {
+    let importer_dir = args
+      .importer
+      .map(|importer| Path::new(importer.as_ref()).parent().unwrap())
+      .unwrap_or_else(|| Path::new(&self.cwd));
+    Ok(
+      self
+        .resolve_specifier(importer_dir, args.specifier)
+        .map(|path| ResolvedId {
+          id: path.to_string_lossy().to_string(),
+          external: false,
+        }),
+    )
+  }
+
+  async fn load(&self, _ctx: &mut Context, args: &mut LoadArgs) -> LoadReturn {
+    Ok(
+      self
+        .files
+        .get(Path::new(args.id.as_ref()))
+        .map(|code| LoadOutput {
+          code: code.clone(),
+          loader: None,
+        }),
+    )
+  }
+}
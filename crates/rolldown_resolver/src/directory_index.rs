@@ -0,0 +1,56 @@
+use std::path::{Path, PathBuf};
+
+use serde_json::Value;
+use sugar_path::SugarPath;
+
+/// `path` itself, or `path` with one of the extensions.
+fn resolve_file(path: &Path, extensions: &[&str]) -> Option<PathBuf> {
+  if path.is_file() {
+    return Some(path.to_path_buf());
+  }
+  extensions
+    .iter()
+    .map(|ext| PathBuf::from(format!("{}{ext}", path.display())))
+    .find(|path| path.is_file())
+}
+
+fn resolve_index(dir: &Path, extensions: &[&str]) -> Option<PathBuf> {
+  resolve_file(&dir.join("index"), extensions)
+}
+
+/// The entry field of `package.json` in `dir` could point at a file, with or without the
+/// extension, or at a directory containing an index file like `"main": "./lib"`.
+fn resolve_dir(dir: &Path, extensions: &[&str]) -> Option<PathBuf> {
+  if !dir.is_dir() {
+    return None;
+  }
+  let entry = std::fs::read_to_string(dir.join("package.json"))
+    .ok()
+    .and_then(|package_json| serde_json::from_str::<Value>(&package_json).ok())
+    .and_then(|package_json| {
+      ["module", "main"]
+        .iter()
+        .find_map(|field| package_json.get(field)?.as_str().map(ToString::to_string))
+    });
+  entry
+    .and_then(|entry| {
+      let path = dir.join(entry).normalize();
+      resolve_file(&path, extensions).or_else(|| resolve_index(&path, extensions))
+    })
+    .or_else(|| resolve_index(dir, extensions))
+}
+
+/// Used when the specifier is unresolvable otherwise, matching how Node.js resolves directories.
+/// A bare specifier is looked up in `node_modules` of every ancestor of the importer.
+pub(crate) fn resolve_directory(
+  importer_dir: &Path,
+  specifier: &str,
+  extensions: &[&str],
+) -> Option<PathBuf> {
+  if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+    return resolve_dir(&importer_dir.join(specifier).normalize(), extensions);
+  }
+  importer_dir
+    .ancestors()
+    .find_map(|dir| resolve_dir(&dir.join("node_modules").join(specifier), extensions))
+}
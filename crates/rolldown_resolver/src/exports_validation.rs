@@ -0,0 +1,85 @@
+use std::path::{Path, PathBuf};
+
+use serde_json::Value;
+
+use crate::self_reference::{read_package_json, PackageJsonCache};
+
+/// `@scope/pkg/util` => `@scope/pkg`, `pkg/util` => `pkg`
+pub(crate) fn package_name(specifier: &str) -> Option<&str> {
+  if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+    return None;
+  }
+  let end = if specifier.starts_with('@') {
+    specifier.match_indices('/').nth(1)
+  } else {
+    specifier.match_indices('/').next()
+  }
+  .map_or(specifier.len(), |(idx, _)| idx);
+  Some(&specifier[..end])
+}
+
+/// Only called after the specifier failed to be resolved, to explain why. A malformed `exports`
+/// doesn't fail the resolution by itself, since Node.js doesn't either.
+pub(crate) fn find_invalid_exports(
+  cache: &PackageJsonCache,
+  importer_dir: &Path,
+  specifier: &str,
+) -> Option<(PathBuf, String)> {
+  let name = package_name(specifier)?;
+  let package_json = cache
+    .find_nearest(importer_dir)
+    .filter(|package_json| package_json.name == name)
+    .map(|package_json| package_json.dir.join("package.json"))
+    .or_else(|| {
+      importer_dir
+        .ancestors()
+        .map(|dir| dir.join("node_modules").join(name).join("package.json"))
+        .find(|path| path.is_file())
+    })?;
+  let exports = read_package_json(&package_json)?.exports;
+  validate_exports(&exports, "exports", true)
+    .err()
+    .map(|reason| (package_json, reason))
+}
+
+/// `path` locates `exports` in `package.json`, like `exports["."]["import"]`.
+fn validate_exports(exports: &Value, path: &str, is_root: bool) -> Result<(), String> {
+  match exports {
+    Value::Object(map) => {
+      let subpath = map.keys().find(|key| key.starts_with('.'));
+      let condition = map.keys().find(|key| !key.starts_with('.'));
+      match (subpath, condition) {
+        (Some(subpath), Some(condition)) => {
+          return Err(format!(
+            r#"{path} mixes the subpath "{subpath}" with the condition "{condition}". Keys of an object must be either all subpaths starting with "." or all conditions."#
+          ));
+        }
+        (Some(subpath), None) if !is_root => {
+          return Err(format!(
+            r#"{path} contains the subpath "{subpath}", but subpaths are only allowed at the top level of "exports"."#
+          ));
+        }
+        (None, Some(_)) => {
+          let after_default = map
+            .keys()
+            .skip_while(|key| key.as_str() != "default")
+            .nth(1);
+          if let Some(condition) = after_default {
+            return Err(format!(
+              r#""default" must be the last condition of {path}, but "{condition}" comes after it and never matches."#
+            ));
+          }
+        }
+        _ => {}
+      }
+      map
+        .iter()
+        .try_for_each(|(key, value)| validate_exports(value, &format!("{path}[{key:?}]"), false))
+    }
+    Value::Array(targets) => targets
+      .iter()
+      .enumerate()
+      .try_for_each(|(idx, value)| validate_exports(value, &format!("{path}[{idx}]"), false)),
+    _ => Ok(()),
+  }
+}
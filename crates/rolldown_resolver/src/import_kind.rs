@@ -0,0 +1,22 @@
+/// How a module is reached, which decides the conditions of conditional exports that match, so a
+/// dual package resolves to its ES module for `import` and to its CommonJS module for `require`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq, Hash, Default)]
+pub enum ImportKind {
+  /// `import` statements, `export ... from` and `import()`
+  #[default]
+  Import,
+  /// `require()` calls
+  Require,
+}
+
+impl ImportKind {
+  /// Conditions are checked in the order of the keys in the exports map, not in this order. Like
+  /// Node.js, `module-sync` matches both, since an ES module without top-level `await` can be
+  /// required as well.
+  pub(crate) fn conditions(self) -> &'static [&'static str] {
+    match self {
+      ImportKind::Import => &["import", "module-sync", "module", "default"],
+      ImportKind::Require => &["require", "module-sync", "default"],
+    }
+  }
+}
@@ -0,0 +1,140 @@
+use std::path::Path;
+
+use serde_json::Value;
+use sugar_path::SugarPath;
+
+/// Keys sorted from the longest, so the most specific prefix wins.
+type SpecifierMap = Vec<(String, String)>;
+
+/// An import map as specified by https://github.com/WICG/import-maps, which is how Deno and browsers
+/// resolve bare specifiers.
+///
+/// Addresses are either absolute paths or URLs. A module mapped to a URL is kept external and
+/// imported from the URL.
+#[derive(Debug, Clone, Default)]
+pub struct ImportMap {
+  imports: SpecifierMap,
+  /// Sorted from the most specific scope.
+  scopes: Vec<(String, SpecifierMap)>,
+}
+
+fn is_url(value: &str) -> bool {
+  value.split_once("://").map_or(false, |(scheme, _)| {
+    !scheme.is_empty() && scheme.chars().all(|c| c.is_ascii_alphanumeric())
+  })
+}
+
+fn is_path_like(value: &str) -> bool {
+  value.starts_with("./") || value.starts_with("../") || value.starts_with('/')
+}
+
+/// `./vendor/` => `/abs/vendor/`. The trailing `/` of a prefix is kept.
+fn resolve_path_like(value: &str, base_dir: &Path) -> String {
+  let mut resolved = base_dir
+    .join(value)
+    .normalize()
+    .to_string_lossy()
+    .to_string();
+  if value.ends_with('/') && !resolved.ends_with('/') {
+    resolved.push('/');
+  }
+  resolved
+}
+
+fn normalize_key(key: &str, base_dir: &Path) -> Option<String> {
+  if key.is_empty() {
+    None
+  } else if is_path_like(key) {
+    Some(resolve_path_like(key, base_dir))
+  } else {
+    Some(key.to_string())
+  }
+}
+
+/// A bare address like `"lodash"` is invalid, as well as a prefix mapped to an address that isn't
+/// a prefix.
+fn normalize_address(key: &str, address: &str, base_dir: &Path) -> Option<String> {
+  let address = if is_url(address) {
+    address.to_string()
+  } else if is_path_like(address) {
+    resolve_path_like(address, base_dir)
+  } else {
+    return None;
+  };
+  (!key.ends_with('/') || address.ends_with('/')).then_some(address)
+}
+
+fn parse_specifier_map(value: &Value, base_dir: &Path) -> Result<SpecifierMap, String> {
+  let map = value
+    .as_object()
+    .ok_or_else(|| "Specifier maps of an import map must be objects".to_string())?;
+  let mut specifier_map = map
+    .iter()
+    .filter_map(|(key, address)| {
+      let key = normalize_key(key, base_dir)?;
+      let address = normalize_address(&key, address.as_str()?, base_dir)?;
+      Some((key, address))
+    })
+    .collect::<Vec<_>>();
+  specifier_map.sort_by(|(a, _), (b, _)| b.len().cmp(&a.len()).then_with(|| a.cmp(b)));
+  Ok(specifier_map)
+}
+
+fn resolve_in(specifier_map: &SpecifierMap, specifier: &str) -> Option<String> {
+  specifier_map.iter().find_map(|(key, address)| {
+    if key == specifier {
+      Some(address.clone())
+    } else if key.ends_with('/') && specifier.starts_with(key.as_str()) {
+      Some(format!("{address}{}", &specifier[key.len()..]))
+    } else {
+      None
+    }
+  })
+}
+
+impl ImportMap {
+  /// Relative keys, addresses and scopes are relative to `base_dir`, which is the directory of the
+  /// import map file. Invalid entries are ignored, like in browsers.
+  pub fn from_json(json: &str, base_dir: &Path) -> Result<Self, String> {
+    let json: Value = serde_json::from_str(json).map_err(|e| format!("Invalid import map: {e}"))?;
+    let imports = match json.get("imports") {
+      Some(imports) => parse_specifier_map(imports, base_dir)?,
+      None => vec![],
+    };
+    let mut scopes = match json.get("scopes") {
+      Some(Value::Object(scopes)) => scopes
+        .iter()
+        .filter_map(|(scope, map)| Some((normalize_key(scope, base_dir)?, map)))
+        .map(|(scope, map)| Ok((scope, parse_specifier_map(map, base_dir)?)))
+        .collect::<Result<Vec<_>, String>>()?,
+      Some(_) => return Err("`scopes` of an import map must be an object".to_string()),
+      None => vec![],
+    };
+    scopes.sort_by(|(a, _), (b, _)| b.len().cmp(&a.len()).then_with(|| a.cmp(b)));
+    Ok(Self { imports, scopes })
+  }
+
+  /// The address of `specifier`, if it's mapped. Scopes containing the importer are tried first,
+  /// from the most specific one, then the top-level `imports`.
+  pub fn resolve(&self, importer: Option<&str>, specifier: &str) -> Option<String> {
+    let specifier = match importer.and_then(|importer| Path::new(importer).parent()) {
+      Some(importer_dir) if specifier.starts_with("./") || specifier.starts_with("../") => {
+        resolve_path_like(specifier, importer_dir)
+      }
+      _ => specifier.to_string(),
+    };
+    importer
+      .into_iter()
+      .flat_map(|importer| {
+        self
+          .scopes
+          .iter()
+          .filter(move |(scope, _)| {
+            importer == scope || (scope.ends_with('/') && importer.starts_with(scope.as_str()))
+          })
+          .map(|(_, map)| map)
+      })
+      .chain(std::iter::once(&self.imports))
+      .find_map(|map| resolve_in(map, &specifier))
+  }
+}
@@ -1,36 +1,100 @@
 use std::path::{Path, PathBuf};
 
+use dashmap::DashMap;
 use nodejs_resolver::{Options, Resolver as EnhancedResolver};
+use package_type::PackageTypeCache;
+use self_reference::PackageJsonCache;
 use sugar_path::AsPath;
+use workspace::WorkspaceCache;
+
+mod directory_index;
+mod exports_validation;
+mod import_kind;
+pub use import_kind::ImportKind;
+mod import_map;
+pub use import_map::ImportMap;
+mod node_builtins;
+pub use node_builtins::node_builtin_name;
+mod package_exports;
+mod package_type;
+pub use package_type::PackageType;
+mod resolve_overrides;
+pub use resolve_overrides::ResolveOverrides;
+mod self_reference;
+mod tsconfig_paths;
+pub use tsconfig_paths::TsConfigPaths;
+mod types_only;
+mod workspace;
+
+/// Extensions probed in this order when a specifier doesn't have one.
+pub const EXTENSIONS: &[&str] = &[".js", ".jsx", ".ts", ".tsx"];
+/// Probed instead of `EXTENSIONS` for TypeScript importers, so `./x` imported by `a.ts` is `x.ts`
+/// even if `x.js` is next to it.
+pub const TS_EXTENSIONS: &[&str] = &[".ts", ".tsx", ".js", ".jsx"];
+
+fn is_typescript(importer: &str) -> bool {
+  matches!(
+    Path::new(importer).extension().and_then(|ext| ext.to_str()),
+    Some("ts" | "tsx" | "mts" | "cts")
+  )
+}
+
+fn new_inner(preserve_symlinks: bool, extensions: &[&str]) -> EnhancedResolver {
+  EnhancedResolver::new(Options {
+    symlinks: !preserve_symlinks,
+    extensions: extensions.iter().map(|ext| ext.to_string()).collect(),
+    // TODO(hyf0): Should we set this as default?
+    prefer_relative: true,
+    ..Default::default()
+  })
+}
 
 #[derive(Debug)]
 pub struct Resolver {
   cwd: PathBuf,
   inner: EnhancedResolver,
+  /// Used for TypeScript importers, probing `TS_EXTENSIONS`.
+  inner_ts: EnhancedResolver,
+  /// Keyed by (importer dir, whether the importer is TypeScript, specifier, import kind). The kind
+  /// decides the matched conditions of conditional exports. `None` means the specifier is
+  /// unresolvable.
+  resolved: DashMap<(PathBuf, bool, String, ImportKind), Option<String>>,
+  package_json: PackageJsonCache,
+  package_type: PackageTypeCache,
+  workspace: WorkspaceCache,
 }
 
 impl Resolver {
   pub fn with_cwd(cwd: PathBuf, preserve_symlinks: bool) -> Self {
     Self {
       cwd,
-      inner: EnhancedResolver::new(Options {
-        symlinks: !preserve_symlinks,
-        extensions: vec![
-          ".js".to_string(),
-          ".jsx".to_string(),
-          ".ts".to_string(),
-          ".tsx".to_string(),
-        ],
-        // TODO(hyf0): Should we set this as default?
-        prefer_relative: true,
-        ..Default::default()
-      }),
+      inner: new_inner(preserve_symlinks, EXTENSIONS),
+      inner_ts: new_inner(preserve_symlinks, TS_EXTENSIONS),
+      resolved: Default::default(),
+      package_json: Default::default(),
+      package_type: Default::default(),
+      workspace: Default::default(),
     }
   }
 
   pub fn cwd(&self) -> &PathBuf {
     &self.cwd
   }
+
+  /// Results are cached until this is called, so it should be called whenever files are changed,
+  /// added or removed.
+  pub fn clear_cache(&self) {
+    self.resolved.clear();
+    self.package_json.clear();
+    self.package_type.clear();
+    self.workspace.clear();
+  }
+
+  /// The `type` of the nearest `package.json` of `path`. `None` if it doesn't specify one.
+  pub fn package_type(&self, path: &str) -> Option<PackageType> {
+    let dir = Path::new(path).parent().expect("Should have a parent dir");
+    self.package_type.find_nearest(dir)
+  }
 }
 
 impl Default for Resolver {
@@ -40,27 +104,133 @@ impl Default for Resolver {
 }
 
 impl Resolver {
+  /// Resolves `specifier` as if it were imported by an `import` statement.
   pub fn resolve(&self, importer: Option<&str>, specifier: &str) -> rolldown_error::Result<String> {
+    self.resolve_with_kind(importer, specifier, ImportKind::Import)
+  }
+
+  /// The same package can resolve to different files for `import` and `require`, through the
+  /// `import` and `require` conditions of its exports.
+  pub fn resolve_with_kind(
+    &self,
+    importer: Option<&str>,
+    specifier: &str,
+    kind: ImportKind,
+  ) -> rolldown_error::Result<String> {
     let importer_dir = importer
       .map(|s| Path::new(s).parent().expect("Should have a parent dir"))
       .unwrap_or(&self.cwd);
 
-    let resolved = self.inner.resolve(importer_dir, specifier);
+    let is_ts_importer = importer.map_or(false, is_typescript);
+    let key = (
+      importer_dir.to_path_buf(),
+      is_ts_importer,
+      specifier.to_string(),
+      kind,
+    );
+    // The guard must be dropped before inserting, otherwise the shard stays locked.
+    let cached = self.resolved.get(&key).map(|resolved| resolved.value().clone());
+    let resolved = match cached {
+      Some(resolved) => resolved,
+      None => {
+        let resolved = self.resolve_uncached(importer_dir, is_ts_importer, specifier, kind);
+        self.resolved.insert(key, resolved.clone());
+        resolved
+      }
+    };
+
     match resolved {
-      Ok(resolved) => match resolved {
-        nodejs_resolver::ResolveResult::Info(info) => Ok(info.path().to_string_lossy().to_string()),
-        nodejs_resolver::ResolveResult::Ignored => unreachable!(),
-      },
-      Err(_err) => {
+      Some(resolved) => Ok(resolved),
+      None => {
         if let Some(importer) = importer {
-          Err(rolldown_error::Error::unresolved_import(
-            specifier.to_string(),
-            importer.as_path().to_path_buf(),
-          ))
+          let invalid_exports =
+            exports_validation::find_invalid_exports(&self.package_json, importer_dir, specifier);
+          Err(match invalid_exports {
+            Some((package_json, reason)) => rolldown_error::Error::invalid_package_exports(
+              specifier.to_string(),
+              importer.as_path().to_path_buf(),
+              package_json,
+              reason,
+            ),
+            None => rolldown_error::Error::unresolved_import(
+              specifier.to_string(),
+              importer.as_path().to_path_buf(),
+            ),
+          })
         } else {
           Err(rolldown_error::Error::unresolved_entry(specifier.as_path()))
         }
       }
     }
   }
+
+  /// `None` if the specifier doesn't reference the package containing the importer.
+  pub fn resolve_self_reference(
+    &self,
+    importer: &str,
+    specifier: &str,
+    kind: ImportKind,
+  ) -> rolldown_error::Result<Option<String>> {
+    let importer_dir = Path::new(importer)
+      .parent()
+      .expect("Should have a parent dir");
+    match self_reference::resolve_self_reference(&self.package_json, importer_dir, specifier, kind)
+    {
+      Some(target) => self
+        .resolve_with_kind(Some(importer), &target.to_string_lossy(), kind)
+        .map(Some),
+      None => Ok(None),
+    }
+  }
+
+  /// The directory of `@types/<name>` if the package imported by `specifier` has no runtime code,
+  /// but only type declarations. Virtual importers aren't in any `node_modules`, so they never
+  /// have one.
+  pub fn find_types_only_package(&self, importer: &str, specifier: &str) -> Option<PathBuf> {
+    let importer = Path::new(importer);
+    if !importer.is_absolute() {
+      return None;
+    }
+    let importer_dir = importer.parent().expect("Should have a parent dir");
+    types_only::find_types_only_package(importer_dir, specifier)
+  }
+
+  fn resolve_uncached(
+    &self,
+    importer_dir: &Path,
+    is_ts_importer: bool,
+    specifier: &str,
+    kind: ImportKind,
+  ) -> Option<String> {
+    let (inner, extensions) = if is_ts_importer {
+      (&self.inner_ts, TS_EXTENSIONS)
+    } else {
+      (&self.inner, EXTENSIONS)
+    };
+    let target =
+      self_reference::resolve_self_reference(&self.package_json, importer_dir, specifier, kind)
+        .or_else(|| {
+          workspace::resolve_workspace_package(&self.workspace, importer_dir, specifier, kind)
+        })
+        .or_else(|| {
+          package_exports::resolve_package_exports(
+            &self.package_json,
+            importer_dir,
+            specifier,
+            kind,
+          )
+        });
+    let resolved = match target {
+      Some(target) => inner.resolve(importer_dir, &target.to_string_lossy()),
+      None => inner.resolve(importer_dir, specifier),
+    };
+    match resolved {
+      Ok(nodejs_resolver::ResolveResult::Info(info)) => {
+        Some(info.path().to_string_lossy().to_string())
+      }
+      Ok(nodejs_resolver::ResolveResult::Ignored) => unreachable!(),
+      Err(_) => directory_index::resolve_directory(importer_dir, specifier, extensions)
+        .map(|resolved| resolved.to_string_lossy().to_string()),
+    }
+  }
 }
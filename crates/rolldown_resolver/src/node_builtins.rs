@@ -0,0 +1,69 @@
+/// Modules shipped with node, which may be imported with or without the `node:` prefix.
+const NODE_BUILTINS: &[&str] = &[
+  "assert",
+  "assert/strict",
+  "async_hooks",
+  "buffer",
+  "child_process",
+  "cluster",
+  "console",
+  "constants",
+  "crypto",
+  "dgram",
+  "diagnostics_channel",
+  "dns",
+  "dns/promises",
+  "domain",
+  "events",
+  "fs",
+  "fs/promises",
+  "http",
+  "http2",
+  "https",
+  "inspector",
+  "module",
+  "net",
+  "os",
+  "path",
+  "path/posix",
+  "path/win32",
+  "perf_hooks",
+  "process",
+  "punycode",
+  "querystring",
+  "readline",
+  "readline/promises",
+  "repl",
+  "stream",
+  "stream/consumers",
+  "stream/promises",
+  "stream/web",
+  "string_decoder",
+  "sys",
+  "timers",
+  "timers/promises",
+  "tls",
+  "trace_events",
+  "tty",
+  "url",
+  "util",
+  "util/types",
+  "v8",
+  "vm",
+  "wasi",
+  "worker_threads",
+  "zlib",
+];
+
+/// Modules that only exist with the `node:` prefix, such as `node:test`.
+const PREFIXED_ONLY_NODE_BUILTINS: &[&str] = &["test"];
+
+/// Returns the name of the builtin without the `node:` prefix, if `specifier` refers to one.
+pub fn node_builtin_name(specifier: &str) -> Option<&str> {
+  match specifier.strip_prefix("node:") {
+    Some(name) => {
+      (NODE_BUILTINS.contains(&name) || PREFIXED_ONLY_NODE_BUILTINS.contains(&name)).then_some(name)
+    }
+    None => NODE_BUILTINS.contains(&specifier).then_some(specifier),
+  }
+}
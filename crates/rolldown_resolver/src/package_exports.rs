@@ -0,0 +1,30 @@
+use std::path::{Path, PathBuf};
+
+use crate::{
+  exports_validation::package_name,
+  self_reference::{resolve_exports, PackageJsonCache},
+  ImportKind,
+};
+
+/// Resolves `@scope/pkg/feature` through the `exports` of `node_modules/@scope/pkg`, whose name has
+/// two segments, so the subpath looked up is `./feature` rather than `./pkg/feature`. The nearest
+/// `node_modules` containing the package wins. `None` if it has no `exports` or doesn't export the
+/// subpath, which is left to the resolver then.
+pub(crate) fn resolve_package_exports(
+  cache: &PackageJsonCache,
+  importer_dir: &Path,
+  specifier: &str,
+  kind: ImportKind,
+) -> Option<PathBuf> {
+  let name = package_name(specifier)?;
+  let package_dir = importer_dir
+    .ancestors()
+    .map(|dir| dir.join("node_modules").join(name))
+    .find(|dir| dir.is_dir())?;
+  let package_json = cache
+    .find_nearest(&package_dir)
+    .filter(|package_json| package_json.dir == package_dir)?;
+  let subpath = format!(".{}", &specifier[name.len()..]);
+  let target = resolve_exports(&package_json.exports, &subpath, kind)?;
+  Some(package_json.dir.join(target))
+}
@@ -0,0 +1,48 @@
+use std::path::{Path, PathBuf};
+
+use dashmap::DashMap;
+use serde_json::Value;
+
+/// The `type` field of `package.json`, which decides whether `.js` files are ES modules.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum PackageType {
+  Module,
+  CommonJs,
+}
+
+/// The `type` of the nearest `package.json` of every visited directory.
+#[derive(Debug, Default)]
+pub(crate) struct PackageTypeCache {
+  nearest_by_dir: DashMap<PathBuf, Option<PackageType>>,
+}
+
+impl PackageTypeCache {
+  pub(crate) fn clear(&self) {
+    self.nearest_by_dir.clear();
+  }
+
+  /// Like Node.js, the search stops at the nearest `package.json`, even if it doesn't have `type`.
+  pub(crate) fn find_nearest(&self, dir: &Path) -> Option<PackageType> {
+    if let Some(package_type) = self.nearest_by_dir.get(dir) {
+      return *package_type;
+    }
+
+    let path = dir.join("package.json");
+    let package_type = if path.is_file() {
+      read_package_type(&path)
+    } else {
+      dir.parent().and_then(|parent| self.find_nearest(parent))
+    };
+    self.nearest_by_dir.insert(dir.to_path_buf(), package_type);
+    package_type
+  }
+}
+
+fn read_package_type(path: &Path) -> Option<PackageType> {
+  let json: Value = serde_json::from_str(&std::fs::read_to_string(path).ok()?).ok()?;
+  match json.get("type")?.as_str()? {
+    "module" => Some(PackageType::Module),
+    "commonjs" => Some(PackageType::CommonJs),
+    _ => None,
+  }
+}
@@ -0,0 +1,104 @@
+use std::path::Path;
+
+use sugar_path::SugarPath;
+
+use crate::exports_validation::package_name;
+
+#[derive(Debug, Clone)]
+struct Override {
+  /// Only imports from files of this package are overridden. `None` means any importer.
+  importer_package: Option<String>,
+  /// An exact specifier, or a prefix ending with `/`.
+  specifier: String,
+  /// An absolute path.
+  target: String,
+}
+
+impl Override {
+  fn is_prefix(&self) -> bool {
+    self.specifier.ends_with('/')
+  }
+}
+
+/// `/a/node_modules/legacy/node_modules/lodash/index.js` is a file of `lodash`, not of `legacy`,
+/// so only the innermost `node_modules` counts.
+fn is_file_of_package(importer: &str, package: &str) -> bool {
+  let importer = importer.replace('\\', "/");
+  importer
+    .rsplit_once("/node_modules/")
+    .map_or(false, |(_, rest)| {
+      rest
+        .strip_prefix(package)
+        .map_or(false, |rest| rest.starts_with('/'))
+    })
+}
+
+/// Forces bare specifiers to resolve to fixed paths regardless of where they are imported from,
+/// like `overrides` of npm and `resolutions` of yarn, so a monorepo bundles a single copy of a
+/// dependency.
+///
+/// - `"lodash"` matches `lodash` only.
+/// - `"lodash/"` matches subpaths like `lodash/fp`, which are resolved in the target.
+/// - `"legacy>lodash"` only matches imports from files of the `legacy` package.
+#[derive(Debug, Clone, Default)]
+pub struct ResolveOverrides {
+  /// Sorted so the most specific override wins. Scoped ones come first, then exact ones, then
+  /// the longest prefixes.
+  overrides: Vec<Override>,
+}
+
+impl ResolveOverrides {
+  /// Targets are paths relative to `base_dir`. Keys of relative or absolute specifiers are
+  /// ignored, since only bare specifiers resolve differently depending on the importer.
+  pub fn new(overrides: impl IntoIterator<Item = (String, String)>, base_dir: &Path) -> Self {
+    let mut overrides = overrides
+      .into_iter()
+      .filter_map(|(key, target)| {
+        let (importer_package, specifier) = match key.split_once('>') {
+          Some((package, specifier)) => (Some(package.trim().to_string()), specifier.trim()),
+          None => (None, key.trim()),
+        };
+        package_name(specifier)?;
+        let mut target = base_dir
+          .join(&target)
+          .normalize()
+          .to_string_lossy()
+          .to_string();
+        if specifier.ends_with('/') && !target.ends_with('/') {
+          target.push('/');
+        }
+        Some(Override {
+          importer_package,
+          specifier: specifier.to_string(),
+          target,
+        })
+      })
+      .collect::<Vec<_>>();
+    overrides.sort_by(|a, b| {
+      (a.importer_package.is_none(), a.is_prefix())
+        .cmp(&(b.importer_package.is_none(), b.is_prefix()))
+        .then_with(|| b.specifier.len().cmp(&a.specifier.len()))
+        .then_with(|| a.specifier.cmp(&b.specifier))
+    });
+    Self { overrides }
+  }
+
+  /// The absolute path `specifier` is forced to, if it's overridden for `importer`.
+  pub fn resolve(&self, importer: Option<&str>, specifier: &str) -> Option<String> {
+    self.overrides.iter().find_map(|item| {
+      if let Some(package) = &item.importer_package {
+        if !importer.map_or(false, |importer| is_file_of_package(importer, package)) {
+          return None;
+        }
+      }
+      if item.specifier == specifier {
+        Some(item.target.clone())
+      } else if item.is_prefix() && specifier.starts_with(item.specifier.as_str()) {
+        let rest = &specifier[item.specifier.len()..];
+        Some(format!("{}{rest}", item.target))
+      } else {
+        None
+      }
+    })
+  }
+}
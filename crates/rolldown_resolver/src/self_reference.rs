@@ -0,0 +1,129 @@
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use dashmap::DashMap;
+use serde_json::Value;
+
+use crate::ImportKind;
+
+#[derive(Debug)]
+pub(crate) struct PackageJson {
+  pub(crate) dir: PathBuf,
+  pub(crate) name: String,
+  pub(crate) exports: Value,
+}
+
+/// The nearest `package.json` of every visited directory, so each `package.json` is read at most
+/// once per build.
+#[derive(Debug, Default)]
+pub(crate) struct PackageJsonCache {
+  nearest_by_dir: DashMap<PathBuf, Option<Arc<PackageJson>>>,
+}
+
+impl PackageJsonCache {
+  pub(crate) fn clear(&self) {
+    self.nearest_by_dir.clear();
+  }
+
+  /// Only the nearest `package.json` matters. A package without `name` or `exports` can't reference
+  /// itself, even if an outer package could.
+  pub(crate) fn find_nearest(&self, dir: &Path) -> Option<Arc<PackageJson>> {
+    let cached = self
+      .nearest_by_dir
+      .get(dir)
+      .map(|package_json| package_json.value().clone());
+    if let Some(package_json) = cached {
+      return package_json;
+    }
+
+    let path = dir.join("package.json");
+    let package_json = if path.is_file() {
+      read_package_json(&path).map(Arc::new)
+    } else {
+      dir.parent().and_then(|parent| self.find_nearest(parent))
+    };
+    self
+      .nearest_by_dir
+      .insert(dir.to_path_buf(), package_json.clone());
+    package_json
+  }
+}
+
+pub(crate) fn read_package_json(path: &Path) -> Option<PackageJson> {
+  let mut json: Value = serde_json::from_str(&std::fs::read_to_string(path).ok()?).ok()?;
+  Some(PackageJson {
+    dir: path.parent()?.to_path_buf(),
+    name: json.get("name")?.as_str()?.to_string(),
+    exports: json.get_mut("exports")?.take(),
+  })
+}
+
+/// Resolve `my-pkg/util` imported from inside `my-pkg` through the exports map of `my-pkg` itself,
+/// instead of searching `node_modules`.
+pub(crate) fn resolve_self_reference(
+  cache: &PackageJsonCache,
+  importer_dir: &Path,
+  specifier: &str,
+  kind: ImportKind,
+) -> Option<PathBuf> {
+  if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+    return None;
+  }
+  let package_json = cache.find_nearest(importer_dir)?;
+  let rest = specifier.strip_prefix(package_json.name.as_str())?;
+  if !rest.is_empty() && !rest.starts_with('/') {
+    // `my-pkg-utils` isn't `my-pkg`
+    return None;
+  }
+  let target = resolve_exports(&package_json.exports, &format!(".{rest}"), kind)?;
+  Some(package_json.dir.join(target))
+}
+
+pub(crate) fn resolve_exports(exports: &Value, subpath: &str, kind: ImportKind) -> Option<String> {
+  let conditions = kind.conditions();
+  let subpath_map = exports
+    .as_object()
+    .filter(|map| map.keys().any(|key| key.starts_with('.')));
+  let Some(subpath_map) = subpath_map else {
+    // `"exports": "./index.js"` or `"exports": { "import": "./index.js" }` only exports the root.
+    return (subpath == ".")
+      .then(|| resolve_target(exports, None, conditions))
+      .flatten();
+  };
+
+  if let Some(target) = subpath_map.get(subpath) {
+    return resolve_target(target, None, conditions);
+  }
+
+  // Subpath patterns such as `"./*": "./src/*.js"`. The longest matched prefix wins.
+  subpath_map
+    .iter()
+    .filter_map(|(key, target)| {
+      let (prefix, suffix) = key.split_once('*')?;
+      let matched = subpath.strip_prefix(prefix)?.strip_suffix(suffix)?;
+      Some((prefix.len(), target, matched))
+    })
+    .max_by_key(|(prefix_len, ..)| *prefix_len)
+    .and_then(|(_, target, matched)| resolve_target(target, Some(matched), conditions))
+}
+
+fn resolve_target(
+  target: &Value,
+  pattern_match: Option<&str>,
+  conditions: &[&str],
+) -> Option<String> {
+  match target {
+    Value::String(target) => Some(match pattern_match {
+      Some(matched) => target.replace('*', matched),
+      None => target.clone(),
+    }),
+    Value::Array(targets) => targets
+      .iter()
+      .find_map(|target| resolve_target(target, pattern_match, conditions)),
+    Value::Object(map) => map
+      .iter()
+      .filter(|(condition, _)| conditions.contains(&condition.as_str()))
+      .find_map(|(_, target)| resolve_target(target, pattern_match, conditions)),
+    _ => None,
+  }
+}
@@ -0,0 +1,102 @@
+use std::path::Path;
+
+use sugar_path::SugarPath;
+
+use crate::{ImportKind, Resolver};
+
+#[derive(Debug, Clone)]
+struct PathMapping {
+  /// `@x/*` has the prefix `@x/` and an empty suffix. An exact key like `jquery` has no suffix.
+  prefix: String,
+  suffix: Option<String>,
+  /// Absolute paths, which may contain a `*` replaced by the matched part of the specifier.
+  targets: Vec<String>,
+}
+
+impl PathMapping {
+  /// The part of `specifier` matched by `*`, or an empty string for an exact key.
+  fn matched<'a>(&self, specifier: &'a str) -> Option<&'a str> {
+    match &self.suffix {
+      None => (specifier == self.prefix).then_some(""),
+      Some(suffix) => specifier
+        .strip_prefix(self.prefix.as_str())?
+        .strip_suffix(suffix.as_str()),
+    }
+  }
+}
+
+/// `paths` of a tsconfig, which maps bare specifiers to paths, like `"@x/*": ["src/x/*"]`.
+///
+/// An exact key wins over patterns, and the pattern with the longest prefix wins over other
+/// patterns, like in TypeScript. Each key may map to several targets, which are tried in order.
+#[derive(Debug, Clone, Default)]
+pub struct TsConfigPaths {
+  /// Sorted so the most specific mapping comes first. Exact keys come first, then the patterns with
+  /// the longest prefixes.
+  mappings: Vec<PathMapping>,
+}
+
+impl TsConfigPaths {
+  /// Targets are paths relative to `base_dir`, which is the `baseUrl` of the tsconfig, or its
+  /// directory if `baseUrl` isn't set. Keys with more than one `*` are ignored, as TypeScript
+  /// reports them as errors.
+  pub fn new(paths: impl IntoIterator<Item = (String, Vec<String>)>, base_dir: &Path) -> Self {
+    let mut mappings = paths
+      .into_iter()
+      .filter_map(|(key, targets)| {
+        let (prefix, suffix) = match key.split_once('*') {
+          Some((_, suffix)) if suffix.contains('*') => return None,
+          Some((prefix, suffix)) => (prefix.to_string(), Some(suffix.to_string())),
+          None => (key, None),
+        };
+        let targets = targets
+          .iter()
+          .map(|target| {
+            base_dir
+              .join(target)
+              .normalize()
+              .to_string_lossy()
+              .to_string()
+          })
+          .collect();
+        Some(PathMapping {
+          prefix,
+          suffix,
+          targets,
+        })
+      })
+      .collect::<Vec<_>>();
+    mappings.sort_by(|a, b| {
+      a.suffix
+        .is_some()
+        .cmp(&b.suffix.is_some())
+        .then_with(|| b.prefix.len().cmp(&a.prefix.len()))
+        .then_with(|| a.prefix.cmp(&b.prefix))
+    });
+    Self { mappings }
+  }
+
+  /// The first target of the most specific mapping of `specifier` that resolves. `None` if none
+  /// of them exists on disk, in which case TypeScript goes on with `node_modules`, and so do we.
+  pub fn resolve(
+    &self,
+    resolver: &Resolver,
+    importer: &str,
+    specifier: &str,
+    kind: ImportKind,
+  ) -> Option<String> {
+    if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+      return None;
+    }
+    let (mapping, matched) = self
+      .mappings
+      .iter()
+      .find_map(|mapping| Some((mapping, mapping.matched(specifier)?)))?;
+    mapping.targets.iter().find_map(|target| {
+      let candidate = target.replacen('*', matched, 1);
+      resolver
+        .resolve_with_kind(Some(importer), &candidate, kind)
+        .ok()
+    })
+  }
+}
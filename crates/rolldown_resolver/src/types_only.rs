@@ -0,0 +1,39 @@
+use std::path::{Path, PathBuf};
+
+/// `foo/bar` => `foo`, `@scope/foo/bar` => `@scope/foo`. `None` for relative or absolute specifiers.
+fn package_name(specifier: &str) -> Option<&str> {
+  if specifier.starts_with('.') || Path::new(specifier).is_absolute() {
+    return None;
+  }
+  let mut parts = specifier.splitn(3, '/');
+  let len = match parts.next()? {
+    scope if scope.starts_with('@') => scope.len() + 1 + parts.next()?.len(),
+    name => name.len(),
+  };
+  Some(&specifier[..len])
+}
+
+/// `foo` => `@types/foo`, `@scope/foo` => `@types/scope__foo`, like the names published by
+/// DefinitelyTyped.
+fn types_package_name(package_name: &str) -> String {
+  match package_name.strip_prefix('@') {
+    Some(scoped) => format!("@types/{}", scoped.replacen('/', "__", 1)),
+    None => format!("@types/{package_name}"),
+  }
+}
+
+/// The directory of `@types/foo` if `foo` only exists as type declarations, which means there's no
+/// runtime code to bundle. The nearest `node_modules` having either of them wins, like node looks
+/// up packages.
+pub(crate) fn find_types_only_package(importer_dir: &Path, specifier: &str) -> Option<PathBuf> {
+  let name = package_name(specifier)?;
+  let types_name = types_package_name(name);
+  importer_dir.ancestors().find_map(|dir| {
+    let node_modules = dir.join("node_modules");
+    if node_modules.join(name).exists() {
+      return Some(None);
+    }
+    let types_dir = node_modules.join(&types_name);
+    types_dir.is_dir().then_some(Some(types_dir))
+  })?
+}
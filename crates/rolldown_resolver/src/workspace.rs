@@ -0,0 +1,229 @@
+use std::collections::HashMap;
+use std::path::{Path, PathBuf};
+use std::sync::Arc;
+
+use dashmap::DashMap;
+use serde_json::Value;
+
+use crate::{exports_validation::package_name, self_reference::resolve_exports, ImportKind};
+
+const DEPENDENCY_FIELDS: &[&str] = &[
+  "dependencies",
+  "devDependencies",
+  "peerDependencies",
+  "optionalDependencies",
+];
+
+/// The dependencies a package declares with the `workspace:` protocol, like `"dep": "workspace:*"`.
+#[derive(Debug)]
+struct WorkspaceDependencies {
+  dir: PathBuf,
+  /// Dependency names => names of the workspace packages. They differ for aliases, like
+  /// `"dep": "workspace:other@*"`.
+  names: HashMap<String, String>,
+}
+
+#[derive(Debug)]
+struct WorkspacePackage {
+  dir: PathBuf,
+  exports: Option<Value>,
+}
+
+/// The nearest `package.json` of every visited directory and the packages of every visited
+/// workspace, so each file is read at most once per build.
+#[derive(Debug, Default)]
+pub(crate) struct WorkspaceCache {
+  nearest_by_dir: DashMap<PathBuf, Option<Arc<WorkspaceDependencies>>>,
+  /// Keyed by the root of the workspace. Packages are looked up by their names.
+  packages_by_root: DashMap<PathBuf, Arc<HashMap<String, WorkspacePackage>>>,
+}
+
+impl WorkspaceCache {
+  pub(crate) fn clear(&self) {
+    self.nearest_by_dir.clear();
+    self.packages_by_root.clear();
+  }
+
+  fn find_nearest(&self, dir: &Path) -> Option<Arc<WorkspaceDependencies>> {
+    let cached = self
+      .nearest_by_dir
+      .get(dir)
+      .map(|dependencies| dependencies.value().clone());
+    if let Some(dependencies) = cached {
+      return dependencies;
+    }
+
+    let path = dir.join("package.json");
+    let dependencies = if path.is_file() {
+      read_workspace_dependencies(&path).map(Arc::new)
+    } else {
+      dir.parent().and_then(|parent| self.find_nearest(parent))
+    };
+    self
+      .nearest_by_dir
+      .insert(dir.to_path_buf(), dependencies.clone());
+    dependencies
+  }
+
+  /// The packages of the nearest workspace containing `package_dir`.
+  fn find_packages(&self, package_dir: &Path) -> Option<Arc<HashMap<String, WorkspacePackage>>> {
+    let (root, patterns) = package_dir
+      .ancestors()
+      .find_map(|dir| Some((dir, read_workspace_patterns(dir)?)))?;
+    let cached = self
+      .packages_by_root
+      .get(root)
+      .map(|packages| packages.value().clone());
+    if let Some(packages) = cached {
+      return Some(packages);
+    }
+
+    let packages = Arc::new(read_workspace_packages(root, &patterns));
+    self
+      .packages_by_root
+      .insert(root.to_path_buf(), packages.clone());
+    Some(packages)
+  }
+}
+
+fn read_json(path: &Path) -> Option<Value> {
+  serde_json::from_str(&std::fs::read_to_string(path).ok()?).ok()
+}
+
+fn read_workspace_dependencies(path: &Path) -> Option<WorkspaceDependencies> {
+  let json = read_json(path)?;
+  let names = DEPENDENCY_FIELDS
+    .iter()
+    .filter_map(|field| json.get(field)?.as_object())
+    .flatten()
+    .filter_map(|(name, version)| {
+      let range = version.as_str()?.strip_prefix("workspace:")?;
+      let workspace_name = match range.rsplit_once('@') {
+        Some((alias, _)) if !alias.is_empty() => alias,
+        _ => name.as_str(),
+      };
+      Some((name.clone(), workspace_name.to_string()))
+    })
+    .collect::<HashMap<_, _>>();
+  if names.is_empty() {
+    return None;
+  }
+  Some(WorkspaceDependencies {
+    dir: path.parent()?.to_path_buf(),
+    names,
+  })
+}
+
+/// The package patterns of a workspace rooted at `dir`, from the `packages` of
+/// `pnpm-workspace.yaml` or the `workspaces` of `package.json`. `None` if `dir` isn't the root of a
+/// workspace.
+fn read_workspace_patterns(dir: &Path) -> Option<Vec<String>> {
+  let pnpm_workspace = dir.join("pnpm-workspace.yaml");
+  if pnpm_workspace.is_file() {
+    return Some(read_pnpm_workspace_patterns(&pnpm_workspace));
+  }
+  let json = read_json(&dir.join("package.json"))?;
+  let workspaces = json.get("workspaces")?;
+  // Yarn also accepts `"workspaces": { "packages": [...] }`
+  let patterns = workspaces
+    .get("packages")
+    .unwrap_or(workspaces)
+    .as_array()?;
+  Some(
+    patterns
+      .iter()
+      .filter_map(|pattern| Some(pattern.as_str()?.to_string()))
+      .collect(),
+  )
+}
+
+/// Only the `packages` list of `pnpm-workspace.yaml` is read, line by line, which is how pnpm
+/// documents it. The list ends at the next key.
+fn read_pnpm_workspace_patterns(path: &Path) -> Vec<String> {
+  let Ok(source) = std::fs::read_to_string(path) else {
+    return vec![];
+  };
+  source
+    .lines()
+    .skip_while(|line| line.trim_end() != "packages:")
+    .skip(1)
+    .map(|line| line.split('#').next().unwrap_or_default().trim())
+    .filter(|line| !line.is_empty())
+    .map_while(|line| line.strip_prefix('-'))
+    .map(|pattern| {
+      pattern
+        .trim()
+        .trim_matches(|c| c == '\'' || c == '"')
+        .to_string()
+    })
+    .collect()
+}
+
+fn collect_dirs(dir: &Path, recursive: bool, dirs: &mut Vec<PathBuf>) {
+  let Ok(entries) = std::fs::read_dir(dir) else {
+    return;
+  };
+  entries
+    .filter_map(|entry| Some(entry.ok()?.path()))
+    .filter(|path| path.is_dir() && !path.ends_with("node_modules"))
+    .for_each(|path| {
+      if recursive {
+        collect_dirs(&path, recursive, dirs);
+      }
+      dirs.push(path);
+    });
+}
+
+/// `packages/*` matches the directories in `packages`, and `packages/**` the ones at any depth.
+/// Other patterns are taken as directories. Negated patterns, like `!**/test/**`, are ignored, which
+/// only makes more packages found by their names.
+fn read_workspace_packages(root: &Path, patterns: &[String]) -> HashMap<String, WorkspacePackage> {
+  let mut dirs = vec![];
+  patterns
+    .iter()
+    .map(|pattern| pattern.trim_start_matches("./").trim_end_matches('/'))
+    .filter(|pattern| !pattern.starts_with('!'))
+    .for_each(|pattern| {
+      let (base, last) = pattern.rsplit_once('/').unwrap_or(("", pattern));
+      match last {
+        "*" => collect_dirs(&root.join(base), false, &mut dirs),
+        "**" => collect_dirs(&root.join(base), true, &mut dirs),
+        _ => dirs.push(root.join(pattern)),
+      }
+    });
+  dirs
+    .into_iter()
+    .filter_map(|dir| {
+      let mut json = read_json(&dir.join("package.json"))?;
+      let name = json.get("name")?.as_str()?.to_string();
+      let exports = json.get_mut("exports").map(Value::take);
+      Some((name, WorkspacePackage { dir, exports }))
+    })
+    .collect()
+}
+
+/// Resolves `dep/feature` to the directory of the workspace package `dep` if the nearest
+/// `package.json` of the importer declares it with the `workspace:` protocol, like
+/// `"dep": "workspace:*"`, so it's found even without `node_modules`. The `exports` of the package
+/// apply as if it were installed.
+pub(crate) fn resolve_workspace_package(
+  cache: &WorkspaceCache,
+  importer_dir: &Path,
+  specifier: &str,
+  kind: ImportKind,
+) -> Option<PathBuf> {
+  let name = package_name(specifier)?;
+  let dependencies = cache.find_nearest(importer_dir)?;
+  let workspace_name = dependencies.names.get(name)?;
+  let packages = cache.find_packages(&dependencies.dir)?;
+  let package = packages.get(workspace_name)?;
+  let rest = &specifier[name.len()..];
+  match &package.exports {
+    Some(exports) => {
+      let target = resolve_exports(exports, &format!(".{rest}"), kind)?;
+      Some(package.dir.join(target))
+    }
+    None if rest.is_empty() => Some(package.dir.clone()),
+    None => Some(package.dir.join(rest.trim_start_matches('/'))),
+  }
+}
@@ -0,0 +1,25 @@
+mod common;
+use common::TempProject;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.js", "import './dep'");
+  dir.write("dep.js", "");
+  dir
+}
+
+#[test]
+fn repeated_resolution_hits_the_cache() {
+  let dir = create_project("repeated_resolution_hits_the_cache");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.js").to_string_lossy().to_string();
+
+  let first = resolver.resolve(Some(&importer), "./dep").unwrap();
+  assert_eq!(first, dir.join("dep.js").to_string_lossy());
+
+  // A cache hit doesn't touch the file system, so the removed file is still found.
+  std::fs::remove_file(dir.join("dep.js")).unwrap();
+  let second = resolver.resolve(Some(&importer), "./dep").unwrap();
+  assert_eq!(first, second);
+}
@@ -0,0 +1,37 @@
+use std::{
+  ops::Deref,
+  path::{Path, PathBuf},
+};
+
+/// A project in the temp dir, removed when the test ends, even if it fails.
+pub struct TempProject(PathBuf);
+
+impl TempProject {
+  pub fn new(name: &str) -> Self {
+    let dir = std::env::temp_dir().join(format!("rolldown_resolver_{name}_{}", std::process::id()));
+    std::fs::create_dir_all(&dir).unwrap();
+    Self(dir)
+  }
+
+  /// Writes the file at `path`, relative to the project, with its parent directories.
+  pub fn write(&self, path: &str, content: &str) {
+    let path = self.0.join(path);
+    std::fs::create_dir_all(path.parent().unwrap()).unwrap();
+    std::fs::write(path, content).unwrap();
+  }
+}
+
+impl Deref for TempProject {
+  type Target = Path;
+
+  fn deref(&self) -> &Path {
+    &self.0
+  }
+}
+
+impl Drop for TempProject {
+  fn drop(&mut self) {
+    // Panicking while a failed test unwinds would abort the tests.
+    let _ = std::fs::remove_dir_all(&self.0);
+  }
+}
@@ -0,0 +1,33 @@
+mod common;
+use common::TempProject;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.js", "import 'legacy'; import './utils'");
+  dir.write("utils/index.ts", "");
+  dir.write(
+    "node_modules/legacy/package.json",
+    r#"{ "name": "legacy", "main": "./lib" }"#,
+  );
+  dir.write("node_modules/legacy/lib/index.js", "");
+  dir
+}
+
+#[test]
+fn directories_resolve_to_their_index() {
+  let dir = create_project("directories_resolve_to_their_index");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.js").to_string_lossy().to_string();
+
+  assert_eq!(
+    resolver.resolve(Some(&importer), "legacy").unwrap(),
+    dir
+      .join("node_modules/legacy/lib/index.js")
+      .to_string_lossy()
+  );
+  assert_eq!(
+    resolver.resolve(Some(&importer), "./utils").unwrap(),
+    dir.join("utils/index.ts").to_string_lossy()
+  );
+}
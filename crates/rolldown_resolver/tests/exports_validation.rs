@@ -0,0 +1,42 @@
+mod common;
+use common::TempProject;
+use rolldown_error::ErrorKind;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str, exports: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.js", "import 'broken'");
+  dir.write(
+    "node_modules/broken/package.json",
+    &format!(r#"{{ "name": "broken", "exports": {exports} }}"#),
+  );
+  dir.write("node_modules/broken/index.js", "");
+  dir
+}
+
+#[test]
+fn condition_after_default_is_reported_with_the_package_json() {
+  // `dist` isn't published, and `import` is never tried because it comes after `default`.
+  let dir = create_project(
+    "condition_after_default_is_reported_with_the_package_json",
+    r#"{ ".": { "default": "./dist/index.cjs", "import": "./index.js" } }"#,
+  );
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.js").to_string_lossy().to_string();
+
+  let err = resolver.resolve(Some(&importer), "broken").unwrap_err();
+  match err.kind {
+    ErrorKind::InvalidPackageExports {
+      package_json,
+      reason,
+      ..
+    } => {
+      assert_eq!(package_json, dir.join("node_modules/broken/package.json"));
+      assert_eq!(
+        reason,
+        r#""default" must be the last condition of exports["."], but "import" comes after it and never matches."#
+      );
+    }
+    kind => panic!("Unexpected error {kind}"),
+  }
+}
@@ -0,0 +1,44 @@
+mod common;
+use common::TempProject;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  for file in [
+    "a.ts",
+    "a.js",
+    "x.ts",
+    "x.js",
+    "lib/index.ts",
+    "lib/index.js",
+  ] {
+    dir.write(file, "");
+  }
+  dir
+}
+
+#[test]
+fn importers_prefer_extensions_of_their_own_language() {
+  let dir = create_project("importers_prefer_extensions_of_their_own_language");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let ts_importer = dir.join("a.ts").to_string_lossy().to_string();
+  let js_importer = dir.join("a.js").to_string_lossy().to_string();
+
+  assert_eq!(
+    resolver.resolve(Some(&ts_importer), "./x").unwrap(),
+    dir.join("x.ts").to_string_lossy()
+  );
+  assert_eq!(
+    resolver.resolve(Some(&js_importer), "./x").unwrap(),
+    dir.join("x.js").to_string_lossy()
+  );
+  // Both importers are in the same directory, so the cache must tell them apart.
+  assert_eq!(
+    resolver.resolve(Some(&ts_importer), "./lib").unwrap(),
+    dir.join("lib/index.ts").to_string_lossy()
+  );
+  assert_eq!(
+    resolver.resolve(Some(&js_importer), "./lib").unwrap(),
+    dir.join("lib/index.js").to_string_lossy()
+  );
+}
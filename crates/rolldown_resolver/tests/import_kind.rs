@@ -0,0 +1,57 @@
+use std::path::PathBuf;
+
+mod common;
+use common::TempProject;
+use rolldown_resolver::{ImportKind, Resolver};
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.js", "");
+  dir.write(
+    "node_modules/dual/package.json",
+    r#"{
+  "name": "dual",
+  "exports": {
+    ".": { "import": "./esm/index.js", "require": "./cjs/index.js" },
+    "./sync": { "module-sync": "./esm/sync.js", "require": "./cjs/sync.js" }
+  }
+}"#,
+  );
+  for file in ["esm/index.js", "cjs/index.js", "esm/sync.js", "cjs/sync.js"] {
+    dir.write(&format!("node_modules/dual/{file}"), "");
+  }
+  dir
+}
+
+#[test]
+fn dual_packages_resolve_by_the_import_kind() {
+  let dir = create_project("dual_packages_resolve_by_the_import_kind");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.js").to_string_lossy().to_string();
+  let package_dir = dir.join("node_modules/dual");
+
+  let resolve = |specifier: &str, kind| {
+    PathBuf::from(
+      resolver
+        .resolve_with_kind(Some(&importer), specifier, kind)
+        .unwrap(),
+    )
+  };
+  assert_eq!(
+    resolve("dual", ImportKind::Import),
+    package_dir.join("esm/index.js")
+  );
+  assert_eq!(
+    resolve("dual", ImportKind::Require),
+    package_dir.join("cjs/index.js")
+  );
+  // `module-sync` comes first, and matches both
+  assert_eq!(
+    resolve("dual/sync", ImportKind::Import),
+    package_dir.join("esm/sync.js")
+  );
+  assert_eq!(
+    resolve("dual/sync", ImportKind::Require),
+    package_dir.join("esm/sync.js")
+  );
+}
@@ -0,0 +1,41 @@
+use std::path::Path;
+
+use rolldown_resolver::ImportMap;
+
+const IMPORT_MAP: &str = r#"{
+  "imports": {
+    "fmt/": "./vendor/fmt/",
+    "log": "https://deno.land/std/log/mod.ts",
+    "invalid": "lodash"
+  },
+  "scopes": {
+    "./vendor/": {
+      "log": "./vendor/log.js"
+    }
+  }
+}"#;
+
+#[test]
+fn specifiers_are_mapped_through_imports_and_scopes() {
+  let base_dir = Path::new("/project");
+  let import_map = ImportMap::from_json(IMPORT_MAP, base_dir).unwrap();
+  let main = Some("/project/main.js");
+  let vendored = Some("/project/vendor/fmt/colors.js");
+
+  assert_eq!(
+    import_map.resolve(main, "fmt/colors.js").as_deref(),
+    Some("/project/vendor/fmt/colors.js")
+  );
+  assert_eq!(
+    import_map.resolve(main, "log").as_deref(),
+    Some("https://deno.land/std/log/mod.ts")
+  );
+  // The scope of the importer wins over the top-level imports
+  assert_eq!(
+    import_map.resolve(vendored, "log").as_deref(),
+    Some("/project/vendor/log.js")
+  );
+  // Bare addresses are invalid
+  assert_eq!(import_map.resolve(main, "invalid"), None);
+  assert_eq!(import_map.resolve(main, "./utils.js"), None);
+}
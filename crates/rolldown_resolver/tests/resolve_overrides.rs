@@ -0,0 +1,44 @@
+use std::path::Path;
+
+use rolldown_resolver::ResolveOverrides;
+
+#[test]
+fn bare_specifiers_are_forced_to_the_pinned_paths() {
+  let overrides = ResolveOverrides::new(
+    [
+      ("lodash", "./node_modules/lodash/index.js"),
+      ("lodash/", "./node_modules/lodash"),
+      ("legacy>lodash", "./vendor/lodash3.js"),
+      ("./utils.js", "./vendor/utils.js"),
+    ]
+    .map(|(key, target)| (key.to_string(), target.to_string())),
+    Path::new("/project"),
+  );
+  let main = Some("/project/main.js");
+  let nested = Some("/project/node_modules/nested/node_modules/ui/index.js");
+  let legacy = Some("/project/node_modules/legacy/lib/index.js");
+
+  // Wherever it's imported from
+  for importer in [main, nested, None] {
+    assert_eq!(
+      overrides.resolve(importer, "lodash").as_deref(),
+      Some("/project/node_modules/lodash/index.js")
+    );
+  }
+  assert_eq!(
+    overrides.resolve(nested, "lodash/fp").as_deref(),
+    Some("/project/node_modules/lodash/fp")
+  );
+  // The override scoped to the importer wins
+  assert_eq!(
+    overrides.resolve(legacy, "lodash").as_deref(),
+    Some("/project/vendor/lodash3.js")
+  );
+  assert_eq!(
+    overrides.resolve(legacy, "lodash/fp").as_deref(),
+    Some("/project/node_modules/lodash/fp")
+  );
+  // Only bare specifiers are overridden
+  assert_eq!(overrides.resolve(main, "./utils.js"), None);
+  assert_eq!(overrides.resolve(main, "lodash-es"), None);
+}
@@ -0,0 +1,53 @@
+use std::path::PathBuf;
+
+mod common;
+use common::TempProject;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.js", "");
+  dir.write(
+    "node_modules/@scope/pkg/package.json",
+    r#"{
+  "name": "@scope/pkg",
+  "main": "./index.js",
+  "exports": {
+    ".": "./dist/index.js",
+    "./feature": { "import": "./dist/feature.js" },
+    "./utils/*": "./dist/utils/*.js"
+  }
+}"#,
+  );
+  // They would be resolved if `exports` were ignored.
+  for file in [
+    "index.js",
+    "feature.js",
+    "dist/index.js",
+    "dist/feature.js",
+    "dist/utils/math.js",
+  ] {
+    dir.write(&format!("node_modules/@scope/pkg/{file}"), "");
+  }
+  dir
+}
+
+#[test]
+fn scoped_packages_are_resolved_through_their_exports() {
+  let dir = create_project("scoped_packages_are_resolved_through_their_exports");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.js").to_string_lossy().to_string();
+  let package_dir = dir.join("node_modules/@scope/pkg");
+
+  let resolve =
+    |specifier: &str| PathBuf::from(resolver.resolve(Some(&importer), specifier).unwrap());
+  assert_eq!(resolve("@scope/pkg"), package_dir.join("dist/index.js"));
+  assert_eq!(
+    resolve("@scope/pkg/feature"),
+    package_dir.join("dist/feature.js")
+  );
+  assert_eq!(
+    resolve("@scope/pkg/utils/math"),
+    package_dir.join("dist/utils/math.js")
+  );
+}
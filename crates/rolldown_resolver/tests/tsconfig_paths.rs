@@ -0,0 +1,38 @@
+use std::path::PathBuf;
+
+mod common;
+use common::TempProject;
+use rolldown_resolver::{ImportKind, Resolver, TsConfigPaths};
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("main.ts", "");
+  dir.write("src/x/button.ts", "");
+  dir.write("generated/x/icons.ts", "");
+  dir
+}
+
+#[test]
+fn paths_try_every_target_in_order() {
+  let dir = create_project("paths_try_every_target_in_order");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  let importer = dir.join("main.ts").to_string_lossy().to_string();
+  let paths = TsConfigPaths::new(
+    [(
+      "@x/*".to_string(),
+      vec!["src/x/*".to_string(), "generated/x/*".to_string()],
+    )],
+    &dir,
+  );
+
+  let resolve = |specifier: &str| {
+    paths
+      .resolve(&resolver, &importer, specifier, ImportKind::Import)
+      .map(PathBuf::from)
+  };
+  assert_eq!(resolve("@x/button"), Some(dir.join("src/x/button.ts")));
+  // Only the second target exists
+  assert_eq!(resolve("@x/icons"), Some(dir.join("generated/x/icons.ts")));
+  assert_eq!(resolve("@x/missing"), None);
+  assert_eq!(resolve("@y/button"), None);
+}
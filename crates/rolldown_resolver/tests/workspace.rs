@@ -0,0 +1,50 @@
+use std::path::PathBuf;
+
+mod common;
+use common::TempProject;
+use rolldown_resolver::Resolver;
+
+fn create_project(name: &str) -> TempProject {
+  let dir = TempProject::new(name);
+  dir.write("pnpm-workspace.yaml", "packages:\n  - 'packages/*'\n");
+  dir.write(
+    "packages/app/package.json",
+    r#"{
+  "name": "app",
+  "dependencies": {
+    "@acme/ui": "workspace:*"
+  }
+}"#,
+  );
+  dir.write("packages/app/main.js", "");
+  dir.write(
+    "packages/ui/package.json",
+    r#"{
+  "name": "@acme/ui",
+  "exports": {
+    ".": "./src/index.js",
+    "./button": "./src/button.js"
+  }
+}"#,
+  );
+  dir.write("packages/ui/src/index.js", "");
+  dir.write("packages/ui/src/button.js", "");
+  dir
+}
+
+#[test]
+fn workspace_dependencies_resolve_to_sibling_packages() {
+  let dir = create_project("workspace_dependencies_resolve_to_sibling_packages");
+  let resolver = Resolver::with_cwd(dir.to_path_buf(), true);
+  // There's no `node_modules` linking the packages.
+  let importer = dir
+    .join("packages/app/main.js")
+    .to_string_lossy()
+    .to_string();
+  let ui_dir = dir.join("packages/ui");
+
+  let resolve =
+    |specifier: &str| PathBuf::from(resolver.resolve(Some(&importer), specifier).unwrap());
+  assert_eq!(resolve("@acme/ui"), ui_dir.join("src/index.js"));
+  assert_eq!(resolve("@acme/ui/button"), ui_dir.join("src/button.js"));
+}
@@ -82,6 +82,9 @@ impl RuntimeHelpers {
 
 define_helpers!(Helpers {
     merge_namespaces(_mergeNamespaces): (),
+    preload(__preload): (),
+    load_chunk(__loadChunk): (),
+    common_js(__commonJS): (),
 });
 
 #[test]
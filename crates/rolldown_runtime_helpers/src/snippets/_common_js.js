@@ -0,0 +1,5 @@
+function __commonJS(cb, mod) {
+	return function () {
+		return mod || cb((mod = { exports: {} }).exports, mod), mod.exports;
+	};
+}
\ No newline at end of file
@@ -0,0 +1,5 @@
+function __loadChunk(load, onError) {
+	return load().catch(function (error) {
+		return onError(error, load);
+	});
+}
\ No newline at end of file
@@ -0,0 +1,13 @@
+function __preload(load, deps) {
+	if (typeof document !== 'undefined') {
+		deps.forEach(function (dep) {
+			var href = new URL(dep, import.meta.url).href;
+			if (document.querySelector('link[rel="modulepreload"][href="' + href + '"]')) return;
+			var link = document.createElement('link');
+			link.rel = 'modulepreload';
+			link.href = href;
+			document.head.appendChild(link);
+		});
+	}
+	return load();
+}
\ No newline at end of file
@@ -1,23 +1,104 @@
-use swc_core::ecma::{ast, utils::member_expr, visit::VisitMut};
+use swc_core::{
+  common::DUMMY_SP,
+  ecma::{
+    ast,
+    utils::{member_expr, quote_str},
+    visit::VisitMut,
+  },
+};
 
-struct DefaultExportModeShimer;
+/// How `module.exports` of a CJS entry is replaced once its exports are assigned to `exports`.
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+pub enum CjsExportShim {
+  /// `module.exports = exports.default`
+  Default,
+  /// `module.exports = Object.assign(exports.default, exports, { __esModule: true })`. `default`
+  /// is copied as well, so interop helpers looking for `__esModule` find the default export.
+  /// Primitives and `null` can't have properties, so `module.exports` stays `exports` for them.
+  Hybrid,
+}
+
+/// `typeof exports.default === type`
+fn default_is(type_name: &str) -> Box<ast::Expr> {
+  Box::new(ast::Expr::Bin(ast::BinExpr {
+    span: DUMMY_SP,
+    op: ast::BinaryOp::EqEqEq,
+    left: Box::new(ast::Expr::Unary(ast::UnaryExpr {
+      span: DUMMY_SP,
+      op: ast::UnaryOp::TypeOf,
+      arg: member_expr!(DUMMY_SP, exports.default),
+    })),
+    right: Box::new(ast::Expr::Lit(ast::Lit::Str(quote_str!(type_name)))),
+  }))
+}
+
+struct ExportModeShimer {
+  shim: CjsExportShim,
+}
 
-impl VisitMut for DefaultExportModeShimer {
+impl VisitMut for ExportModeShimer {
   fn visit_mut_module(&mut self, node: &mut ast::Module) {
-    // apeend `module.exports = exports.default`
+    let right = match self.shim {
+      CjsExportShim::Default => member_expr!(DUMMY_SP, exports.default),
+      CjsExportShim::Hybrid => {
+        let es_module = ast::ObjectLit {
+          span: DUMMY_SP,
+          props: vec![ast::PropOrSpread::Prop(Box::new(ast::Prop::KeyValue(
+            ast::KeyValueProp {
+              key: ast::PropName::Ident(ast::Ident::new("__esModule".into(), DUMMY_SP)),
+              value: Box::new(ast::Expr::Lit(ast::Lit::Bool(true.into()))),
+            },
+          )))],
+        };
+        let assign = Box::new(ast::Expr::Call(ast::CallExpr {
+          span: DUMMY_SP,
+          callee: ast::Callee::Expr(member_expr!(DUMMY_SP, Object.assign)),
+          args: vec![
+            member_expr!(DUMMY_SP, exports.default).into(),
+            member_expr!(DUMMY_SP, exports).into(),
+            ast::Expr::Object(es_module).into(),
+          ],
+          type_args: None,
+        }));
+        // typeof exports.default === "function" || typeof exports.default === "object" &&
+        // exports.default !== null
+        let has_properties = Box::new(ast::Expr::Bin(ast::BinExpr {
+          span: DUMMY_SP,
+          op: ast::BinaryOp::LogicalOr,
+          left: default_is("function"),
+          right: Box::new(ast::Expr::Bin(ast::BinExpr {
+            span: DUMMY_SP,
+            op: ast::BinaryOp::LogicalAnd,
+            left: default_is("object"),
+            right: Box::new(ast::Expr::Bin(ast::BinExpr {
+              span: DUMMY_SP,
+              op: ast::BinaryOp::NotEqEq,
+              left: member_expr!(DUMMY_SP, exports.default),
+              right: Box::new(ast::Expr::Lit(ast::Lit::Null(ast::Null { span: DUMMY_SP }))),
+            })),
+          })),
+        }));
+        Box::new(ast::Expr::Cond(ast::CondExpr {
+          span: DUMMY_SP,
+          test: has_properties,
+          cons: assign,
+          alt: member_expr!(DUMMY_SP, exports),
+        }))
+      }
+    };
     let item = ast::ModuleItem::Stmt(ast::Stmt::Expr(ast::ExprStmt {
-      span: Default::default(),
+      span: DUMMY_SP,
       expr: Box::new(ast::Expr::Assign(ast::AssignExpr {
-        span: Default::default(),
+        span: DUMMY_SP,
         op: ast::AssignOp::Assign,
-        left: ast::PatOrExpr::Expr(member_expr!(Default::default(), module.exports)),
-        right: member_expr!(Default::default(), exports.default),
+        left: ast::PatOrExpr::Expr(member_expr!(DUMMY_SP, module.exports)),
+        right,
       })),
     }));
     node.body.push(item);
   }
 }
 
-pub fn default_export_mode_shimer() -> impl VisitMut {
-  DefaultExportModeShimer
+pub fn export_mode_shimer(shim: CjsExportShim) -> impl VisitMut {
+  ExportModeShimer { shim }
 }
@@ -1,5 +1,5 @@
 use ast::{ExportNamedSpecifier, Id, Ident, PropName};
-use rolldown_common::{ChunkId, ModuleId};
+use rolldown_common::{relative_chunk_path, ChunkId, ModuleId, Symbol};
 use rustc_hash::{FxHashMap as HashMap, FxHashSet as HashSet};
 use swc_common::{util::take::Take, SyntaxContext, DUMMY_SP};
 use swc_core::{
@@ -41,20 +41,42 @@ impl IdentType {
   }
 }
 
+/// What `require("./a")` is rewritten to if the required module is bundled.
+#[derive(Debug, Clone, PartialEq, Eq)]
+pub enum RequiredModule {
+  /// `require_a()`, the wrapper of the CommonJS module, which evaluates it on the first call.
+  CommonJs(Symbol),
+  /// `a_exports`, the namespace of the ES module.
+  Esm(Symbol),
+  /// `require("./native-1a2b3c4d.node")`, an external required by another path, like the copy of a
+  /// native addon.
+  External(JsWord),
+}
+
 #[derive(Debug, Clone)]
 pub struct FinalizeContext<'me> {
   /// Used to rewrite dynamic import
   pub resolved_ids: &'me HashMap<JsWord, ModuleId>,
+  /// Used to rewrite `require` calls with a literal argument. Others are left to be resolved at
+  /// runtime.
+  pub required_modules: &'me HashMap<JsWord, RequiredModule>,
   /// All declared scoped names in this chunk
   pub declared_scoped_names: &'me HashSet<JsWord>,
   pub unresolved_ctxt: SyntaxContext,
   /// Used to rewrite dynamic import
   pub chunk_filename_by_id: &'me HashMap<ChunkId, String>,
+  /// Filename of the chunk being finalized. Rewritten dynamic imports are relative to it.
+  pub chunk_filename: &'me str,
   // All top_level_ctxt of modules belong to this chunk
   pub top_level_ctxt_set: &'me HashSet<SyntaxContext>,
   pub top_level_id_to_final_name: &'me HashMap<Id, JsWord>,
   pub split_point_id_to_chunk_id: &'me HashMap<ModuleId, ChunkId>,
   pub top_level_names: &'me HashSet<&'me JsWord>,
+  /// Files to preload for chunks imported dynamically, relative to the output dir. Dynamic imports
+  /// are wrapped with the `__preload` helper if it's set.
+  pub preload_deps_by_chunk_id: Option<&'me HashMap<ChunkId, Vec<String>>>,
+  /// The global function dynamic imports are passed to by the `__loadChunk` helper if they fail.
+  pub chunk_load_error_handler: Option<&'me str>,
 }
 
 #[instrument(skip_all)]
@@ -145,7 +167,8 @@ impl<'a> Finalizer<'a> {
     }
   }
 
-  fn rewrite_dynamic_import(&mut self, node: &mut ast::CallExpr) -> Option<()> {
+  /// Returns the imported chunk.
+  fn rewrite_dynamic_import(&mut self, node: &mut ast::CallExpr) -> Option<ChunkId> {
     if node.callee.is_import() {
       let first_arg = node.args.get_mut(0)?.expr.as_mut_lit()?;
       if let ast::Lit::Str(ast::Str {
@@ -158,13 +181,129 @@ impl<'a> Finalizer<'a> {
         let module_id = self.resolve_module_id(local_module_id)?;
         let chunk_id = self.ctx.split_point_id_to_chunk_id.get(module_id)?;
         let filename = self.ctx.chunk_filename_by_id.get(chunk_id)?;
-        *local_module_id = format!("./{}", filename.clone()).into();
+        *local_module_id = relative_chunk_path(self.ctx.chunk_filename, filename).into();
+        return Some(chunk_id.clone());
       };
     }
 
+    None
+  }
+
+  /// `import("./a.js")` => `__preload(() => import("./a.js"), ["./a.js", "./shared.js"])`. The
+  /// importer itself is already loaded, so it's left out.
+  fn preload_dynamic_import(&self, node: &mut ast::CallExpr, chunk_id: &ChunkId) -> Option<()> {
+    let deps = self.ctx.preload_deps_by_chunk_id?.get(chunk_id)?;
+    let deps = deps
+      .iter()
+      .filter(|dep| *dep != self.ctx.chunk_filename)
+      .map(|dep| {
+        let specifier = relative_chunk_path(self.ctx.chunk_filename, dep);
+        Some(ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(ast::Expr::Lit(quote_str!(specifier).into())),
+        })
+      })
+      .collect();
+    let import = node.take();
+    let load = ast::ArrowExpr {
+      span: DUMMY_SP,
+      params: vec![],
+      body: ast::BlockStmtOrExpr::Expr(Box::new(ast::Expr::Call(import))).into(),
+      is_async: false,
+      is_generator: false,
+      type_params: None,
+      return_type: None,
+    };
+    *node = ast::CallExpr {
+      span: DUMMY_SP,
+      callee: ast::Callee::Expr(Box::new(quote_ident!("__preload").into())),
+      args: vec![
+        ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(ast::Expr::Arrow(load)),
+        },
+        ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(ast::Expr::Array(ast::ArrayLit {
+            span: DUMMY_SP,
+            elems: deps,
+          })),
+        },
+      ],
+      type_args: None,
+    };
+    Some(())
+  }
+
+  /// `import("./a.js")` => `__loadChunk(() => import("./a.js"), onChunkLoadError)`
+  fn catch_chunk_load_error(&self, node: &mut ast::CallExpr) -> Option<()> {
+    let handler = self.ctx.chunk_load_error_handler?;
+    let load = ast::ArrowExpr {
+      span: DUMMY_SP,
+      params: vec![],
+      body: ast::BlockStmtOrExpr::Expr(Box::new(ast::Expr::Call(node.take()))).into(),
+      is_async: false,
+      is_generator: false,
+      type_params: None,
+      return_type: None,
+    };
+    *node = ast::CallExpr {
+      span: DUMMY_SP,
+      callee: ast::Callee::Expr(Box::new(quote_ident!("__loadChunk").into())),
+      args: vec![
+        ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(ast::Expr::Arrow(load)),
+        },
+        ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(quote_ident!(handler).into()),
+        },
+      ],
+      type_args: None,
+    };
     Some(())
   }
 
+  /// `require("./a")` => `require_a()`, or `a_exports` if `./a` is an ES module. The symbols are
+  /// renamed along with the other top-level ones. Externals may be required by another path.
+  fn rewrite_require(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Call(ast::CallExpr {
+      callee: ast::Callee::Expr(box ast::Expr::Ident(callee)),
+      args,
+      ..
+    }) = expr else {
+      return;
+    };
+    if &*callee.sym != "require" || callee.span.ctxt != self.ctx.unresolved_ctxt {
+      return;
+    }
+    let Some(ast::ExprOrSpread {
+      spread: None,
+      expr: box ast::Expr::Lit(ast::Lit::Str(specifier)),
+    }) = args.get_mut(0) else {
+      return;
+    };
+    match self.ctx.required_modules.get(&specifier.value) {
+      Some(RequiredModule::CommonJs(wrapper)) => {
+        *expr = ast::Expr::Call(ast::CallExpr {
+          span: DUMMY_SP,
+          callee: ast::Callee::Expr(Box::new(Ident::from(wrapper.clone().to_id()).into())),
+          args: vec![],
+          type_args: None,
+        });
+      }
+      Some(RequiredModule::Esm(namespace)) => {
+        *expr = Ident::from(namespace.clone().to_id()).into();
+      }
+      Some(RequiredModule::External(path)) => {
+        specifier.value = path.clone();
+        specifier.raw = None;
+      }
+      None => {}
+    }
+  }
+
   fn resolve_module_id(&self, local_module_id: &JsWord) -> Option<&ModuleId> {
     let resolved_id = self.ctx.resolved_ids.get(local_module_id)?;
     Some(resolved_id)
@@ -296,6 +435,11 @@ impl<'a> Finalizer<'a> {
 }
 
 impl<'a> VisitMut for Finalizer<'a> {
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    self.rewrite_require(expr);
+    expr.visit_mut_children_with(self);
+  }
+
   fn visit_mut_ident(&mut self, ident: &mut Ident) {
     match self.ident_type(ident) {
       IdentType::TopLevel => {
@@ -344,7 +488,13 @@ impl<'a> VisitMut for Finalizer<'a> {
   }
 
   fn visit_mut_call_expr(&mut self, node: &mut ast::CallExpr) {
-    self.rewrite_dynamic_import(node);
+    let imported_chunk = self.rewrite_dynamic_import(node);
     node.visit_mut_children_with(self);
+    // Wrapped after visiting the children, so the import isn't visited again.
+    if let Some(chunk_id) = imported_chunk {
+      self.preload_dynamic_import(node, &chunk_id);
+      // A retry preloads the dependencies again.
+      self.catch_chunk_load_error(node);
+    }
   }
 }
@@ -19,10 +19,20 @@ use swc_core::{
 pub use treeshake::*;
 mod to_cjs;
 pub use to_cjs::*;
+mod to_system;
+pub use to_system::*;
+mod to_iife;
+pub use to_iife::*;
 mod export_mode_shimer;
 pub use export_mode_shimer::*;
 mod clean_ast;
 pub use clean_ast::clean_ast;
+mod minify;
+pub use minify::*;
+mod top_level_this;
+pub use top_level_this::*;
+mod lower_logical_assignment;
+pub use lower_logical_assignment::*;
 
 struct ClearSyntaxContext;
 
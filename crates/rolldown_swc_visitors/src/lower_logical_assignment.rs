@@ -0,0 +1,346 @@
+use rustc_hash::FxHashSet as HashSet;
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::{
+    ast,
+    atoms::JsWord,
+    visit::{noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+#[derive(Default)]
+struct NameCollector {
+  names: HashSet<JsWord>,
+}
+
+impl Visit for NameCollector {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    self.names.insert(ident.sym.clone());
+  }
+}
+
+fn ident_expr(name: JsWord) -> Box<ast::Expr> {
+  Box::new(ast::Expr::Ident(ast::Ident::new(name, DUMMY_SP)))
+}
+
+fn ident_target(name: JsWord) -> ast::PatOrExpr {
+  ast::PatOrExpr::Pat(Box::new(ast::Pat::Ident(
+    ast::Ident::new(name, DUMMY_SP).into(),
+  )))
+}
+
+fn assign_expr(left: ast::PatOrExpr, right: Box<ast::Expr>) -> Box<ast::Expr> {
+  Box::new(ast::Expr::Assign(ast::AssignExpr {
+    span: DUMMY_SP,
+    op: ast::AssignOp::Assign,
+    left,
+    right,
+  }))
+}
+
+fn paren(expr: Box<ast::Expr>) -> Box<ast::Expr> {
+  Box::new(ast::Expr::Paren(ast::ParenExpr {
+    span: DUMMY_SP,
+    expr,
+  }))
+}
+
+/// `var _ref, _ref$1;` after the directives of the body.
+fn declare_temps(stmts: &mut Vec<ast::Stmt>, temps: Vec<JsWord>) {
+  if temps.is_empty() {
+    return;
+  }
+  let idx = stmts
+    .iter()
+    .take_while(|stmt| {
+      matches!(
+        stmt,
+        ast::Stmt::Expr(ast::ExprStmt {
+          expr: box ast::Expr::Lit(ast::Lit::Str(_)),
+          ..
+        })
+      )
+    })
+    .count();
+  stmts.insert(idx, temps_decl(temps));
+}
+
+fn temps_decl(temps: Vec<JsWord>) -> ast::Stmt {
+  ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+    span: DUMMY_SP,
+    kind: ast::VarDeclKind::Var,
+    declare: false,
+    decls: temps
+      .into_iter()
+      .map(|name| ast::VarDeclarator {
+        span: DUMMY_SP,
+        name: ast::Pat::Ident(ast::Ident::new(name, DUMMY_SP).into()),
+        init: None,
+        definite: false,
+      })
+      .collect(),
+  })))
+}
+
+struct LogicalAssignmentLowerer<'a> {
+  /// Names of the module and of the chunk, which temporaries never shadow.
+  used_names: HashSet<JsWord>,
+  top_level_names: &'a HashSet<&'a JsWord>,
+  /// Temporaries of the function being visited. Each function declares its own, so a recursive
+  /// call in the right side can't overwrite the ones of its caller.
+  temps: Vec<JsWord>,
+  next_temp: usize,
+}
+
+impl LogicalAssignmentLowerer<'_> {
+  fn temp(&mut self) -> JsWord {
+    loop {
+      let name: JsWord = match self.next_temp {
+        0 => "_ref".into(),
+        idx => format!("_ref${idx}").into(),
+      };
+      self.next_temp += 1;
+      if !self.used_names.contains(&name) && !self.top_level_names.contains(&name) {
+        self.temps.push(name.clone());
+        return name;
+      }
+    }
+  }
+
+  /// Visits a function with temporaries of its own, and returns them to be declared in its body.
+  fn in_function_scope(&mut self, visit: impl FnOnce(&mut Self)) -> Vec<JsWord> {
+    let temps = std::mem::take(&mut self.temps);
+    let next_temp = std::mem::take(&mut self.next_temp);
+    visit(self);
+    self.next_temp = next_temp;
+    std::mem::replace(&mut self.temps, temps)
+  }
+
+  /// The expression for the read and the one for the write, which evaluate it once together.
+  /// Reusable ones, like identifiers, have no side effects, so they are used by both.
+  fn memoize(
+    &mut self,
+    expr: &mut Box<ast::Expr>,
+    is_reusable: bool,
+  ) -> (Box<ast::Expr>, Box<ast::Expr>) {
+    if is_reusable {
+      return (expr.clone(), expr.take());
+    }
+    let temp = self.temp();
+    (
+      assign_expr(ident_target(temp.clone()), expr.take()),
+      ident_expr(temp),
+    )
+  }
+
+  fn split_member_prop(
+    &mut self,
+    prop: &mut ast::MemberProp,
+  ) -> (ast::MemberProp, ast::MemberProp) {
+    match prop {
+      ast::MemberProp::Computed(computed) => {
+        let is_reusable = matches!(&*computed.expr, ast::Expr::Lit(_));
+        let (read, write) = self.memoize(&mut computed.expr, is_reusable);
+        (
+          ast::MemberProp::Computed(ast::ComputedPropName {
+            span: computed.span,
+            expr: read,
+          }),
+          ast::MemberProp::Computed(ast::ComputedPropName {
+            span: computed.span,
+            expr: write,
+          }),
+        )
+      }
+      prop => (prop.clone(), prop.clone()),
+    }
+  }
+
+  /// The read and the write of the target of the assignment, or `None` if it's not a reference.
+  fn split_target(&mut self, target: &mut ast::Expr) -> Option<(Box<ast::Expr>, ast::PatOrExpr)> {
+    match target {
+      ast::Expr::Ident(ident) => Some((
+        Box::new(ast::Expr::Ident(ident.clone())),
+        ast::PatOrExpr::Pat(Box::new(ast::Pat::Ident(ident.clone().into()))),
+      )),
+      ast::Expr::Member(member) => {
+        let is_reusable = matches!(&*member.obj, ast::Expr::Ident(_) | ast::Expr::This(_));
+        let (mut read_obj, write_obj) = self.memoize(&mut member.obj, is_reusable);
+        if !is_reusable {
+          read_obj = paren(read_obj);
+        }
+        let (read_prop, write_prop) = self.split_member_prop(&mut member.prop);
+        Some((
+          Box::new(ast::Expr::Member(ast::MemberExpr {
+            span: member.span,
+            obj: read_obj,
+            prop: read_prop,
+          })),
+          ast::PatOrExpr::Expr(Box::new(ast::Expr::Member(ast::MemberExpr {
+            span: member.span,
+            obj: write_obj,
+            prop: write_prop,
+          }))),
+        ))
+      }
+      ast::Expr::SuperProp(super_prop) => {
+        let (read_prop, write_prop) = match &mut super_prop.prop {
+          ast::SuperProp::Computed(computed) => {
+            let is_reusable = matches!(&*computed.expr, ast::Expr::Lit(_));
+            let (read, write) = self.memoize(&mut computed.expr, is_reusable);
+            (
+              ast::SuperProp::Computed(ast::ComputedPropName {
+                span: computed.span,
+                expr: read,
+              }),
+              ast::SuperProp::Computed(ast::ComputedPropName {
+                span: computed.span,
+                expr: write,
+              }),
+            )
+          }
+          prop => (prop.clone(), prop.clone()),
+        };
+        Some((
+          Box::new(ast::Expr::SuperProp(ast::SuperPropExpr {
+            span: super_prop.span,
+            obj: super_prop.obj.clone(),
+            prop: read_prop,
+          })),
+          ast::PatOrExpr::Expr(Box::new(ast::Expr::SuperProp(ast::SuperPropExpr {
+            span: super_prop.span,
+            obj: super_prop.obj.clone(),
+            prop: write_prop,
+          }))),
+        ))
+      }
+      _ => None,
+    }
+  }
+
+  /// `a.b ||= c` => `a.b || (a.b = c)`
+  fn lower(&mut self, expr: &mut ast::Expr) {
+    let ast::Expr::Assign(assign) = expr else {
+      return;
+    };
+    let op = match assign.op {
+      ast::AssignOp::OrAssign => ast::BinaryOp::LogicalOr,
+      ast::AssignOp::AndAssign => ast::BinaryOp::LogicalAnd,
+      ast::AssignOp::NullishAssign => ast::BinaryOp::NullishCoalescing,
+      _ => return,
+    };
+    let split = match &mut assign.left {
+      ast::PatOrExpr::Expr(target) | ast::PatOrExpr::Pat(box ast::Pat::Expr(target)) => {
+        self.split_target(target)
+      }
+      ast::PatOrExpr::Pat(box ast::Pat::Ident(ident)) => {
+        self.split_target(&mut ast::Expr::Ident(ident.id.clone()))
+      }
+      _ => None,
+    };
+    let Some((read, write)) = split else {
+      return;
+    };
+    *expr = ast::Expr::Bin(ast::BinExpr {
+      span: assign.span,
+      op,
+      left: read,
+      right: paren(assign_expr(write, assign.right.take())),
+    });
+  }
+}
+
+impl VisitMut for LogicalAssignmentLowerer<'_> {
+  fn visit_mut_module(&mut self, module: &mut ast::Module) {
+    let mut collector = NameCollector::default();
+    module.visit_with(&mut collector);
+    self.used_names = collector.names;
+    module.visit_mut_children_with(self);
+    let temps = std::mem::take(&mut self.temps);
+    if !temps.is_empty() {
+      module
+        .body
+        .insert(0, ast::ModuleItem::Stmt(temps_decl(temps)));
+    }
+  }
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    expr.visit_mut_children_with(self);
+    self.lower(expr);
+  }
+
+  fn visit_mut_function(&mut self, function: &mut ast::Function) {
+    let temps = self.in_function_scope(|this| function.visit_mut_children_with(this));
+    if let Some(body) = &mut function.body {
+      declare_temps(&mut body.stmts, temps);
+    }
+  }
+
+  fn visit_mut_constructor(&mut self, constructor: &mut ast::Constructor) {
+    let temps = self.in_function_scope(|this| constructor.visit_mut_children_with(this));
+    if let Some(body) = &mut constructor.body {
+      declare_temps(&mut body.stmts, temps);
+    }
+  }
+
+  fn visit_mut_getter_prop(&mut self, prop: &mut ast::GetterProp) {
+    prop.key.visit_mut_with(self);
+    let temps = self.in_function_scope(|this| prop.body.visit_mut_with(this));
+    if let Some(body) = &mut prop.body {
+      declare_temps(&mut body.stmts, temps);
+    }
+  }
+
+  fn visit_mut_setter_prop(&mut self, prop: &mut ast::SetterProp) {
+    prop.key.visit_mut_with(self);
+    let temps = self.in_function_scope(|this| {
+      prop.param.visit_mut_with(this);
+      prop.body.visit_mut_with(this);
+    });
+    if let Some(body) = &mut prop.body {
+      declare_temps(&mut body.stmts, temps);
+    }
+  }
+
+  fn visit_mut_arrow_expr(&mut self, arrow: &mut ast::ArrowExpr) {
+    let temps = self.in_function_scope(|this| arrow.visit_mut_children_with(this));
+    if temps.is_empty() {
+      return;
+    }
+    // `() => a.b ||= c` => `() => { var _ref; return ... }`
+    if let Some(expr) = arrow.body.as_mut_expr() {
+      let ret = ast::Stmt::Return(ast::ReturnStmt {
+        span: DUMMY_SP,
+        arg: Some(expr.take()),
+      });
+      arrow.body = ast::BlockStmtOrExpr::BlockStmt(ast::BlockStmt {
+        span: DUMMY_SP,
+        stmts: vec![ret],
+      })
+      .into();
+    }
+    if let Some(block) = arrow.body.as_mut_block_stmt() {
+      declare_temps(&mut block.stmts, temps);
+    }
+  }
+}
+
+/// Lowers logical assignments for targets without them. The object and the computed key of the
+/// target are evaluated once, through temporaries declared by the enclosing function:
+///
+/// - `a ||= b` => `a || (a = b)`
+/// - `a.b &&= c` => `a.b && (a.b = c)`
+/// - `f().b ??= c` => `(_ref = f()).b ?? (_ref.b = c)`
+///
+/// `top_level_names` are the names of the chunk, since a temporary declared in the scope of the
+/// module is in the scope of the chunk as well.
+pub fn lower_logical_assignment(module: &mut ast::Module, top_level_names: &HashSet<&JsWord>) {
+  module.visit_mut_with(&mut LogicalAssignmentLowerer {
+    used_names: Default::default(),
+    top_level_names,
+    temps: vec![],
+    next_temp: 0,
+  });
+}
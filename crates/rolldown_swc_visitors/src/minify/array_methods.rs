@@ -0,0 +1,170 @@
+use swc_core::{
+  common::{util::take::Take, Span},
+  ecma::ast,
+};
+
+use super::{template_literal::str_expr, MinifySyntax};
+
+struct MethodCall<'a> {
+  span: Span,
+  receiver: &'a mut ast::Expr,
+  args: &'a mut Vec<ast::ExprOrSpread>,
+}
+
+/// `receiver.method(...args)`, which is only folded for the safelisted methods.
+fn as_method_call<'a>(expr: &'a mut ast::Expr, method: &str) -> Option<MethodCall<'a>> {
+  let ast::Expr::Call(ast::CallExpr {
+    span,
+    callee:
+      ast::Callee::Expr(box ast::Expr::Member(ast::MemberExpr {
+        obj,
+        prop: ast::MemberProp::Ident(prop),
+        ..
+      })),
+    args,
+    ..
+  }) = expr
+  else {
+    return None;
+  };
+  (&*prop.sym == method).then_some(MethodCall {
+    span: *span,
+    receiver: obj,
+    args,
+  })
+}
+
+/// `"a,b".split(",")` => `["a", "b"]`. An empty separator splits UTF-16 code units, so strings out
+/// of the BMP are kept, since a lone surrogate can't be written as a string literal.
+fn split_str(span: Span, value: &str, separator: &str) -> Option<ast::ArrayLit> {
+  let parts: Vec<String> = match separator {
+    "" if value.chars().any(|c| c.len_utf16() > 1) => return None,
+    "" => value.chars().map(String::from).collect(),
+    separator => value.split(separator).map(String::from).collect(),
+  };
+  Some(ast::ArrayLit {
+    span,
+    elems: parts
+      .into_iter()
+      .map(|part| {
+        Some(ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(str_expr(span, part)),
+        })
+      })
+      .collect(),
+  })
+}
+
+impl MinifySyntax<'_> {
+  /// How an element is stringified by `join`, where `null` and `undefined` are empty.
+  fn as_joined_string(&self, elem: Option<&ast::ExprOrSpread>) -> Option<String> {
+    let Some(elem) = elem else {
+      return Some(String::new());
+    };
+    if elem.spread.is_some() {
+      return None;
+    }
+    match &*elem.expr {
+      ast::Expr::Lit(ast::Lit::Null(_)) => Some(String::new()),
+      ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Void,
+        arg,
+        ..
+      }) if self.is_pure(arg) => Some(String::new()),
+      ast::Expr::Ident(ident)
+        if &*ident.sym == "undefined" && ident.span.ctxt == self.unresolved_ctxt =>
+      {
+        Some(String::new())
+      }
+      expr => self.as_const_string(expr),
+    }
+  }
+
+  /// `[1, 2, 3].join("-")` => `"1-2-3"`. The separator is `,` if it's omitted.
+  fn fold_join(&self, expr: &mut ast::Expr) -> Option<ast::Expr> {
+    let call = as_method_call(expr, "join")?;
+    let separator = match &call.args[..] {
+      [] => ",".to_string(),
+      [ast::ExprOrSpread { spread: None, expr }] => match &**expr {
+        ast::Expr::Lit(ast::Lit::Str(str)) => str.value.to_string(),
+        _ => return None,
+      },
+      _ => return None,
+    };
+    let ast::Expr::Array(array) = call.receiver else {
+      return None;
+    };
+    let parts = array
+      .elems
+      .iter()
+      .map(|elem| self.as_joined_string(elem.as_ref()))
+      .collect::<Option<Vec<_>>>()?;
+    Some(str_expr(call.span, parts.join(&separator)))
+  }
+
+  /// `[1, 2].concat([3], 4)` => `[1, 2, 3, 4]`. Only array literals and primitives are accepted as
+  /// arguments, since other objects could be spread by `Symbol.isConcatSpreadable`.
+  fn fold_concat(&self, expr: &mut ast::Expr) -> Option<ast::Expr> {
+    let call = as_method_call(expr, "concat")?;
+    let is_concatenable = |expr: &ast::Expr| match expr {
+      ast::Expr::Array(_) => self.is_pure(expr),
+      ast::Expr::Lit(ast::Lit::Regex(_)) => false,
+      ast::Expr::Lit(_) => true,
+      _ => false,
+    };
+    let are_args_concatenable = call
+      .args
+      .iter()
+      .all(|arg| arg.spread.is_none() && is_concatenable(&arg.expr));
+    if !are_args_concatenable || !self.is_pure(call.receiver) {
+      return None;
+    }
+    let ast::Expr::Array(array) = call.receiver else {
+      return None;
+    };
+    let mut array = array.take();
+    for arg in call.args.take() {
+      match *arg.expr {
+        ast::Expr::Array(arg) => array.elems.extend(arg.elems),
+        expr => array.elems.push(Some(ast::ExprOrSpread {
+          spread: None,
+          expr: Box::new(expr),
+        })),
+      }
+    }
+    Some(ast::Expr::Array(array))
+  }
+
+  /// `"a,b".split(",")`, only when it's accessed by an index or `length` right away, since the array
+  /// is usually longer than the call.
+  pub(super) fn fold_split_for_access(&self, obj: &mut ast::Expr) {
+    let Some(call) = as_method_call(obj, "split") else {
+      return;
+    };
+    let [ast::ExprOrSpread {
+      spread: None,
+      expr: box ast::Expr::Lit(ast::Lit::Str(separator)),
+    }] = &call.args[..]
+    else {
+      return;
+    };
+    let ast::Expr::Lit(ast::Lit::Str(str)) = &*call.receiver else {
+      return;
+    };
+    let Some(array) = split_str(call.span, &str.value, &separator.value) else {
+      return;
+    };
+    *obj = ast::Expr::Array(array);
+  }
+
+  /// Calls of pure and deterministic methods on literals, whose arguments are literals as well.
+  ///
+  /// - `[1, 2, 3].join("-")` => `"1-2-3"`
+  /// - `[1, 2].concat([3])` => `[1, 2, 3]`
+  pub(super) fn fold_array_method(&self, expr: &mut ast::Expr) {
+    if let Some(folded) = self.fold_join(expr).or_else(|| self.fold_concat(expr)) {
+      *expr = folded;
+    }
+  }
+}
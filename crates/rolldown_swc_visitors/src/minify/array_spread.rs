@@ -0,0 +1,82 @@
+use swc_core::{common::util::take::Take, ecma::ast};
+
+use super::{void_context::void_zero, MinifySyntax};
+
+/// Iterating an array literal yields its elements in order, and holes as `undefined`, so the copy of
+/// it is dense.
+fn into_dense_elems(array: ast::ArrayLit) -> Vec<Option<ast::ExprOrSpread>> {
+  let span = array.span;
+  array
+    .elems
+    .into_iter()
+    .map(|elem| {
+      Some(elem.unwrap_or_else(|| ast::ExprOrSpread {
+        spread: None,
+        expr: Box::new(void_zero(span)),
+      }))
+    })
+    .collect()
+}
+
+/// `[0, ...[1, 2]]` => `[0, 1, 2]`
+fn flatten_array_spreads(array: &mut ast::ArrayLit) {
+  let has_array_spread = array.elems.iter().any(|elem| {
+    matches!(
+      elem,
+      Some(ast::ExprOrSpread {
+        spread: Some(_),
+        expr: box ast::Expr::Array(_),
+      })
+    )
+  });
+  if !has_array_spread {
+    return;
+  }
+  array.elems = array
+    .elems
+    .take()
+    .into_iter()
+    .flat_map(|elem| match elem {
+      Some(ast::ExprOrSpread {
+        spread: Some(_),
+        expr: box ast::Expr::Array(inner),
+      }) => into_dense_elems(inner),
+      elem => vec![elem],
+    })
+    .collect();
+}
+
+impl MinifySyntax<'_> {
+  fn is_array_from(&self, callee: &ast::Callee) -> bool {
+    matches!(
+      callee,
+      ast::Callee::Expr(box ast::Expr::Member(ast::MemberExpr {
+        obj: box ast::Expr::Ident(obj),
+        prop: ast::MemberProp::Ident(prop),
+        ..
+      })) if &*obj.sym == "Array" && obj.span.ctxt == self.unresolved_ctxt && &*prop.sym == "from"
+    )
+  }
+
+  /// - `Array.from([1, 2])` => `[1, 2]`, without a map function
+  /// - `[...[1, 2, 3]]` => `[1, 2, 3]`
+  pub(super) fn fold_array_spread(&self, expr: &mut ast::Expr) {
+    if let ast::Expr::Call(call) = expr
+      && self.is_array_from(&call.callee)
+      && let [ast::ExprOrSpread {
+        spread: None,
+        expr: box ast::Expr::Array(source),
+      }] = call.args.as_mut_slice()
+    {
+      let span = call.span;
+      *expr = ast::Expr::Array(ast::ArrayLit {
+        span,
+        elems: into_dense_elems(source.take()),
+      });
+    }
+
+    if let ast::Expr::Array(array) = expr {
+      flatten_array_spreads(array);
+    }
+  }
+}
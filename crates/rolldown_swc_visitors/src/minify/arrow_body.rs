@@ -0,0 +1,73 @@
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::ast,
+};
+
+/// Whether the printed expression would start with `{`, which would be parsed as a block if it's
+/// the body of an arrow function.
+pub(super) fn starts_with_object_literal(expr: &ast::Expr) -> bool {
+  match expr {
+    ast::Expr::Object(_) => true,
+    ast::Expr::Member(ast::MemberExpr { obj, .. }) => starts_with_object_literal(obj),
+    ast::Expr::Call(ast::CallExpr {
+      callee: ast::Callee::Expr(callee),
+      ..
+    }) => starts_with_object_literal(callee),
+    ast::Expr::Bin(ast::BinExpr { left, .. }) => starts_with_object_literal(left),
+    ast::Expr::Cond(ast::CondExpr { test, .. }) => starts_with_object_literal(test),
+    ast::Expr::Seq(ast::SeqExpr { exprs, .. }) => exprs
+      .first()
+      .map_or(false, |expr| starts_with_object_literal(expr)),
+    ast::Expr::Assign(ast::AssignExpr { left, .. }) => match left {
+      ast::PatOrExpr::Pat(box ast::Pat::Object(_)) => true,
+      ast::PatOrExpr::Pat(box ast::Pat::Expr(expr)) | ast::PatOrExpr::Expr(expr) => {
+        starts_with_object_literal(expr)
+      }
+      _ => false,
+    },
+    ast::Expr::TaggedTpl(ast::TaggedTpl { tag, .. }) => starts_with_object_literal(tag),
+    ast::Expr::Update(ast::UpdateExpr {
+      prefix: false, arg, ..
+    }) => starts_with_object_literal(arg),
+    _ => false,
+  }
+}
+
+/// - `(x) => { return x + 1 }` => `(x) => x + 1`
+/// - `() => { return }` => `() => {}`
+/// - `() => { return { a } }` => `() => ({ a })`
+///
+/// Wrapping the returned expression with parens when needed is always shorter than `{return }`.
+pub(super) fn collapse_arrow_body(arrow: &mut ast::ArrowExpr) {
+  // Other transforms could turn the body into an object literal, like `Object.assign({}, a)`.
+  if let Some(expr) = arrow.body.as_mut_expr() {
+    if starts_with_object_literal(expr) {
+      *expr = Box::new(ast::Expr::Paren(ast::ParenExpr {
+        span: DUMMY_SP,
+        expr: expr.take(),
+      }));
+    }
+    return;
+  }
+  let Some(block) = arrow.body.as_mut_block_stmt() else {
+    return;
+  };
+  let [ast::Stmt::Return(ret)] = block.stmts.as_mut_slice() else {
+    return;
+  };
+
+  match ret.arg.take() {
+    Some(arg) => {
+      let arg = if starts_with_object_literal(&arg) || arg.is_seq() {
+        Box::new(ast::Expr::Paren(ast::ParenExpr {
+          span: DUMMY_SP,
+          expr: arg,
+        }))
+      } else {
+        arg
+      };
+      arrow.body = ast::BlockStmtOrExpr::Expr(arg).into();
+    }
+    None => block.stmts.clear(),
+  }
+}
@@ -0,0 +1,32 @@
+use swc_core::ecma::{
+  ast,
+  visit::{VisitMut, VisitMutWith},
+};
+
+struct Booleans;
+
+impl VisitMut for Booleans {
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    expr.visit_mut_children_with(self);
+    let ast::Expr::Lit(ast::Lit::Bool(bool)) = expr else {
+      return;
+    };
+    *expr = ast::Expr::Unary(ast::UnaryExpr {
+      span: bool.span,
+      op: ast::UnaryOp::Bang,
+      arg: Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+        span: bool.span,
+        value: if bool.value { 0.0 } else { 1.0 },
+        raw: None,
+      }))),
+    });
+  }
+}
+
+/// Writes `true` as `!0` and `false` as `!1`, which are shorter. Property keys like `{ true: 1 }`
+/// and `a.true` aren't expressions, so they are kept.
+///
+/// It's done once everything is folded, since the other transforms look for boolean literals.
+pub(super) fn shorten_booleans(module: &mut ast::Module) {
+  module.visit_mut_with(&mut Booleans);
+}
@@ -0,0 +1,130 @@
+use swc_core::{
+  common::{util::take::Take, Span, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::{exponent::number_expr, template_literal::str_expr, MinifySyntax};
+
+/// Whitespace and line terminators trimmed by `StringToNumber`. Unlike Rust, JavaScript doesn't
+/// count U+0085 as whitespace but counts U+FEFF.
+fn is_js_whitespace(c: char) -> bool {
+  c == '\u{feff}' || (c != '\u{85}' && c.is_whitespace())
+}
+
+/// `Number(value)` of a string. `None` if the result is `NaN` or infinite, which can't be written
+/// as a literal.
+pub(super) fn string_to_number(value: &str) -> Option<f64> {
+  let value = value.trim_matches(is_js_whitespace);
+  if value.is_empty() {
+    return Some(0.0);
+  }
+  let radix = match value
+    .get(..2)
+    .map(|prefix| prefix.to_ascii_lowercase())
+    .as_deref()
+  {
+    Some("0x") => Some(16),
+    Some("0o") => Some(8),
+    Some("0b") => Some(2),
+    _ => None,
+  };
+  let number = match radix {
+    // Signs aren't allowed with a prefix, and `from_str_radix` would accept them.
+    Some(radix) => {
+      let digits = &value[2..];
+      if digits.is_empty() || !digits.chars().all(|c| c.is_digit(radix)) {
+        return None;
+      }
+      u128::from_str_radix(digits, radix).ok()? as f64
+    }
+    // Rust accepts `inf` and `nan`, while JavaScript only accepts `Infinity`, which isn't folded.
+    None
+      if value
+        .chars()
+        .all(|c| c.is_ascii_digit() || "+-.eE".contains(c)) =>
+    {
+      value.parse::<f64>().ok()?
+    }
+    None => return None,
+  };
+  number.is_finite().then_some(number)
+}
+
+impl MinifySyntax<'_> {
+  pub(super) fn as_truthiness(&self, expr: &ast::Expr) -> Option<bool> {
+    match expr {
+      ast::Expr::Lit(ast::Lit::Str(str)) => Some(!str.value.is_empty()),
+      ast::Expr::Lit(ast::Lit::Num(num)) => Some(num.value != 0.0 && !num.value.is_nan()),
+      ast::Expr::Lit(ast::Lit::Bool(bool)) => Some(bool.value),
+      ast::Expr::Lit(ast::Lit::Null(_)) => Some(false),
+      expr if self.is_undefined(expr) => Some(false),
+      _ => None,
+    }
+  }
+
+  fn as_number(&self, expr: &ast::Expr) -> Option<f64> {
+    match expr {
+      ast::Expr::Lit(ast::Lit::Num(num)) => Some(num.value),
+      ast::Expr::Lit(ast::Lit::Str(str)) => string_to_number(&str.value),
+      ast::Expr::Lit(ast::Lit::Bool(bool)) => Some(if bool.value { 1.0 } else { 0.0 }),
+      ast::Expr::Lit(ast::Lit::Null(_)) => Some(0.0),
+      _ => None,
+    }
+  }
+
+  /// - `String(123)` => `"123"`
+  /// - `Number("0x10")` => `16`
+  /// - `Boolean(0)` => `false`
+  /// - `Boolean(x)` => `!!x`
+  pub(super) fn fold_coercion(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Call(ast::CallExpr {
+      span,
+      callee: ast::Callee::Expr(box ast::Expr::Ident(callee)),
+      args,
+      ..
+    }) = expr
+    else {
+      return;
+    };
+    if callee.span.ctxt != self.unresolved_ctxt || args.iter().any(|arg| arg.spread.is_some()) {
+      return;
+    }
+    // Arguments after the first one are still evaluated.
+    let arg = match &mut args[..] {
+      [] => None,
+      [arg] => Some(&mut arg.expr),
+      _ => return,
+    };
+
+    let folded = match (&*callee.sym, arg) {
+      ("String", None) => Some(str_expr(*span, String::new())),
+      ("String", Some(arg)) => self
+        .as_const_string(arg)
+        .map(|value| str_expr(*span, value)),
+      ("Number", None) => Some(number_expr(*span, 0.0)),
+      ("Number", Some(arg)) => self.as_number(arg).map(|value| number_expr(*span, value)),
+      ("Boolean", None) => Some(bool_expr(*span, false)),
+      ("Boolean", Some(arg)) => Some(match self.as_truthiness(arg) {
+        Some(value) => bool_expr(*span, value),
+        None => not_expr(not_expr(*arg.take())),
+      }),
+      _ => None,
+    };
+
+    if let Some(folded) = folded {
+      *expr = folded;
+    }
+  }
+}
+
+pub(super) fn bool_expr(span: Span, value: bool) -> ast::Expr {
+  ast::Expr::Lit(ast::Lit::Bool(ast::Bool { span, value }))
+}
+
+fn not_expr(arg: ast::Expr) -> ast::Expr {
+  ast::Expr::Unary(ast::UnaryExpr {
+    span: DUMMY_SP,
+    op: ast::UnaryOp::Bang,
+    arg: Box::new(arg),
+  })
+}
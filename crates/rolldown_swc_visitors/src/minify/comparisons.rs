@@ -0,0 +1,130 @@
+use std::cmp::Ordering;
+
+use swc_core::ecma::{ast, atoms::JsWord};
+
+use super::{
+  coercions::{bool_expr, string_to_number},
+  MinifySyntax,
+};
+
+/// A primitive whose value is known, so comparing it has no side effects.
+enum Primitive<'a> {
+  Undefined,
+  Null,
+  Bool(bool),
+  Num(f64),
+  Str(&'a JsWord),
+}
+
+impl Primitive<'_> {
+  /// `ToNumber` of the value. `None` if a string isn't a number literal, which isn't folded.
+  fn to_number(&self) -> Option<f64> {
+    match self {
+      Primitive::Undefined => Some(f64::NAN),
+      Primitive::Null => Some(0.0),
+      Primitive::Bool(value) => Some(if *value { 1.0 } else { 0.0 }),
+      Primitive::Num(value) => Some(*value),
+      Primitive::Str(value) => string_to_number(value),
+    }
+  }
+}
+
+/// `IsStrictlyEqual`. `NaN` is never equal to itself.
+fn strict_equals(left: &Primitive, right: &Primitive) -> bool {
+  match (left, right) {
+    (Primitive::Undefined, Primitive::Undefined) | (Primitive::Null, Primitive::Null) => true,
+    (Primitive::Bool(left), Primitive::Bool(right)) => left == right,
+    (Primitive::Num(left), Primitive::Num(right)) => left == right,
+    (Primitive::Str(left), Primitive::Str(right)) => left == right,
+    _ => false,
+  }
+}
+
+/// `IsLooselyEqual`:
+///
+/// - `null == undefined` is `true`, and both are only equal to each other.
+/// - Otherwise values of different types are compared as numbers, like `0 == ""`.
+fn loose_equals(left: &Primitive, right: &Primitive) -> Option<bool> {
+  match (left, right) {
+    (Primitive::Undefined | Primitive::Null, Primitive::Undefined | Primitive::Null) => Some(true),
+    (Primitive::Undefined | Primitive::Null, _) | (_, Primitive::Undefined | Primitive::Null) => {
+      Some(false)
+    }
+    (Primitive::Bool(_), Primitive::Bool(_))
+    | (Primitive::Num(_), Primitive::Num(_))
+    | (Primitive::Str(_), Primitive::Str(_)) => Some(strict_equals(left, right)),
+    _ => Some(left.to_number()? == right.to_number()?),
+  }
+}
+
+/// `IsLessThan`. Strings are compared by their UTF-16 code units, anything else as numbers.
+/// `None` in the ordering means one of them is `NaN`, which makes every comparison `false`.
+fn compare(left: &Primitive, right: &Primitive) -> Option<Option<Ordering>> {
+  match (left, right) {
+    (Primitive::Str(left), Primitive::Str(right)) => {
+      Some(Some(left.encode_utf16().cmp(right.encode_utf16())))
+    }
+    _ => Some(left.to_number()?.partial_cmp(&right.to_number()?)),
+  }
+}
+
+impl MinifySyntax<'_> {
+  fn as_primitive<'a>(&self, expr: &'a ast::Expr) -> Option<Primitive<'a>> {
+    match expr {
+      ast::Expr::Lit(ast::Lit::Null(_)) => Some(Primitive::Null),
+      ast::Expr::Lit(ast::Lit::Bool(bool)) => Some(Primitive::Bool(bool.value)),
+      ast::Expr::Lit(ast::Lit::Num(num)) => Some(Primitive::Num(num.value)),
+      ast::Expr::Lit(ast::Lit::Str(str)) => Some(Primitive::Str(&str.value)),
+      ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Minus,
+        arg: box ast::Expr::Lit(ast::Lit::Num(num)),
+        ..
+      }) => Some(Primitive::Num(-num.value)),
+      // Like `undefined`, they could be shadowed by a local.
+      ast::Expr::Ident(ident) if ident.span.ctxt == self.unresolved_ctxt => match &*ident.sym {
+        "NaN" => Some(Primitive::Num(f64::NAN)),
+        "Infinity" => Some(Primitive::Num(f64::INFINITY)),
+        _ => self.is_undefined(expr).then_some(Primitive::Undefined),
+      },
+      expr => self.is_undefined(expr).then_some(Primitive::Undefined),
+    }
+  }
+
+  /// The result of comparing two primitives with known values.
+  pub(super) fn compare_constants(&self, bin: &ast::BinExpr) -> Option<bool> {
+    let left = self.as_primitive(&bin.left)?;
+    let right = self.as_primitive(&bin.right)?;
+    match bin.op {
+      ast::BinaryOp::EqEqEq => Some(strict_equals(&left, &right)),
+      ast::BinaryOp::NotEqEq => Some(!strict_equals(&left, &right)),
+      ast::BinaryOp::EqEq => loose_equals(&left, &right),
+      ast::BinaryOp::NotEq => loose_equals(&left, &right).map(|is_equal| !is_equal),
+      ast::BinaryOp::Lt => Some(compare(&left, &right)? == Some(Ordering::Less)),
+      ast::BinaryOp::Gt => Some(compare(&left, &right)? == Some(Ordering::Greater)),
+      ast::BinaryOp::LtEq => Some(matches!(
+        compare(&left, &right)?,
+        Some(Ordering::Less | Ordering::Equal)
+      )),
+      ast::BinaryOp::GtEq => Some(matches!(
+        compare(&left, &right)?,
+        Some(Ordering::Greater | Ordering::Equal)
+      )),
+      _ => None,
+    }
+  }
+
+  /// - `1 < 2` => `true`
+  /// - `null == undefined` => `true`
+  /// - `0 == ""` => `true`
+  /// - `NaN === NaN` => `false`
+  ///
+  /// Only primitives with known values are compared, so no `valueOf` or `toString` could run.
+  pub(super) fn fold_comparison(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(bin) = expr else {
+      return;
+    };
+    if let Some(value) = self.compare_constants(bin) {
+      *expr = bool_expr(bin.span, value);
+    }
+  }
+}
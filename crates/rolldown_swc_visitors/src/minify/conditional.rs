@@ -0,0 +1,64 @@
+use swc_core::{
+  common::{util::take::Take, EqIgnoreSpan},
+  ecma::ast,
+};
+
+use super::MinifySyntax;
+
+impl MinifySyntax<'_> {
+  fn as_known_test(&self, test: &ast::Expr) -> Option<bool> {
+    match test {
+      ast::Expr::Bin(bin) => self.compare_constants(bin),
+      test => self.as_known_truthiness(test),
+    }
+  }
+
+  /// - `1 === 1 ? a : b` => `a`
+  /// - `a === 1 ? x : 2 === 2 ? y : z` => `a === 1 ? x : y`
+  /// - `void f() ? a : b` => `(void f(), b)`
+  ///
+  /// Chains are folded from the innermost conditional, since children are visited first.
+  pub(super) fn fold_constant_test(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Cond(cond) = expr else {
+      return;
+    };
+    let Some(is_truthy) = self.as_known_test(&cond.test) else {
+      return;
+    };
+    let branch = if is_truthy {
+      cond.cons.take()
+    } else {
+      cond.alt.take()
+    };
+    *expr = if self.is_pure(&cond.test) {
+      *branch
+    } else {
+      ast::Expr::Seq(ast::SeqExpr {
+        span: cond.span,
+        exprs: vec![cond.test.take(), branch],
+      })
+    };
+  }
+
+  /// - `c ? x : x` => `x`
+  /// - `c() ? x : x` => `(c(), x)`
+  ///
+  /// The condition is still evaluated first if it could have side effects.
+  pub(super) fn fold_identical_branches(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Cond(cond) = expr else {
+      return;
+    };
+    if !cond.cons.eq_ignore_span(&cond.alt) {
+      return;
+    }
+    let branch = cond.cons.take();
+    *expr = if self.is_pure(&cond.test) {
+      *branch
+    } else {
+      ast::Expr::Seq(ast::SeqExpr {
+        span: cond.span,
+        exprs: vec![cond.test.take(), branch],
+      })
+    };
+  }
+}
@@ -0,0 +1,130 @@
+use swc_core::{common::util::take::Take, ecma::ast};
+
+use super::MinifySyntax;
+
+/// Only a pattern of plain characters, escapes and quantifiers, like `a+|^b\.c`, is folded into a
+/// regex literal. `new RegExp("(")` throws when it runs, but `/(/` is an early error, and telling
+/// valid groups and classes apart would take parsing the pattern.
+fn is_plain_pattern(pattern: &str) -> bool {
+  // Whether the last item could be quantified, and whether it's a quantifier, which could be made
+  // lazy by a `?`.
+  let mut quantifiable = false;
+  let mut quantified = false;
+  let mut chars = pattern.chars();
+  while let Some(char) = chars.next() {
+    (quantifiable, quantified) = match char {
+      '*' | '+' | '?' if quantifiable => (false, true),
+      '?' if quantified => (false, false),
+      '^' | '$' | '|' => (false, false),
+      '\\' => match chars.next() {
+        Some('b' | 'B') => (false, false),
+        Some(escaped) if "dDwWsSfnrtv^$\\.*+?()[]{}|/".contains(escaped) => (true, false),
+        _ => return false,
+      },
+      '.' | '-' | '_' | ' ' | ',' | ':' | ';' | '=' | '!' | '<' | '>' | '@' | '#' | '%' | '&'
+      | '\'' | '"' | '~' | '`' => (true, false),
+      char if char.is_alphanumeric() => (true, false),
+      _ => return false,
+    };
+  }
+  true
+}
+
+/// Only a pattern that could be written as is between `/` is folded into a regex literal.
+fn is_valid_regex_literal(pattern: &str, flags: &str) -> bool {
+  !pattern.is_empty()
+    && is_plain_pattern(pattern)
+    && flags
+      .char_indices()
+      .all(|(idx, flag)| "dgimsuy".contains(flag) && !flags[..idx].contains(flag))
+}
+
+fn as_str_arg(arg: &ast::ExprOrSpread) -> Option<&str> {
+  match (&arg.spread, &*arg.expr) {
+    (None, ast::Expr::Lit(ast::Lit::Str(str))) => Some(&str.value),
+    _ => None,
+  }
+}
+
+fn is_array_args_foldable(args: &[ast::ExprOrSpread]) -> bool {
+  match args {
+    [arg] => matches!(
+      (&arg.spread, &*arg.expr),
+      (
+        None,
+        ast::Expr::Lit(ast::Lit::Str(_) | ast::Lit::Bool(_) | ast::Lit::Null(_))
+          | ast::Expr::Array(_)
+          | ast::Expr::Object(_)
+      )
+    ),
+    args => args.iter().all(|arg| arg.spread.is_none()),
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// - `new Array(1, 2, 3)` => `[1, 2, 3]`
+  /// - `new Object()` => `{}`
+  /// - `new Object({ a })` => `{ a }`
+  /// - `new RegExp('a', 'g')` => `/a/g`
+  ///
+  /// `new Array(n)` creates an array with `n` holes, so a single argument is only folded if it's
+  /// a literal that can't be a number.
+  pub(super) fn fold_new(&self, expr: &mut ast::Expr) {
+    let ast::Expr::New(new_expr) = expr else {
+      return;
+    };
+    let ast::Expr::Ident(callee) = &*new_expr.callee else {
+      return;
+    };
+    if callee.span.ctxt != self.unresolved_ctxt {
+      // The constructor is shadowed
+      return;
+    }
+    let span = new_expr.span;
+    let args = new_expr.args.as_deref_mut().unwrap_or_default();
+
+    let folded = match &*callee.sym {
+      "Array" if is_array_args_foldable(args) => Some(ast::Expr::Array(ast::ArrayLit {
+        span,
+        elems: args
+          .iter_mut()
+          .map(|arg| {
+            Some(ast::ExprOrSpread {
+              spread: None,
+              expr: arg.expr.take(),
+            })
+          })
+          .collect(),
+      })),
+      "Object" => match args {
+        [] => Some(ast::Expr::Object(ast::ObjectLit {
+          span,
+          props: vec![],
+        })),
+        [ast::ExprOrSpread { spread: None, expr }] if expr.is_object() => Some(*expr.take()),
+        _ => None,
+      },
+      "RegExp" => {
+        let pattern_and_flags = match &*args {
+          [pattern] => as_str_arg(pattern).map(|pattern| (pattern, "")),
+          [pattern, flags] => as_str_arg(pattern).zip(as_str_arg(flags)),
+          _ => None,
+        };
+        pattern_and_flags
+          .filter(|(pattern, flags)| is_valid_regex_literal(pattern, flags))
+          .map(|(pattern, flags)| {
+            ast::Expr::Lit(ast::Lit::Regex(ast::Regex {
+              span,
+              exp: pattern.into(),
+              flags: flags.into(),
+            }))
+          })
+      }
+      _ => None,
+    };
+
+    if let Some(folded) = folded {
+      *expr = folded;
+    }
+  }
+}
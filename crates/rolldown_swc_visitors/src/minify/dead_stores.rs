@@ -0,0 +1,209 @@
+use rustc_hash::{FxHashMap, FxHashSet};
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::{
+    ast::{self, Id},
+    visit::{noop_visit_type, Visit, VisitMut, VisitMutWith, VisitWith},
+  },
+};
+
+use super::MinifySyntax;
+
+fn as_ident_target(left: &ast::PatOrExpr) -> Option<&ast::Ident> {
+  match left {
+    ast::PatOrExpr::Pat(box ast::Pat::Ident(binding)) => Some(&binding.id),
+    ast::PatOrExpr::Expr(box ast::Expr::Ident(ident))
+    | ast::PatOrExpr::Pat(box ast::Pat::Expr(box ast::Expr::Ident(ident))) => Some(ident),
+    _ => None,
+  }
+}
+
+/// Every occurrence of a name is counted, and a binding is dead if the only ones are its
+/// declarations and the targets of `x = ...`, which can be removed with it.
+#[derive(Default)]
+struct Occurrences {
+  all: FxHashMap<Id, usize>,
+  removable: FxHashMap<Id, usize>,
+  /// Declared by a statement, so it's surely a local. Declarations in loop heads are kept.
+  declared: FxHashSet<Id>,
+  /// Direct `eval` and `with` could read any binding by its name.
+  has_dynamic_scope: bool,
+}
+
+impl Visit for Occurrences {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    *self.all.entry(ident.to_id()).or_default() += 1;
+  }
+
+  fn visit_stmts(&mut self, stmts: &[ast::Stmt]) {
+    for stmt in stmts {
+      if let ast::Stmt::Decl(ast::Decl::Var(decl)) = stmt {
+        for declarator in &decl.decls {
+          if let ast::Pat::Ident(binding) = &declarator.name {
+            let id = binding.id.to_id();
+            *self.removable.entry(id.clone()).or_default() += 1;
+            self.declared.insert(id);
+          }
+        }
+      }
+    }
+    for stmt in stmts {
+      stmt.visit_with(self);
+    }
+  }
+
+  fn visit_assign_expr(&mut self, assign: &ast::AssignExpr) {
+    if assign.op == ast::AssignOp::Assign
+      && let Some(target) = as_ident_target(&assign.left)
+    {
+      *self.removable.entry(target.to_id()).or_default() += 1;
+    }
+    assign.visit_children_with(self);
+  }
+
+  fn visit_call_expr(&mut self, call: &ast::CallExpr) {
+    if let ast::Callee::Expr(box ast::Expr::Ident(ident)) = &call.callee
+      && &*ident.sym == "eval"
+    {
+      self.has_dynamic_scope = true;
+    }
+    call.visit_children_with(self);
+  }
+
+  fn visit_with_stmt(&mut self, stmt: &ast::WithStmt) {
+    self.has_dynamic_scope = true;
+    stmt.visit_children_with(self);
+  }
+}
+
+/// Parameters are counted as well, since `var x` in the body is the parameter `x` if there's one.
+fn find_dead_ids<N: VisitWith<Occurrences>>(function: &N) -> FxHashSet<Id> {
+  let mut occurrences = Occurrences::default();
+  function.visit_with(&mut occurrences);
+  if occurrences.has_dynamic_scope {
+    return Default::default();
+  }
+  occurrences
+    .declared
+    .into_iter()
+    .filter(|id| occurrences.all.get(id) == occurrences.removable.get(id))
+    .collect()
+}
+
+struct DeadStoreRemover<'a, 'b> {
+  minify: &'a MinifySyntax<'b>,
+  dead_ids: FxHashSet<Id>,
+}
+
+impl DeadStoreRemover<'_, '_> {
+  /// `x = ...` of a dead binding
+  fn is_dead_store(&self, left: &ast::PatOrExpr) -> bool {
+    as_ident_target(left).map_or(false, |target| self.dead_ids.contains(&target.to_id()))
+  }
+
+  /// `let a = 1, x = f(), b = 2` => `let a = 1; f(); let b = 2`
+  fn split_decl(&self, decl: ast::VarDecl, stmts: &mut Vec<ast::Stmt>) {
+    let ast::VarDecl {
+      span,
+      kind,
+      declare,
+      decls,
+    } = decl;
+    let flush = |kept: &mut Vec<ast::VarDeclarator>, stmts: &mut Vec<ast::Stmt>| {
+      if !kept.is_empty() {
+        stmts.push(ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+          span,
+          kind,
+          declare,
+          decls: std::mem::take(kept),
+        }))));
+      }
+    };
+    let mut kept = vec![];
+    for declarator in decls {
+      let is_dead = matches!(
+        &declarator.name,
+        ast::Pat::Ident(binding) if self.dead_ids.contains(&binding.id.to_id())
+      );
+      if !is_dead {
+        kept.push(declarator);
+        continue;
+      }
+      flush(&mut kept, stmts);
+      if let Some(init) = declarator.init.filter(|init| !self.minify.is_pure(init)) {
+        stmts.push(ast::Stmt::Expr(ast::ExprStmt {
+          span: DUMMY_SP,
+          expr: init,
+        }));
+      }
+    }
+    flush(&mut kept, stmts);
+  }
+}
+
+impl VisitMut for DeadStoreRemover<'_, '_> {
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    expr.visit_mut_children_with(self);
+    if let ast::Expr::Assign(assign) = expr
+      && assign.op == ast::AssignOp::Assign
+      && self.is_dead_store(&assign.left)
+    {
+      *expr = *assign.right.take();
+    }
+  }
+
+  fn visit_mut_stmts(&mut self, stmts: &mut Vec<ast::Stmt>) {
+    let mut rewritten = Vec::with_capacity(stmts.len());
+    for mut stmt in stmts.take() {
+      match stmt {
+        ast::Stmt::Decl(ast::Decl::Var(mut decl)) => {
+          decl.visit_mut_children_with(self);
+          self.split_decl(*decl, &mut rewritten);
+        }
+        // A pure value is removed instead of being left as a statement, which could become a
+        // directive if it's a string.
+        ast::Stmt::Expr(ast::ExprStmt {
+          expr: box ast::Expr::Assign(ref assign),
+          ..
+        }) if self.is_dead_store(&assign.left) && self.minify.is_pure(&assign.right) => {}
+        _ => {
+          stmt.visit_mut_with(self);
+          rewritten.push(stmt);
+        }
+      }
+    }
+    *stmts = rewritten;
+  }
+}
+
+impl MinifySyntax<'_> {
+  fn remove_dead_stores(&self, body: &mut ast::BlockStmt, dead_ids: FxHashSet<Id>) {
+    if !dead_ids.is_empty() {
+      body.visit_mut_with(&mut DeadStoreRemover {
+        minify: self,
+        dead_ids,
+      });
+    }
+  }
+
+  /// Removes the locals of a function that are never read, along with every assignment to them.
+  /// Values with side effects are kept, without the binding.
+  ///
+  /// - `let x = 1; x = 2; return 3` => `return 3`
+  /// - `let x = f(); return 3` => `f(); return 3`
+  pub(super) fn drop_dead_stores_of_function(&self, function: &mut ast::Function) {
+    let dead_ids = find_dead_ids(&*function);
+    if let Some(body) = &mut function.body {
+      self.remove_dead_stores(body, dead_ids);
+    }
+  }
+
+  pub(super) fn drop_dead_stores_of_arrow(&self, arrow: &mut ast::ArrowExpr) {
+    let dead_ids = find_dead_ids(&*arrow);
+    if let Some(body) = arrow.body.as_mut_block_stmt() {
+      self.remove_dead_stores(body, dead_ids);
+    }
+  }
+}
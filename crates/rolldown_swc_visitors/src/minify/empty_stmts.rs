@@ -0,0 +1,71 @@
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::{arrow_body::starts_with_object_literal, MinifySyntax};
+
+/// `;` and `{}`. Labels are kept even if their body is empty, since `break` could refer to them.
+pub(super) fn is_empty_stmt(stmt: &ast::Stmt) -> bool {
+  match stmt {
+    ast::Stmt::Empty(_) => true,
+    ast::Stmt::Block(block) => block.stmts.is_empty(),
+    _ => false,
+  }
+}
+
+/// `c` => `!c`, `!c` => `c`. Only for tests, which are converted to booleans anyway.
+pub(super) fn negate_test(test: Box<ast::Expr>) -> Box<ast::Expr> {
+  match *test {
+    ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::Bang,
+      arg,
+      ..
+    }) => arg,
+    test => Box::new(ast::Expr::Unary(ast::UnaryExpr {
+      span: DUMMY_SP,
+      op: ast::UnaryOp::Bang,
+      arg: Box::new(test),
+    })),
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// - `if (c) a(); else {}` => `if (c) a()`
+  /// - `if (c) {} else a()` => `if (!c) a()`
+  /// - `if (c) {}` => `c`, which is removed if `c` is pure
+  pub(super) fn fold_empty_if(&self, stmt: &mut ast::Stmt) {
+    let ast::Stmt::If(if_stmt) = stmt else {
+      return;
+    };
+    if if_stmt.alt.as_deref().map_or(false, is_empty_stmt) {
+      if_stmt.alt = None;
+    }
+    if !is_empty_stmt(&if_stmt.cons) {
+      return;
+    }
+    match if_stmt.alt.take() {
+      Some(alt) => {
+        if_stmt.test = negate_test(if_stmt.test.take());
+        if_stmt.cons = alt;
+      }
+      None if self.is_pure(&if_stmt.test) => {
+        *stmt = ast::Stmt::Empty(ast::EmptyStmt { span: DUMMY_SP });
+      }
+      None => {
+        let mut expr = if_stmt.test.take();
+        // An object literal at the start of a statement would be parsed as a block.
+        if starts_with_object_literal(&expr) {
+          expr = Box::new(ast::Expr::Paren(ast::ParenExpr {
+            span: DUMMY_SP,
+            expr,
+          }));
+        }
+        *stmt = ast::Stmt::Expr(ast::ExprStmt {
+          span: if_stmt.span,
+          expr,
+        });
+      }
+    }
+  }
+}
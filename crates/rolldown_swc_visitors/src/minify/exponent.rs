@@ -0,0 +1,132 @@
+use swc_core::{
+  common::{util::take::Take, Span, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::MinifySyntax;
+
+/// The largest integer `n` such that every integer up to `n` is exactly representable.
+const MAX_SAFE_INTEGER: f64 = 9007199254740991.0;
+
+fn as_number(expr: &ast::Expr) -> Option<f64> {
+  match expr {
+    ast::Expr::Lit(ast::Lit::Num(num)) => Some(num.value),
+    ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::Minus,
+      arg: box ast::Expr::Lit(ast::Lit::Num(num)),
+      ..
+    }) => Some(-num.value),
+    _ => None,
+  }
+}
+
+pub(super) fn number_expr(span: Span, value: f64) -> ast::Expr {
+  let lit = ast::Expr::Lit(ast::Lit::Num(ast::Number {
+    span,
+    value: value.abs(),
+    raw: None,
+  }));
+  if value.is_sign_negative() {
+    ast::Expr::Unary(ast::UnaryExpr {
+      span,
+      op: ast::UnaryOp::Minus,
+      arg: Box::new(lit),
+    })
+  } else {
+    lit
+  }
+}
+
+/// Only folds results that are printed exactly as they are computed. `powf` agrees with `**` for
+/// finite operands, and the special cases where they don't, like `1 ** NaN`, can't be written as
+/// number literals.
+fn fold_pow(span: Span, base: &ast::Expr, exponent: &ast::Expr) -> Option<ast::Expr> {
+  let value = as_number(base)?.powf(as_number(exponent)?);
+  (value.is_finite() && value.fract() == 0.0 && value.abs() <= MAX_SAFE_INTEGER)
+    .then(|| number_expr(span, value))
+}
+
+/// Whether `expr` can be an operand of `**` without parentheses.
+fn is_exponent_operand(expr: &ast::Expr, is_left: bool) -> bool {
+  match expr {
+    ast::Expr::Ident(_)
+    | ast::Expr::Lit(_)
+    | ast::Expr::This(_)
+    | ast::Expr::Array(_)
+    | ast::Expr::Object(_)
+    | ast::Expr::Tpl(_)
+    | ast::Expr::Member(_)
+    | ast::Expr::SuperProp(_)
+    | ast::Expr::Call(_)
+    | ast::Expr::OptChain(_)
+    | ast::Expr::Paren(_) => true,
+    // `-a ** b` is a syntax error
+    ast::Expr::Unary(_) | ast::Expr::Update(_) | ast::Expr::Await(_) => !is_left,
+    // `**` is right-associative
+    ast::Expr::Bin(ast::BinExpr {
+      op: ast::BinaryOp::Exp,
+      ..
+    }) => !is_left,
+    _ => false,
+  }
+}
+
+fn exponent_operand(expr: Box<ast::Expr>, is_left: bool) -> Box<ast::Expr> {
+  if is_exponent_operand(&expr, is_left) {
+    expr
+  } else {
+    Box::new(ast::Expr::Paren(ast::ParenExpr {
+      span: DUMMY_SP,
+      expr,
+    }))
+  }
+}
+
+impl MinifySyntax<'_> {
+  fn is_math_pow(&self, callee: &ast::Callee) -> bool {
+    matches!(
+      callee,
+      ast::Callee::Expr(box ast::Expr::Member(ast::MemberExpr {
+        obj: box ast::Expr::Ident(obj),
+        prop: ast::MemberProp::Ident(prop),
+        ..
+      })) if &*obj.sym == "Math" && obj.span.ctxt == self.unresolved_ctxt && &*prop.sym == "pow"
+    )
+  }
+
+  /// - `2 ** 10` => `1024`
+  /// - `Math.pow(2, 10)` => `1024`
+  /// - `Math.pow(x, 2)` => `x ** 2`, if the target supports `**`
+  pub(super) fn fold_exponent(&self, expr: &mut ast::Expr) {
+    let folded = match expr {
+      ast::Expr::Bin(ast::BinExpr {
+        span,
+        op: ast::BinaryOp::Exp,
+        left,
+        right,
+      }) => fold_pow(*span, left, right),
+      ast::Expr::Call(ast::CallExpr {
+        span, callee, args, ..
+      }) if self.is_math_pow(callee) => match &mut args[..] {
+        [base, exponent] if base.spread.is_none() && exponent.spread.is_none() => {
+          fold_pow(*span, &base.expr, &exponent.expr).or_else(|| {
+            self.target.supports_exponent_operator().then(|| {
+              ast::Expr::Bin(ast::BinExpr {
+                span: *span,
+                op: ast::BinaryOp::Exp,
+                left: exponent_operand(base.expr.take(), true),
+                right: exponent_operand(exponent.expr.take(), false),
+              })
+            })
+          })
+        }
+        _ => None,
+      },
+      _ => None,
+    };
+
+    if let Some(folded) = folded {
+      *expr = folded;
+    }
+  }
+}
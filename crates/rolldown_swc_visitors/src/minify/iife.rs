@@ -0,0 +1,108 @@
+use swc_core::{
+  common::util::take::Take,
+  ecma::{
+    ast,
+    atoms::{js_word, JsWord},
+    visit::{noop_visit_type, Visit, VisitWith},
+  },
+};
+
+use super::{void_context::void_zero, MinifySyntax};
+
+/// Finds what an expression would see differently once moved out of a function expression: its
+/// `this`, `arguments` and `new.target`, and the name of the function itself. Nested functions
+/// aren't skipped, which only keeps more IIFEs.
+struct FunctionScopeFinder<'a> {
+  name: Option<&'a JsWord>,
+  found: bool,
+}
+
+impl Visit for FunctionScopeFinder<'_> {
+  noop_visit_type!();
+
+  fn visit_this_expr(&mut self, _: &ast::ThisExpr) {
+    self.found = true;
+  }
+
+  fn visit_meta_prop_expr(&mut self, _: &ast::MetaPropExpr) {
+    self.found = true;
+  }
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    if ident.sym == js_word!("arguments") || Some(&ident.sym) == self.name {
+      self.found = true;
+    }
+  }
+}
+
+fn uses_function_scope(expr: &ast::Expr, name: Option<&JsWord>) -> bool {
+  let mut finder = FunctionScopeFinder { name, found: false };
+  expr.visit_with(&mut finder);
+  finder.found
+}
+
+/// The argument of a body made of a single `return`. An empty body returns `undefined`. Bodies
+/// with any other statement are kept, since what they declare would leak into the enclosing
+/// scope.
+fn single_return(stmts: &mut [ast::Stmt]) -> Option<Option<&mut Box<ast::Expr>>> {
+  match stmts {
+    [] => Some(None),
+    [ast::Stmt::Return(ret)] => Some(ret.arg.as_mut()),
+    _ => None,
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// - `(() => a)()` => `a`
+  /// - `(function () { return a })()` => `a`
+  /// - `(() => {})()` => `void 0`
+  ///
+  /// Only IIFEs without parameters or arguments are folded. Arrows already see the `this` and
+  /// `arguments` of the enclosing code, while function expressions using them are kept.
+  pub(super) fn fold_iife(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Call(call) = expr else {
+      return;
+    };
+    let span = call.span;
+    if !call.args.is_empty() {
+      return;
+    }
+    let ast::Callee::Expr(callee) = &mut call.callee else {
+      return;
+    };
+    let returned = match &mut **callee {
+      ast::Expr::Arrow(arrow)
+        if arrow.params.is_empty() && !arrow.is_async && !arrow.is_generator =>
+      {
+        if arrow.body.is_expr() {
+          arrow.body.as_mut_expr().map(Some)
+        } else {
+          arrow
+            .body
+            .as_mut_block_stmt()
+            .and_then(|block| single_return(&mut block.stmts))
+        }
+      }
+      ast::Expr::Fn(ast::FnExpr { ident, function })
+        if function.params.is_empty() && !function.is_async && !function.is_generator =>
+      {
+        let name = ident.as_ref().map(|ident| &ident.sym);
+        function
+          .body
+          .as_mut()
+          .and_then(|body| single_return(&mut body.stmts))
+          .filter(|returned| {
+            returned
+              .as_ref()
+              .map_or(true, |arg| !uses_function_scope(arg, name))
+          })
+      }
+      _ => None,
+    };
+    let Some(returned) = returned else {
+      return;
+    };
+    let folded = returned.map_or_else(|| void_zero(span), |arg| *arg.take());
+    *expr = folded;
+  }
+}
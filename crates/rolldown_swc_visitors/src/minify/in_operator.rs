@@ -0,0 +1,130 @@
+use swc_core::ecma::ast;
+
+use super::{coercions::bool_expr, MinifySyntax};
+
+/// Properties every object inherits from `Object.prototype`, so `key in {}` is `true` for them.
+const OBJECT_PROTOTYPE_KEYS: &[&str] = &[
+  "__defineGetter__",
+  "__defineSetter__",
+  "__lookupGetter__",
+  "__lookupSetter__",
+  "__proto__",
+  "constructor",
+  "hasOwnProperty",
+  "isPrototypeOf",
+  "propertyIsEnumerable",
+  "toLocaleString",
+  "toString",
+  "valueOf",
+];
+
+/// The own keys of the object literal, if all of them are known.
+fn own_keys(object: &ast::ObjectLit) -> Option<Vec<String>> {
+  object
+    .props
+    .iter()
+    .map(|prop| {
+      let ast::PropOrSpread::Prop(prop) = prop else {
+        return None;
+      };
+      let key = match &**prop {
+        ast::Prop::Shorthand(ident) => return Some(ident.sym.to_string()),
+        ast::Prop::KeyValue(ast::KeyValueProp { key, .. })
+        | ast::Prop::Getter(ast::GetterProp { key, .. })
+        | ast::Prop::Setter(ast::SetterProp { key, .. })
+        | ast::Prop::Method(ast::MethodProp { key, .. }) => key,
+        ast::Prop::Assign(_) => return None,
+      };
+      match key {
+        ast::PropName::Ident(ident) => Some(ident.sym.to_string()),
+        ast::PropName::Str(str) => Some(str.value.to_string()),
+        ast::PropName::Num(num) if num.value.fract() == 0.0 && num.value >= 0.0 => {
+          Some((num.value as u64).to_string())
+        }
+        _ => None,
+      }
+    })
+    .collect()
+}
+
+impl MinifySyntax<'_> {
+  fn as_known_in(&self, key: &str, object: &ast::Expr) -> Option<bool> {
+    match object {
+      ast::Expr::Object(object) => {
+        let own_keys = own_keys(object)?;
+        // `__proto__: null` changes the inherited properties.
+        if own_keys.iter().any(|key| key == "__proto__") {
+          return None;
+        }
+        if own_keys.iter().any(|own_key| own_key == key) {
+          Some(true)
+        } else {
+          (!OBJECT_PROTOTYPE_KEYS.contains(&key)).then_some(false)
+        }
+      }
+      ast::Expr::Array(array) => {
+        if array
+          .elems
+          .iter()
+          .flatten()
+          .any(|elem| elem.spread.is_some())
+        {
+          return None;
+        }
+        match key.parse::<usize>() {
+          // `"01" in [0, 1]` is `false`.
+          Ok(index) if index.to_string() == key => {
+            Some(matches!(array.elems.get(index), Some(Some(_))))
+          }
+          _ => (key == "length").then_some(true),
+        }
+      }
+      _ => None,
+    }
+  }
+
+  /// Dropping the object can't be observed. Accessors and methods aren't called.
+  fn is_pure_object(&self, expr: &ast::Expr) -> bool {
+    match expr {
+      ast::Expr::Object(object) => object.props.iter().all(|prop| match prop {
+        ast::PropOrSpread::Prop(prop) => match &**prop {
+          ast::Prop::KeyValue(prop) => self.is_pure(&prop.value),
+          // It throws if the binding doesn't exist.
+          ast::Prop::Shorthand(ident) => ident.span.ctxt != self.unresolved_ctxt,
+          ast::Prop::Getter(_) | ast::Prop::Setter(_) | ast::Prop::Method(_) => true,
+          ast::Prop::Assign(_) => false,
+        },
+        ast::PropOrSpread::Spread(_) => false,
+      }),
+      expr => self.is_pure(expr),
+    }
+  }
+
+  /// - `"k" in { k: 1 }` => `true`
+  /// - `"z" in { a: 1 }` => `false`
+  /// - `0 in [1]` => `true`
+  ///
+  /// Missing keys are only folded if they can't be inherited, like `toString`. The object is
+  /// dropped, so its values must be pure. `instanceof` is left alone, since it depends on the
+  /// prototype chain at runtime.
+  pub(super) fn fold_in(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(ast::BinExpr {
+      span,
+      op: ast::BinaryOp::In,
+      left,
+      right,
+    }) = expr
+    else {
+      return;
+    };
+    let Some(key) = self.as_const_string(left) else {
+      return;
+    };
+    if !self.is_pure(left) || !self.is_pure_object(right) {
+      return;
+    }
+    if let Some(value) = self.as_known_in(&key, right) {
+      *expr = bool_expr(*span, value);
+    }
+  }
+}
@@ -0,0 +1,239 @@
+use rustc_hash::FxHashSet;
+use swc_core::ecma::{
+  ast::{self, Id},
+  visit::{noop_visit_type, Visit, VisitWith},
+};
+
+use super::MinifySyntax;
+
+fn as_ident_target(left: &ast::PatOrExpr) -> Option<&ast::Ident> {
+  match left {
+    ast::PatOrExpr::Pat(box ast::Pat::Ident(binding)) => Some(&binding.id),
+    ast::PatOrExpr::Expr(box ast::Expr::Ident(ident))
+    | ast::PatOrExpr::Pat(box ast::Pat::Expr(box ast::Expr::Ident(ident))) => Some(ident),
+    _ => None,
+  }
+}
+
+fn as_number(expr: &ast::Expr) -> Option<f64> {
+  match expr {
+    ast::Expr::Lit(ast::Lit::Num(num)) => Some(num.value),
+    ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::Minus,
+      arg: box ast::Expr::Lit(ast::Lit::Num(num)),
+      ..
+    }) => Some(-num.value),
+    _ => None,
+  }
+}
+
+/// `x + n`, `n + x` or `x - n` for the target `x`.
+fn as_step(target: &ast::Ident, value: &ast::Expr) -> Option<(ast::BinaryOp, f64)> {
+  let ast::Expr::Bin(bin) = value else {
+    return None;
+  };
+  let is_target =
+    |expr: &ast::Expr| matches!(expr, ast::Expr::Ident(ident) if ident.to_id() == target.to_id());
+  match bin.op {
+    ast::BinaryOp::Add if is_target(&bin.left) => Some((bin.op, as_number(&bin.right)?)),
+    ast::BinaryOp::Add if is_target(&bin.right) => Some((bin.op, as_number(&bin.left)?)),
+    ast::BinaryOp::Sub if is_target(&bin.left) => Some((bin.op, as_number(&bin.right)?)),
+    _ => None,
+  }
+}
+
+/// Whether the target of the assignment stays a number if it's one already.
+fn keeps_number(target: &ast::Ident, op: ast::AssignOp, value: &ast::Expr) -> bool {
+  match op {
+    ast::AssignOp::Assign => as_number(value).is_some() || as_step(target, value).is_some(),
+    ast::AssignOp::AddAssign => as_number(value).is_some(),
+    ast::AssignOp::SubAssign
+    | ast::AssignOp::MulAssign
+    | ast::AssignOp::DivAssign
+    | ast::AssignOp::ModAssign
+    | ast::AssignOp::ExpAssign
+    | ast::AssignOp::LShiftAssign
+    | ast::AssignOp::RShiftAssign
+    | ast::AssignOp::ZeroFillRShiftAssign
+    | ast::AssignOp::BitOrAssign
+    | ast::AssignOp::BitXorAssign
+    | ast::AssignOp::BitAndAssign => true,
+    // `a ||= b` is `b` then.
+    ast::AssignOp::AndAssign | ast::AssignOp::OrAssign | ast::AssignOp::NullishAssign => false,
+  }
+}
+
+/// Bindings declared with a value of some type, or without a value and assigned one later, which
+/// are only assigned values of that type. Any other binding of the same name, like a parameter, a
+/// destructured one or the binding of a `for-in` or `for-of` loop, rules it out.
+pub(super) struct TypedBindings {
+  is_of_type: fn(&ast::Expr) -> bool,
+  /// Whether the target of the assignment stays of the type if it's of the type already.
+  keeps_type: fn(&ast::Ident, ast::AssignOp, &ast::Expr) -> bool,
+  /// `x++` and `x--` turn anything into a number.
+  is_numeric: bool,
+  declared: FxHashSet<Id>,
+  /// Declared without a value, and still `undefined` unless `assigned` a value of the type.
+  uninitialized: FxHashSet<Id>,
+  assigned: FxHashSet<Id>,
+  ruled_out: FxHashSet<Id>,
+  /// In the head of a `for-in` or `for-of` loop, where the values come from the iterated object.
+  in_loop_head: bool,
+}
+
+impl TypedBindings {
+  pub(super) fn collect(
+    module: &ast::Module,
+    is_of_type: fn(&ast::Expr) -> bool,
+    keeps_type: fn(&ast::Ident, ast::AssignOp, &ast::Expr) -> bool,
+    is_numeric: bool,
+  ) -> FxHashSet<Id> {
+    let mut bindings = Self {
+      is_of_type,
+      keeps_type,
+      is_numeric,
+      declared: Default::default(),
+      uninitialized: Default::default(),
+      assigned: Default::default(),
+      ruled_out: Default::default(),
+      in_loop_head: false,
+    };
+    module.visit_with(&mut bindings);
+    bindings
+      .declared
+      .into_iter()
+      .filter(|id| !bindings.ruled_out.contains(id))
+      .filter(|id| !bindings.uninitialized.contains(id) || bindings.assigned.contains(id))
+      .collect()
+  }
+
+  fn visit_loop_head(&mut self, head: &impl VisitWith<Self>) {
+    let in_loop_head = std::mem::replace(&mut self.in_loop_head, true);
+    head.visit_with(self);
+    self.in_loop_head = in_loop_head;
+  }
+}
+
+impl Visit for TypedBindings {
+  noop_visit_type!();
+
+  fn visit_var_declarator(&mut self, decl: &ast::VarDeclarator) {
+    match (&decl.name, decl.init.as_deref()) {
+      (ast::Pat::Ident(binding), Some(init)) if (self.is_of_type)(init) => {
+        self.declared.insert(binding.id.to_id());
+      }
+      (ast::Pat::Ident(binding), None) if !self.in_loop_head => {
+        self.declared.insert(binding.id.to_id());
+        self.uninitialized.insert(binding.id.to_id());
+      }
+      (name, _) => name.visit_with(self),
+    }
+    decl.init.visit_with(self);
+  }
+
+  fn visit_for_in_stmt(&mut self, stmt: &ast::ForInStmt) {
+    self.visit_loop_head(&stmt.left);
+    stmt.right.visit_with(self);
+    stmt.body.visit_with(self);
+  }
+
+  fn visit_for_of_stmt(&mut self, stmt: &ast::ForOfStmt) {
+    self.visit_loop_head(&stmt.left);
+    stmt.right.visit_with(self);
+    stmt.body.visit_with(self);
+  }
+
+  fn visit_binding_ident(&mut self, binding: &ast::BindingIdent) {
+    self.ruled_out.insert(binding.id.to_id());
+  }
+
+  fn visit_pat(&mut self, pat: &ast::Pat) {
+    if let ast::Pat::Expr(box ast::Expr::Ident(ident)) = pat {
+      self.ruled_out.insert(ident.to_id());
+    }
+    pat.visit_children_with(self);
+  }
+
+  fn visit_fn_decl(&mut self, decl: &ast::FnDecl) {
+    self.ruled_out.insert(decl.ident.to_id());
+    decl.function.visit_with(self);
+  }
+
+  fn visit_class_decl(&mut self, decl: &ast::ClassDecl) {
+    self.ruled_out.insert(decl.ident.to_id());
+    decl.class.visit_with(self);
+  }
+
+  fn visit_assign_expr(&mut self, assign: &ast::AssignExpr) {
+    let Some(target) = as_ident_target(&assign.left) else {
+      assign.visit_children_with(self);
+      return;
+    };
+    if !(self.keeps_type)(target, assign.op, &assign.right) {
+      self.ruled_out.insert(target.to_id());
+    } else if assign.op == ast::AssignOp::Assign && (self.is_of_type)(&assign.right) {
+      self.assigned.insert(target.to_id());
+    }
+    assign.right.visit_with(self);
+  }
+
+  fn visit_update_expr(&mut self, update: &ast::UpdateExpr) {
+    match &*update.arg {
+      ast::Expr::Ident(ident) if !self.is_numeric => {
+        self.ruled_out.insert(ident.to_id());
+      }
+      arg => arg.visit_with(self),
+    }
+  }
+}
+
+pub(super) fn collect_numeric_ids(module: &ast::Module) -> FxHashSet<Id> {
+  TypedBindings::collect(module, |expr| as_number(expr).is_some(), keeps_number, true)
+}
+
+/// The value of a statement is unused, so `++x` is written as `x++` there, like people do.
+pub(super) fn prefer_postfix(expr: &mut ast::Expr) {
+  if let ast::Expr::Update(update) = expr {
+    update.prefix = false;
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// - `x -= 1` => `--x`
+  /// - `x = x - 1` => `--x`
+  /// - `x += 1` => `++x`
+  /// - `x = x + 1` => `++x`
+  ///
+  /// `+` concatenates strings, while `++` converts to a number, so increments are only folded for
+  /// bindings that surely hold numbers. `x = x - 1` reads `x` twice, so a global, which could be a
+  /// getter, is kept.
+  pub(super) fn fold_increment(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Assign(assign) = expr else {
+      return;
+    };
+    let Some(target) = as_ident_target(&assign.left) else {
+      return;
+    };
+    let step = match assign.op {
+      ast::AssignOp::AddAssign => as_number(&assign.right).map(|n| (ast::BinaryOp::Add, n)),
+      ast::AssignOp::SubAssign => as_number(&assign.right).map(|n| (ast::BinaryOp::Sub, n)),
+      ast::AssignOp::Assign if target.span.ctxt != self.unresolved_ctxt => {
+        as_step(target, &assign.right)
+      }
+      _ => None,
+    };
+    let op = match step {
+      Some((ast::BinaryOp::Add, n)) if n == 1.0 && self.numeric_ids.contains(&target.to_id()) => {
+        ast::UpdateOp::PlusPlus
+      }
+      Some((ast::BinaryOp::Sub, n)) if n == 1.0 => ast::UpdateOp::MinusMinus,
+      _ => return,
+    };
+    *expr = ast::Expr::Update(ast::UpdateExpr {
+      span: assign.span,
+      op,
+      prefix: true,
+      arg: Box::new(ast::Expr::Ident(target.clone())),
+    });
+  }
+}
@@ -0,0 +1,63 @@
+use swc_core::ecma::ast;
+
+use super::AsStmtMut;
+
+fn as_var_decl_mut<T: AsStmtMut>(item: &mut T) -> Option<&mut ast::VarDecl> {
+  match item.as_stmt_mut()? {
+    ast::Stmt::Decl(ast::Decl::Var(var_decl)) if !var_decl.declare => Some(var_decl),
+    _ => None,
+  }
+}
+
+/// Declarations without initializers don't do anything at runtime, so they could be moved.
+fn is_uninitialized(var_decl: &ast::VarDecl) -> bool {
+  var_decl
+    .decls
+    .iter()
+    .all(|decl| decl.init.is_none() && decl.name.is_ident())
+}
+
+/// - `var a = 1; var b = 2;` => `var a = 1, b = 2;`
+/// - `const a = 1; const b = a;` => `const a = 1, b = a;`
+/// - `var a = 1; f(a); var b;` => `var a = 1, b; f(a);`
+///
+/// Adjacent declarations of the same kind are always safe to join, since declarators are evaluated
+/// in order just like the statements were. Nothing is moved across other statements except
+/// uninitialized `var`s, which are hoisted anyway.
+pub(super) fn join_vars<T: AsStmtMut>(items: &mut Vec<T>) {
+  let mut joined: Vec<T> = Vec::with_capacity(items.len());
+  // Index of the first `var` declaration in `joined`, which later uninitialized `var`s are moved into.
+  let mut hoisted_var_idx: Option<usize> = None;
+
+  for mut item in std::mem::take(items) {
+    let Some(var_decl) = as_var_decl_mut(&mut item) else {
+      joined.push(item);
+      continue;
+    };
+
+    if let Some(prev) = joined.last_mut().and_then(as_var_decl_mut)
+      && prev.kind == var_decl.kind
+    {
+      prev.decls.append(&mut var_decl.decls);
+      continue;
+    }
+
+    if var_decl.kind == ast::VarDeclKind::Var {
+      if let Some(idx) = hoisted_var_idx {
+        if is_uninitialized(var_decl) {
+          as_var_decl_mut(&mut joined[idx])
+            .unwrap()
+            .decls
+            .append(&mut var_decl.decls);
+          continue;
+        }
+      } else {
+        hoisted_var_idx = Some(joined.len());
+      }
+    }
+
+    joined.push(item);
+  }
+
+  *items = joined;
+}
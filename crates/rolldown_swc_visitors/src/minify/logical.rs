@@ -0,0 +1,75 @@
+use swc_core::{common::util::take::Take, ecma::ast};
+
+use super::MinifySyntax;
+
+impl MinifySyntax<'_> {
+  /// Values that are always truthy or always falsy, even if evaluating them has side effects, like
+  /// `void f()`.
+  pub(super) fn as_known_truthiness(&self, expr: &ast::Expr) -> Option<bool> {
+    match expr {
+      ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Void,
+        ..
+      }) => Some(false),
+      ast::Expr::Array(_)
+      | ast::Expr::Object(_)
+      | ast::Expr::Fn(_)
+      | ast::Expr::Arrow(_)
+      | ast::Expr::Lit(ast::Lit::Regex(_)) => Some(true),
+      expr => self.as_truthiness(expr),
+    }
+  }
+
+  /// Whether the value is always `null` or `undefined`, or never.
+  fn as_known_nullishness(&self, expr: &ast::Expr) -> Option<bool> {
+    match expr {
+      ast::Expr::Lit(ast::Lit::Null(_))
+      | ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Void,
+        ..
+      }) => Some(true),
+      ast::Expr::Ident(ident)
+        if &*ident.sym == "undefined" && ident.span.ctxt == self.unresolved_ctxt =>
+      {
+        Some(true)
+      }
+      ast::Expr::Lit(_)
+      | ast::Expr::Array(_)
+      | ast::Expr::Object(_)
+      | ast::Expr::Fn(_)
+      | ast::Expr::Arrow(_)
+      | ast::Expr::Tpl(_) => Some(false),
+      _ => None,
+    }
+  }
+
+  /// - `true && x` => `x`, `false && x` => `false`
+  /// - `true || x` => `true`, `false || x` => `x`
+  /// - `null ?? x` => `x`, `0 ?? x` => `0`
+  /// - `void f() || x` => `(void f(), x)`
+  ///
+  /// The right operand is never evaluated if the left one is the result. The left one is still
+  /// evaluated first if it's dropped and could have side effects.
+  pub(super) fn fold_logical(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(bin) = expr else {
+      return;
+    };
+    let is_left_the_result = match bin.op {
+      ast::BinaryOp::LogicalAnd => self.as_known_truthiness(&bin.left).map(|truthy| !truthy),
+      ast::BinaryOp::LogicalOr => self.as_known_truthiness(&bin.left),
+      ast::BinaryOp::NullishCoalescing => {
+        self.as_known_nullishness(&bin.left).map(|nullish| !nullish)
+      }
+      _ => None,
+    };
+    *expr = match is_left_the_result {
+      Some(true) => *bin.left.take(),
+      Some(false) if self.is_pure(&bin.left) => *bin.right.take(),
+      Some(false) => ast::Expr::Seq(ast::SeqExpr {
+        span: bin.span,
+        exprs: vec![bin.left.take(), bin.right.take()],
+      }),
+      None => return,
+    };
+  }
+}
@@ -0,0 +1,105 @@
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::{
+  empty_stmts::{is_empty_stmt, negate_test},
+  MinifySyntax,
+};
+
+/// `if (c) break;` without a label, which leaves the loop if it's the first statement of the body.
+fn as_break_test(stmt: &mut ast::Stmt) -> Option<&mut Box<ast::Expr>> {
+  let ast::Stmt::If(ast::IfStmt {
+    test,
+    cons,
+    alt: None,
+    ..
+  }) = stmt
+  else {
+    return None;
+  };
+  let is_break = match &**cons {
+    ast::Stmt::Break(ast::BreakStmt { label: None, .. }) => true,
+    ast::Stmt::Block(block) => matches!(
+      &block.stmts[..],
+      [ast::Stmt::Break(ast::BreakStmt { label: None, .. })]
+    ),
+    _ => false,
+  };
+  is_break.then_some(test)
+}
+
+/// `for (;;) { if (c) break; f() }` => `for (; !c;) { f() }`. The test runs at the same point of
+/// every iteration, after the update.
+fn hoist_break_test(for_stmt: &mut ast::ForStmt) {
+  if for_stmt.test.is_some() {
+    return;
+  }
+  match &mut *for_stmt.body {
+    ast::Stmt::Block(block) => {
+      let Some(test) = block.stmts.first_mut().and_then(as_break_test) else {
+        return;
+      };
+      for_stmt.test = Some(negate_test(test.take()));
+      block.stmts.remove(0);
+    }
+    body => {
+      let Some(test) = as_break_test(body) else {
+        return;
+      };
+      for_stmt.test = Some(negate_test(test.take()));
+      *body = ast::Stmt::Empty(ast::EmptyStmt { span: DUMMY_SP });
+    }
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// - `while (true) f()` => `for (;;) f()`
+  /// - `for (; c;) f()` => `while (c) f()`
+  /// - `for (0; true; 0) f()` => `for (;;) f()`
+  /// - `for (;;) { if (c) break; f() }` => `while (!c) { f() }`
+  /// - `for (;;) {}` => `for (;;);`
+  ///
+  /// Both loops are written as a `for` loop, and then as a `while` loop if it only has a test,
+  /// since `while(c)` is shorter than `for(;c;)` and `for(;;)` is shorter than `while(true)`.
+  pub(super) fn fold_loop(&self, stmt: &mut ast::Stmt) {
+    let mut for_stmt = match stmt.take() {
+      ast::Stmt::For(for_stmt) => for_stmt,
+      ast::Stmt::While(ast::WhileStmt { span, test, body }) => ast::ForStmt {
+        span,
+        init: None,
+        test: Some(test),
+        update: None,
+        body,
+      },
+      other => {
+        *stmt = other;
+        return;
+      }
+    };
+    if matches!(&for_stmt.init, Some(ast::VarDeclOrExpr::Expr(init)) if self.is_pure(init)) {
+      for_stmt.init = None;
+    }
+    if matches!(&for_stmt.update, Some(update) if self.is_pure(update)) {
+      for_stmt.update = None;
+    }
+    if matches!(&for_stmt.test, Some(test) if self.as_truthiness(test) == Some(true)) {
+      for_stmt.test = None;
+    }
+    hoist_break_test(&mut for_stmt);
+    if is_empty_stmt(&for_stmt.body) {
+      for_stmt.body = Box::new(ast::Stmt::Empty(ast::EmptyStmt { span: DUMMY_SP }));
+    }
+    *stmt = match for_stmt {
+      ast::ForStmt {
+        span,
+        init: None,
+        test: Some(test),
+        update: None,
+        body,
+      } => ast::Stmt::While(ast::WhileStmt { span, test, body }),
+      for_stmt => ast::Stmt::For(for_stmt),
+    };
+  }
+}
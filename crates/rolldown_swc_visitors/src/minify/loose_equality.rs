@@ -0,0 +1,120 @@
+use rustc_hash::FxHashSet;
+use swc_core::ecma::ast::{self, Id};
+
+use super::{increments::TypedBindings, MinifySyntax};
+
+#[derive(Debug, Clone, Copy, PartialEq, Eq)]
+enum PrimitiveType {
+  String,
+  Number,
+  Boolean,
+}
+
+/// Whether `expr` surely evaluates to a string. The interpolations of a template literal and the
+/// other side of `+` could throw, but nothing but a string could be returned.
+fn is_string(expr: &ast::Expr) -> bool {
+  match expr {
+    ast::Expr::Lit(ast::Lit::Str(_))
+    | ast::Expr::Tpl(_)
+    | ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::TypeOf,
+      ..
+    }) => true,
+    ast::Expr::Bin(ast::BinExpr {
+      op: ast::BinaryOp::Add,
+      left,
+      right,
+      ..
+    }) => is_string(left) || is_string(right),
+    ast::Expr::Paren(paren) => is_string(&paren.expr),
+    _ => false,
+  }
+}
+
+/// Only a string value is known to keep the target a string, since `undefined += 1` is `NaN`.
+fn keeps_string(_: &ast::Ident, op: ast::AssignOp, value: &ast::Expr) -> bool {
+  match op {
+    ast::AssignOp::Assign
+    | ast::AssignOp::AddAssign
+    | ast::AssignOp::AndAssign
+    | ast::AssignOp::OrAssign
+    | ast::AssignOp::NullishAssign => is_string(value),
+    _ => false,
+  }
+}
+
+pub(super) fn collect_string_ids(module: &ast::Module) -> FxHashSet<Id> {
+  TypedBindings::collect(module, is_string, keeps_string, false)
+}
+
+impl MinifySyntax<'_> {
+  /// Bindings of `numeric_ids` and `string_ids` may also be `undefined`, if they are declared
+  /// without a value. That's fine, since `undefined` is only loosely equal to `null` and itself.
+  fn primitive_type(&self, expr: &ast::Expr) -> Option<PrimitiveType> {
+    if is_string(expr) {
+      return Some(PrimitiveType::String);
+    }
+    match expr {
+      ast::Expr::Lit(ast::Lit::Num(_)) => Some(PrimitiveType::Number),
+      ast::Expr::Lit(ast::Lit::Bool(_)) => Some(PrimitiveType::Boolean),
+      ast::Expr::Unary(unary) => match unary.op {
+        // `-x` could be a BigInt, but `+x` throws for them.
+        ast::UnaryOp::Plus => Some(PrimitiveType::Number),
+        ast::UnaryOp::Minus if matches!(*unary.arg, ast::Expr::Lit(ast::Lit::Num(_))) => {
+          Some(PrimitiveType::Number)
+        }
+        ast::UnaryOp::Bang => Some(PrimitiveType::Boolean),
+        _ => None,
+      },
+      ast::Expr::Bin(bin) => matches!(
+        bin.op,
+        ast::BinaryOp::EqEq
+          | ast::BinaryOp::NotEq
+          | ast::BinaryOp::EqEqEq
+          | ast::BinaryOp::NotEqEq
+          | ast::BinaryOp::Lt
+          | ast::BinaryOp::LtEq
+          | ast::BinaryOp::Gt
+          | ast::BinaryOp::GtEq
+          | ast::BinaryOp::In
+          | ast::BinaryOp::InstanceOf
+      )
+      .then_some(PrimitiveType::Boolean),
+      ast::Expr::Ident(ident) if ident.span.ctxt == self.unresolved_ctxt => {
+        matches!(&*ident.sym, "NaN" | "Infinity").then_some(PrimitiveType::Number)
+      }
+      ast::Expr::Ident(ident) => {
+        let id = ident.to_id();
+        if self.numeric_ids.contains(&id) {
+          Some(PrimitiveType::Number)
+        } else if self.string_ids.contains(&id) {
+          Some(PrimitiveType::String)
+        } else {
+          None
+        }
+      }
+      _ => None,
+    }
+  }
+
+  /// - `typeof x === "string"` => `typeof x == "string"`
+  /// - `a !== "x"` => `a != "x"`, if `a` only ever holds strings
+  ///
+  /// `==` only converts operands of different types. Operands of the same type are compared just
+  /// like `===` does, including `NaN` and `-0`, so it's used when both sides surely have the same
+  /// primitive type.
+  pub(super) fn fold_loose_equality(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(bin) = expr else {
+      return;
+    };
+    let op = match bin.op {
+      ast::BinaryOp::EqEqEq => ast::BinaryOp::EqEq,
+      ast::BinaryOp::NotEqEq => ast::BinaryOp::NotEq,
+      _ => return,
+    };
+    let left = self.primitive_type(&bin.left);
+    if left.is_some() && left == self.primitive_type(&bin.right) {
+      bin.op = op;
+    }
+  }
+}
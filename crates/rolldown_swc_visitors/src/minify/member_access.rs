@@ -0,0 +1,158 @@
+use swc_core::{
+  common::util::take::Take,
+  ecma::{ast, atoms::JsWord},
+};
+
+use super::{
+  exponent::number_expr, template_literal::str_expr, void_context::void_zero, MinifySyntax,
+};
+
+/// Integers above this aren't array indices.
+const MAX_ARRAY_INDEX: f64 = 4294967294.0;
+
+enum Key {
+  Index(usize),
+  Name(JsWord),
+}
+
+fn key_of(prop: &ast::MemberProp) -> Option<Key> {
+  match prop {
+    ast::MemberProp::Ident(ident) => Some(Key::Name(ident.sym.clone())),
+    ast::MemberProp::Computed(ast::ComputedPropName { expr, .. }) => match &**expr {
+      ast::Expr::Lit(ast::Lit::Num(num))
+        if num.value.fract() == 0.0 && (0.0..=MAX_ARRAY_INDEX).contains(&num.value) =>
+      {
+        Some(Key::Index(num.value as usize))
+      }
+      ast::Expr::Lit(ast::Lit::Str(str)) => Some(Key::Name(str.value.clone())),
+      _ => None,
+    },
+    ast::MemberProp::PrivateName(_) => None,
+  }
+}
+
+/// A value taken out of the literal could be called, like `[f][0]()`, and `this` would no longer
+/// be the literal then.
+fn is_detachable(expr: &ast::Expr) -> bool {
+  !matches!(
+    expr,
+    ast::Expr::Fn(_)
+      | ast::Expr::Arrow(_)
+      | ast::Expr::Class(_)
+      | ast::Expr::Ident(_)
+      | ast::Expr::This(_)
+      | ast::Expr::Seq(_)
+      | ast::Expr::Paren(_)
+      | ast::Expr::Call(_)
+  )
+}
+
+impl MinifySyntax<'_> {
+  /// `[a, b][i]`. Holes and indices out of bounds are `undefined`.
+  fn fold_array_access(&self, array: &mut ast::ArrayLit, index: usize) -> Option<ast::Expr> {
+    let is_pure = array
+      .elems
+      .iter()
+      .flatten()
+      .all(|elem| elem.spread.is_none() && self.is_pure(&elem.expr));
+    if !is_pure {
+      return None;
+    }
+    match array.elems.get_mut(index) {
+      Some(Some(elem)) => is_detachable(&elem.expr).then(|| *elem.expr.take()),
+      Some(None) | None => Some(void_zero(array.span)),
+    }
+  }
+
+  /// `[a, , b].length` => `3`. Holes are counted as well.
+  fn fold_array_length(&self, array: &ast::ArrayLit) -> Option<ast::Expr> {
+    let is_pure = array
+      .elems
+      .iter()
+      .flatten()
+      .all(|elem| elem.spread.is_none() && self.is_pure(&elem.expr));
+    is_pure.then(|| number_expr(array.span, array.elems.len() as f64))
+  }
+
+  /// `{ a: 1 }.a`. A missing key is kept, since it could be on `Object.prototype`, like `toString`.
+  fn fold_object_access(&self, object: &mut ast::ObjectLit, name: &JsWord) -> Option<ast::Expr> {
+    let mut found = None;
+    for (idx, prop) in object.props.iter().enumerate() {
+      let ast::PropOrSpread::Prop(box ast::Prop::KeyValue(prop)) = prop else {
+        return None;
+      };
+      let key = match &prop.key {
+        ast::PropName::Ident(ident) => &ident.sym,
+        ast::PropName::Str(str) => &str.value,
+        _ => return None,
+      };
+      // It sets the prototype instead of defining a property.
+      if &**key == "__proto__" || !self.is_pure(&prop.value) {
+        return None;
+      }
+      // The last one wins if the key is duplicated.
+      if key == name {
+        found = Some(idx);
+      }
+    }
+    let ast::PropOrSpread::Prop(box ast::Prop::KeyValue(prop)) = &mut object.props[found?] else {
+      unreachable!()
+    };
+    is_detachable(&prop.value).then(|| *prop.value.take())
+  }
+
+  /// `"abc"[0]` => `"a"`. A lone surrogate can't be written as a string literal, so it's kept.
+  fn fold_string_access(&self, str: &ast::Str, index: usize) -> Option<ast::Expr> {
+    match str.value.encode_utf16().nth(index) {
+      Some(unit) => {
+        let value = String::from_utf16(&[unit]).ok()?;
+        Some(str_expr(str.span, value))
+      }
+      None => Some(void_zero(str.span)),
+    }
+  }
+
+  /// `"a😀b".length` => `4`. Like in JavaScript, it's the number of UTF-16 code units, so
+  /// characters outside the BMP count twice.
+  fn fold_string_length(&self, str: &ast::Str) -> ast::Expr {
+    number_expr(str.span, str.value.encode_utf16().count() as f64)
+  }
+
+  /// - `[10, 20][0]` => `10`
+  /// - `({ x: 5 }).x` => `5`
+  /// - `"abc"[0]` => `"a"`
+  /// - `[10, 20][2]` => `void 0`
+  /// - `["a", "b"].length` => `2`
+  /// - `"abc".length` => `3`
+  /// - `"a,b".split(",")[0]` => `"a"`
+  ///
+  /// Only literals whose other values are pure are folded, since they are dropped.
+  pub(super) fn fold_member_access(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Member(member) = expr else {
+      return;
+    };
+    let Some(key) = key_of(&member.prop) else {
+      return;
+    };
+    if matches!(&key, Key::Index(_)) || matches!(&key, Key::Name(name) if &**name == "length") {
+      self.fold_split_for_access(&mut member.obj);
+    }
+    let folded = match (&mut *member.obj, key) {
+      (ast::Expr::Array(array), Key::Index(index)) => self.fold_array_access(array, index),
+      (ast::Expr::Array(array), Key::Name(name)) if &*name == "length" => {
+        self.fold_array_length(array)
+      }
+      (ast::Expr::Object(object), Key::Name(name)) => self.fold_object_access(object, &name),
+      (ast::Expr::Lit(ast::Lit::Str(str)), Key::Index(index)) => {
+        self.fold_string_access(str, index)
+      }
+      (ast::Expr::Lit(ast::Lit::Str(str)), Key::Name(name)) if &*name == "length" => {
+        Some(self.fold_string_length(str))
+      }
+      _ => None,
+    };
+    if let Some(folded) = folded {
+      *expr = folded;
+    }
+  }
+}
@@ -0,0 +1,274 @@
+use rolldown_common::Target;
+use rustc_hash::FxHashSet;
+use swc_core::{
+  common::{comments::Comments, util::take::Take, SyntaxContext, DUMMY_SP},
+  ecma::{
+    ast::{self, Id},
+    transforms::base::fixer::fixer,
+    visit::{VisitMut, VisitMutWith},
+  },
+};
+
+mod array_methods;
+mod array_spread;
+mod arrow_body;
+mod booleans;
+mod coercions;
+mod comparisons;
+mod conditional;
+mod constructors;
+mod dead_stores;
+mod empty_stmts;
+mod exponent;
+mod iife;
+mod in_operator;
+mod increments;
+mod join_vars;
+mod logical;
+mod loose_equality;
+mod loops;
+mod member_access;
+mod nullish_checks;
+mod object_spread;
+mod parens;
+mod quotes;
+mod returns;
+mod sequences;
+mod shorthands;
+mod tails;
+mod template_literal;
+mod try_stmt;
+mod typeof_checks;
+mod unused_params;
+mod void_context;
+
+/// Transforms on statement lists work on both `Vec<ast::Stmt>` and `Vec<ast::ModuleItem>`.
+pub(crate) trait AsStmtMut {
+  fn as_stmt_mut(&mut self) -> Option<&mut ast::Stmt>;
+  fn from_stmt(stmt: ast::Stmt) -> Self;
+}
+
+impl AsStmtMut for ast::Stmt {
+  fn as_stmt_mut(&mut self) -> Option<&mut ast::Stmt> {
+    Some(self)
+  }
+
+  fn from_stmt(stmt: ast::Stmt) -> Self {
+    stmt
+  }
+}
+
+impl AsStmtMut for ast::ModuleItem {
+  fn as_stmt_mut(&mut self) -> Option<&mut ast::Stmt> {
+    match self {
+      ast::ModuleItem::Stmt(stmt) => Some(stmt),
+      _ => None,
+    }
+  }
+
+  fn from_stmt(stmt: ast::Stmt) -> Self {
+    ast::ModuleItem::Stmt(stmt)
+  }
+}
+
+/// Syntax-level minification. Each transform lives in its own file and is driven from here.
+pub struct MinifySyntax<'a> {
+  /// Identifiers with this ctxt are unresolved references, which could refer to globals.
+  unresolved_ctxt: SyntaxContext,
+  /// Syntax newer than the target is never introduced.
+  target: Target,
+  /// Where `/*#__PURE__*/` annotations are looked up.
+  comments: &'a dyn Comments,
+  /// `const` declarations and imports of the module, which throw when they are assigned.
+  immutable_ids: FxHashSet<Id>,
+  /// Bindings that only ever hold numbers, so `+ 1` never concatenates a string to them.
+  numeric_ids: FxHashSet<Id>,
+  /// Bindings that only ever hold strings, so comparing them to strings doesn't need `===`.
+  string_ids: FxHashSet<Id>,
+}
+
+pub fn minify_syntax(
+  unresolved_ctxt: SyntaxContext,
+  target: Target,
+  comments: &dyn Comments,
+) -> MinifySyntax<'_> {
+  MinifySyntax {
+    unresolved_ctxt,
+    target,
+    comments,
+    immutable_ids: Default::default(),
+    numeric_ids: Default::default(),
+    string_ids: Default::default(),
+  }
+}
+
+/// Methods are skipped on purpose, since a setter must have exactly one parameter.
+fn drop_unused_trailing_params_of_function(function: &mut ast::Function) {
+  let len = unused_params::used_params_len(&function.params, |param| &param.pat, &*function);
+  function.params.truncate(len);
+}
+
+impl MinifySyntax<'_> {
+  /// Statements that do nothing are removed from the list instead of being left as `;`.
+  fn remove_no_op_stmts<T: AsStmtMut>(&self, stmts: &mut Vec<T>) {
+    stmts.retain_mut(|stmt| match stmt.as_stmt_mut() {
+      Some(stmt) => {
+        !empty_stmts::is_empty_stmt(stmt)
+          && !try_stmt::is_removable_try(stmt)
+          && !self.is_unused_pure_stmt(stmt)
+      }
+      None => true,
+    });
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// The target of an assignment or an update must stay a reference, so `undefined++` and
+  /// `[a][0] = 1` aren't folded. Only the parts of a member expression are.
+  fn visit_mut_target(&mut self, target: &mut ast::Expr) {
+    match target {
+      ast::Expr::Ident(_) => {}
+      ast::Expr::Member(member) => member.visit_mut_children_with(self),
+      target => target.visit_mut_children_with(self),
+    }
+  }
+}
+
+impl VisitMut for MinifySyntax<'_> {
+  fn visit_mut_module(&mut self, module: &mut ast::Module) {
+    self.immutable_ids = try_stmt::collect_immutable_ids(module);
+    self.numeric_ids = increments::collect_numeric_ids(module);
+    self.string_ids = loose_equality::collect_string_ids(module);
+    module.visit_mut_children_with(self);
+    // Strings are only written once they are all folded.
+    quotes::normalize_quotes(module, self.target);
+    booleans::shorten_booleans(module);
+    module.visit_mut_with(&mut fixer(Some(self.comments)));
+  }
+
+  fn visit_mut_module_items(&mut self, items: &mut Vec<ast::ModuleItem>) {
+    items.visit_mut_children_with(self);
+    self.hoist_common_tails(items);
+    self.remove_no_op_stmts(items);
+    join_vars::join_vars(items);
+    sequences::fold_sequences(items);
+  }
+
+  fn visit_mut_arrow_expr(&mut self, arrow: &mut ast::ArrowExpr) {
+    self.drop_dead_stores_of_arrow(arrow);
+    arrow.visit_mut_children_with(self);
+    if let Some(block) = arrow.body.as_mut_block_stmt() {
+      returns::drop_trailing_return(&mut block.stmts);
+    }
+    let len = unused_params::used_params_len(&arrow.params, |pat| pat, &*arrow);
+    arrow.params.truncate(len);
+    arrow_body::collapse_arrow_body(arrow);
+  }
+
+  fn visit_mut_function(&mut self, function: &mut ast::Function) {
+    self.drop_dead_stores_of_function(function);
+    function.visit_mut_children_with(self);
+    if let Some(body) = &mut function.body {
+      returns::drop_trailing_return(&mut body.stmts);
+    }
+  }
+
+  fn visit_mut_return_stmt(&mut self, ret: &mut ast::ReturnStmt) {
+    ret.visit_mut_children_with(self);
+    self.collapse_return_undefined(ret);
+  }
+
+  fn visit_mut_fn_decl(&mut self, decl: &mut ast::FnDecl) {
+    decl.visit_mut_children_with(self);
+    drop_unused_trailing_params_of_function(&mut decl.function);
+  }
+
+  fn visit_mut_fn_expr(&mut self, expr: &mut ast::FnExpr) {
+    expr.visit_mut_children_with(self);
+    drop_unused_trailing_params_of_function(&mut expr.function);
+  }
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    parens::drop_parens(expr);
+    expr.visit_mut_children_with(self);
+    self.fold_iife(expr);
+    self.fold_undefined(expr);
+    self.fold_new(expr);
+    self.fold_coercion(expr);
+    self.fold_comparison(expr);
+    self.fold_typeof_undefined(expr);
+    self.fold_nullish_check(expr);
+    self.fold_exponent(expr);
+    self.fold_template_literal(expr);
+    self.fold_object_spread(expr);
+    self.fold_object_shorthands(expr);
+    self.fold_array_spread(expr);
+    self.fold_array_method(expr);
+    self.fold_member_access(expr);
+    self.fold_in(expr);
+    self.fold_void(expr);
+    self.fold_logical(expr);
+    self.fold_constant_test(expr);
+    self.fold_identical_branches(expr);
+    self.fold_increment(expr);
+    self.fold_loose_equality(expr);
+  }
+
+  fn visit_mut_update_expr(&mut self, expr: &mut ast::UpdateExpr) {
+    self.visit_mut_target(&mut expr.arg);
+  }
+
+  fn visit_mut_pat_or_expr(&mut self, target: &mut ast::PatOrExpr) {
+    match target {
+      ast::PatOrExpr::Expr(expr) => self.visit_mut_target(expr),
+      ast::PatOrExpr::Pat(box ast::Pat::Expr(expr)) => self.visit_mut_target(expr),
+      ast::PatOrExpr::Pat(pat) => pat.visit_mut_with(self),
+    }
+  }
+
+  fn visit_mut_expr_stmt(&mut self, stmt: &mut ast::ExprStmt) {
+    match &mut *stmt.expr {
+      // A string statement at the start of a body would become a directive, like `"use strict"`.
+      ast::Expr::Tpl(tpl) => tpl.visit_mut_children_with(self),
+      // Dropping the parens would turn it into a directive as well.
+      ast::Expr::Paren(paren)
+        if matches!(
+          *paren.expr,
+          ast::Expr::Lit(ast::Lit::Str(_)) | ast::Expr::Tpl(_)
+        ) =>
+      {
+        paren.expr.visit_mut_children_with(self)
+      }
+      expr => expr.visit_mut_with(self),
+    }
+    increments::prefer_postfix(&mut stmt.expr);
+    // An object literal at the start of a statement would be parsed as a block.
+    if arrow_body::starts_with_object_literal(&stmt.expr) {
+      stmt.expr = Box::new(ast::Expr::Paren(ast::ParenExpr {
+        span: DUMMY_SP,
+        expr: stmt.expr.take(),
+      }));
+    }
+  }
+
+  fn visit_mut_class_members(&mut self, members: &mut Vec<ast::ClassMember>) {
+    members.visit_mut_children_with(self);
+    // `m() {};` in a class body
+    members.retain(|member| !matches!(member, ast::ClassMember::Empty(_)));
+  }
+
+  fn visit_mut_stmt(&mut self, stmt: &mut ast::Stmt) {
+    stmt.visit_mut_children_with(self);
+    self.fold_try(stmt);
+    self.fold_empty_if(stmt);
+    self.fold_loop(stmt);
+  }
+
+  fn visit_mut_stmts(&mut self, stmts: &mut Vec<ast::Stmt>) {
+    stmts.visit_mut_children_with(self);
+    self.hoist_common_tails(stmts);
+    self.remove_no_op_stmts(stmts);
+    join_vars::join_vars(stmts);
+    sequences::fold_sequences(stmts);
+  }
+}
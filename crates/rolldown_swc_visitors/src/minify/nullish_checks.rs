@@ -0,0 +1,77 @@
+use swc_core::{common::DUMMY_SP, ecma::ast};
+
+use super::MinifySyntax;
+
+/// `x === null`, `null === x`, `x === undefined` and their negations, where `x` is a name.
+struct NullishComparison<'a> {
+  ident: &'a ast::Ident,
+  /// Compared with `null`, otherwise with `undefined`.
+  is_null: bool,
+}
+
+impl MinifySyntax<'_> {
+  fn as_nullish_comparison<'a>(
+    &self,
+    expr: &'a ast::Expr,
+    is_negated: bool,
+  ) -> Option<NullishComparison<'a>> {
+    let ast::Expr::Bin(bin) = expr else {
+      return None;
+    };
+    let ops = if is_negated {
+      [ast::BinaryOp::NotEqEq, ast::BinaryOp::NotEq]
+    } else {
+      [ast::BinaryOp::EqEqEq, ast::BinaryOp::EqEq]
+    };
+    if !ops.contains(&bin.op) {
+      return None;
+    }
+    let (ident, value) = match (&*bin.left, &*bin.right) {
+      (ast::Expr::Ident(ident), value) if !self.is_undefined(&bin.left) => (ident, value),
+      (value, ast::Expr::Ident(ident)) => (ident, value),
+      _ => return None,
+    };
+    let is_null = match value {
+      ast::Expr::Lit(ast::Lit::Null(_)) => true,
+      value if self.is_undefined(value) => false,
+      _ => return None,
+    };
+    Some(NullishComparison { ident, is_null })
+  }
+
+  /// - `x === null || x === undefined` => `x == null`
+  /// - `x !== null && x !== undefined` => `x != null`
+  ///
+  /// `==` only equals `null` to `null` and `undefined`. Only names are folded, since reading a
+  /// property twice could run a getter twice.
+  pub(super) fn fold_nullish_check(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(bin) = expr else {
+      return;
+    };
+    let is_negated = match bin.op {
+      ast::BinaryOp::LogicalOr => false,
+      ast::BinaryOp::LogicalAnd => true,
+      _ => return,
+    };
+    let (Some(left), Some(right)) = (
+      self.as_nullish_comparison(&bin.left, is_negated),
+      self.as_nullish_comparison(&bin.right, is_negated),
+    ) else {
+      return;
+    };
+    if left.ident.to_id() != right.ident.to_id() || left.is_null == right.is_null {
+      return;
+    }
+    let ident = left.ident.clone();
+    *expr = ast::Expr::Bin(ast::BinExpr {
+      span: bin.span,
+      op: if is_negated {
+        ast::BinaryOp::NotEq
+      } else {
+        ast::BinaryOp::EqEq
+      },
+      left: Box::new(ast::Expr::Ident(ident)),
+      right: Box::new(ast::Expr::Lit(ast::Lit::Null(ast::Null { span: DUMMY_SP }))),
+    });
+  }
+}
@@ -0,0 +1,103 @@
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::MinifySyntax;
+
+pub(super) fn is_proto_key(key: &ast::PropName) -> bool {
+  match key {
+    ast::PropName::Ident(ident) => &*ident.sym == "__proto__",
+    ast::PropName::Str(str) => &*str.value == "__proto__",
+    _ => false,
+  }
+}
+
+/// Properties are assigned to a fresh `{}` without calling any setter, same as spreading.
+fn is_empty_object(expr: &ast::Expr) -> bool {
+  matches!(expr, ast::Expr::Object(object) if object.props.is_empty())
+}
+
+/// Spreading an object literal copies its properties as they are, unless the literal sets the
+/// prototype with `__proto__` or has accessors, which are called instead of being copied.
+fn is_flattenable(object: &ast::ObjectLit) -> bool {
+  object.props.iter().all(|prop| match prop {
+    ast::PropOrSpread::Spread(_) => true,
+    ast::PropOrSpread::Prop(box ast::Prop::KeyValue(ast::KeyValueProp { key, .. })) => {
+      !is_proto_key(key)
+    }
+    ast::PropOrSpread::Prop(box ast::Prop::Shorthand(_)) => true,
+    ast::PropOrSpread::Prop(_) => false,
+  })
+}
+
+/// `{ ...{ a: 1 }, b: 2 }` => `{ a: 1, b: 2 }`
+///
+/// The properties are spliced in place, so a later key still wins.
+fn flatten_object_spreads(object: &mut ast::ObjectLit) {
+  let has_flattenable_spread = object.props.iter().any(|prop| {
+    matches!(
+      prop,
+      ast::PropOrSpread::Spread(ast::SpreadElement {
+        expr: box ast::Expr::Object(inner),
+        ..
+      }) if is_flattenable(inner)
+    )
+  });
+  if !has_flattenable_spread {
+    return;
+  }
+  object.props = object
+    .props
+    .take()
+    .into_iter()
+    .flat_map(|prop| match prop {
+      ast::PropOrSpread::Spread(ast::SpreadElement {
+        expr: box ast::Expr::Object(inner),
+        ..
+      }) if is_flattenable(&inner) => inner.props,
+      prop => vec![prop],
+    })
+    .collect();
+}
+
+impl MinifySyntax<'_> {
+  fn is_object_assign(&self, callee: &ast::Callee) -> bool {
+    matches!(
+      callee,
+      ast::Callee::Expr(box ast::Expr::Member(ast::MemberExpr {
+        obj: box ast::Expr::Ident(obj),
+        prop: ast::MemberProp::Ident(prop),
+        ..
+      })) if &*obj.sym == "Object" && obj.span.ctxt == self.unresolved_ctxt && &*prop.sym == "assign"
+    )
+  }
+
+  /// - `Object.assign({}, a, b)` => `{ ...a, ...b }`, if the target supports object spread
+  /// - `{ ...{ a: 1 }, b: 2 }` => `{ a: 1, b: 2 }`
+  pub(super) fn fold_object_spread(&self, expr: &mut ast::Expr) {
+    if let ast::Expr::Call(call) = expr
+      && self.target.supports_object_spread()
+      && self.is_object_assign(&call.callee)
+      && call.args.first().map_or(false, |target| is_empty_object(&target.expr))
+      && call.args.iter().all(|arg| arg.spread.is_none())
+    {
+      let span = call.span;
+      let props = call
+        .args
+        .drain(1..)
+        .map(|source| {
+          ast::PropOrSpread::Spread(ast::SpreadElement {
+            dot3_token: DUMMY_SP,
+            expr: source.expr,
+          })
+        })
+        .collect();
+      *expr = ast::Expr::Object(ast::ObjectLit { span, props });
+    }
+
+    if let ast::Expr::Object(object) = expr {
+      flatten_object_spreads(object);
+    }
+  }
+}
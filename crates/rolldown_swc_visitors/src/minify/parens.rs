@@ -0,0 +1,16 @@
+use swc_core::{common::util::take::Take, ecma::ast};
+
+/// `((a))` => `a`
+///
+/// All the other parens are dropped too. The ones required by precedence, or to keep an object
+/// literal or a function from starting a statement, are added back by the fixer once folding is
+/// done. Parens around optional chains are kept, since `(a?.b).c` throws if `a` is nullish while
+/// `a?.b.c` doesn't.
+pub(super) fn drop_parens(expr: &mut ast::Expr) {
+  while let ast::Expr::Paren(paren) = expr
+    && !matches!(*paren.expr, ast::Expr::OptChain(_))
+  {
+    let inner = paren.expr.take();
+    *expr = *inner;
+  }
+}
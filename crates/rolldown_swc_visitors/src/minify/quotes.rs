@@ -0,0 +1,149 @@
+use std::fmt::Write;
+
+use rolldown_common::Target;
+use swc_core::ecma::{
+  ast,
+  visit::{VisitMut, VisitMutWith},
+};
+
+#[derive(Clone, Copy, PartialEq, Eq)]
+enum Quote {
+  Double,
+  Single,
+  Backtick,
+}
+
+impl Quote {
+  fn as_char(self) -> char {
+    match self {
+      Quote::Double => '"',
+      Quote::Single => '\'',
+      Quote::Backtick => '`',
+    }
+  }
+}
+
+/// How many characters of the value are escaped if it's written with the quote.
+fn escapes_with(value: &str, quote: Quote) -> usize {
+  match quote {
+    // Line breaks are written as they are in templates.
+    Quote::Backtick => value.matches('`').count(),
+    quote => value.matches(quote.as_char()).count() + value.matches('\n').count(),
+  }
+}
+
+/// Picks the quote that needs the fewest escapes. A tie prefers `"`, then `'`, so the output keeps
+/// using the same quote, which compresses better.
+fn best_quote(value: &str, allows_backtick: bool) -> Quote {
+  let mut quotes = vec![Quote::Double, Quote::Single];
+  // `${` would start a substitution in a template.
+  if allows_backtick && !value.contains("${") {
+    quotes.push(Quote::Backtick);
+  }
+  quotes
+    .into_iter()
+    .min_by_key(|quote| escapes_with(value, *quote))
+    .unwrap()
+}
+
+/// The value as it's written between the quotes.
+fn escape(value: &str, quote: Quote) -> String {
+  let quote = quote.as_char();
+  let mut raw = String::with_capacity(value.len());
+  value.chars().for_each(|c| match c {
+    '\\' => raw.push_str("\\\\"),
+    '\n' if quote != '`' => raw.push_str("\\n"),
+    '\n' => raw.push('\n'),
+    '\r' => raw.push_str("\\r"),
+    '\u{2028}' => raw.push_str("\\u2028"),
+    '\u{2029}' => raw.push_str("\\u2029"),
+    c if c == quote => {
+      raw.push('\\');
+      raw.push(c);
+    }
+    // `\0` can't be used, since it's an octal escape if a digit follows.
+    c if c != '\t' && c.is_ascii_control() => write!(raw, "\\x{:02x}", c as u32).unwrap(),
+    c => raw.push(c),
+  });
+  raw
+}
+
+/// The raw of a string literal, with the quote that needs fewer escapes.
+pub(super) fn quote_str(value: &str) -> String {
+  let quote = best_quote(value, false);
+  format!("{0}{1}{0}", quote.as_char(), escape(value, quote))
+}
+
+/// A lone surrogate like `"\uD800"` can't be kept in the value, so only the original raw is right.
+fn has_surrogate_escape(raw: &str) -> bool {
+  let raw = raw.to_ascii_lowercase();
+  raw
+    .match_indices("\\ud")
+    .any(|(idx, _)| matches!(raw.as_bytes().get(idx + 3), Some(b'8'..=b'9' | b'a'..=b'f')))
+}
+
+struct Quotes {
+  allows_templates: bool,
+}
+
+impl VisitMut for Quotes {
+  fn visit_mut_str(&mut self, str: &mut ast::Str) {
+    if str.raw.as_deref().map_or(false, has_surrogate_escape) {
+      return;
+    }
+    str.raw = Some(quote_str(&str.value).into());
+  }
+
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    expr.visit_mut_children_with(self);
+    if !self.allows_templates {
+      return;
+    }
+    // Property keys and module sources aren't expressions, so they are never written as templates.
+    let ast::Expr::Lit(ast::Lit::Str(str)) = expr else {
+      return;
+    };
+    if str.raw.as_deref().map_or(false, has_surrogate_escape)
+      || best_quote(&str.value, true) != Quote::Backtick
+    {
+      return;
+    }
+    *expr = ast::Expr::Tpl(ast::Tpl {
+      span: str.span,
+      exprs: vec![],
+      quasis: vec![ast::TplElement {
+        span: str.span,
+        tail: true,
+        cooked: Some(str.value.to_string().into()),
+        raw: escape(&str.value, Quote::Backtick).into(),
+      }],
+    });
+  }
+
+  fn visit_mut_expr_stmt(&mut self, stmt: &mut ast::ExprStmt) {
+    match &mut *stmt.expr {
+      // A string statement could be a directive, like `"use strict"`, which can't be a template.
+      ast::Expr::Lit(ast::Lit::Str(str)) => str.visit_mut_with(self),
+      expr => expr.visit_mut_with(self),
+    }
+  }
+
+  fn visit_mut_jsx_attr_value(&mut self, value: &mut ast::JSXAttrValue) {
+    // Escapes aren't allowed in the strings of JSX attributes.
+    if !matches!(value, ast::JSXAttrValue::Lit(_)) {
+      value.visit_mut_children_with(self);
+    }
+  }
+}
+
+/// Writes every string literal with the quote that needs the fewest escapes, instead of keeping the
+/// original one.
+///
+/// - `'it\'s'` => `"it's"`
+/// - `"say \"hi\""` => `'say "hi"'`
+/// - `'it\'s "a"'` => `` `it's "a"` ``
+pub(super) fn normalize_quotes(module: &mut ast::Module, target: Target) {
+  module.visit_mut_with(&mut Quotes {
+    allows_templates: target.supports_template_literals(),
+  });
+}
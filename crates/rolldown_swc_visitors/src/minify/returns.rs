@@ -0,0 +1,38 @@
+use swc_core::ecma::ast;
+
+use super::MinifySyntax;
+
+impl MinifySyntax<'_> {
+  pub(super) fn is_undefined(&self, expr: &ast::Expr) -> bool {
+    match expr {
+      // `undefined` could be a local variable or parameter.
+      ast::Expr::Ident(ident) => {
+        &*ident.sym == "undefined" && ident.span.ctxt == self.unresolved_ctxt
+      }
+      ast::Expr::Unary(ast::UnaryExpr {
+        op: ast::UnaryOp::Void,
+        arg: box ast::Expr::Lit(ast::Lit::Num(_)),
+        ..
+      }) => true,
+      _ => false,
+    }
+  }
+
+  /// - `return undefined` => `return`
+  /// - `return void 0` => `return`
+  pub(crate) fn collapse_return_undefined(&self, ret: &mut ast::ReturnStmt) {
+    if matches!(ret.arg.as_deref(), Some(arg) if self.is_undefined(arg)) {
+      ret.arg = None;
+    }
+  }
+}
+
+/// `function f() { a(); return }` => `function f() { a() }`
+///
+/// Only the last statement of the body itself is dropped. A `return` at the end of a nested block
+/// skips the statements after the block.
+pub(super) fn drop_trailing_return(stmts: &mut Vec<ast::Stmt>) {
+  if let Some(ast::Stmt::Return(ast::ReturnStmt { arg: None, .. })) = stmts.last() {
+    stmts.pop();
+  }
+}
@@ -0,0 +1,59 @@
+use swc_core::{
+  common::{util::take::Take, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::AsStmtMut;
+
+fn join_exprs(a: Box<ast::Expr>, b: Box<ast::Expr>) -> Box<ast::Expr> {
+  let mut exprs = match *a {
+    ast::Expr::Seq(seq) => seq.exprs,
+    a => vec![Box::new(a)],
+  };
+  match *b {
+    ast::Expr::Seq(seq) => exprs.extend(seq.exprs),
+    b => exprs.push(Box::new(b)),
+  }
+  Box::new(ast::Expr::Seq(ast::SeqExpr {
+    span: DUMMY_SP,
+    exprs,
+  }))
+}
+
+/// Folding a string literal would break directives like `"use strict"`.
+fn is_foldable(expr_stmt: &ast::ExprStmt) -> bool {
+  !matches!(*expr_stmt.expr, ast::Expr::Lit(ast::Lit::Str(_)))
+}
+
+/// - `a(); b();` => `a(), b();`
+/// - `a(); b(); return c;` => `return a(), b(), c;`
+///
+/// Only adjacent statements are folded, so the order of side effects stays the same and nothing is
+/// moved across declarations or control flow.
+pub(super) fn fold_sequences<T: AsStmtMut>(items: &mut Vec<T>) {
+  let mut folded: Vec<T> = Vec::with_capacity(items.len());
+
+  for mut item in std::mem::take(items) {
+    if let Some(stmt) = item.as_stmt_mut()
+      && let Some(ast::Stmt::Expr(prev)) = folded.last_mut().and_then(|prev| prev.as_stmt_mut())
+      && is_foldable(prev)
+    {
+      match stmt {
+        ast::Stmt::Expr(expr_stmt) if is_foldable(expr_stmt) => {
+          prev.expr = join_exprs(prev.expr.take(), expr_stmt.expr.take());
+          continue;
+        }
+        ast::Stmt::Return(ast::ReturnStmt { arg: Some(arg), .. })
+        | ast::Stmt::Throw(ast::ThrowStmt { arg, .. }) => {
+          *arg = join_exprs(prev.expr.take(), arg.take());
+          folded.pop();
+        }
+        _ => {}
+      }
+    }
+
+    folded.push(item);
+  }
+
+  *items = folded;
+}
@@ -0,0 +1,96 @@
+use swc_core::{
+  common::util::take::Take,
+  ecma::{
+    ast,
+    atoms::JsWord,
+    visit::{noop_visit_type, Visit, VisitWith},
+  },
+};
+
+use super::{object_spread::is_proto_key, MinifySyntax};
+
+/// Finds references to the name of a function expression, which is the function itself. Property
+/// names are taken as references too, which only keeps more names.
+struct NameFinder<'a> {
+  name: &'a JsWord,
+  found: bool,
+}
+
+impl Visit for NameFinder<'_> {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    if &ident.sym == self.name {
+      self.found = true;
+    }
+  }
+}
+
+fn refers_to_name(function: &ast::Function, name: &JsWord) -> bool {
+  let mut finder = NameFinder { name, found: false };
+  function.visit_with(&mut finder);
+  finder.found
+}
+
+fn key_name(key: &ast::PropName) -> Option<&JsWord> {
+  match key {
+    ast::PropName::Ident(ident) => Some(&ident.sym),
+    ast::PropName::Str(str) => Some(&str.value),
+    _ => None,
+  }
+}
+
+/// A function named after its key is called the same as a method, as long as the body doesn't
+/// need the name to refer to the function.
+fn is_method_like(
+  key: &ast::PropName,
+  ident: Option<&ast::Ident>,
+  function: &ast::Function,
+) -> bool {
+  ident.map_or(true, |ident| {
+    key_name(key) == Some(&ident.sym) && !refers_to_name(function, &ident.sym)
+  })
+}
+
+impl MinifySyntax<'_> {
+  /// - `{ a: a }` => `{ a }`
+  /// - `{ f: function () {} }` => `{ f() {} }`
+  ///
+  /// Computed keys are kept, and so is `__proto__`, which sets the prototype as a key but not as a
+  /// shorthand or a method. Methods can't be called with `new`, which bundled code isn't expected
+  /// to do with functions of object literals.
+  pub(super) fn fold_object_shorthands(&self, expr: &mut ast::Expr) {
+    if !self.target.supports_object_shorthands() {
+      return;
+    }
+    let ast::Expr::Object(object) = expr else {
+      return;
+    };
+    object.props.iter_mut().for_each(|prop| {
+      let ast::PropOrSpread::Prop(prop) = prop else {
+        return;
+      };
+      let ast::Prop::KeyValue(ast::KeyValueProp { key, value }) = &mut **prop else {
+        return;
+      };
+      if key.is_computed() || is_proto_key(key) {
+        return;
+      }
+      let folded = match &mut **value {
+        ast::Expr::Ident(ident) if key_name(key) == Some(&ident.sym) => {
+          ast::Prop::Shorthand(ident.take())
+        }
+        ast::Expr::Fn(ast::FnExpr { ident, function })
+          if is_method_like(key, ident.as_ref(), function) =>
+        {
+          ast::Prop::Method(ast::MethodProp {
+            key: key.clone(),
+            function: function.take(),
+          })
+        }
+        _ => return,
+      };
+      **prop = folded;
+    });
+  }
+}
@@ -0,0 +1,109 @@
+use swc_core::{
+  common::{util::take::Take, EqIgnoreSpan, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::{AsStmtMut, MinifySyntax};
+
+/// The statements of a branch, which is a block or a single statement.
+fn branch_stmts(stmt: &ast::Stmt) -> &[ast::Stmt] {
+  match stmt {
+    ast::Stmt::Block(block) => &block.stmts,
+    ast::Stmt::Empty(_) => &[],
+    stmt => std::slice::from_ref(stmt),
+  }
+}
+
+fn into_branch_stmts(stmt: ast::Stmt) -> Vec<ast::Stmt> {
+  match stmt {
+    ast::Stmt::Block(block) => block.stmts,
+    ast::Stmt::Empty(_) => vec![],
+    stmt => vec![stmt],
+  }
+}
+
+/// A lone statement is written without the block, unless it could be a declaration or end with an
+/// `if` that a following `else` would attach to.
+fn from_branch_stmts(mut stmts: Vec<ast::Stmt>) -> Box<ast::Stmt> {
+  let is_single_simple = matches!(
+    &stmts[..],
+    [ast::Stmt::Expr(_)
+      | ast::Stmt::Return(_)
+      | ast::Stmt::Throw(_)
+      | ast::Stmt::Break(_)
+      | ast::Stmt::Continue(_)
+      | ast::Stmt::Debugger(_)]
+  );
+  Box::new(match stmts.len() {
+    0 => ast::Stmt::Empty(ast::EmptyStmt { span: DUMMY_SP }),
+    1 if is_single_simple => stmts.pop().unwrap(),
+    _ => ast::Stmt::Block(ast::BlockStmt {
+      span: DUMMY_SP,
+      stmts,
+    }),
+  })
+}
+
+/// `let`, `const`, classes and functions are scoped to the block, so a statement after them could
+/// refer to a binding of the branch.
+fn has_block_scoped_decl(stmts: &[ast::Stmt]) -> bool {
+  stmts.iter().any(|stmt| match stmt {
+    ast::Stmt::Decl(ast::Decl::Var(decl)) => decl.kind != ast::VarDeclKind::Var,
+    ast::Stmt::Decl(_) => true,
+    _ => false,
+  })
+}
+
+/// How many statements both branches end with.
+fn common_tail_len(cons: &[ast::Stmt], alt: &[ast::Stmt]) -> usize {
+  if has_block_scoped_decl(cons) || has_block_scoped_decl(alt) {
+    return 0;
+  }
+  cons
+    .iter()
+    .rev()
+    .zip(alt.iter().rev())
+    .take_while(|(a, b)| !matches!(a, ast::Stmt::Decl(_)) && a.eq_ignore_span(b))
+    .count()
+}
+
+/// Takes the statements both branches end with out of them.
+fn take_common_tail(if_stmt: &mut ast::IfStmt) -> Vec<ast::Stmt> {
+  let Some(alt) = &if_stmt.alt else {
+    return vec![];
+  };
+  let len = common_tail_len(branch_stmts(&if_stmt.cons), branch_stmts(alt));
+  if len == 0 {
+    return vec![];
+  }
+  let mut cons = into_branch_stmts(*if_stmt.cons.take());
+  let mut alt = into_branch_stmts(*if_stmt.alt.take().unwrap());
+  let tail = cons.split_off(cons.len() - len);
+  alt.truncate(alt.len() - len);
+  if_stmt.cons = from_branch_stmts(cons);
+  if_stmt.alt = Some(from_branch_stmts(alt));
+  tail
+}
+
+impl MinifySyntax<'_> {
+  /// `if (c) { f(); x() } else { g(); x() }` => `if (c) f(); else g(); x()`
+  ///
+  /// The statements both branches end with run after either of them, so they are written once
+  /// after the `if`. Branches declaring bindings scoped to their block are kept as they are.
+  pub(super) fn hoist_common_tails<T: AsStmtMut>(&self, items: &mut Vec<T>) {
+    let mut hoisted = Vec::with_capacity(items.len());
+    for mut item in std::mem::take(items) {
+      let tail = match item.as_stmt_mut() {
+        Some(ast::Stmt::If(if_stmt)) => take_common_tail(if_stmt),
+        _ => vec![],
+      };
+      if !tail.is_empty() {
+        // A branch could be left empty, like `if (c) x(); else { g(); x() }`.
+        self.fold_empty_if(item.as_stmt_mut().unwrap());
+      }
+      hoisted.push(item);
+      hoisted.extend(tail.into_iter().map(T::from_stmt));
+    }
+    *items = hoisted;
+  }
+}
@@ -0,0 +1,66 @@
+use swc_core::{common::Span, ecma::ast};
+
+use super::{quotes::quote_str, MinifySyntax};
+
+/// Integers outside of this range might be stringified in exponent notation.
+const MAX_SAFE_INTEGER: f64 = 9007199254740991.0;
+
+pub(super) fn str_expr(span: Span, value: String) -> ast::Expr {
+  ast::Expr::Lit(ast::Lit::Str(ast::Str {
+    span,
+    raw: Some(quote_str(&value).into()),
+    value: value.into(),
+  }))
+}
+
+impl MinifySyntax<'_> {
+  /// The string of a substitution, if it's known without running the code.
+  pub(super) fn as_const_string(&self, expr: &ast::Expr) -> Option<String> {
+    match expr {
+      ast::Expr::Lit(ast::Lit::Str(str)) => Some(str.value.to_string()),
+      ast::Expr::Lit(ast::Lit::Bool(bool)) => Some(bool.value.to_string()),
+      ast::Expr::Lit(ast::Lit::Null(_)) => Some("null".to_string()),
+      ast::Expr::Lit(ast::Lit::Num(num))
+        if num.value.fract() == 0.0 && num.value.abs() <= MAX_SAFE_INTEGER =>
+      {
+        Some((num.value as i64).to_string())
+      }
+      ast::Expr::Ident(ident)
+        if &*ident.sym == "undefined" && ident.span.ctxt == self.unresolved_ctxt =>
+      {
+        Some("undefined".to_string())
+      }
+      _ => None,
+    }
+  }
+
+  /// - `` `abc` `` => `"abc"`
+  /// - `` `a${1}b${"c"}` `` => `"a1bc"`
+  ///
+  /// Line breaks in the template are written as escapes in the string.
+  pub(crate) fn fold_template_literal(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Tpl(tpl) = expr else {
+      return;
+    };
+    let Some(substitutions) = tpl
+      .exprs
+      .iter()
+      .map(|expr| self.as_const_string(expr))
+      .collect::<Option<Vec<_>>>()
+    else {
+      return;
+    };
+    let mut value = String::new();
+    for (idx, quasi) in tpl.quasis.iter().enumerate() {
+      // Only tagged templates could have invalid escapes, which leave `cooked` empty.
+      let Some(cooked) = &quasi.cooked else {
+        return;
+      };
+      value.push_str(cooked);
+      if let Some(substitution) = substitutions.get(idx) {
+        value.push_str(substitution);
+      }
+    }
+    *expr = str_expr(tpl.span, value);
+  }
+}
@@ -0,0 +1,133 @@
+use rustc_hash::FxHashSet;
+use swc_core::{
+  common::util::take::Take,
+  ecma::{
+    ast::{self, Id},
+    utils::find_pat_ids,
+    visit::{noop_visit_type, Visit, VisitWith},
+  },
+};
+
+use super::{unused_params::is_unreferenced, MinifySyntax};
+
+/// Bindings that throw when they are assigned, which are `const` declarations and imports.
+#[derive(Default)]
+struct ImmutableBindings {
+  ids: FxHashSet<Id>,
+}
+
+impl Visit for ImmutableBindings {
+  noop_visit_type!();
+
+  fn visit_var_decl(&mut self, decl: &ast::VarDecl) {
+    if decl.kind == ast::VarDeclKind::Const {
+      self.ids.extend(find_pat_ids::<_, Id>(&decl.decls));
+    }
+    decl.visit_children_with(self);
+  }
+
+  fn visit_import_decl(&mut self, decl: &ast::ImportDecl) {
+    self
+      .ids
+      .extend(decl.specifiers.iter().map(|specifier| match specifier {
+        ast::ImportSpecifier::Named(named) => named.local.to_id(),
+        ast::ImportSpecifier::Default(default) => default.local.to_id(),
+        ast::ImportSpecifier::Namespace(namespace) => namespace.local.to_id(),
+      }));
+  }
+}
+
+pub(super) fn collect_immutable_ids(module: &ast::Module) -> FxHashSet<Id> {
+  let mut bindings = ImmutableBindings::default();
+  module.visit_with(&mut bindings);
+  bindings.ids
+}
+
+fn is_empty_block(block: &ast::BlockStmt) -> bool {
+  block.stmts.is_empty()
+}
+
+/// `try {} catch (e) { a() }` never runs the `catch` clause, so the whole statement does nothing.
+pub(super) fn is_removable_try(stmt: &ast::Stmt) -> bool {
+  match stmt {
+    ast::Stmt::Try(try_stmt) => {
+      is_empty_block(&try_stmt.block) && try_stmt.finalizer.as_ref().map_or(true, is_empty_block)
+    }
+    _ => false,
+  }
+}
+
+impl MinifySyntax<'_> {
+  /// `a = 1` can't throw if `a` is a declared binding that could be assigned. A `let` could still
+  /// be in its temporal dead zone, which isn't something code relies on.
+  fn is_throw_free_stmt(&self, stmt: &ast::Stmt) -> bool {
+    let ast::Stmt::Expr(ast::ExprStmt { expr, .. }) = stmt else {
+      return false;
+    };
+    match &**expr {
+      ast::Expr::Assign(ast::AssignExpr {
+        op: ast::AssignOp::Assign,
+        left,
+        right,
+        ..
+      }) => {
+        let target = match left {
+          ast::PatOrExpr::Pat(box ast::Pat::Ident(binding)) => &binding.id,
+          ast::PatOrExpr::Expr(box ast::Expr::Ident(ident))
+          | ast::PatOrExpr::Pat(box ast::Pat::Expr(box ast::Expr::Ident(ident))) => ident,
+          _ => return false,
+        };
+        target.span.ctxt != self.unresolved_ctxt
+          && !self.immutable_ids.contains(&target.to_id())
+          && self.is_pure(right)
+      }
+      expr => self.is_pure(expr),
+    }
+  }
+
+  /// - `try { a() } catch (e) {}` => `try { a() } catch {}`
+  /// - `try { a() } catch { b() } finally {}` => `try { a() } catch { b() }`
+  /// - `try { a() } finally {}` => `{ a() }`
+  /// - `try { a = 1 } catch {}` => `a = 1`
+  pub(super) fn fold_try(&self, stmt: &mut ast::Stmt) {
+    let ast::Stmt::Try(try_stmt) = stmt else {
+      return;
+    };
+    if is_empty_block(&try_stmt.block) {
+      // Removed by the statement list as a whole.
+      return;
+    }
+
+    if let Some(handler) = &mut try_stmt.handler
+      && self.target.supports_optional_catch_binding()
+      && let Some(ast::Pat::Ident(binding)) = &handler.param
+      && is_unreferenced(&binding.id, &*handler)
+    {
+      handler.param = None;
+    }
+
+    if try_stmt.finalizer.as_ref().map_or(false, is_empty_block) {
+      if try_stmt.handler.is_some() {
+        try_stmt.finalizer = None;
+      } else {
+        // A `try` needs either `catch` or `finally`. The block keeps the scope of the declarations.
+        let block = try_stmt.block.take();
+        *stmt = ast::Stmt::Block(block);
+        return;
+      }
+    }
+
+    // Errors are swallowed by the empty `catch`, but the only statement never throws one.
+    let is_catch_empty = try_stmt
+      .handler
+      .as_ref()
+      .map_or(false, |handler| is_empty_block(&handler.body));
+    if is_catch_empty
+      && try_stmt.finalizer.is_none()
+      && let [only] = &try_stmt.block.stmts[..]
+      && self.is_throw_free_stmt(only)
+    {
+      *stmt = try_stmt.block.stmts.pop().unwrap();
+    }
+  }
+}
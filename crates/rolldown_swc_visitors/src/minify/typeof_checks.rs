@@ -0,0 +1,52 @@
+use swc_core::ecma::ast;
+
+use super::{void_context::void_zero, MinifySyntax};
+
+fn is_undefined_str(expr: &ast::Expr) -> bool {
+  matches!(expr, ast::Expr::Lit(ast::Lit::Str(str)) if &*str.value == "undefined")
+}
+
+impl MinifySyntax<'_> {
+  /// `typeof x`, where `x` is declared, so reading it can't throw a `ReferenceError`.
+  fn as_typeof_of_declared<'a>(&self, expr: &'a ast::Expr) -> Option<&'a ast::Ident> {
+    let ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::TypeOf,
+      arg: box ast::Expr::Ident(ident),
+      ..
+    }) = expr
+    else {
+      return None;
+    };
+    (ident.span.ctxt != self.unresolved_ctxt).then_some(ident)
+  }
+
+  /// - `typeof x === "undefined"` => `x === void 0`
+  /// - `typeof x !== "undefined"` => `x !== void 0`
+  ///
+  /// Only declared bindings are folded. `typeof` is the only way to check a global that may not
+  /// exist, like `typeof window`.
+  pub(super) fn fold_typeof_undefined(&self, expr: &mut ast::Expr) {
+    let ast::Expr::Bin(bin) = expr else {
+      return;
+    };
+    let op = match bin.op {
+      ast::BinaryOp::EqEqEq | ast::BinaryOp::EqEq => ast::BinaryOp::EqEqEq,
+      ast::BinaryOp::NotEqEq | ast::BinaryOp::NotEq => ast::BinaryOp::NotEqEq,
+      _ => return,
+    };
+    let ident = match (&*bin.left, &*bin.right) {
+      (left, right) if is_undefined_str(right) => self.as_typeof_of_declared(left),
+      (left, right) if is_undefined_str(left) => self.as_typeof_of_declared(right),
+      _ => None,
+    };
+    let Some(ident) = ident.cloned() else {
+      return;
+    };
+    *expr = ast::Expr::Bin(ast::BinExpr {
+      span: bin.span,
+      op,
+      left: Box::new(ast::Expr::Ident(ident)),
+      right: Box::new(void_zero(bin.span)),
+    });
+  }
+}
@@ -0,0 +1,77 @@
+use rustc_hash::FxHashMap;
+use swc_core::ecma::{
+  ast,
+  atoms::{js_word, JsWord},
+  visit::{noop_visit_type, Visit, VisitWith},
+};
+
+/// Counts how many times each name appears, no matter whether it's a binding, a reference or even
+/// a property name. Overcounting only makes the caller keep more parameters.
+#[derive(Default)]
+pub(crate) struct NameCounter {
+  counts: FxHashMap<JsWord, usize>,
+}
+
+impl Visit for NameCounter {
+  noop_visit_type!();
+
+  fn visit_ident(&mut self, ident: &ast::Ident) {
+    *self.counts.entry(ident.sym.clone()).or_default() += 1;
+  }
+}
+
+/// `a` and `...a`, the parameters that only bind a name
+fn as_plain_binding(pat: &ast::Pat) -> Option<&ast::BindingIdent> {
+  match pat {
+    ast::Pat::Ident(binding) => Some(binding),
+    ast::Pat::Rest(ast::RestPat {
+      arg: box ast::Pat::Ident(binding),
+      ..
+    }) => Some(binding),
+    _ => None,
+  }
+}
+
+/// The number of parameters to keep after dropping trailing ones that are never referenced, so
+/// `(a, b) => a` becomes `(a) => a`. `func` is the whole function, including its parameters.
+///
+/// Only plain identifiers are dropped, including a rest parameter like `...rest`. A parameter with a
+/// default value may have side effects when the default is evaluated, and destructuring may throw,
+/// so we stop at the first of them. Functions using `arguments` or `eval` are left untouched, since
+/// they could observe every parameter.
+pub(crate) fn used_params_len<P>(
+  params: &[P],
+  pat_of: impl Fn(&P) -> &ast::Pat,
+  func: &impl VisitWith<NameCounter>,
+) -> usize {
+  if !params
+    .last()
+    .map_or(false, |param| as_plain_binding(pat_of(param)).is_some())
+  {
+    return params.len();
+  }
+
+  let mut counter = NameCounter::default();
+  func.visit_with(&mut counter);
+  if counter.counts.contains_key(&js_word!("arguments"))
+    || counter.counts.contains_key(&js_word!("eval"))
+  {
+    return params.len();
+  }
+
+  params
+    .iter()
+    .rposition(|param| match as_plain_binding(pat_of(param)) {
+      // The only occurrence is the binding itself
+      Some(binding) => counter.counts.get(&binding.id.sym) != Some(&1),
+      None => true,
+    })
+    .map_or(0, |idx| idx + 1)
+}
+
+/// Whether `binding` is never referenced in `scope`, which includes the binding itself.
+pub(crate) fn is_unreferenced(binding: &ast::Ident, scope: &impl VisitWith<NameCounter>) -> bool {
+  let mut counter = NameCounter::default();
+  scope.visit_with(&mut counter);
+  !counter.counts.contains_key(&js_word!("eval")) && counter.counts.get(&binding.sym) == Some(&1)
+}
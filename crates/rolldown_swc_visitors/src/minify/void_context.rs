@@ -0,0 +1,103 @@
+use swc_core::{
+  common::{Span, DUMMY_SP},
+  ecma::ast,
+};
+
+use super::MinifySyntax;
+
+/// `void 0`, the shortest way to write `undefined`.
+pub(super) fn void_zero(span: Span) -> ast::Expr {
+  ast::Expr::Unary(ast::UnaryExpr {
+    span,
+    op: ast::UnaryOp::Void,
+    arg: Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+      span: DUMMY_SP,
+      value: 0.0,
+      raw: None,
+    }))),
+  })
+}
+
+impl MinifySyntax<'_> {
+  /// A `/*#__PURE__*/` call can be dropped as a whole, including its callee, if the arguments can.
+  fn is_pure_call(&self, call: &ast::CallExpr) -> bool {
+    self.comments.has_flag(call.span.lo, "PURE")
+      && call
+        .args
+        .iter()
+        .all(|arg| arg.spread.is_none() && self.is_pure(&arg.expr))
+  }
+
+  /// Whether dropping `expr` can't be observed. Unary operators that could call `valueOf` and
+  /// unresolved references that could throw, except `undefined`, are not pure.
+  pub(super) fn is_pure(&self, expr: &ast::Expr) -> bool {
+    match expr {
+      ast::Expr::Lit(_) | ast::Expr::This(_) | ast::Expr::Arrow(_) | ast::Expr::Fn(_) => true,
+      ast::Expr::Ident(ident) => {
+        ident.span.ctxt != self.unresolved_ctxt || &*ident.sym == "undefined"
+      }
+      ast::Expr::Unary(unary) => {
+        matches!(
+          unary.op,
+          ast::UnaryOp::Void | ast::UnaryOp::Bang | ast::UnaryOp::TypeOf
+        ) && self.is_pure(&unary.arg)
+      }
+      ast::Expr::Array(array) => array
+        .elems
+        .iter()
+        .flatten()
+        .all(|elem| elem.spread.is_none() && self.is_pure(&elem.expr)),
+      ast::Expr::Seq(seq) => seq.exprs.iter().all(|expr| self.is_pure(expr)),
+      ast::Expr::Paren(paren) => self.is_pure(&paren.expr),
+      ast::Expr::Call(call) => self.is_pure_call(call),
+      _ => false,
+    }
+  }
+
+  /// `void /*#__PURE__*/ f()` => `void 0`
+  pub(super) fn fold_void(&self, expr: &mut ast::Expr) {
+    if let ast::Expr::Unary(ast::UnaryExpr {
+      op: ast::UnaryOp::Void,
+      arg,
+      ..
+    }) = expr
+      && !matches!(**arg, ast::Expr::Lit(ast::Lit::Num(_)))
+      && self.is_pure(arg)
+    {
+      *arg = Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+        span: DUMMY_SP,
+        value: 0.0,
+        raw: None,
+      })));
+    }
+  }
+
+  /// `undefined` => `void 0`, which is shorter and can't be shadowed. A local named `undefined` is
+  /// left alone.
+  pub(super) fn fold_undefined(&self, expr: &mut ast::Expr) {
+    if let ast::Expr::Ident(ident) = expr
+      && &*ident.sym == "undefined"
+      && ident.span.ctxt == self.unresolved_ctxt
+    {
+      *expr = void_zero(ident.span);
+    }
+  }
+
+  /// `/*#__PURE__*/ f();` and `void 0;` do nothing. Other pure statements are kept, since a string
+  /// could be a directive like `"use strict"`.
+  pub(super) fn is_unused_pure_stmt(&self, stmt: &ast::Stmt) -> bool {
+    match stmt {
+      ast::Stmt::Expr(ast::ExprStmt { expr, .. }) => {
+        matches!(
+          &**expr,
+          ast::Expr::Call(_)
+            | ast::Expr::Unary(ast::UnaryExpr {
+              op: ast::UnaryOp::Void,
+              ..
+            })
+        ) && self.is_pure(expr)
+      }
+      _ => false,
+    }
+  }
+}
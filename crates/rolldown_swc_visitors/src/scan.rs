@@ -1,7 +1,7 @@
 use std::sync::atomic::AtomicBool;
 
 use ast::{CallExpr, Callee, ExportSpecifier, Expr, Id, Ident, Lit, ModuleDecl, ModuleItem, Stmt};
-use hashlink::LinkedHashSet;
+use hashlink::{LinkedHashMap, LinkedHashSet};
 use rolldown_common::{ExportedSpecifier, ImportedSpecifier, Symbol};
 use rolldown_common::{ModuleId, ReExportedSpecifier};
 use rolldown_swc_utils::{ExportNamedSpecifierExt, ImportNamedSpecifierExt, ModuleExportNameExt};
@@ -53,6 +53,12 @@ pub struct ScanResult {
   pub statement_parts: Vec<StatementPart>,
   pub imports: FxHashMap<JsWord, Vec<ImportedSpecifier>>,
   pub suggested_names: FxHashMap<JsWord, JsWord>,
+  /// `require(name)` with a non-literal argument can't be resolved at build time, so it's left as
+  /// it is.
+  pub has_dynamic_require: bool,
+  /// `require("./a")` => the symbol of the function the call is rewritten to, if `./a` is bundled.
+  /// The symbol is only referenced, like an imported one.
+  pub requires: LinkedHashMap<JsWord, Symbol>,
 }
 
 /// Notices
@@ -155,6 +161,38 @@ impl Scanner {
     }
   }
 
+  fn scan_require(&mut self, node: &CallExpr) {
+    let Callee::Expr(callee) = &node.callee else {
+      return;
+    };
+    let Expr::Ident(callee) = callee.as_ref() else {
+      return;
+    };
+    if &*callee.sym != "require" || callee.span.ctxt != self.unresolved_ctxt {
+      return;
+    }
+    let specifier = node.args.get(0).and_then(|arg| match arg {
+      ast::ExprOrSpread {
+        spread: None,
+        expr: box Expr::Lit(Lit::Str(specifier)),
+      } => Some(specifier.value.clone()),
+      _ => None,
+    });
+    let Some(specifier) = specifier else {
+      self.result.has_dynamic_require = true;
+      return;
+    };
+    // Invalid like the members of namespaces, so it's never conflicted with a local name.
+    let name: JsWord = format!("require#{specifier}").into();
+    let symbol = self
+      .result
+      .requires
+      .entry(specifier)
+      .or_insert_with(|| (name, self.top_level_ctxt).into())
+      .clone();
+    self.statement_part.referenced.insert(symbol);
+  }
+
   fn add_imported_specifier(
     &mut self,
     local_module_id: JsWord,
@@ -556,6 +594,7 @@ impl VisitMut for Scanner {
 
   fn visit_mut_call_expr(&mut self, node: &mut CallExpr) {
     self.add_dynamic_import(node);
+    self.scan_require(node);
     node.visit_mut_children_with(self);
   }
 
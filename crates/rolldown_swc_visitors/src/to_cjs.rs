@@ -1,6 +1,5 @@
 use swc_common::{comments::SingleThreadedComments, Mark};
 use swc_core::common as swc_common;
-use swc_core::common::pass::Optional;
 use swc_core::ecma::transforms::base::helpers::{self, HELPERS};
 use swc_core::ecma::transforms::base::{
   fixer::{self, paren_remover},
@@ -14,21 +13,24 @@ use swc_core::ecma::{
   visit::FoldWith,
 };
 
-use crate::default_export_mode_shimer;
+use crate::{export_mode_shimer, CjsExportShim};
 
 pub fn to_cjs(
   ast: ast::Module,
   unresolved_mark: Mark,
   comments: &SingleThreadedComments,
-  shim_default_export: bool,
+  export_shim: Option<CjsExportShim>,
 ) -> ast::Module {
-  HELPERS.set(&helpers::Helpers::new(false), || {
+  let ast = HELPERS.set(&helpers::Helpers::new(false), || {
     ast
       .fold_with(&mut paren_remover(Some(comments)))
       .fold_with(&mut resolver(unresolved_mark, Mark::new(), false))
       .fold_with(&mut common_js::common_js::<SingleThreadedComments>(
         unresolved_mark,
         common_js::Config {
+          // `import()` becomes a promise of the namespace object of `require()`, which gives the
+          // same `default` and named exports as in ESM output, even if the importee is CommonJS.
+          ignore_dynamic: false,
           ..Default::default()
         },
         Default::default(),
@@ -37,9 +39,9 @@ pub fn to_cjs(
       .fold_with(&mut hygiene())
       .fold_with(&mut fixer::fixer(Some(comments)))
       .fold_with(&mut inject_helpers(unresolved_mark))
-      .fold_with(&mut Optional {
-        visitor: as_folder(default_export_mode_shimer()),
-        enabled: shim_default_export,
-      })
-  })
+  });
+  match export_shim {
+    Some(shim) => ast.fold_with(&mut as_folder(export_mode_shimer(shim))),
+    None => ast,
+  }
 }
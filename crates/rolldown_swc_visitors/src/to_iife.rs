@@ -0,0 +1,337 @@
+use rustc_hash::FxHashMap;
+use swc_common::{comments::SingleThreadedComments, Mark, DUMMY_SP};
+use swc_core::common as swc_common;
+use swc_core::ecma::transforms::base::{
+  fixer::{self, paren_remover},
+  hygiene::hygiene,
+};
+use swc_core::ecma::{
+  ast::{self, Id},
+  transforms::base::resolver,
+  utils::{quote_ident, quote_str},
+  visit::{FoldWith, VisitMut, VisitMutWith},
+};
+
+/// `React` => `React`, `window.jQuery` => `window_jQuery`
+fn param_name(global: &str) -> String {
+  global
+    .chars()
+    .map(|c| {
+      if c.is_ascii_alphanumeric() || c == '_' || c == '$' {
+        c
+      } else {
+        '_'
+      }
+    })
+    .collect()
+}
+
+/// `window.jQuery` => `window.jQuery`, read from the global scope.
+fn global_expr(global: &str, unresolved_mark: Mark) -> ast::Expr {
+  let mut parts = global.split('.');
+  let obj = ast::Expr::Ident(quote_ident!(
+    DUMMY_SP.apply_mark(unresolved_mark),
+    parts.next().unwrap()
+  ));
+  parts.fold(obj, |obj, prop| member_expr(obj, prop))
+}
+
+fn member_expr(obj: ast::Expr, prop: &str) -> ast::Expr {
+  ast::Expr::Member(ast::MemberExpr {
+    span: DUMMY_SP,
+    obj: Box::new(obj),
+    prop: ast::MemberProp::Ident(quote_ident!(prop)),
+  })
+}
+
+fn export_name(name: &ast::ModuleExportName) -> &str {
+  match name {
+    ast::ModuleExportName::Ident(ident) => &ident.sym,
+    ast::ModuleExportName::Str(str) => &str.value,
+  }
+}
+
+fn is_identifier_name(name: &str) -> bool {
+  let is_start = |c: char| c.is_ascii_alphabetic() || c == '_' || c == '$';
+  name.starts_with(is_start) && name.chars().all(|c| is_start(c) || c.is_ascii_digit())
+}
+
+fn prop_name(name: &str) -> ast::PropName {
+  if is_identifier_name(name) {
+    ast::PropName::Ident(quote_ident!(name))
+  } else {
+    ast::PropName::Str(quote_str!(name))
+  }
+}
+
+/// Reads the bindings of imports from the parameters of the function.
+struct ImportRewriter {
+  replacements: FxHashMap<Id, ast::Expr>,
+}
+
+impl VisitMut for ImportRewriter {
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    match expr {
+      ast::Expr::Ident(ident) => {
+        if let Some(replacement) = self.replacements.get(&ident.to_id()) {
+          *expr = replacement.clone();
+        }
+      }
+      _ => expr.visit_mut_children_with(self),
+    }
+  }
+
+  fn visit_mut_callee(&mut self, callee: &mut ast::Callee) {
+    let replacement = match callee {
+      ast::Callee::Expr(box ast::Expr::Ident(ident)) => {
+        self.replacements.get(&ident.to_id()).cloned()
+      }
+      _ => None,
+    };
+    match replacement {
+      // An imported function is called without a `this`, like `(0, _.debounce)()`.
+      Some(member @ ast::Expr::Member(_)) => {
+        *callee = ast::Callee::Expr(Box::new(ast::Expr::Seq(ast::SeqExpr {
+          span: DUMMY_SP,
+          exprs: vec![
+            Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+              span: DUMMY_SP,
+              value: 0.0,
+              raw: None,
+            }))),
+            Box::new(member),
+          ],
+        })));
+      }
+      _ => callee.visit_mut_children_with(self),
+    }
+  }
+
+  fn visit_mut_prop(&mut self, prop: &mut ast::Prop) {
+    match prop {
+      ast::Prop::Shorthand(ident) => {
+        if let Some(replacement) = self.replacements.get(&ident.to_id()) {
+          *prop = ast::Prop::KeyValue(ast::KeyValueProp {
+            key: ast::PropName::Ident(ident.clone()),
+            value: Box::new(replacement.clone()),
+          });
+        }
+      }
+      _ => prop.visit_mut_children_with(self),
+    }
+  }
+}
+
+/// The globals of every imported module, which are passed to the function as parameters.
+struct Globals {
+  params: Vec<ast::Param>,
+  args: Vec<ast::ExprOrSpread>,
+  param_by_src: FxHashMap<String, ast::Ident>,
+}
+
+impl Globals {
+  fn param_of(
+    &mut self,
+    src: &str,
+    global_of: &impl Fn(&str) -> String,
+    unresolved_mark: Mark,
+  ) -> ast::Ident {
+    if let Some(param) = self.param_by_src.get(src) {
+      return param.clone();
+    }
+    let global = global_of(src);
+    let param = quote_ident!(DUMMY_SP.apply_mark(Mark::new()), param_name(&global));
+    self.params.push(ast::Param {
+      span: DUMMY_SP,
+      decorators: vec![],
+      pat: ast::Pat::Ident(param.clone().into()),
+    });
+    self.args.push(ast::ExprOrSpread {
+      spread: None,
+      expr: Box::new(global_expr(&global, unresolved_mark)),
+    });
+    self.param_by_src.insert(src.to_string(), param.clone());
+    param
+  }
+}
+
+/// Wraps the chunk in `(function (React) { ... })(React)`, so it runs as a script. Imports read
+/// the globals `global_of` returns for their sources, which are passed as parameters. Imports
+/// without bindings are dropped, since the external is expected to be loaded already. Exports are
+/// the properties of the returned object, assigned to `var name = ...` if there's a `name`. They
+/// are dropped otherwise.
+pub fn to_iife(
+  ast: ast::Module,
+  unresolved_mark: Mark,
+  comments: &SingleThreadedComments,
+  name: Option<&str>,
+  global_of: impl Fn(&str) -> String,
+) -> ast::Module {
+  let ast = ast
+    .fold_with(&mut paren_remover(Some(comments)))
+    .fold_with(&mut resolver(unresolved_mark, Mark::new(), false));
+
+  let mut globals = Globals {
+    params: vec![],
+    args: vec![],
+    param_by_src: Default::default(),
+  };
+  let mut replacements = FxHashMap::default();
+  let mut exports = vec![];
+  let mut stmts = vec![ast::Stmt::Expr(ast::ExprStmt {
+    span: DUMMY_SP,
+    expr: Box::new(ast::Expr::Lit(ast::Lit::Str(quote_str!("use strict")))),
+  })];
+
+  for item in ast.body {
+    let decl = match item {
+      ast::ModuleItem::Stmt(stmt) => {
+        stmts.push(stmt);
+        continue;
+      }
+      ast::ModuleItem::ModuleDecl(decl) => decl,
+    };
+    match decl {
+      // Like `import "polyfill"`, which has no bindings to read from the global, and the global
+      // might not exist, so it isn't passed.
+      ast::ModuleDecl::Import(import) if import.specifiers.is_empty() => {}
+      ast::ModuleDecl::Import(import) => {
+        let param = globals.param_of(&import.src.value, &global_of, unresolved_mark);
+        for specifier in import.specifiers {
+          let (local, replacement) = match specifier {
+            ast::ImportSpecifier::Named(named) => {
+              let imported = named
+                .imported
+                .as_ref()
+                .map_or(&*named.local.sym, export_name);
+              let replacement = if imported == "default" {
+                ast::Expr::Ident(param.clone())
+              } else {
+                member_expr(ast::Expr::Ident(param.clone()), imported)
+              };
+              (named.local, replacement)
+            }
+            ast::ImportSpecifier::Default(default) => {
+              (default.local, ast::Expr::Ident(param.clone()))
+            }
+            ast::ImportSpecifier::Namespace(namespace) => {
+              (namespace.local, ast::Expr::Ident(param.clone()))
+            }
+          };
+          replacements.insert(local.to_id(), replacement);
+        }
+      }
+      ast::ModuleDecl::ExportNamed(named) => {
+        let param = named
+          .src
+          .as_ref()
+          .map(|src| globals.param_of(&src.value, &global_of, unresolved_mark));
+        for specifier in named.specifiers {
+          let (exported, value) = match (specifier, &param) {
+            (ast::ExportSpecifier::Named(named), None) => {
+              let orig = match named.orig {
+                ast::ModuleExportName::Ident(ident) => ident,
+                ast::ModuleExportName::Str(_) => continue,
+              };
+              let exported = named
+                .exported
+                .as_ref()
+                .map_or(&*orig.sym, export_name)
+                .to_string();
+              (exported, ast::Expr::Ident(orig))
+            }
+            (ast::ExportSpecifier::Named(named), Some(param)) => {
+              let orig = export_name(&named.orig);
+              let exported = named.exported.as_ref().map_or(orig, export_name);
+              let value = member_expr(ast::Expr::Ident(param.clone()), orig);
+              (exported.to_string(), value)
+            }
+            (ast::ExportSpecifier::Namespace(namespace), Some(param)) => (
+              export_name(&namespace.name).to_string(),
+              ast::Expr::Ident(param.clone()),
+            ),
+            _ => continue,
+          };
+          let prop = match value {
+            ast::Expr::Ident(ident) if *ident.sym == *exported => ast::Prop::Shorthand(ident),
+            value => ast::Prop::KeyValue(ast::KeyValueProp {
+              key: prop_name(&exported),
+              value: Box::new(value),
+            }),
+          };
+          exports.push(ast::PropOrSpread::Prop(Box::new(prop)));
+        }
+      }
+      ast::ModuleDecl::ExportAll(export_all) => {
+        let param = globals.param_of(&export_all.src.value, &global_of, unresolved_mark);
+        exports.push(ast::PropOrSpread::Spread(ast::SpreadElement {
+          dot3_token: DUMMY_SP,
+          expr: Box::new(ast::Expr::Ident(param)),
+        }));
+      }
+      // The chunk only has imports and `export { ... }` at this point.
+      _ => {}
+    }
+  }
+
+  if name.is_some() && !exports.is_empty() {
+    stmts.push(ast::Stmt::Return(ast::ReturnStmt {
+      span: DUMMY_SP,
+      arg: Some(Box::new(ast::Expr::Object(ast::ObjectLit {
+        span: DUMMY_SP,
+        props: exports,
+      }))),
+    }));
+  }
+  stmts.visit_mut_with(&mut ImportRewriter { replacements });
+
+  let iife = ast::Expr::Call(ast::CallExpr {
+    span: DUMMY_SP,
+    callee: ast::Callee::Expr(Box::new(ast::Expr::Paren(ast::ParenExpr {
+      span: DUMMY_SP,
+      expr: Box::new(ast::Expr::Fn(ast::FnExpr {
+        ident: None,
+        function: Box::new(ast::Function {
+          params: globals.params,
+          body: Some(ast::BlockStmt {
+            span: DUMMY_SP,
+            stmts,
+          }),
+          decorators: vec![],
+          span: DUMMY_SP,
+          is_generator: false,
+          is_async: false,
+          type_params: None,
+          return_type: None,
+        }),
+      })),
+    }))),
+    args: globals.args,
+    type_args: None,
+  });
+  let stmt = match name {
+    Some(name) => ast::Stmt::Decl(ast::Decl::Var(Box::new(ast::VarDecl {
+      span: DUMMY_SP,
+      kind: ast::VarDeclKind::Var,
+      declare: false,
+      decls: vec![ast::VarDeclarator {
+        span: DUMMY_SP,
+        name: ast::Pat::Ident(quote_ident!(DUMMY_SP.apply_mark(unresolved_mark), name).into()),
+        init: Some(Box::new(iife)),
+        definite: false,
+      }],
+    }))),
+    None => ast::Stmt::Expr(ast::ExprStmt {
+      span: DUMMY_SP,
+      expr: Box::new(iife),
+    }),
+  };
+
+  ast::Module {
+    span: ast.span,
+    body: vec![ast::ModuleItem::Stmt(stmt)],
+    shebang: ast.shebang,
+  }
+  .fold_with(&mut hygiene())
+  .fold_with(&mut fixer::fixer(Some(comments)))
+}
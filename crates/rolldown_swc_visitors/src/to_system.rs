@@ -0,0 +1,32 @@
+use swc_common::{comments::SingleThreadedComments, Mark};
+use swc_core::common as swc_common;
+use swc_core::ecma::transforms::base::{
+  fixer::{self, paren_remover},
+  hygiene::hygiene,
+};
+use swc_core::ecma::{
+  ast,
+  transforms::{base::resolver, module::system_js},
+  visit::FoldWith,
+};
+
+/// Wraps the chunk in `System.register([deps], function (_export, _context) { ... })`. Exports are
+/// updated through `_export()` whenever they are assigned, so they are live bindings, and `import()`
+/// becomes `_context.import()`.
+pub fn to_system(
+  ast: ast::Module,
+  unresolved_mark: Mark,
+  comments: &SingleThreadedComments,
+) -> ast::Module {
+  ast
+    .fold_with(&mut paren_remover(Some(comments)))
+    .fold_with(&mut resolver(unresolved_mark, Mark::new(), false))
+    .fold_with(&mut system_js::system_js(
+      unresolved_mark,
+      system_js::Config {
+        ..Default::default()
+      },
+    ))
+    .fold_with(&mut hygiene())
+    .fold_with(&mut fixer::fixer(Some(comments)))
+}
@@ -0,0 +1,73 @@
+use swc_core::{
+  common::DUMMY_SP,
+  ecma::{
+    ast,
+    visit::{VisitMut, VisitMutWith},
+  },
+};
+
+/// `this` outside of functions is `undefined` in ES modules, and `exports` in CommonJS modules.
+/// Functions and class bodies have their own `this`, so only what's evaluated in the scope of the
+/// module, like computed keys, is visited.
+struct ReplaceTopLevelThis<'a> {
+  /// `None` for ES modules
+  exports: Option<&'a ast::Ident>,
+}
+
+impl VisitMut for ReplaceTopLevelThis<'_> {
+  fn visit_mut_expr(&mut self, expr: &mut ast::Expr) {
+    if let ast::Expr::This(this) = expr {
+      *expr = match self.exports {
+        Some(exports) => ast::Expr::Ident(ast::Ident {
+          span: this.span.with_ctxt(exports.span.ctxt),
+          ..exports.clone()
+        }),
+        None => ast::Expr::Unary(ast::UnaryExpr {
+          span: this.span,
+          op: ast::UnaryOp::Void,
+          arg: Box::new(ast::Expr::Lit(ast::Lit::Num(ast::Number {
+            span: DUMMY_SP,
+            value: 0.0,
+            raw: None,
+          }))),
+        }),
+      };
+    } else {
+      expr.visit_mut_children_with(self);
+    }
+  }
+
+  fn visit_mut_function(&mut self, _function: &mut ast::Function) {}
+
+  fn visit_mut_getter_prop(&mut self, prop: &mut ast::GetterProp) {
+    prop.key.visit_mut_with(self);
+  }
+
+  fn visit_mut_setter_prop(&mut self, prop: &mut ast::SetterProp) {
+    prop.key.visit_mut_with(self);
+  }
+
+  fn visit_mut_class(&mut self, class: &mut ast::Class) {
+    class.decorators.visit_mut_with(self);
+    class.super_class.visit_mut_with(self);
+    class.body.iter_mut().for_each(|member| match member {
+      ast::ClassMember::Method(method) => method.key.visit_mut_with(self),
+      ast::ClassMember::ClassProp(prop) => prop.key.visit_mut_with(self),
+      _ => {}
+    });
+  }
+}
+
+/// Should only be called on ES modules. In CommonJS modules, `this` is `module.exports` instead.
+pub fn replace_top_level_this(ast: &mut ast::Module) {
+  ast.visit_mut_with(&mut ReplaceTopLevelThis { exports: None });
+}
+
+/// Called on the statements of a CommonJS module once they are wrapped into an arrow function,
+/// which would see the `this` of the chunk otherwise. `exports` is the parameter of the wrapper.
+/// Like `this`, it stays the initial `module.exports` even if `module.exports` is reassigned.
+pub fn replace_top_level_this_with_exports(stmts: &mut Vec<ast::Stmt>, exports: &ast::Ident) {
+  stmts.visit_mut_with(&mut ReplaceTopLevelThis {
+    exports: Some(exports),
+  });
+}
@@ -1,2 +1,3 @@
+pub mod temp_dir;
 pub mod test_config;
 pub mod tester;
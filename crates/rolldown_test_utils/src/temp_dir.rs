@@ -0,0 +1,39 @@
+use std::{
+  ops::Deref,
+  path::{Path, PathBuf},
+};
+
+/// A directory in the temp dir for the files of a test, removed when the test ends, even if it
+/// fails.
+pub struct TempDir(PathBuf);
+
+impl TempDir {
+  pub fn new(name: &str) -> Self {
+    let dir = std::env::temp_dir().join(format!("rolldown_{name}_{}", std::process::id()));
+    std::fs::create_dir_all(&dir).unwrap();
+    // The modules are identified by real paths, which the temp dir might not be on macOS.
+    Self(dir.canonicalize().unwrap())
+  }
+
+  /// Writes the file at `path`, relative to the directory, with its parent directories.
+  pub fn write(&self, path: impl AsRef<Path>, content: &str) {
+    let path = self.0.join(path);
+    std::fs::create_dir_all(path.parent().unwrap()).unwrap();
+    std::fs::write(path, content).unwrap();
+  }
+}
+
+impl Deref for TempDir {
+  type Target = Path;
+
+  fn deref(&self) -> &Path {
+    &self.0
+  }
+}
+
+impl Drop for TempDir {
+  fn drop(&mut self) {
+    // Panicking while a failed test unwinds would abort the tests.
+    let _ = std::fs::remove_dir_all(&self.0);
+  }
+}
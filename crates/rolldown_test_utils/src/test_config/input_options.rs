@@ -1,3 +1,5 @@
+use std::collections::HashMap;
+
 use schemars::JsonSchema;
 use serde::Deserialize;
 
@@ -14,6 +16,14 @@ fn true_by_default() -> bool {
   true
 }
 
+fn browser_by_default() -> String {
+  "browser".to_string()
+}
+
+fn transform_by_default() -> String {
+  "transform".to_string()
+}
+
 #[derive(Deserialize, JsonSchema)]
 #[serde(rename_all = "camelCase", deny_unknown_fields)]
 pub struct InputOptions {
@@ -31,6 +41,24 @@ pub struct InputOptions {
 
   #[serde(default)]
   pub builtins: Builtins,
+
+  #[serde(default = "browser_by_default")]
+  pub platform: String,
+
+  #[serde(default)]
+  pub prefix_node_builtins: bool,
+
+  /// Path of an import map file, relative to the fixture
+  #[serde(default)]
+  pub import_map: Option<String>,
+
+  /// Targets are relative to the fixture
+  #[serde(default)]
+  pub resolve_overrides: HashMap<String, String>,
+
+  /// `paths` of a tsconfig whose `baseUrl` is the fixture
+  #[serde(default)]
+  pub tsconfig_paths: HashMap<String, Vec<String>>,
 }
 
 #[derive(Deserialize, JsonSchema)]
@@ -45,6 +73,11 @@ pub struct InputItem {
 pub struct Builtins {
   #[serde(default)]
   pub tsconfig: TsConfig,
+  #[serde(default = "true_by_default")]
+  pub jsx_side_effects: bool,
+  /// "transform" or "preserve"
+  #[serde(default = "transform_by_default")]
+  pub jsx: String,
 }
 
 #[derive(Deserialize, JsonSchema)]
@@ -1,3 +1,5 @@
+use std::collections::HashMap;
+
 use schemars::JsonSchema;
 use serde::Deserialize;
 
@@ -11,6 +13,10 @@ fn auto_by_default() -> String {
   "auto".to_string()
 }
 
+fn name_js_by_default() -> String {
+  "[name].js".to_string()
+}
+
 #[derive(Deserialize, JsonSchema)]
 #[serde(rename_all = "camelCase", deny_unknown_fields)]
 pub struct OutputOptions {
@@ -18,6 +24,48 @@ pub struct OutputOptions {
   pub format: String,
   #[serde(default = "auto_by_default")]
   pub export_mode: String,
+  /// Non-entry chunks don't get a hash in tests by default, so snapshots stay readable.
+  #[serde(default = "name_js_by_default")]
+  pub chunk_file_names: String,
+  #[serde(default)]
+  pub manual_chunks: Vec<ManualChunk>,
+  /// The global variable of the exports of IIFE output
+  #[serde(default)]
+  pub name: Option<String>,
+  /// Globals of externals in IIFE output
+  #[serde(default)]
+  pub globals: HashMap<String, String>,
+  #[serde(default)]
+  pub minify_enums: bool,
+  #[serde(default)]
+  pub minify_hoist_common_subexpressions: bool,
+  #[serde(default)]
+  pub minify_hoist_strings: bool,
+  #[serde(default)]
+  pub minify_identifiers: bool,
+  #[serde(default)]
+  pub minify_inline_functions: bool,
+  #[serde(default)]
+  pub minify_syntax: bool,
+  /// `production` or `development`, which picks the env sections of the browserslist config
+  #[serde(default)]
+  pub mode: Option<String>,
+  /// Modules imported for built-ins the target lacks, keyed by the built-ins
+  #[serde(default)]
+  pub polyfills: HashMap<String, String>,
+  #[serde(default)]
+  pub preserve_modules: bool,
+  /// Inferred from the browserslist config of the fixture when unspecified
+  #[serde(default)]
+  pub target: Option<String>,
 }
 
 impl_serde_default!(OutputOptions);
+
+#[derive(Deserialize, JsonSchema)]
+#[serde(rename_all = "camelCase", deny_unknown_fields)]
+pub struct ManualChunk {
+  pub name: String,
+  /// A glob matching module ids, like `**/node_modules/**`
+  pub test: String,
+}
@@ -1,6 +1,7 @@
 use std::{
   collections::HashSet,
   path::{Path, PathBuf},
+  str::FromStr,
   sync::{Arc, Mutex},
 };
 
@@ -25,6 +26,15 @@ impl Tester {
 
   pub fn input_options(&self, cwd: PathBuf) -> rolldown::InputOptions {
     let warning_collector = self.warnings.clone();
+    let import_map = self.config.input.import_map.as_ref().map(|path| {
+      let path = cwd.join(path);
+      let json = std::fs::read_to_string(&path).unwrap();
+      rolldown::ImportMap::from_json(&json, path.parent().unwrap()).unwrap()
+    });
+    let resolve_overrides =
+      rolldown::ResolveOverrides::new(self.config.input.resolve_overrides.clone(), &cwd);
+    let tsconfig_paths =
+      rolldown::TsConfigPaths::new(self.config.input.tsconfig_paths.clone(), &cwd);
     rolldown::InputOptions {
       // TODO: the order should be preserved
       input: self
@@ -65,8 +75,17 @@ impl Tester {
             .tsconfig
             .use_define_for_class_fields,
         }),
+        jsx_side_effects: self.config.input.builtins.jsx_side_effects,
+        jsx: rolldown::JsxMode::from_str(&self.config.input.builtins.jsx).unwrap(),
       },
       shim_missing_exports: self.config.input.shim_missing_exports,
+      concurrency: None,
+      platform: rolldown::Platform::from_str(&self.config.input.platform).unwrap(),
+      prefix_node_builtins: self.config.input.prefix_node_builtins,
+      import_map,
+      resolve_overrides,
+      tsconfig_paths,
+      resolver: None,
     }
   }
 }
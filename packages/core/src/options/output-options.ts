@@ -133,8 +133,15 @@ export interface OutputOptions extends RollupOutputOptions {
 function normalizeFormat(
   format: OutputOptions['format'],
 ): BindingOutputOptions['format'] {
-  if (format === 'esm' || format === 'cjs') {
+  if (
+    format === 'esm' ||
+    format === 'cjs' ||
+    format === 'system' ||
+    format === 'iife'
+  ) {
     return format
+  } else if (format === 'systemjs') {
+    return 'system'
   } else {
     return unimplemented(`output.format: ${format}`)
   }